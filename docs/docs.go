@@ -15,6 +15,627 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/audit-logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search audit log of user actions (logins, password resets, device logouts, expense and debt changes). newest first (Admin or Support role Required)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Search audit log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user that did action",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "action. e.g: user.login, expense.update, debt.accept",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user, device, expense or debt",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id of target",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number. starts from 1",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size. max 50",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "logs and total",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_query_param: a query param has invalid format\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Count of users, devices, expenses and debts (Admin role Required)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "System stats",
+                "responses": {
+                    "200": {
+                        "description": "stats",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Search users by part of name or number (Admin or Support role Required)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Search users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "part of name or number",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number. starts from 1",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size. max 50",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "users and total",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_query_param: page or limit is not a number\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/block": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Block or unblock user. all devices of blocked user are logged out (Admin role Required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Block or unblock user",
+                "parameters": [
+                    {
+                        "description": "user id, is_blocked and reason",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_admin.BlockUserInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user blocked or unblocked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=user_not_found"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Logout all devices of user (Admin or Support role Required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Force logout user",
+                "parameters": [
+                    {
+                        "description": "user id",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_admin.UserIDInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user logged out",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=user_not_found"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get user info, devices and expense counts (Admin or Support role Required)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "user, devices, created_expenses and participated_expenses",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=user_not_found"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/debts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "get debt. user must be creditor or debtor of debt.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debts"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "debt id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is version of debt"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=debt_not_found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "request deletion of debt by creditor or debtor. debt is deleted when both of them request it. is_deleted shows debt is deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debts"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "debt id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of debt. deletion is requested only if debt is not changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is new version of debt if it is not deleted"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid\u003cbr\u003ecode=invalid_header: invalid If-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=debt_not_found"
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=version_conflict: debt is changed. current debt is returned"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/debts/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "accept debt by creditor or debtor. debt is accepted for other side if other side is not registered.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debts"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "debt id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of debt. debt is accepted only if it is not changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is new version of debt"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid\u003cbr\u003ecode=invalid_header: invalid If-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=debt_not_found"
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=version_conflict: debt is changed. current debt is returned"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/debts/{id}/accept-payment": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "accept payment of debt by creditor. debt is settled. debt is paid too if debtor is not registered.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debts"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "debt id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of debt. payment is accepted only if debt is not changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is new version of debt"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid\u003cbr\u003ecode=invalid_header: invalid If-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied: user is not creditor"
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=debt_not_found"
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=version_conflict: debt is changed. current debt is returned\u003cbr\u003ecode=debt_not_paid: debtor has not paid debt"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/debts/{id}/pay": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "pay debt by debtor. payment is accepted too if creditor is not registered.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debts"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "debt id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of debt. debt is paid only if it is not changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is new version of debt"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid\u003cbr\u003ecode=invalid_header: invalid If-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied: user is not debtor"
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=debt_not_found"
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=version_conflict: debt is changed. current debt is returned"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
         "/devices/logout": {
             "post": {
                 "security": [
@@ -22,7 +643,349 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Logout current user device",
+                "description": "Logout current user device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Logout current device",
+                "responses": {
+                    "200": {
+                        "description": "Successfully logged out",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Logout all user devices",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Logout all user devices",
+                "responses": {
+                    "200": {
+                        "description": "Successfully logged out from all devices",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/expenses": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "create new expense.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "expenses"
+                ],
+                "parameters": [
+                    {
+                        "description": "expense name",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "expense description",
+                        "name": "description",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "creditors key value list: phone number is key and credit amount is value",
+                        "name": "creditors",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    {
+                        "example": "\"[\"+989123456786\", \"+989123456787\"]\"",
+                        "description": "list of debtors phone number",
+                        "name": "debtors",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of request. retries with same key get response of first request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=idempotency_key_in_progress: first request with same key is not finished"
+                    },
+                    "422": {
+                        "description": "UnprocessableEntity:\u003cbr\u003ecode=idempotency_key_mismatch: key is used for another request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/expenses/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "get expense. user must be creditor or debtor of expense.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "expenses"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "expense id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is version of expense"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=expense_not_found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "update name and description of expense. only creator of expense can update it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "expenses"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "expense id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of expense. expense is updated only if it is not changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "expense name",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "expense description",
+                        "name": "description",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. ETag header is new version of expense"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid\u003cbr\u003ecode=invalid_header: invalid If-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden:\u003cbr\u003ecode=permission_denied"
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=expense_not_found"
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=version_conflict: expense is changed. current expense is returned"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "delete expense with its debts and comments. only creator of expense can delete it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "expenses"
+                ],
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "expense id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of expense. expense is deleted only if it is not changed",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid\u003cbr\u003ecode=invalid_header: invalid If-Match"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "NotFound:\u003cbr\u003ecode=expense_not_found"
+                    },
+                    "409": {
+                        "description": "Conflict:\u003cbr\u003ecode=version_conflict: expense is changed. current expense is returned"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enable two factor with a code from authenticator app. Recovery codes are returned only once (Authentication Required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,17 +993,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "users"
+                ],
+                "summary": "Confirm two factor authentication",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_user.TwoFactorCodeInput"
+                        }
+                    }
                 ],
-                "summary": "Logout current device",
                 "responses": {
                     "200": {
-                        "description": "Successfully logged out",
+                        "description": "recovery codes",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=enroll_two_factor_first: enroll first\u003cbr\u003ecode=two_factor_already_enabled: two factor is already enabled\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -51,14 +1028,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices/logout-all": {
+        "/users/2fa/disable": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Logout all user devices",
+                "description": "Disable two factor. password and code (or recovery_code) are required (Authentication Required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -66,17 +1043,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "users"
+                ],
+                "summary": "Disable two factor authentication",
+                "parameters": [
+                    {
+                        "description": "password and code",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_user.TwoFactorDisableInput"
+                        }
+                    }
                 ],
-                "summary": "Logout all user devices",
                 "responses": {
                     "200": {
-                        "description": "Successfully logged out from all devices",
+                        "description": "two factor disabled",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=two_factor_not_enabled: two factor is not enabled\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -87,14 +1078,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/expenses": {
+        "/users/2fa/enroll": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "create new expense.",
+                "description": "Generate totp secret and provisioning uri. Two factor is enabled after confirm (Authentication Required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -102,76 +1093,311 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "expenses"
+                    "users"
+                ],
+                "summary": "Enroll two factor authentication",
+                "responses": {
+                    "200": {
+                        "description": "secret and uri",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=two_factor_already_enabled: two factor is already enabled"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/verify": {
+            "post": {
+                "description": "Second step of login and reset password. code or recovery_code is required",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
                 ],
+                "summary": "Verify two factor challenge",
                 "parameters": [
                     {
-                        "description": "expense name",
-                        "name": "name",
+                        "description": "challenge token and code",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/app_user.TwoFactorVerifyInput"
                         }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ok. login tokens\u003cbr\u003eOk. code: go_reset_password"
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=challenge_expired: login again\u003cbr\u003ecode=invalid_field: a field is invalid"
                     },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/users/activity": {
+            "get": {
+                "security": [
                     {
-                        "description": "expense description",
-                        "name": "description",
-                        "in": "body",
-                        "required": true,
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get audit log of actions that user did (logins, password resets, device logouts, expense and debt changes). newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get my activity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number. starts from 1",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size. max 50",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "logs and total",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_query_param: page or limit is not a number\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/avatar": {
+            "get": {
+                "description": "Get list of available avatar images",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get available avatars",
+                "responses": {
+                    "200": {
+                        "description": "List of avatars",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
                     {
-                        "description": "creditors key value list: phone number is key and credit amount is value",
-                        "name": "creditors",
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set user's avatar image",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Choose user avatar",
+                "parameters": [
+                    {
+                        "description": "Avatar URL",
+                        "name": "input",
                         "in": "body",
                         "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_user.AvatarChooseInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Avatar updated",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/avatar/upload": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload jpeg or png photo as avatar. photo is cropped to square and metadata is removed (Authentication Required)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Upload user avatar",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "avatar image",
+                        "name": "avatar",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "avatar urls",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/change-number/send-otp": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Send otp to both current number and new number (Authentication Required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Send otp for changing number",
+                "parameters": [
                     {
-                        "example": "\"[\"+989123456786\", \"+989123456787\"]\"",
-                        "description": "list of debtors phone number",
-                        "name": "debtors",
+                        "description": "new phone number",
+                        "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/app_user.ChangeNumberInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Ok"
+                        "description": "Ok. code: code_sent_to_number"
                     },
                     "400": {
-                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                        "description": "BadRequest:\u003cbr\u003ecode=number_delay: Wait some minutes.\u003cbr\u003ecode=user_already_registered: new number is registered\u003cbr\u003ecode=invalid_field: a field is invalid"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    },
-                    "500": {
-                        "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/users/avatar": {
-            "get": {
-                "description": "Get list of available avatar images",
+        "/users/change-number/verify": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verify otp of current number and new number and change number. history of unregistered user with new number is merged (Authentication Required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -181,15 +1407,29 @@ const docTemplate = `{
                 "tags": [
                     "users"
                 ],
-                "summary": "Get available avatars",
+                "summary": "Verify changing number",
+                "parameters": [
+                    {
+                        "description": "token and otp codes",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_user.VerifyChangeNumberInput"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "List of avatars",
+                        "description": "new tokens",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=go_send_otp_first: Must go to send otp first.\u003cbr\u003ecode=wrong_otp: The OTP is wrong.\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -198,14 +1438,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/users/check-number": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Set user's avatar image",
+                "description": "Check if phone number is registered",
                 "consumes": [
                     "application/json"
                 ],
@@ -215,21 +1452,21 @@ const docTemplate = `{
                 "tags": [
                     "users"
                 ],
-                "summary": "Choose user avatar",
+                "summary": "Check number existence",
                 "parameters": [
                     {
-                        "description": "Avatar URL",
+                        "description": "Phone number to check",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/app_user.AvatarChooseInput"
+                            "$ref": "#/definitions/app_user.NumberInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Avatar updated",
+                        "description": "Number status",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -252,9 +1489,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/check-number": {
+        "/users/delete-account": {
             "post": {
-                "description": "Check if phone number is registered",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete user account. personal data is removed but debts are kept for other users. code is required if two factor is enabled (Authentication Required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -264,33 +1506,105 @@ const docTemplate = `{
                 "tags": [
                     "users"
                 ],
-                "summary": "Check number existence",
+                "summary": "Delete account",
                 "parameters": [
                     {
-                        "description": "Phone number to check",
+                        "description": "password and two factor code",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/app_user.NumberInput"
+                            "$ref": "#/definitions/app_account.DeleteAccountInput"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Number status",
+                        "description": "account deleted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get status of last data export and download url if it is ready (Authentication Required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get personal data export",
+                "responses": {
+                    "200": {
+                        "description": "status and url",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=export_not_found: request export first"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start building a zip archive of user data (profile, devices, expenses, debts, comments, notifications) in background (Authentication Required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Request personal data export",
+                "responses": {
+                    "200": {
+                        "description": "export started",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=export_in_progress: wait until export is ready"
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -379,7 +1693,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Ok\u003cbr\u003eOk. code: two_factor_required. go to verify two factor with challenge_token"
                     },
                     "400": {
                         "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
@@ -390,6 +1704,56 @@ const docTemplate = `{
                 }
             }
         },
+        "/users/profile": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update name, language and currency. empty fields are not changed (Authentication Required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user profile",
+                "parameters": [
+                    {
+                        "description": "profile fields",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/app_user.UpdateProfileInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated profile and new access token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest:\u003cbr\u003ecode=invalid_field: a field is invalid"
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/users/refresh": {
             "post": {
                 "description": "Get new access token using refresh token",
@@ -651,7 +2015,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "303": {
-                        "description": "Success\u003cbr\u003eOk. code: go_reset_password \u003cbr\u003eOk. code: go_signup. verify number done. user must signup"
+                        "description": "Success\u003cbr\u003eOk. code: go_reset_password \u003cbr\u003eOk. code: go_signup. verify number done. user must signup\u003cbr\u003eOk. code: two_factor_required. go to verify two factor with challenge_token"
                     },
                     "400": {
                         "description": "BadRequest:\u003cbr\u003ecode=go_send_otp_first: Must go to send-otp first.\u003cbr\u003ecode=wrong_otp: The OTP is wrong.\u003cbr\u003ecode=invalid_field: a field is invalid"
@@ -664,6 +2028,44 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "app_account.DeleteAccountInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "required if two factor is enabled",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "description": "can be used instead of code",
+                    "type": "string"
+                }
+            }
+        },
+        "app_admin.BlockUserInput": {
+            "type": "object",
+            "properties": {
+                "is_blocked": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "app_admin.UserIDInput": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "app_user.AvatarChooseInput": {
             "type": "object",
             "properties": {
@@ -672,6 +2074,17 @@ const docTemplate = `{
                 }
             }
         },
+        "app_user.ChangeNumberInput": {
+            "type": "object",
+            "required": [
+                "new_number"
+            ],
+            "properties": {
+                "new_number": {
+                    "type": "string"
+                }
+            }
+        },
         "app_user.NumberInput": {
             "type": "object",
             "required": [
@@ -693,6 +2106,80 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "app_user.TwoFactorCodeInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "app_user.TwoFactorDisableInput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "app_user.TwoFactorVerifyInput": {
+            "type": "object",
+            "required": [
+                "challenge_token"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "app_user.UpdateProfileInput": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "app_user.VerifyChangeNumberInput": {
+            "type": "object",
+            "required": [
+                "new_otp",
+                "old_otp",
+                "token"
+            ],
+            "properties": {
+                "new_otp": {
+                    "description": "otp sent to new number",
+                    "type": "integer"
+                },
+                "old_otp": {
+                    "description": "otp sent to current number",
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -704,7 +2191,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{},
 	Title:            "Pedarkharj",
-	Description:      "Pedarkharj project",
+	Description:      "Pedarkharj project. routes of v2 (/api/v2) are same as v1 and errors of v2 are problem details (application/problem+json)",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,111 @@
+package app_account
+
+import (
+	domain_account "github.com/yaghoubi-mn/pedarkharj/internal/domain/account"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+)
+
+type DeleteAccountInput struct {
+	shared_dto.DeleteAccountInput
+}
+
+// exported personal data. every field is saved as a json file in export archive
+type UserDataOutput struct {
+	Profile       shared_dto.ExportProfileOutput
+	Devices       []shared_dto.ExportDeviceOutput
+	Expenses      []shared_dto.ExportExpenseOutput
+	Debts         []shared_dto.ExportDebtOutput
+	Comments      []shared_dto.ExportCommentOutput
+	Notifications []shared_dto.NotificationOutput
+}
+
+func (u *UserDataOutput) Fill(data domain_account.UserData) {
+	u.Profile = shared_dto.ExportProfileOutput{
+		ID:            data.User.ID,
+		Name:          data.User.Name,
+		Number:        data.User.Number,
+		Avatar:        data.User.Avatar,
+		RegisteredAt:  data.User.RegisteredAt,
+		IsTOTPEnabled: data.User.IsTOTPEnabled,
+	}
+
+	u.Devices = make([]shared_dto.ExportDeviceOutput, 0, len(data.Devices))
+	for _, device := range data.Devices {
+		u.Devices = append(u.Devices, shared_dto.ExportDeviceOutput{
+			Name:       device.Name,
+			LastIP:     device.LastIP,
+			FirstLogin: device.FirstLogin,
+			LastLogin:  device.LastLogin,
+		})
+	}
+
+	u.Expenses = make([]shared_dto.ExportExpenseOutput, 0, len(data.Expenses))
+	for _, expense := range data.Expenses {
+		u.Expenses = append(u.Expenses, shared_dto.ExportExpenseOutput{
+			ID:          expense.ID,
+			CreatorID:   expense.CreatorID,
+			Name:        expense.Name,
+			Description: expense.Description,
+			TotalAmount: expense.TotalAmount,
+			CreatedAt:   expense.CreatedAt,
+			UpdatedAt:   expense.UpdatedAt,
+		})
+	}
+
+	u.Debts = make([]shared_dto.ExportDebtOutput, 0, len(data.Debts))
+	for _, debt := range data.Debts {
+		u.Debts = append(u.Debts, shared_dto.ExportDebtOutput{
+			ID:                           debt.ID,
+			ExpenseID:                    debt.ExpenseID,
+			CreditorID:                   debt.CreditorID,
+			DebtorID:                     debt.DebtorID,
+			Amount:                       debt.Amount,
+			IsCreditorAccepted:           debt.IsCreditorAccepted,
+			IsDebtorAccepted:             debt.IsDebtorAccepted,
+			IsCreditorRejected:           debt.IsCreditorRejected,
+			IsDebtorRejected:             debt.IsDebtorRejected,
+			IsPaid:                       debt.IsPaid,
+			IsPaymentAccepted:            debt.IsPaymentAccepted,
+			IsCreditorRequestedForDelete: debt.IsCreditorRequestedForDelete,
+			IsDebtorRequestedForDelete:   debt.IsDebtorRequestedForDelete,
+		})
+	}
+
+	u.Comments = make([]shared_dto.ExportCommentOutput, 0, len(data.Comments))
+	for _, comment := range data.Comments {
+		u.Comments = append(u.Comments, shared_dto.ExportCommentOutput{
+			ID:        comment.ID,
+			ExpenseID: comment.ExpenseID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		})
+	}
+
+	u.Notifications = make([]shared_dto.NotificationOutput, 0, len(data.Notifications))
+	for _, notif := range data.Notifications {
+		u.Notifications = append(u.Notifications, shared_dto.NotificationOutput{
+			Title:       notif.Title,
+			Image:       notif.Image,
+			Description: notif.Description,
+			UserID:      notif.UserID,
+			DebtID:      notif.DebtID,
+			Type:        notif.Type,
+			Amount:      notif.Amount,
+			IsCreditor:  notif.IsCreditor,
+			CreatedAt:   notif.CreatedAt,
+		})
+	}
+}
+
+// file name in archive and its content
+func (u UserDataOutput) Files() map[string]any {
+	return map[string]any{
+		"profile.json":       u.Profile,
+		"devices.json":       u.Devices,
+		"expenses.json":      u.Expenses,
+		"debts.json":         u.Debts,
+		"comments.json":      u.Comments,
+		"notifications.json": u.Notifications,
+	}
+}
@@ -0,0 +1,458 @@
+package app_account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	domain_account "github.com/yaghoubi-mn/pedarkharj/internal/domain/account"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/s3"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+)
+
+var errUserDeleted = errors.New("user is deleted")
+
+const (
+	exportStatusPending = "pending"
+	exportStatusReady   = "ready"
+	exportStatusFailed  = "failed"
+)
+
+type AccountAppService interface {
+	RequestExport(ctx context.Context, userID uint64) app_shared.ResponseDTO
+	GetExport(ctx context.Context, userID uint64) app_shared.ResponseDTO
+	DeleteAccount(ctx context.Context, input DeleteAccountInput, userID uint64) app_shared.ResponseDTO
+	// wait for running exports
+	Shutdown(ctx context.Context) error
+}
+
+type service struct {
+	repo              domain_account.AccountDomainRepository
+	userRepo          domain_user.UserDomainRepository
+	cacheRepo         domain_shared.CacheRepository
+	domainService     domain_account.AccountDomainService
+	userDomainService domain_user.UserDomainService
+
+	// running exports
+	exports sync.WaitGroup
+	// running exports of users. they are canceled when account is deleted
+	runningMu sync.Mutex
+	running   map[uint64]runningExport
+}
+
+type runningExport struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewAccountAppService(repo domain_account.AccountDomainRepository, userRepo domain_user.UserDomainRepository, cacheRepo domain_shared.CacheRepository, domainService domain_account.AccountDomainService, userDomainService domain_user.UserDomainService) AccountAppService {
+	return &service{
+		repo:              repo,
+		userRepo:          userRepo,
+		cacheRepo:         cacheRepo,
+		domainService:     domainService,
+		userDomainService: userDomainService,
+		running:           make(map[uint64]runningExport),
+	}
+}
+
+// counter key. it must not be read with Get
+func deleteAccountAttemptsCacheKey(userID uint64) string {
+	return "delete_account_attempts:" + strconv.FormatUint(userID, 10)
+}
+
+func exportCacheKey(userID uint64) string {
+	return "data_export:" + strconv.FormatUint(userID, 10)
+}
+
+// export is built in background. user must check result with GetExport
+func (s *service) RequestExport(ctx context.Context, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_account.RequestExport")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	userErr := s.domainService.RequestExport(userID)
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.InvalidField
+		return
+	}
+
+	// pending export is expired if worker is stopped before saving result
+	pendingInfo := make(map[string]string)
+	pendingInfo["status"] = exportStatusPending
+	pendingInfo["requested_at"] = time.Now().Format(time.RFC3339)
+
+	oldInfo, reserved, err := s.reserveExport(userID, pendingInfo)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	if !reserved {
+		responseDTO.ResponseCode = rcodes.ExportInProgress
+		responseDTO.UserErr = service_errors.ErrExportInProgress
+		return
+	}
+
+	// only last export is kept
+	if key := oldInfo["key"]; key != "" {
+		if err := s3.DeleteObject(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "cannot delete old data export", "userID", userID, "error", err)
+		}
+	}
+
+	// export must not be canceled when request is finished. it is canceled when account is deleted
+	exportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.DataExportTimeout)
+	export := runningExport{cancel: cancel, done: make(chan struct{})}
+
+	s.runningMu.Lock()
+	s.running[userID] = export
+	s.runningMu.Unlock()
+
+	s.exports.Add(1)
+	go func() {
+		defer s.exports.Done()
+		defer func() {
+			s.runningMu.Lock()
+			if s.running[userID].done == export.done {
+				delete(s.running, userID)
+			}
+			s.runningMu.Unlock()
+			cancel()
+			close(export.done)
+		}()
+		s.buildExport(exportCtx, userID, pendingInfo)
+	}()
+
+	responseDTO.Data["status"] = exportStatusPending
+	responseDTO.Data["msg"] = "export started"
+	return
+}
+
+// saves pending export info if no export of user is pending. returns info of replaced export.
+// info is saved only if it is not changed after reading it, so one of concurrent requests starts export
+func (s *service) reserveExport(userID uint64, pendingInfo map[string]string) (map[string]string, bool, error) {
+	cacheKey := exportCacheKey(userID)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		oldInfo, _, err := s.cacheRepo.Get(cacheKey)
+		if err != nil {
+			if err != database_errors.ErrRecordNotFound && err != database_errors.ErrExpired {
+				return nil, false, err
+			}
+
+			saved, err := s.cacheRepo.SaveIfAbsent(cacheKey, pendingInfo, config.DataExportTimeout)
+			if err != nil || saved {
+				return nil, saved, err
+			}
+			// export is requested by other request between get and save
+			continue
+		}
+
+		if oldInfo["status"] == exportStatusPending {
+			return nil, false, nil
+		}
+
+		swapped, err := s.cacheRepo.CompareAndSwap(cacheKey, oldInfo, pendingInfo, config.DataExportTimeout)
+		if err != nil || swapped {
+			return oldInfo, swapped, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+// cancels running export of user and waits for it
+func (s *service) cancelExport(ctx context.Context, userID uint64) error {
+	s.runningMu.Lock()
+	export, ok := s.running[userID]
+	s.runningMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	export.cancel()
+	select {
+	case <-export.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *service) buildExport(ctx context.Context, userID uint64, exportInfo map[string]string) {
+	ctx, span := tracing.Start(ctx, "app_account.buildExport")
+	defer span.End()
+
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "panic in data export", "userID", userID, "panic", r)
+			exportInfo["status"] = exportStatusFailed
+			if err := s.cacheRepo.Save(exportCacheKey(userID), exportInfo, config.DataExportExpireTime); err != nil {
+				slog.ErrorContext(ctx, "cannot save data export info", "userID", userID, "error", err)
+			}
+		}
+	}()
+
+	key, err := s.createExportArchive(ctx, userID)
+	if err == errUserDeleted {
+		// account is deleted while export was pending
+		if err := s.cacheRepo.Delete(exportCacheKey(userID)); err != nil {
+			slog.ErrorContext(ctx, "cannot delete data export info", "userID", userID, "error", err)
+		}
+		return
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		slog.ErrorContext(ctx, "cannot create data export", "userID", userID, "error", err)
+		exportInfo["status"] = exportStatusFailed
+	} else {
+		exportInfo["status"] = exportStatusReady
+		exportInfo["key"] = key
+		exportInfo["created_at"] = time.Now().Format(time.RFC3339)
+	}
+
+	if err := s.cacheRepo.Save(exportCacheKey(userID), exportInfo, config.DataExportExpireTime); err != nil {
+		slog.ErrorContext(ctx, "cannot save data export info", "userID", userID, "error", err)
+	}
+}
+
+func (s *service) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.exports.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// returns s3 key of zip archive
+func (s *service) createExportArchive(ctx context.Context, userID uint64) (string, error) {
+
+	data, err := s.repo.GetUserData(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	var output UserDataOutput
+	output.Fill(data)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for name, content := range output.Files() {
+		file, err := archive.Create(name)
+		if err != nil {
+			return "", err
+		}
+
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(content); err != nil {
+			return "", err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return "", err
+	}
+
+	// data of deleted user must not be uploaded
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound {
+			return "", errUserDeleted
+		}
+		return "", err
+	}
+	if user.IsDeleted {
+		return "", errUserDeleted
+	}
+
+	key := path.Join(config.DataExportPath, strconv.FormatUint(userID, 10), uuid.New().String()+".zip")
+	if err := s3.PutObject(ctx, key, bytes.NewReader(buf.Bytes())); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *service) GetExport(ctx context.Context, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_account.GetExport")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	exportInfo, _, err := s.cacheRepo.Get(exportCacheKey(userID))
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound || err == database_errors.ErrExpired {
+			responseDTO.ResponseCode = rcodes.ExportNotFound
+			responseDTO.UserErr = service_errors.ErrExportNotFound
+			return
+		}
+
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data["status"] = exportInfo["status"]
+	responseDTO.Data["requested_at"] = exportInfo["requested_at"]
+
+	if exportInfo["status"] == exportStatusReady {
+		url, err := s3.GetPresignedURL(exportInfo["key"], config.DataExportURLExpireTime)
+		if err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		responseDTO.Data["url"] = url
+		responseDTO.Data["created_at"] = exportInfo["created_at"]
+	}
+
+	return
+}
+
+// user is anonymized instead of deleting. debts and expenses of other users are kept
+func (s *service) DeleteAccount(ctx context.Context, input DeleteAccountInput, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_account.DeleteAccount")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	// attempt is counted before checking password, so concurrent requests cannot pass limit
+	attempts, _, err := s.cacheRepo.Increment(deleteAccountAttemptsCacheKey(userID), config.DeleteAccountAttemptsWindow)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	if attempts > config.DeleteAccountMaxAttempts {
+		responseDTO.ResponseCode = rcodes.TooManyRequests
+		responseDTO.UserErr = service_errors.ErrTooManyPasswordAttempts
+		return
+	}
+
+	userErr := s.domainService.DeleteAccount(domain_account.NewDeleteAccountInput(
+		input.Password,
+		input.Code,
+		input.RecoveryCode,
+		user.Password,
+		user.Salt,
+	))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	if user.IsTOTPEnabled {
+		recoveryCodes, lastCounter, userErr := s.userDomainService.VerifyTwoFactor(domain_user.NewTwoFactorInput(
+			input.Code,
+			input.RecoveryCode,
+			user,
+		))
+		if userErr != nil {
+			responseDTO.ResponseCode = rcodes.InvalidField
+			responseDTO.UserErr = userErr
+			return
+		}
+
+		// used code is saved before deleting so it cannot be used again if deleting fails
+		if lastCounter != user.TOTPLastCounter {
+			if err := s.userRepo.UpdateTOTPCounter(ctx, user.ID, lastCounter); err != nil {
+				if err == database_errors.ErrVersionConflict {
+					responseDTO.ResponseCode = rcodes.InvalidField
+					responseDTO.UserErr = service_errors.ErrWrongTOTPCode
+					return
+				}
+				responseDTO.ServerErr = err
+				return
+			}
+		}
+
+		if recoveryCodes != user.TOTPRecoveryCodes {
+			if err := s.userRepo.UpdateTOTPRecoveryCodes(ctx, user.ID, user.TOTPRecoveryCodes, recoveryCodes); err != nil {
+				if err == database_errors.ErrVersionConflict {
+					responseDTO.ResponseCode = rcodes.InvalidField
+					responseDTO.UserErr = service_errors.ErrWrongRecoveryCode
+					return
+				}
+				responseDTO.ServerErr = err
+				return
+			}
+		}
+	}
+
+	// attempts are reset like login when password and second factor are correct
+	if err := s.cacheRepo.Delete(deleteAccountAttemptsCacheKey(userID)); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	// account is not deleted without its audit log
+	log, err := app_audit.NewLog(ctx, userID, domain_audit.ActionAccountDelete, domain_audit.TargetUser, userID, nil, nil)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	err = s.repo.DeleteUser(ctx, s.domainService.Anonymize(user), log)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	// delete uploaded avatar
+	for _, key := range user.UploadedAvatarKeys() {
+		if err := s3.DeleteObject(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "cannot delete avatar of deleted user", "userID", userID, "error", err)
+		}
+	}
+
+	// delete exported data. running export is finished first, so it cannot save data after deleting
+	if err := s.cancelExport(ctx, userID); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	exportInfo, _, err := s.cacheRepo.Get(exportCacheKey(userID))
+	if err == nil && exportInfo["key"] != "" {
+		if err := s3.DeleteObject(ctx, exportInfo["key"]); err != nil {
+			slog.ErrorContext(ctx, "cannot delete data export of deleted user", "userID", userID, "error", err)
+		}
+	}
+	if err := s.cacheRepo.Delete(exportCacheKey(userID)); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data["msg"] = "account deleted"
+	return
+}
@@ -0,0 +1,64 @@
+package app_admin
+
+import (
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+)
+
+type SearchUsersInput struct {
+	shared_dto.SearchUsersInput
+}
+
+type BlockUserInput struct {
+	shared_dto.BlockUserInput
+}
+
+type AuditLogsInput struct {
+	shared_dto.AuditLogsInput
+}
+
+type UserIDInput struct {
+	shared_dto.UserIDInput
+}
+
+func NewUserOutput(user domain_user.User) shared_dto.AdminUserOutput {
+	return shared_dto.AdminUserOutput{
+		ID:            user.ID,
+		Name:          user.Name,
+		Number:        user.Number,
+		Avatar:        user.Avatar,
+		Role:          user.Role,
+		RegisteredAt:  user.RegisteredAt,
+		IsRegistered:  user.IsRegistered,
+		IsBlocked:     user.IsBlocked,
+		IsDeleted:     user.IsDeleted,
+		IsTOTPEnabled: user.IsTOTPEnabled,
+	}
+}
+
+func NewDeviceOutput(device domain_device.Device) shared_dto.AdminDeviceOutput {
+	return shared_dto.AdminDeviceOutput{
+		ID:         device.ID,
+		Name:       device.Name,
+		LastIP:     device.LastIP,
+		FirstLogin: device.FirstLogin,
+		LastLogin:  device.LastLogin,
+		IsActive:   device.RefreshToken != "",
+	}
+}
+
+func NewStatsOutput(stats domain_admin.Stats) shared_dto.AdminStatsOutput {
+	return shared_dto.AdminStatsOutput{
+		TotalUsers:      stats.TotalUsers,
+		RegisteredUsers: stats.RegisteredUsers,
+		BlockedUsers:    stats.BlockedUsers,
+		DeletedUsers:    stats.DeletedUsers,
+		NewUsersToday:   stats.NewUsersToday,
+		ActiveDevices:   stats.ActiveDevices,
+		TotalExpenses:   stats.TotalExpenses,
+		TotalDebts:      stats.TotalDebts,
+		UnpaidDebts:     stats.UnpaidDebts,
+	}
+}
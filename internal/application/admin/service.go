@@ -0,0 +1,346 @@
+package app_admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+)
+
+type AdminAppService interface {
+	SearchUsers(ctx context.Context, input SearchUsersInput, adminID uint64) app_shared.ResponseDTO
+	GetUser(ctx context.Context, userID uint64, adminID uint64) app_shared.ResponseDTO
+	BlockUser(ctx context.Context, input BlockUserInput, adminID uint64) app_shared.ResponseDTO
+	LogoutUser(ctx context.Context, input UserIDInput, adminID uint64) app_shared.ResponseDTO
+	GetStats(ctx context.Context, adminID uint64) app_shared.ResponseDTO
+	// search audit log of user actions. newest first
+	GetAuditLogs(ctx context.Context, input AuditLogsInput, adminID uint64) app_shared.ResponseDTO
+}
+
+type service struct {
+	repo               domain_admin.AdminDomainRepository
+	userRepo           domain_user.UserDomainRepository
+	deviceRepo         domain_device.DeviceDomainRepository
+	auditRepo          domain_audit.AuditDomainRepository
+	cacheRepo          domain_shared.CacheRepository
+	domainService      domain_admin.AdminDomainService
+	auditDomainService domain_audit.AuditDomainService
+}
+
+func NewAdminAppService(repo domain_admin.AdminDomainRepository, userRepo domain_user.UserDomainRepository, deviceRepo domain_device.DeviceDomainRepository, auditRepo domain_audit.AuditDomainRepository, cacheRepo domain_shared.CacheRepository, domainService domain_admin.AdminDomainService, auditDomainService domain_audit.AuditDomainService) AdminAppService {
+	return &service{
+		repo:               repo,
+		userRepo:           userRepo,
+		deviceRepo:         deviceRepo,
+		auditRepo:          auditRepo,
+		cacheRepo:          cacheRepo,
+		domainService:      domainService,
+		auditDomainService: auditDomainService,
+	}
+}
+
+// role is read from database (not jwt) so role changes and blocks take effect immediately
+func (s *service) authorize(ctx context.Context, adminID uint64, permission domain_admin.Permission) (responseDTO app_shared.ResponseDTO, ok bool) {
+	responseDTO.Data = make(map[string]any)
+
+	admin, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound {
+			responseDTO.ResponseCode = rcodes.PermissionDenied
+			responseDTO.UserErr = service_errors.ErrPermissionDenied
+			return responseDTO, false
+		}
+
+		responseDTO.ServerErr = err
+		return responseDTO, false
+	}
+
+	role := admin.Role
+	if admin.IsBlocked || admin.IsDeleted {
+		role = domain_admin.RoleUser
+	}
+
+	if userErr := s.domainService.CheckPermission(role, permission); userErr != nil {
+		slog.WarnContext(ctx, "admin permission denied", "user_id", adminID, "permission", permission)
+		responseDTO.ResponseCode = rcodes.PermissionDenied
+		responseDTO.UserErr = userErr
+		return responseDTO, false
+	}
+
+	return responseDTO, true
+}
+
+func (s *service) SearchUsers(ctx context.Context, input SearchUsersInput, adminID uint64) app_shared.ResponseDTO {
+	ctx, span := tracing.Start(ctx, "app_admin.SearchUsers")
+	defer span.End()
+
+	responseDTO, ok := s.authorize(ctx, adminID, domain_admin.PermissionSearchUsers)
+	if !ok {
+		return responseDTO
+	}
+
+	userErr := s.domainService.SearchUsers(domain_admin.NewSearchUsersInput(input.Query, input.Page, input.Limit))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return responseDTO
+	}
+
+	users, total, err := s.repo.SearchUsers(ctx, input.Query, (input.Page-1)*input.Limit, input.Limit)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	if err := app_audit.Record(ctx, s.auditRepo, adminID, domain_audit.ActionAdminSearchUsers, "", 0, nil, map[string]any{"query": input.Query}); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	outputs := make([]shared_dto.AdminUserOutput, 0, len(users))
+	for _, user := range users {
+		outputs = append(outputs, NewUserOutput(user))
+	}
+
+	responseDTO.Data["users"] = outputs
+	responseDTO.Data["total"] = total
+	return responseDTO
+}
+
+// user info with devices and expense counts
+func (s *service) GetUser(ctx context.Context, userID uint64, adminID uint64) app_shared.ResponseDTO {
+	ctx, span := tracing.Start(ctx, "app_admin.GetUser")
+	defer span.End()
+
+	responseDTO, ok := s.authorize(ctx, adminID, domain_admin.PermissionViewUser)
+	if !ok {
+		return responseDTO
+	}
+
+	if userErr := s.domainService.CheckUserID(userID); userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return responseDTO
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound {
+			responseDTO.ResponseCode = rcodes.UserNotFound
+			responseDTO.UserErr = service_errors.ErrUserNotFound
+			return responseDTO
+		}
+
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	devices, err := s.repo.GetUserDevices(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	counts, err := s.repo.GetUserExpenseCounts(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	if err := app_audit.Record(ctx, s.auditRepo, adminID, domain_audit.ActionAdminViewUser, domain_audit.TargetUser, userID, nil, nil); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	deviceOutputs := make([]shared_dto.AdminDeviceOutput, 0, len(devices))
+	for _, device := range devices {
+		deviceOutputs = append(deviceOutputs, NewDeviceOutput(device))
+	}
+
+	responseDTO.Data["user"] = NewUserOutput(user)
+	responseDTO.Data["devices"] = deviceOutputs
+	responseDTO.Data["created_expenses"] = counts.Created
+	responseDTO.Data["participated_expenses"] = counts.Participated
+	return responseDTO
+}
+
+// block or unblock user. all devices of blocked user are logged out
+func (s *service) BlockUser(ctx context.Context, input BlockUserInput, adminID uint64) app_shared.ResponseDTO {
+	ctx, span := tracing.Start(ctx, "app_admin.BlockUser")
+	defer span.End()
+
+	responseDTO, ok := s.authorize(ctx, adminID, domain_admin.PermissionBlockUser)
+	if !ok {
+		return responseDTO
+	}
+
+	if userErr := s.domainService.CheckUserID(input.UserID); userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return responseDTO
+	}
+
+	user, err := s.userRepo.GetByID(ctx, input.UserID)
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound {
+			responseDTO.ResponseCode = rcodes.UserNotFound
+			responseDTO.UserErr = service_errors.ErrUserNotFound
+			return responseDTO
+		}
+
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	userErr := s.domainService.BlockUser(domain_admin.NewBlockUserInput(input.UserID, input.IsBlocked, input.Reason, adminID, user.Role))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return responseDTO
+	}
+
+	action := domain_audit.ActionAdminBlockUser
+	if !input.IsBlocked {
+		action = domain_audit.ActionAdminUnblockUser
+	}
+	log, err := app_audit.NewLog(ctx, adminID, action, domain_audit.TargetUser, input.UserID, map[string]any{"is_blocked": user.IsBlocked}, map[string]any{"is_blocked": input.IsBlocked, "reason": input.Reason})
+	if err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	// access tokens are revoked before block, so they are not valid if block is saved
+	if input.IsBlocked {
+		if err := app_user.RevokeAccess(s.cacheRepo, input.UserID); err != nil {
+			responseDTO.ServerErr = err
+			return responseDTO
+		}
+	}
+
+	// block is not saved without its audit log
+	if err := s.repo.SetBlocked(ctx, input.UserID, input.IsBlocked, log); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	responseDTO.Data["msg"] = fmt.Sprintf("user %d is_blocked=%t", input.UserID, input.IsBlocked)
+	return responseDTO
+}
+
+// remove refresh tokens of all user devices
+func (s *service) LogoutUser(ctx context.Context, input UserIDInput, adminID uint64) app_shared.ResponseDTO {
+	ctx, span := tracing.Start(ctx, "app_admin.LogoutUser")
+	defer span.End()
+
+	responseDTO, ok := s.authorize(ctx, adminID, domain_admin.PermissionLogoutUser)
+	if !ok {
+		return responseDTO
+	}
+
+	if userErr := s.domainService.CheckUserID(input.UserID); userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return responseDTO
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, input.UserID); err != nil {
+		if err == database_errors.ErrRecordNotFound {
+			responseDTO.ResponseCode = rcodes.UserNotFound
+			responseDTO.UserErr = service_errors.ErrUserNotFound
+			return responseDTO
+		}
+
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	// refresh tokens are deleted with devices and access tokens are revoked
+	if err := app_user.RevokeAccess(s.cacheRepo, input.UserID); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+	if err := s.deviceRepo.LogoutAllUserDevices(ctx, input.UserID); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	// logout is done, so failure of audit log does not fail request
+	app_audit.RecordOrLog(ctx, s.auditRepo, adminID, domain_audit.ActionAdminLogoutUser, domain_audit.TargetUser, input.UserID, nil, nil)
+
+	responseDTO.Data["msg"] = "all devices of user logged out"
+	return responseDTO
+}
+
+func (s *service) GetStats(ctx context.Context, adminID uint64) app_shared.ResponseDTO {
+	ctx, span := tracing.Start(ctx, "app_admin.GetStats")
+	defer span.End()
+
+	responseDTO, ok := s.authorize(ctx, adminID, domain_admin.PermissionViewStats)
+	if !ok {
+		return responseDTO
+	}
+
+	stats, err := s.repo.GetStats(ctx)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	app_audit.RecordOrLog(ctx, s.auditRepo, adminID, domain_audit.ActionAdminViewStats, "", 0, nil, nil)
+
+	responseDTO.Data["stats"] = NewStatsOutput(stats)
+	return responseDTO
+}
+
+func (s *service) GetAuditLogs(ctx context.Context, input AuditLogsInput, adminID uint64) app_shared.ResponseDTO {
+	ctx, span := tracing.Start(ctx, "app_admin.GetAuditLogs")
+	defer span.End()
+
+	responseDTO, ok := s.authorize(ctx, adminID, domain_admin.PermissionViewAudit)
+	if !ok {
+		return responseDTO
+	}
+
+	userErr := s.auditDomainService.Search(domain_audit.NewAuditLogsInput(input.ActorID, input.Action, input.TargetType, input.TargetID, input.From, input.To, input.Page, input.Limit))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return responseDTO
+	}
+
+	filter := domain_audit.Filter{
+		ActorID:    input.ActorID,
+		Action:     input.Action,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		From:       input.From,
+		To:         input.To,
+	}
+	logs, total, err := s.auditRepo.Search(ctx, filter, (input.Page-1)*input.Limit, input.Limit)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	if err := app_audit.Record(ctx, s.auditRepo, adminID, domain_audit.ActionAdminViewAudit, "", 0, nil, filter); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	responseDTO.Data["logs"] = app_audit.NewAuditLogOutputs(logs)
+	responseDTO.Data["total"] = total
+	return responseDTO
+}
@@ -0,0 +1,21 @@
+package app_audit
+
+import "context"
+
+type contextKey struct{}
+
+// client of request. saved in every audit log of request
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// empty info is returned outside of requests (e.g: background workers)
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(contextKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,40 @@
+package app_audit
+
+import (
+	"encoding/json"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+)
+
+func NewAuditLogOutput(log domain_audit.AuditLog) shared_dto.AuditLogOutput {
+	output := shared_dto.AuditLogOutput{
+		ID:         log.ID,
+		ActorID:    log.ActorID,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		IP:         log.IP,
+		UserAgent:  log.UserAgent,
+		RequestID:  log.RequestID,
+		CreatedAt:  log.CreatedAt,
+	}
+
+	if log.Before != "" {
+		output.Before = json.RawMessage(log.Before)
+	}
+	if log.After != "" {
+		output.After = json.RawMessage(log.After)
+	}
+
+	return output
+}
+
+func NewAuditLogOutputs(logs []domain_audit.AuditLog) []shared_dto.AuditLogOutput {
+	outputs := make([]shared_dto.AuditLogOutput, 0, len(logs))
+	for _, log := range logs {
+		outputs = append(outputs, NewAuditLogOutput(log))
+	}
+
+	return outputs
+}
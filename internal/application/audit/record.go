@@ -0,0 +1,75 @@
+package app_audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+)
+
+// save audit log with request info of ctx. before and after are snapshots of target and nil snapshots are not saved.
+// repo must be bound to transaction of change if change is transactional (see app_shared.TxRepositories)
+func Record(ctx context.Context, repo domain_audit.AuditDomainRepository, actorID uint64, action string, targetType string, targetID uint64, before any, after any) error {
+	log, err := NewLog(ctx, actorID, action, targetType, targetID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return repo.Create(ctx, log)
+}
+
+// audit log with request info of ctx for repositories that save it in transaction of their change
+func NewLog(ctx context.Context, actorID uint64, action string, targetType string, targetID uint64, before any, after any) (*domain_audit.AuditLog, error) {
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	info := RequestInfoFromContext(ctx)
+
+	return &domain_audit.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		IP:         info.IP,
+		UserAgent:  truncate(info.UserAgent, 300),
+		RequestID:  logger.RequestIDFromContext(ctx),
+	}, nil
+}
+
+// for actions that are done even if they cannot be audited (e.g: failed login)
+func RecordOrLog(ctx context.Context, repo domain_audit.AuditDomainRepository, actorID uint64, action string, targetType string, targetID uint64, before any, after any) {
+	if err := Record(ctx, repo, actorID, action, targetType, targetID, before, after); err != nil {
+		slog.ErrorContext(ctx, "cannot save audit log", "action", action, "actor_id", actorID, "error", err)
+	}
+}
+
+func snapshot(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+
+	return s[:length]
+}
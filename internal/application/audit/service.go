@@ -0,0 +1,50 @@
+package app_audit
+
+import (
+	"context"
+
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+)
+
+type AuditAppService interface {
+	// audit logs of actions that user did. newest first
+	GetMyActivity(ctx context.Context, userID uint64, page int, limit int) app_shared.ResponseDTO
+}
+
+type service struct {
+	repo          domain_audit.AuditDomainRepository
+	domainService domain_audit.AuditDomainService
+}
+
+func NewAuditAppService(repo domain_audit.AuditDomainRepository, domainService domain_audit.AuditDomainService) AuditAppService {
+	return service{
+		repo:          repo,
+		domainService: domainService,
+	}
+}
+
+func (s service) GetMyActivity(ctx context.Context, userID uint64, page int, limit int) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_audit.GetMyActivity")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	if userErr := s.domainService.GetMyActivity(page, limit); userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.InvalidField
+		return
+	}
+
+	logs, total, err := s.repo.Search(ctx, domain_audit.Filter{ActorID: userID}, (page-1)*limit, limit)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data["logs"] = NewAuditLogOutputs(logs)
+	responseDTO.Data["total"] = total
+	return
+}
@@ -1,6 +1,10 @@
 package app_debt
 
-import shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+import (
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+)
 
 type ExpenseDebtInputWithID struct {
 	shared_dto.ExpenseDebtInputWithID
@@ -18,3 +22,34 @@ func NewExpenseDebtInputWithID(name, description string, creditors map[uint64]ui
 	}
 
 }
+
+func NewDebtOutput(debt domain_debt.Debt) shared_dto.DebtOutput {
+	return shared_dto.DebtOutput{
+		ID:                           debt.ID,
+		ExpenseID:                    debt.ExpenseID,
+		CreditorID:                   debt.CreditorID,
+		DebtorID:                     debt.DebtorID,
+		Amount:                       debt.Amount,
+		IsCreditorAccepted:           debt.IsCreditorAccepted,
+		IsDebtorAccepted:             debt.IsDebtorAccepted,
+		IsCreditorRejected:           debt.IsCreditorRejected,
+		IsDebtorRejected:             debt.IsDebtorRejected,
+		IsPaid:                       debt.IsPaid,
+		IsPaymentAccepted:            debt.IsPaymentAccepted,
+		IsCreditorRequestedForDelete: debt.IsCreditorRequestedForDelete,
+		IsDebtorRequestedForDelete:   debt.IsDebtorRequestedForDelete,
+		Version:                      debt.Version,
+	}
+}
+
+// actorID is user that changed debt
+func newDebtPayload(debt domain_debt.Debt, actorID uint64) domain_outbox.DebtPayload {
+	return domain_outbox.DebtPayload{
+		DebtID:     debt.ID,
+		ExpenseID:  debt.ExpenseID,
+		CreditorID: debt.CreditorID,
+		DebtorID:   debt.DebtorID,
+		Amount:     debt.Amount,
+		ActorID:    actorID,
+	}
+}
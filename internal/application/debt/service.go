@@ -1,29 +1,68 @@
 package app_debt
 
 import (
+	"context"
+	"errors"
+
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	app_outbox "github.com/yaghoubi-mn/pedarkharj/internal/application/outbox"
 	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
 	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
 )
 
 type DebtAppService interface {
-	Create(input ExpenseDebtInputWithID) app_shared.ResponseDTO
+	// debts are created in transaction of repository. number of created debts is in "count" of data
+	Create(ctx context.Context, input ExpenseDebtInputWithID) app_shared.ResponseDTO
+	Get(ctx context.Context, debtID, userID uint64) app_shared.ResponseDTO
+	// version is expected version of debt (If-Match). zero skips the check
+	Accept(ctx context.Context, debtID, userID, version uint64) app_shared.ResponseDTO
+	// payment of debt by debtor. payment is accepted for creditor that is not registered
+	Pay(ctx context.Context, debtID, userID, version uint64) app_shared.ResponseDTO
+	// acceptance of payment by creditor. debt is settled when its payment is accepted
+	AcceptPayment(ctx context.Context, debtID, userID, version uint64) app_shared.ResponseDTO
+	// debt is deleted when both creditor and debtor request it
+	Delete(ctx context.Context, debtID, userID, version uint64) app_shared.ResponseDTO
+	// returns copy of service that uses repo. used for running in a unit of work
+	WithRepository(repo domain_debt.DebtDomainRepository) DebtAppService
 }
 
 type service struct {
 	repo          domain_debt.DebtDomainRepository
+	userRepo      domain_user.UserDomainRepository
+	unitOfWork    app_shared.UnitOfWork
 	domainService domain_debt.DebtDomainService
 }
 
-func NewDebtAppService(repo domain_debt.DebtDomainRepository, domainService domain_debt.DebtDomainService) DebtAppService {
+// changes of debt are saved with their outbox events in one transaction of unitOfWork
+func NewDebtAppService(repo domain_debt.DebtDomainRepository, userRepo domain_user.UserDomainRepository, unitOfWork app_shared.UnitOfWork, domainService domain_debt.DebtDomainService) DebtAppService {
 	return service{
 		repo:          repo,
+		userRepo:      userRepo,
+		unitOfWork:    unitOfWork,
 		domainService: domainService,
 	}
 }
 
-func (s service) Create(input ExpenseDebtInputWithID) (responseDTO app_shared.ResponseDTO) {
+func (s service) WithRepository(repo domain_debt.DebtDomainRepository) DebtAppService {
+	s.repo = repo
+	return s
+}
+
+func (s service) Create(ctx context.Context, input ExpenseDebtInputWithID) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_debt.Create")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
+	_, domainSpan := tracing.Start(ctx, "domain_debt.Create")
 	debts, userErr := s.domainService.Create(domain_debt.NewExpenseDebtInput(
 		input.Name,
 		input.Description,
@@ -31,17 +70,283 @@ func (s service) Create(input ExpenseDebtInputWithID) (responseDTO app_shared.Re
 		input.Debtors,
 		input.ExpenseID,
 	))
+	domainSpan.End()
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	err := s.repo.CreateMultipleWithTransaction(ctx, debts)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data["msg"] = "Done"
+	responseDTO.Data["count"] = len(debts)
+	return
+}
+
+func (s service) Get(ctx context.Context, debtID uint64, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_debt.Get")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	debt, ok := s.get(ctx, debtID, userID, &responseDTO)
+	if !ok {
+		return
+	}
+
+	responseDTO.Data["debt"] = NewDebtOutput(debt)
+	return
+}
+
+func (s service) Accept(ctx context.Context, debtID uint64, userID uint64, version uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_debt.Accept")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	debt, ok := s.get(ctx, debtID, userID, &responseDTO)
+	if !ok || !s.checkVersion(debt, version, &responseDTO) {
+		return
+	}
+
+	// debt is accepted for side that is not registered
+	creditor, err := s.userRepo.GetByID(ctx, debt.CreditorID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	debtor, err := s.userRepo.GetByID(ctx, debt.DebtorID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	before := NewDebtOutput(debt)
+	debt, userErr := s.domainService.Accept(debt, userID, creditor.IsRegistered, debtor.IsRegistered)
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.PermissionDenied
+		return
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos app_shared.TxRepositories) error {
+		if err := repos.Debt.Update(ctx, &debt); err != nil {
+			return err
+		}
+
+		if err := app_outbox.SaveEvent(ctx, repos.Outbox, domain_outbox.EventDebtAccepted, domain_outbox.AggregateDebt, debt.ID, newDebtPayload(debt, userID)); err != nil {
+			return err
+		}
+
+		return app_audit.Record(ctx, repos.Audit, userID, domain_audit.ActionDebtAccept, domain_audit.TargetDebt, debt.ID, before, NewDebtOutput(debt))
+	})
+	if errors.Is(err, database_errors.ErrVersionConflict) {
+		s.versionConflict(ctx, debtID, userID, &responseDTO)
+		return
+	}
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data["debt"] = NewDebtOutput(debt)
+	responseDTO.Data["msg"] = "Done"
+	return
+}
+
+func (s service) Pay(ctx context.Context, debtID uint64, userID uint64, version uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_debt.Pay")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	debt, ok := s.get(ctx, debtID, userID, &responseDTO)
+	if !ok || !s.checkVersion(debt, version, &responseDTO) {
+		return
+	}
+
+	creditor, err := s.userRepo.GetByID(ctx, debt.CreditorID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	before := debt
+	debt, userErr := s.domainService.Pay(debt, userID, creditor.IsRegistered)
 	if userErr != nil {
 		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.PermissionDenied
 		return
 	}
 
-	err := s.repo.CreateMultipleWithTransaction(debts)
+	s.savePayment(ctx, before, debt, userID, domain_outbox.EventDebtPaid, domain_audit.ActionDebtPay, &responseDTO)
+	return
+}
+
+func (s service) AcceptPayment(ctx context.Context, debtID uint64, userID uint64, version uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_debt.AcceptPayment")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	debt, ok := s.get(ctx, debtID, userID, &responseDTO)
+	if !ok || !s.checkVersion(debt, version, &responseDTO) {
+		return
+	}
+
+	debtor, err := s.userRepo.GetByID(ctx, debt.DebtorID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	before := debt
+	debt, userErr := s.domainService.AcceptPayment(debt, userID, debtor.IsRegistered)
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.PermissionDenied
+		if userErr == service_errors.ErrDebtIsNotPaid {
+			responseDTO.ResponseCode = rcodes.DebtNotPaid
+		}
+		return
+	}
+
+	s.savePayment(ctx, before, debt, userID, domain_outbox.EventDebtPaymentAccepted, domain_audit.ActionDebtAcceptPayment, &responseDTO)
+	return
+}
+
+// save paid or payment accepted debt with its event and audit log. settled debts are counted after commit
+func (s service) savePayment(ctx context.Context, before domain_debt.Debt, debt domain_debt.Debt, userID uint64, eventType string, action string, responseDTO *app_shared.ResponseDTO) {
+	err := s.unitOfWork.Do(ctx, func(repos app_shared.TxRepositories) error {
+		if err := repos.Debt.Update(ctx, &debt); err != nil {
+			return err
+		}
+
+		if err := app_outbox.SaveEvent(ctx, repos.Outbox, eventType, domain_outbox.AggregateDebt, debt.ID, newDebtPayload(debt, userID)); err != nil {
+			return err
+		}
+
+		return app_audit.Record(ctx, repos.Audit, userID, action, domain_audit.TargetDebt, debt.ID, NewDebtOutput(before), NewDebtOutput(debt))
+	})
+	if errors.Is(err, database_errors.ErrVersionConflict) {
+		s.versionConflict(ctx, debt.ID, userID, responseDTO)
+		return
+	}
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
 	}
 
+	if !before.IsPaymentAccepted && debt.IsPaymentAccepted {
+		metrics.DebtsSettled.Inc()
+	}
+
+	responseDTO.Data["debt"] = NewDebtOutput(debt)
+	responseDTO.Data["msg"] = "Done"
+}
+
+func (s service) Delete(ctx context.Context, debtID uint64, userID uint64, version uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_debt.Delete")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	debt, ok := s.get(ctx, debtID, userID, &responseDTO)
+	if !ok || !s.checkVersion(debt, version, &responseDTO) {
+		return
+	}
+
+	before := NewDebtOutput(debt)
+	proceedDeletion, debt, userErr := s.domainService.Delete(debt, userID)
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.PermissionDenied
+		return
+	}
+
+	err := s.unitOfWork.Do(ctx, func(repos app_shared.TxRepositories) error {
+		if proceedDeletion {
+			if err := repos.Debt.Delete(ctx, debt.ID, debt.Version); err != nil {
+				return err
+			}
+			if err := app_outbox.SaveEvent(ctx, repos.Outbox, domain_outbox.EventDebtDeleted, domain_outbox.AggregateDebt, debt.ID, newDebtPayload(debt, userID)); err != nil {
+				return err
+			}
+			return app_audit.Record(ctx, repos.Audit, userID, domain_audit.ActionDebtDelete, domain_audit.TargetDebt, debt.ID, before, nil)
+		}
+
+		if err := repos.Debt.Update(ctx, &debt); err != nil {
+			return err
+		}
+		if err := app_outbox.SaveEvent(ctx, repos.Outbox, domain_outbox.EventDebtDeleteRequested, domain_outbox.AggregateDebt, debt.ID, newDebtPayload(debt, userID)); err != nil {
+			return err
+		}
+		return app_audit.Record(ctx, repos.Audit, userID, domain_audit.ActionDebtDeleteRequest, domain_audit.TargetDebt, debt.ID, before, NewDebtOutput(debt))
+	})
+	if errors.Is(err, database_errors.ErrVersionConflict) {
+		s.versionConflict(ctx, debtID, userID, &responseDTO)
+		return
+	}
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	if !proceedDeletion {
+		responseDTO.Data["debt"] = NewDebtOutput(debt)
+	}
+	responseDTO.Data["is_deleted"] = proceedDeletion
 	responseDTO.Data["msg"] = "Done"
 	return
 }
+
+// debt is found only if user is creditor or debtor of it. ok is false if error is set in response
+func (s service) get(ctx context.Context, debtID uint64, userID uint64, responseDTO *app_shared.ResponseDTO) (debt domain_debt.Debt, ok bool) {
+	userErr := s.domainService.Get(debtID)
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.InvalidField
+		return debt, false
+	}
+
+	debt, err := s.repo.GetByID(ctx, debtID, userID)
+	if errors.Is(err, database_errors.ErrRecordNotFound) {
+		responseDTO.UserErr = service_errors.ErrDebtNotFound
+		responseDTO.ResponseCode = rcodes.DebtNotFound
+		return debt, false
+	}
+	if err != nil {
+		responseDTO.ServerErr = err
+		return debt, false
+	}
+
+	return debt, true
+}
+
+// version of zero is not checked
+func (s service) checkVersion(debt domain_debt.Debt, version uint64, responseDTO *app_shared.ResponseDTO) bool {
+	if version == 0 || debt.Version == version {
+		return true
+	}
+
+	responseDTO.UserErr = service_errors.ErrVersionConflict
+	responseDTO.ResponseCode = rcodes.VersionConflict
+	responseDTO.Data["debt"] = NewDebtOutput(debt)
+	return false
+}
+
+// set version conflict error with current state of debt in response
+func (s service) versionConflict(ctx context.Context, debtID uint64, userID uint64, responseDTO *app_shared.ResponseDTO) {
+	current, ok := s.get(ctx, debtID, userID, responseDTO)
+	if !ok {
+		return
+	}
+
+	responseDTO.UserErr = service_errors.ErrVersionConflict
+	responseDTO.ResponseCode = rcodes.VersionConflict
+	responseDTO.Data["debt"] = NewDebtOutput(current)
+}
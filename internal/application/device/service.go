@@ -1,34 +1,42 @@
 package app_device
 
 import (
-	"errors"
+	"context"
 
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
 	"github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
 	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
 	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
 )
 
 type DeviceAppService interface {
-	CreateOrUpdate(deviceInput domain_device.DeviceInput) error
-	GetDeviceUserByRefreshToken(refresh string) (user domain_user.User, userErr error, serverErr error)
-	Logout(userID uint64, deviceName string) app_shared.ResponseDTO
-	LogoutAllUserDevices(userID uint64) app_shared.ResponseDTO
+	CreateOrUpdate(ctx context.Context, deviceInput domain_device.DeviceInput) error
+	GetDeviceUserByRefreshToken(ctx context.Context, refresh string) (user domain_user.User, userErr error, serverErr error)
+	Logout(ctx context.Context, userID uint64, deviceName string) app_shared.ResponseDTO
+	LogoutAllUserDevices(ctx context.Context, userID uint64) app_shared.ResponseDTO
 }
 
 type service struct {
 	repo          domain_device.DeviceDomainRepository
+	auditRepo     domain_audit.AuditDomainRepository
 	domainService domain_device.DeviceDomainService
 }
 
-func NewDeviceAppService(repo domain_device.DeviceDomainRepository, domainService domain_device.DeviceDomainService) DeviceAppService {
+func NewDeviceAppService(repo domain_device.DeviceDomainRepository, auditRepo domain_audit.AuditDomainRepository, domainService domain_device.DeviceDomainService) DeviceAppService {
 	return &service{
 		repo:          repo,
+		auditRepo:     auditRepo,
 		domainService: domainService,
 	}
 }
 
-func (s *service) CreateOrUpdate(deviceInput domain_device.DeviceInput) error {
+func (s *service) CreateOrUpdate(ctx context.Context, deviceInput domain_device.DeviceInput) error {
+	ctx, span := tracing.Start(ctx, "app_device.CreateOrUpdate")
+	defer span.End()
 
 	device := deviceInput.CreateDevice()
 
@@ -37,7 +45,7 @@ func (s *service) CreateOrUpdate(deviceInput domain_device.DeviceInput) error {
 		return err
 	}
 
-	err = s.repo.CreateOrUpdate(device)
+	err = s.repo.CreateOrUpdate(ctx, device)
 	if err != nil {
 		return err
 	}
@@ -45,18 +53,23 @@ func (s *service) CreateOrUpdate(deviceInput domain_device.DeviceInput) error {
 	return nil
 }
 
-func (s *service) GetDeviceUserByRefreshToken(refresh string) (user domain_user.User, userErr error, err error) {
+func (s *service) GetDeviceUserByRefreshToken(ctx context.Context, refresh string) (user domain_user.User, userErr error, err error) {
+	ctx, span := tracing.Start(ctx, "app_device.GetDeviceUserByRefreshToken")
+	defer span.End()
 
 	_, err = jwt.VerifyJwt(refresh)
 	if err != nil {
-		return user, errors.New("refresh: invalid token"), nil
+		return user, service_errors.ErrInvalidRefreshToken, nil
 	}
-	user, err = s.repo.GetUserByRefreshToken(refresh)
+	user, err = s.repo.GetUserByRefreshToken(ctx, refresh)
 	return user, nil, err
 
 }
 
-func (s *service) Logout(userID uint64, deviceName string) (responseDTO app_shared.ResponseDTO) {
+func (s *service) Logout(ctx context.Context, userID uint64, deviceName string) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_device.Logout")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
 	err := s.domainService.Logout(userID, deviceName)
@@ -65,14 +78,22 @@ func (s *service) Logout(userID uint64, deviceName string) (responseDTO app_shar
 		return
 	}
 
-	err = s.repo.Logout(userID, deviceName)
-	responseDTO.ServerErr = err
+	err = s.repo.Logout(ctx, userID, deviceName)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
 
+	// device is already logged out. failure of audit log does not fail request
+	app_audit.RecordOrLog(ctx, s.auditRepo, userID, domain_audit.ActionDeviceLogout, domain_audit.TargetUser, userID, nil, map[string]string{"device_name": deviceName})
 	return
 
 }
 
-func (s *service) LogoutAllUserDevices(userID uint64) (responseDTO app_shared.ResponseDTO) {
+func (s *service) LogoutAllUserDevices(ctx context.Context, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_device.LogoutAllUserDevices")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
 	err := s.domainService.LogoutAllUserDevices(userID)
@@ -81,7 +102,12 @@ func (s *service) LogoutAllUserDevices(userID uint64) (responseDTO app_shared.Re
 		return
 	}
 
-	err = s.repo.LogoutAllUserDevices(userID)
-	responseDTO.ServerErr = err
+	err = s.repo.LogoutAllUserDevices(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	app_audit.RecordOrLog(ctx, s.auditRepo, userID, domain_audit.ActionDeviceLogoutAll, domain_audit.TargetUser, userID, nil, nil)
 	return
 }
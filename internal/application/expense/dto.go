@@ -1,6 +1,8 @@
 package app_expense
 
 import (
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
 	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
 )
 
@@ -36,3 +38,40 @@ type ExpenseInputWithPhoneNumber struct {
 type ExpenseUpdateInput struct {
 	shared_dto.ExpenseUpdateInput
 }
+
+func NewExpenseOutput(expense domain_expense.Expense) shared_dto.ExpenseOutput {
+	return shared_dto.ExpenseOutput{
+		ID:          expense.ID,
+		CreatorID:   expense.CreatorID,
+		Name:        expense.Name,
+		Description: expense.Description,
+		TotalAmount: expense.TotalAmount,
+		CreatedAt:   expense.CreatedAt,
+		UpdatedAt:   expense.UpdatedAt,
+		Version:     expense.Version,
+	}
+}
+
+func newExpensePayload(expense domain_expense.Expense) domain_outbox.ExpensePayload {
+	return domain_outbox.ExpensePayload{
+		ExpenseID:   expense.ID,
+		CreatorID:   expense.CreatorID,
+		Name:        expense.Name,
+		Description: expense.Description,
+	}
+}
+
+// audit log snapshot of created expense. credits and debtors are saved for resolving disputes
+type expenseSnapshot struct {
+	shared_dto.ExpenseOutput
+	Creditors map[uint64]uint64 `json:"creditors"` // {"<ID>": <Amount>, ...}
+	Debtors   []uint64          `json:"debtors"`
+}
+
+func newExpenseSnapshot(expense domain_expense.Expense, input ExpenseInputWithID) expenseSnapshot {
+	return expenseSnapshot{
+		ExpenseOutput: NewExpenseOutput(expense),
+		Creditors:     input.Creditors,
+		Debtors:       input.Debtors,
+	}
+}
@@ -1,37 +1,60 @@
 package app_expense
 
 import (
+	"context"
+	"errors"
+	"slices"
+
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
 	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	app_outbox "github.com/yaghoubi-mn/pedarkharj/internal/application/outbox"
 	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
 	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
 )
 
 type ExpenseAppService interface {
-	Create(input ExpenseInputWithPhoneNumber, userID uint64, userPhoneNumber string) app_shared.ResponseDTO
-	Update(input ExpenseUpdateInput)
-	Delete(expenseID, userID uint64) app_shared.ResponseDTO
-	Get(expenseID, userID uint64) app_shared.ResponseDTO
-	GetLimited(userID uint64, page, limit uint) app_shared.ResponseDTO
+	Create(ctx context.Context, input ExpenseInputWithPhoneNumber, userID uint64, userPhoneNumber string) app_shared.ResponseDTO
+	// version is expected version of expense (If-Match). zero skips the check
+	Update(ctx context.Context, expenseID, userID, version uint64, input ExpenseUpdateInput) app_shared.ResponseDTO
+	Delete(ctx context.Context, expenseID, userID, version uint64) app_shared.ResponseDTO
+	Get(ctx context.Context, expenseID, userID uint64) app_shared.ResponseDTO
+	GetLimited(ctx context.Context, userID uint64, page, limit uint) app_shared.ResponseDTO
 }
 
+// returned from unit of work when user error is set in response, so changes are rolled back
+var errRollback = errors.New("rollback")
+
 type service struct {
 	domainService  domain_expense.ExpenseDomainService
 	repo           domain_expense.ExpenseDomainRepository
+	unitOfWork     app_shared.UnitOfWork
 	debtAppService app_debt.DebtAppService
 }
 
-func NewExpenseAppService(repo domain_expense.ExpenseDomainRepository, domainService domain_expense.ExpenseDomainService, debtAppService app_debt.DebtAppService) ExpenseAppService {
+// changes of expense, placeholder users and debts are saved with unitOfWork in one transaction
+func NewExpenseAppService(repo domain_expense.ExpenseDomainRepository, unitOfWork app_shared.UnitOfWork, domainService domain_expense.ExpenseDomainService, debtAppService app_debt.DebtAppService) ExpenseAppService {
 	return service{
 		repo:           repo,
+		unitOfWork:     unitOfWork,
 		debtAppService: debtAppService,
 		domainService:  domainService,
 	}
 }
 
-func (s service) Create(input ExpenseInputWithPhoneNumber, userID uint64, userPhoneNumber string) (responseDTO app_shared.ResponseDTO) {
+func (s service) Create(ctx context.Context, input ExpenseInputWithPhoneNumber, userID uint64, userPhoneNumber string) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_expense.Create")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
+	_, domainSpan := tracing.Start(ctx, "domain_expense.Create")
 	expense, userErr := s.domainService.Create(domain_expense.NewExpenseInputWithPhoneNumber(
 		input.Name,
 		input.Description,
@@ -40,6 +63,7 @@ func (s service) Create(input ExpenseInputWithPhoneNumber, userID uint64, userPh
 		userID,
 		userPhoneNumber,
 	))
+	domainSpan.End()
 
 	if userErr != nil {
 		responseDTO.UserErr = userErr
@@ -47,11 +71,6 @@ func (s service) Create(input ExpenseInputWithPhoneNumber, userID uint64, userPh
 		return
 	}
 
-	err := s.repo.Create(&expense)
-	if err != nil {
-		responseDTO.ServerErr = err
-		return
-	}
 	numbers := make([]string, 0, len(input.Creditors))
 	for k := range input.Creditors {
 		numbers = append(numbers, k)
@@ -59,39 +78,74 @@ func (s service) Create(input ExpenseInputWithPhoneNumber, userID uint64, userPh
 
 	numbers = append(numbers, input.Debtors...)
 
-	err = s.repo.CreateUsersWithNumbers(numbers)
-	if err != nil {
-		responseDTO.ServerErr = err
-		return
-	}
+	// expense is not saved if debts cannot be created
+	var debtsCount int
+	err := s.unitOfWork.Do(ctx, func(repos app_shared.TxRepositories) error {
+		if err := repos.Expense.Create(ctx, &expense); err != nil {
+			return err
+		}
 
-	idPhoneMap, err := s.repo.GetUserIDOfPhoneNumbers(numbers)
-	if err != nil {
-		responseDTO.ServerErr = err
-		return
-	}
+		if err := repos.Expense.CreateUsersWithNumbers(ctx, numbers); err != nil {
+			return err
+		}
 
-	// create expense input with userID instead of phone number
-	var expenseInput ExpenseInputWithID
-	expenseInput.Fill(input, idPhoneMap, expense.ID)
+		idPhoneMap, err := repos.Expense.GetUserIDOfPhoneNumbers(ctx, numbers)
+		if err != nil {
+			return err
+		}
 
-	responseDTO2 := s.debtAppService.Create(app_debt.NewExpenseDebtInputWithID(
-		expenseInput.Name,
-		expenseInput.Description,
-		expenseInput.Creditors,
-		expenseInput.Debtors,
-		expenseInput.ExpenseID,
-	))
+		// create expense input with userID instead of phone number
+		var expenseInput ExpenseInputWithID
+		expenseInput.Fill(input, idPhoneMap, expense.ID)
 
-	if responseDTO2.UserErr != nil || responseDTO2.ServerErr != nil {
-		return responseDTO2
+		debtResponseDTO := s.debtAppService.WithRepository(repos.Debt).Create(ctx, app_debt.NewExpenseDebtInputWithID(
+			expenseInput.Name,
+			expenseInput.Description,
+			expenseInput.Creditors,
+			expenseInput.Debtors,
+			expenseInput.ExpenseID,
+		))
+
+		if debtResponseDTO.ServerErr != nil {
+			return debtResponseDTO.ServerErr
+		}
+		if debtResponseDTO.UserErr != nil {
+			responseDTO = debtResponseDTO
+			return errRollback
+		}
+		debtsCount, _ = debtResponseDTO.Data["count"].(int)
+
+		userIDs := make([]uint64, 0, len(idPhoneMap))
+		for _, id := range idPhoneMap {
+			userIDs = append(userIDs, id)
+		}
+		slices.Sort(userIDs)
+
+		payload := newExpensePayload(expense)
+		payload.UserIDs = slices.Compact(userIDs)
+		if err := app_outbox.SaveEvent(ctx, repos.Outbox, domain_outbox.EventExpenseCreated, domain_outbox.AggregateExpense, expense.ID, payload); err != nil {
+			return err
+		}
+
+		return app_audit.Record(ctx, repos.Audit, userID, domain_audit.ActionExpenseCreate, domain_audit.TargetExpense, expense.ID, nil, newExpenseSnapshot(expense, expenseInput))
+	})
+	if err != nil {
+		if !errors.Is(err, errRollback) {
+			responseDTO.ServerErr = err
+		}
+		return
 	}
 
+	metrics.ExpensesCreated.Inc()
+	metrics.DebtsCreated.Add(float64(debtsCount))
+
 	responseDTO.Data["msg"] = "Done"
 	return
 }
 
-func (s service) Delete(expenseID uint64, userID uint64) (responseDTO app_shared.ResponseDTO) {
+func (s service) Delete(ctx context.Context, expenseID uint64, userID uint64, version uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_expense.Delete")
+	defer span.End()
 
 	responseDTO.Data = make(map[string]any)
 
@@ -101,9 +155,61 @@ func (s service) Delete(expenseID uint64, userID uint64) (responseDTO app_shared
 		return
 	}
 
-	err := s.repo.Delete(expenseID, userID)
+	// debts and their notifications are deleted with expense
+	err := s.unitOfWork.Do(ctx, func(repos app_shared.TxRepositories) error {
+		// expense is read before its debts are deleted for payload of event
+		expense, err := repos.Expense.GetByID(ctx, expenseID, userID)
+		if errors.Is(err, database_errors.ErrRecordNotFound) {
+			responseDTO.UserErr = service_errors.ErrExpenseNotFound
+			responseDTO.ResponseCode = rcodes.ExpenseNotFound
+			return errRollback
+		}
+		if err != nil {
+			return err
+		}
+
+		if expense.CreatorID != userID {
+			responseDTO.UserErr = service_errors.ErrPermissionDenied
+			responseDTO.ResponseCode = rcodes.PermissionDenied
+			return errRollback
+		}
+
+		if version != 0 {
+			// conditional update locks expense until it is deleted
+			expense.Version = version
+			err = repos.Expense.Update(ctx, &expense)
+			if errors.Is(err, database_errors.ErrVersionConflict) {
+				return s.versionConflict(ctx, repos, expenseID, userID, &responseDTO)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Debt.DeleteByExpenseID(ctx, expenseID); err != nil {
+			return err
+		}
+
+		err = repos.Expense.Delete(ctx, expenseID, userID)
+		if errors.Is(err, database_errors.ErrRecordNotFound) {
+			responseDTO.UserErr = service_errors.ErrExpenseNotFound
+			responseDTO.ResponseCode = rcodes.ExpenseNotFound
+			return errRollback
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := app_outbox.SaveEvent(ctx, repos.Outbox, domain_outbox.EventExpenseDeleted, domain_outbox.AggregateExpense, expenseID, newExpensePayload(expense)); err != nil {
+			return err
+		}
+
+		return app_audit.Record(ctx, repos.Audit, userID, domain_audit.ActionExpenseDelete, domain_audit.TargetExpense, expenseID, NewExpenseOutput(expense), nil)
+	})
 	if err != nil {
-		responseDTO.ServerErr = err
+		if !errors.Is(err, errRollback) {
+			responseDTO.ServerErr = err
+		}
 		return
 	}
 
@@ -111,7 +217,27 @@ func (s service) Delete(expenseID uint64, userID uint64) (responseDTO app_shared
 	return
 }
 
-func (s service) Get(expenseID uint64, userID uint64) (responseDTO app_shared.ResponseDTO) {
+// set version conflict error with current state of expense in response
+func (s service) versionConflict(ctx context.Context, repos app_shared.TxRepositories, expenseID, userID uint64, responseDTO *app_shared.ResponseDTO) error {
+	current, err := repos.Expense.GetByID(ctx, expenseID, userID)
+	if errors.Is(err, database_errors.ErrRecordNotFound) {
+		responseDTO.UserErr = service_errors.ErrExpenseNotFound
+		responseDTO.ResponseCode = rcodes.ExpenseNotFound
+		return errRollback
+	}
+	if err != nil {
+		return err
+	}
+
+	responseDTO.UserErr = service_errors.ErrVersionConflict
+	responseDTO.ResponseCode = rcodes.VersionConflict
+	responseDTO.Data["expense"] = NewExpenseOutput(current)
+	return errRollback
+}
+
+func (s service) Get(ctx context.Context, expenseID uint64, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_expense.Get")
+	defer span.End()
 
 	responseDTO.Data = make(map[string]any)
 
@@ -121,17 +247,24 @@ func (s service) Get(expenseID uint64, userID uint64) (responseDTO app_shared.Re
 		return
 	}
 
-	expense, err := s.repo.GetByID(expenseID, userID)
+	expense, err := s.repo.GetByID(ctx, expenseID, userID)
+	if errors.Is(err, database_errors.ErrRecordNotFound) {
+		responseDTO.UserErr = service_errors.ErrExpenseNotFound
+		responseDTO.ResponseCode = rcodes.ExpenseNotFound
+		return
+	}
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
 	}
 
-	responseDTO.Data["expense"] = expense
+	responseDTO.Data["expense"] = NewExpenseOutput(expense)
 	return
 }
 
-func (s service) GetLimited(userID uint64, page, limit uint) (responseDTO app_shared.ResponseDTO) {
+func (s service) GetLimited(ctx context.Context, userID uint64, page, limit uint) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_expense.GetLimited")
+	defer span.End()
 
 	responseDTO.Data = make(map[string]any)
 
@@ -141,7 +274,7 @@ func (s service) GetLimited(userID uint64, page, limit uint) (responseDTO app_sh
 		return
 	}
 
-	expenses, err := s.repo.GetLimitedExpenseDebtByUserID(userID, int(page), int(limit))
+	expenses, err := s.repo.GetLimitedExpenseDebtByUserID(ctx, userID, int((page-1)*limit), int(limit))
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
@@ -151,6 +284,78 @@ func (s service) GetLimited(userID uint64, page, limit uint) (responseDTO app_sh
 	return
 }
 
-func (s service) Update(input ExpenseUpdateInput) {
-	panic("unimplemented")
+// only creator of expense can update it
+func (s service) Update(ctx context.Context, expenseID uint64, userID uint64, version uint64, input ExpenseUpdateInput) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_expense.Update")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	userErr := s.domainService.Get(expenseID)
+	if userErr == nil {
+		userErr = s.domainService.Update(domain_expense.NewExpenseUpdateInput(input.Name, input.Description))
+	}
+	if userErr != nil {
+		responseDTO.UserErr = userErr
+		responseDTO.ResponseCode = rcodes.InvalidField
+		return
+	}
+
+	err := s.unitOfWork.Do(ctx, func(repos app_shared.TxRepositories) error {
+		expense, err := repos.Expense.GetByID(ctx, expenseID, userID)
+		if errors.Is(err, database_errors.ErrRecordNotFound) {
+			responseDTO.UserErr = service_errors.ErrExpenseNotFound
+			responseDTO.ResponseCode = rcodes.ExpenseNotFound
+			return errRollback
+		}
+		if err != nil {
+			return err
+		}
+
+		if expense.CreatorID != userID {
+			responseDTO.UserErr = service_errors.ErrPermissionDenied
+			responseDTO.ResponseCode = rcodes.PermissionDenied
+			return errRollback
+		}
+
+		if version != 0 && expense.Version != version {
+			responseDTO.UserErr = service_errors.ErrVersionConflict
+			responseDTO.ResponseCode = rcodes.VersionConflict
+			responseDTO.Data["expense"] = NewExpenseOutput(expense)
+			return errRollback
+		}
+
+		before := NewExpenseOutput(expense)
+		expense.Name = input.Name
+		expense.Description = input.Description
+
+		// expense may be changed by another request after it was read
+		err = repos.Expense.Update(ctx, &expense)
+		if errors.Is(err, database_errors.ErrVersionConflict) {
+			return s.versionConflict(ctx, repos, expenseID, userID, &responseDTO)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := app_outbox.SaveEvent(ctx, repos.Outbox, domain_outbox.EventExpenseUpdated, domain_outbox.AggregateExpense, expense.ID, newExpensePayload(expense)); err != nil {
+			return err
+		}
+
+		if err := app_audit.Record(ctx, repos.Audit, userID, domain_audit.ActionExpenseUpdate, domain_audit.TargetExpense, expense.ID, before, NewExpenseOutput(expense)); err != nil {
+			return err
+		}
+
+		responseDTO.Data["expense"] = NewExpenseOutput(expense)
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, errRollback) {
+			responseDTO.ServerErr = err
+		}
+		return
+	}
+
+	responseDTO.Data["msg"] = "Done"
+	return
 }
@@ -0,0 +1,17 @@
+package app_outbox
+
+import (
+	"context"
+
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+)
+
+// save event in outbox. repo must be bound to transaction of state change (see app_shared.TxRepositories)
+func SaveEvent(ctx context.Context, repo domain_outbox.OutboxDomainRepository, eventType string, aggregateType string, aggregateID uint64, payload any) error {
+	event, err := domain_outbox.NewOutboxEvent(eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+
+	return repo.Create(ctx, &event)
+}
@@ -0,0 +1,165 @@
+package app_outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/eventbus"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+)
+
+const lastErrorMaxLength = 500
+
+type RelayOptions struct {
+	Interval    time.Duration // time between polls of outbox
+	BatchSize   int
+	MaxAttempts int           // event is marked as failed after this many attempts
+	Backoff     time.Duration // delay after first failed attempt. doubled after each attempt
+	MaxBackoff  time.Duration
+	ClaimLease  time.Duration // events of batch are not given to other relays for this long
+}
+
+// publish committed outbox events with at-least-once delivery. relays of multiple instances claim different events.
+// an event may be published more than once (e.g: crash after publish or expired claim), so consumers deduplicate with message id
+type Relay struct {
+	repo      domain_outbox.OutboxDomainRepository
+	publisher eventbus.Publisher
+	options   RelayOptions
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewRelay(repo domain_outbox.OutboxDomainRepository, publisher eventbus.Publisher, options RelayOptions) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		options:   options,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// publish pending events every interval until Shutdown
+func (r *Relay) Start() {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.options.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+
+			// drain outbox if there are more events than batch size
+			for {
+				published, err := r.PublishPending(context.Background())
+				if err != nil {
+					slog.Error("cannot relay outbox events", "error", err)
+					break
+				}
+				if published < r.options.BatchSize {
+					break
+				}
+			}
+		}
+	}()
+}
+
+// stop polling and wait for current batch. relay must be started
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// publish one batch of pending events. returns number of processed events
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "app_outbox.PublishPending")
+	defer span.End()
+
+	events, err := r.repo.ClaimPending(ctx, time.Now(), r.options.ClaimLease, r.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := r.publish(ctx, event); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
+}
+
+// returns error only if delivery state cannot be saved
+func (r *Relay) publish(ctx context.Context, event domain_outbox.OutboxEvent) error {
+	msg := eventbus.Message{
+		ID:         event.EventID,
+		Type:       event.Type,
+		Payload:    json.RawMessage(event.Payload),
+		OccurredAt: event.CreatedAt,
+	}
+
+	event.Attempts++
+	now := time.Now()
+
+	err := r.publisher.Publish(ctx, msg)
+	switch {
+	case err == nil:
+		event.IsPublished = true
+		event.PublishedAt = now
+		event.LastError = ""
+		metrics.OutboxEvents.WithLabelValues("published").Inc()
+
+	case event.Attempts >= r.options.MaxAttempts:
+		event.IsFailed = true
+		event.LastError = truncate(err.Error(), lastErrorMaxLength)
+		metrics.OutboxEvents.WithLabelValues("failed").Inc()
+		slog.ErrorContext(ctx, "outbox event failed after max attempts", "eventID", event.EventID, "type", event.Type, "attempts", event.Attempts, "error", err)
+
+	default:
+		event.NextAttemptAt = now.Add(r.backoff(event.Attempts))
+		event.LastError = truncate(err.Error(), lastErrorMaxLength)
+		metrics.OutboxEvents.WithLabelValues("retry").Inc()
+		slog.WarnContext(ctx, "cannot publish outbox event", "eventID", event.EventID, "type", event.Type, "attempts", event.Attempts, "error", err)
+	}
+
+	return r.repo.UpdateDelivery(ctx, event)
+}
+
+// exponential backoff after attempts
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.options.Backoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.options.MaxBackoff {
+			return r.options.MaxBackoff
+		}
+	}
+
+	return min(delay, r.options.MaxBackoff)
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+
+	return s[:length]
+}
@@ -0,0 +1,28 @@
+package app_shared
+
+import (
+	"context"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
+
+// repositories bound to a transaction of unit of work
+type TxRepositories struct {
+	User    domain_user.UserDomainRepository
+	Expense domain_expense.ExpenseDomainRepository
+	Debt    domain_debt.DebtDomainRepository
+	// events saved with outbox are published only if transaction is committed
+	Outbox domain_outbox.OutboxDomainRepository
+	// audit logs of changes are saved only if changes are committed
+	Audit domain_audit.AuditDomainRepository
+}
+
+// runs operations of multiple repositories in one transaction
+type UnitOfWork interface {
+	// changes of repos are committed if fn returns nil. otherwise they are rolled back and error of fn is returned
+	Do(ctx context.Context, fn func(repos TxRepositories) error) error
+}
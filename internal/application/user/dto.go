@@ -79,14 +79,48 @@ type ResetPasswordInput struct {
 // 	}
 // }
 
+type TwoFactorCodeInput struct {
+	shared_dto.TwoFactorCodeInput
+}
+
+type TwoFactorVerifyInput struct {
+	shared_dto.TwoFactorVerifyInput
+}
+
+type TwoFactorDisableInput struct {
+	shared_dto.TwoFactorDisableInput
+}
+
+type UpdateProfileInput struct {
+	shared_dto.UpdateProfileInput
+}
+
+type ChangeNumberInput struct {
+	shared_dto.ChangeNumberInput
+}
+
+type VerifyChangeNumberInput struct {
+	shared_dto.VerifyChangeNumberInput
+}
+
 type UserOutput struct {
 	Name   string `json:"name"`
 	Number string `json:"number"` // number must be fill from user contact for security. user contact may be empty for adding unknown user to user contact
 	Avatar string `json:"avatar"`
+	// small size of avatar
+	AvatarThumbnail string `json:"avatar_thumbnail"`
+	Language        string `json:"language"`
+	Currency        string `json:"currency"`
 }
 
 func (u *UserOutput) Fill(user domain_user.User) {
 	u.Name = user.Name
 	u.Number = user.Number
 	u.Avatar = user.Avatar
+	u.AvatarThumbnail = user.AvatarThumbnail
+	if u.AvatarThumbnail == "" {
+		u.AvatarThumbnail = user.Avatar
+	}
+	u.Language = user.Language
+	u.Currency = user.Currency
 }
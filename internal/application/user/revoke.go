@@ -0,0 +1,40 @@
+package app_user
+
+import (
+	"strconv"
+	"time"
+
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+// access tokens are not stored, so revoke time of user is kept in cache until all tokens issued before it are expired
+func accessRevokedCacheKey(userID uint64) string {
+	return "access_revoked:" + strconv.FormatUint(userID, 10)
+}
+
+// reject access tokens of user that are issued until now. used when user is blocked or logged out by admin
+func RevokeAccess(cacheRepo domain_shared.CacheRepository, userID uint64) error {
+	return cacheRepo.Save(accessRevokedCacheKey(userID), map[string]string{
+		"revoked_at": strconv.FormatInt(time.Now().UnixMilli(), 10),
+	}, config.JWTAccessExpire)
+}
+
+// issuedAt is in milliseconds
+func IsAccessRevoked(cacheRepo domain_shared.CacheRepository, userID uint64, issuedAt int64) (bool, error) {
+	info, _, err := cacheRepo.Get(accessRevokedCacheKey(userID))
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound || err == database_errors.ErrExpired {
+			return false, nil
+		}
+		return false, err
+	}
+
+	revokedAt, err := strconv.ParseInt(info["revoked_at"], 10, 64)
+	if err != nil {
+		return false, err
+	}
+
+	return issuedAt <= revokedAt, nil
+}
@@ -1,62 +1,83 @@
 package app_user
 
 import (
+	"bytes"
+	"context"
 	"errors"
-	"fmt"
+	"log/slog"
 	"math"
 	"math/rand"
+	"path"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
 	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
 	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
-
 	// app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
 	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
 	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
 	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
 	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/imageproc"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/s3"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/sms"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/totp"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
 )
 
 type UserAppService interface {
-	SendOTP(input SendOTPInput) app_shared.ResponseDTO
-	VerifyOTP(input VerifyOTPInput, deviceName string, deviceIP string) (mode int, responseDTO app_shared.ResponseDTO)
-	Signup(userInput SignupUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO)
-	GetUserInfo(userID uint64) app_shared.ResponseDTO
-	CheckNumber(numberInput NumberInput) app_shared.ResponseDTO
-	Login(loginInput LoginUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO)
-	GetAccessFromRefresh(refresh string) (responseDTO app_shared.ResponseDTO)
-	ChooseUserAvatar(avatarName string, userID uint64) app_shared.ResponseDTO
-	GetAvatars() app_shared.ResponseDTO
-	ResetPassword(input ResetPasswordInput) app_shared.ResponseDTO
+	SendOTP(ctx context.Context, input SendOTPInput) app_shared.ResponseDTO
+	VerifyOTP(ctx context.Context, input VerifyOTPInput, deviceName string, deviceIP string) (mode int, responseDTO app_shared.ResponseDTO)
+	Signup(ctx context.Context, userInput SignupUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO)
+	GetUserInfo(ctx context.Context, userID uint64) app_shared.ResponseDTO
+	CheckNumber(ctx context.Context, numberInput NumberInput) app_shared.ResponseDTO
+	Login(ctx context.Context, loginInput LoginUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO)
+	GetAccessFromRefresh(ctx context.Context, refresh string) (responseDTO app_shared.ResponseDTO)
+	ChooseUserAvatar(ctx context.Context, avatarName string, userID uint64) app_shared.ResponseDTO
+	GetAvatars(ctx context.Context) app_shared.ResponseDTO
+	ResetPassword(ctx context.Context, input ResetPasswordInput) app_shared.ResponseDTO
+	EnrollTwoFactor(ctx context.Context, userID uint64) app_shared.ResponseDTO
+	ConfirmTwoFactor(ctx context.Context, input TwoFactorCodeInput, userID uint64) app_shared.ResponseDTO
+	VerifyTwoFactor(ctx context.Context, input TwoFactorVerifyInput) app_shared.ResponseDTO
+	DisableTwoFactor(ctx context.Context, input TwoFactorDisableInput, userID uint64) app_shared.ResponseDTO
+	UpdateProfile(ctx context.Context, input UpdateProfileInput, userID uint64) app_shared.ResponseDTO
+	SendChangeNumberOTP(ctx context.Context, input ChangeNumberInput, userID uint64) app_shared.ResponseDTO
+	VerifyChangeNumber(ctx context.Context, input VerifyChangeNumberInput, userID uint64, deviceName string, deviceIP string) app_shared.ResponseDTO
+	UploadAvatar(ctx context.Context, image []byte, userID uint64) app_shared.ResponseDTO
 }
 
 type service struct {
 	repo             domain_user.UserDomainRepository
 	cacheRepo        domain_shared.CacheRepository
+	auditRepo        domain_audit.AuditDomainRepository
 	domainService    domain_user.UserDomainService
 	deviceAppService app_device.DeviceAppService
 }
 
-func NewUserService(repo domain_user.UserDomainRepository, cacheRepo domain_shared.CacheRepository, deviceAppService app_device.DeviceAppService, domainService domain_user.UserDomainService) UserAppService {
+// logins, signups and changes of password, two factor and number are saved in audit log with auditRepo
+func NewUserService(repo domain_user.UserDomainRepository, cacheRepo domain_shared.CacheRepository, auditRepo domain_audit.AuditDomainRepository, deviceAppService app_device.DeviceAppService, domainService domain_user.UserDomainService) UserAppService {
 	return &service{
 		repo:             repo,
 		cacheRepo:        cacheRepo,
+		auditRepo:        auditRepo,
 		domainService:    domainService,
 		deviceAppService: deviceAppService,
 	}
 }
 
 // sent otp code to number
-func (s *service) SendOTP(input SendOTPInput) (responseDTO app_shared.ResponseDTO) {
+func (s *service) SendOTP(ctx context.Context, input SendOTPInput) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.SendOTP")
+	defer span.End()
 
 	responseDTO.Data = make(map[string]any)
 
@@ -81,7 +102,7 @@ func (s *service) SendOTP(input SendOTPInput) (responseDTO app_shared.ResponseDT
 		if config.Debug == false {
 
 			// send code to number
-			err = sms.SendOTPSMS(input.PhoneNumber[3:], otp)
+			err = sms.SendOTPSMS(ctx, input.PhoneNumber[3:], otp)
 			if err != nil {
 				responseDTO.ServerErr = err
 				return responseDTO
@@ -98,7 +119,11 @@ func (s *service) SendOTP(input SendOTPInput) (responseDTO app_shared.ResponseDT
 			responseDTO.ServerErr = err
 			return responseDTO
 		}
-		fmt.Println("OTP: ", otp)
+
+		// sms is not sent in debug mode
+		if config.Debug {
+			slog.DebugContext(ctx, "debug mode: sms is not sent", "otp", logger.Unredacted(strconv.Itoa(otp)))
+		}
 
 		responseDTO.ResponseCode = rcodes.CodeSendToNumber
 		responseDTO.Data["token"] = token.String()
@@ -122,7 +147,9 @@ func (s *service) SendOTP(input SendOTPInput) (responseDTO app_shared.ResponseDT
 }
 
 // check otp code
-func (s *service) VerifyOTP(verifyNumberInput VerifyOTPInput, deviceName string, deviceIP string) (int, app_shared.ResponseDTO) {
+func (s *service) VerifyOTP(ctx context.Context, verifyNumberInput VerifyOTPInput, deviceName string, deviceIP string) (int, app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.VerifyOTP")
+	defer span.End()
 
 	var responseDTO app_shared.ResponseDTO
 	responseDTO.Data = make(map[string]any)
@@ -190,7 +217,7 @@ func (s *service) VerifyOTP(verifyNumberInput VerifyOTPInput, deviceName string,
 	if otp == strconv.Itoa(int(verifyNumberInput.OTP)) {
 
 		// get user
-		user, databaseErr := s.repo.GetByNumber(verifyNumberInput.PhoneNumber)
+		user, databaseErr := s.repo.GetByNumber(ctx, verifyNumberInput.PhoneNumber)
 
 		var isUserRegistered bool
 		isUserExist := true
@@ -256,6 +283,28 @@ func (s *service) VerifyOTP(verifyNumberInput VerifyOTPInput, deviceName string,
 			verifyInfo["mode"] = "reset_password"
 			verifyInfo["is_user_exist"] = strconv.FormatBool(isUserExist)
 
+			// user must pass two factor before reset password
+			if user.IsTOTPEnabled {
+				verifyInfo["mode"] = "reset_password_two_factor"
+
+				err := s.cacheRepo.Save(verifyNumberInput.PhoneNumber, verifyInfo, config.VerifyNumberCacheExpireTime)
+				if err != nil {
+					responseDTO.ServerErr = err
+					return 0, responseDTO
+				}
+
+				challengeToken, err := s.createTwoFactorChallenge(ctx, user, "reset_password", deviceName, deviceIP)
+				if err != nil {
+					responseDTO.ServerErr = err
+					return 0, responseDTO
+				}
+
+				responseDTO.Data["challenge_token"] = challengeToken
+				responseDTO.Data["expireTimeSeconds"] = math.Round(config.TwoFactorChallengeExpireTime.Seconds())
+				responseDTO.ResponseCode = rcodes.TwoFactorRequired
+				return 3, responseDTO
+			}
+
 			err := s.cacheRepo.Save(verifyNumberInput.PhoneNumber, verifyInfo, config.VerifyNumberCacheExpireTime)
 			if err != nil {
 				responseDTO.ServerErr = err
@@ -274,11 +323,14 @@ func (s *service) VerifyOTP(verifyNumberInput VerifyOTPInput, deviceName string,
 
 }
 
-func (s *service) Signup(userInput SignupUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO) {
+func (s *service) Signup(ctx context.Context, userInput SignupUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.Signup")
+	defer span.End()
 
 	responseDTO.Data = make(map[string]any)
 
 	// call domain service
+	_, domainSpan := tracing.Start(ctx, "domain_user.Signup")
 	user, userErr, serverErr := s.domainService.Signup(
 		domain_user.NewSignupUserInput(
 			userInput.PhoneNumber,
@@ -286,6 +338,7 @@ func (s *service) Signup(userInput SignupUserInput, deviceName string, deviceIP
 			userInput.Password,
 			userInput.Token,
 		))
+	domainSpan.End()
 
 	if serverErr != nil {
 		responseDTO.ServerErr = serverErr
@@ -338,7 +391,7 @@ func (s *service) Signup(userInput SignupUserInput, deviceName string, deviceIP
 
 	// select random avatar for user
 	// get list of avatars
-	avatars, err := s3.GetListObjects(config.AvatarPath)
+	avatars, err := s3.GetListObjects(ctx, config.AvatarPath)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
@@ -357,20 +410,22 @@ func (s *service) Signup(userInput SignupUserInput, deviceName string, deviceIP
 	if isUserExist == "true" {
 		// update user
 
-		err = s.repo.UpdateColumns(user)
+		err = s.repo.UpdateColumns(ctx, user)
 		if err != nil {
 			responseDTO.ServerErr = err
 			return
 		}
 	} else {
 		// insert user into database
-		err = s.repo.Create(&user)
+		err = s.repo.Create(ctx, &user)
 		if err != nil {
 			responseDTO.ServerErr = err
 			return responseDTO
 		}
 	}
 
+	app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionSignup, domain_audit.TargetUser, user.ID, nil, map[string]string{"name": user.Name, "number": user.Number})
+
 	tokens, err := jwt.CreateRefreshAndAccessFromUserWithMap(config.JWtRefreshExpire, config.JWTAccessExpire, user.ID, user.Name, user.Number, user.IsRegistered)
 	if err != nil {
 		responseDTO.ServerErr = err
@@ -378,7 +433,7 @@ func (s *service) Signup(userInput SignupUserInput, deviceName string, deviceIP
 	}
 
 	// create device
-	err = s.deviceAppService.CreateOrUpdate(
+	err = s.deviceAppService.CreateOrUpdate(ctx,
 		domain_device.NewDeviceInput(
 			deviceName,
 			deviceIP,
@@ -403,15 +458,20 @@ func (s *service) Signup(userInput SignupUserInput, deviceName string, deviceIP
 	return responseDTO
 }
 
-func (s *service) ResetPassword(input ResetPasswordInput) (responseDTO app_shared.ResponseDTO) {
+func (s *service) ResetPassword(ctx context.Context, input ResetPasswordInput) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.ResetPassword")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
+	_, domainSpan := tracing.Start(ctx, "domain_user.ResetPassword")
 	userErr, serverErr, salt, hashedPassword := s.domainService.ResetPassword(
 		domain_user.NewResetPasswordInput(
 			input.PhoneNumber,
 			input.Password,
 			input.Token,
 		))
+	domainSpan.End()
 
 	if serverErr != nil {
 		responseDTO.ServerErr = serverErr
@@ -456,7 +516,7 @@ func (s *service) ResetPassword(input ResetPasswordInput) (responseDTO app_share
 		return
 	}
 
-	user, err := s.repo.GetByNumber(input.PhoneNumber)
+	user, err := s.repo.GetByNumber(ctx, input.PhoneNumber)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
@@ -465,12 +525,14 @@ func (s *service) ResetPassword(input ResetPasswordInput) (responseDTO app_share
 	user.Password = hashedPassword
 	user.Salt = salt
 
-	err = s.repo.Update(user)
+	err = s.repo.Update(ctx, user)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
 	}
 
+	app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionPasswordReset, domain_audit.TargetUser, user.ID, nil, nil)
+
 	// user must be wait until number delay
 	verifyInfo["mode"] = "done"
 	if err = s.cacheRepo.Save(input.PhoneNumber, verifyInfo, config.VerifyNumberCacheExpireTime); err != nil {
@@ -490,12 +552,15 @@ func (s *service) ResetPassword(input ResetPasswordInput) (responseDTO app_share
 
 }
 
-func (s *service) GetUserInfo(userID uint64) (responseDTO app_shared.ResponseDTO) {
+func (s *service) GetUserInfo(ctx context.Context, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.GetUserInfo")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
 	var userOutput UserOutput
 	// get user from database for full information
-	user, err := s.repo.GetByID(userID)
+	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
@@ -506,7 +571,10 @@ func (s *service) GetUserInfo(userID uint64) (responseDTO app_shared.ResponseDTO
 	return responseDTO
 }
 
-func (s *service) CheckNumber(numberInput NumberInput) (responseDTO app_shared.ResponseDTO) {
+func (s *service) CheckNumber(ctx context.Context, numberInput NumberInput) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.CheckNumber")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
 	err := s.domainService.CheckNumber(numberInput.PhoneNumber)
@@ -516,7 +584,7 @@ func (s *service) CheckNumber(numberInput NumberInput) (responseDTO app_shared.R
 		return responseDTO
 	}
 
-	user, err := s.repo.GetByNumber(numberInput.PhoneNumber)
+	user, err := s.repo.GetByNumber(ctx, numberInput.PhoneNumber)
 	isExist := false
 	if err != nil {
 		if err == database_errors.ErrRecordNotFound {
@@ -540,7 +608,10 @@ func (s *service) CheckNumber(numberInput NumberInput) (responseDTO app_shared.R
 
 }
 
-func (s *service) Login(loginInput LoginUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO) {
+func (s *service) Login(ctx context.Context, loginInput LoginUserInput, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.Login")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
 	if err := s.domainService.CheckNumber(loginInput.PhoneNumber); err != nil {
@@ -548,7 +619,33 @@ func (s *service) Login(loginInput LoginUserInput, deviceName string, deviceIP s
 		return
 	}
 
-	user, err := s.repo.GetByNumber(loginInput.PhoneNumber)
+	// limit guessing password. attempts are counted before checking password, so concurrent requests cannot pass limit.
+	// window starts from first attempt. counters are reset after successful login
+	attemptsKey := loginAttemptsCacheKey(loginInput.PhoneNumber, deviceIP)
+	numberAttemptsKey := loginNumberAttemptsCacheKey(loginInput.PhoneNumber)
+	attempts, _, err := s.cacheRepo.Increment(attemptsKey, config.LoginAttemptsWindow)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	if attempts > config.LoginMaxAttempts {
+		responseDTO.ResponseCode = rcodes.TooManyRequests
+		responseDTO.UserErr = service_errors.ErrTooManyLoginAttempts
+		return
+	}
+	// number attempts are counted only for allowed attempts of ip, so one ip cannot lock out user
+	numberAttempts, _, err := s.cacheRepo.Increment(numberAttemptsKey, config.LoginAttemptsWindow)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	if numberAttempts > config.LoginMaxNumberAttempts {
+		responseDTO.ResponseCode = rcodes.TooManyRequests
+		responseDTO.UserErr = service_errors.ErrTooManyLoginAttempts
+		return
+	}
+
+	user, err := s.repo.GetByNumber(ctx, loginInput.PhoneNumber)
 	if err != nil {
 		if err == database_errors.ErrRecordNotFound {
 			responseDTO.ResponseCode = rcodes.NumberNotExist
@@ -560,6 +657,7 @@ func (s *service) Login(loginInput LoginUserInput, deviceName string, deviceIP s
 		return responseDTO
 	}
 
+	_, domainSpan := tracing.Start(ctx, "domain_user.Login")
 	userErr, serverErr := s.domainService.Login(
 		domain_user.NewLoginUserInput(
 			loginInput.PhoneNumber,
@@ -569,6 +667,7 @@ func (s *service) Login(loginInput LoginUserInput, deviceName string, deviceIP s
 			user.IsBlocked,
 			user.IsRegistered,
 		))
+	domainSpan.End()
 
 	if serverErr != nil {
 		responseDTO.ServerErr = serverErr
@@ -580,17 +679,72 @@ func (s *service) Login(loginInput LoginUserInput, deviceName string, deviceIP s
 			responseDTO.ResponseCode = rcodes.UserNotRegistered
 		}
 		responseDTO.UserErr = userErr
+
+		app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionLoginFailed, domain_audit.TargetUser, user.ID, nil, map[string]string{"reason": userErr.Error()})
 		return responseDTO
 	}
 
-	tokens, err := jwt.CreateRefreshAndAccessFromUserWithMap(config.JWtRefreshExpire, config.JWTAccessExpire, user.ID, user.Name, user.Number, user.IsRegistered)
+	// user must pass second step with totp code. login counters are reset after second step,
+	// so password cannot be used to get new challenges for guessing code
+	if user.IsTOTPEnabled {
+		challengeToken, err := s.createTwoFactorChallenge(ctx, user, "login", deviceName, deviceIP)
+		if err != nil {
+			responseDTO.ServerErr = err
+			return responseDTO
+		}
+
+		responseDTO.ResponseCode = rcodes.TwoFactorRequired
+		responseDTO.Data["challenge_token"] = challengeToken
+		responseDTO.Data["expireTimeSeconds"] = math.Round(config.TwoFactorChallengeExpireTime.Seconds())
+		return responseDTO
+	}
+
+	if err := s.resetLoginAttempts(loginInput.PhoneNumber, deviceIP); err != nil {
+		responseDTO.ServerErr = err
+		return responseDTO
+	}
+
+	tokens, err := s.createTokensAndDevice(ctx, user, deviceName, deviceIP)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return responseDTO
 	}
 
+	// device is already created. failure of audit log does not fail login
+	app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionLogin, domain_audit.TargetUser, user.ID, nil, map[string]string{"method": "password"})
+
+	responseDTO.Data = utils.ConvertMapStringStringToMapStringAny(tokens)
+	return responseDTO
+}
+
+// attempts of number from one ip
+func loginAttemptsCacheKey(number string, ip string) string {
+	return "login_attempts:" + number + ":" + ip
+}
+
+// attempts of number from all ips
+func loginNumberAttemptsCacheKey(number string) string {
+	return "login_attempts:" + number
+}
+
+func (s *service) resetLoginAttempts(number string, ip string) error {
+	for _, key := range []string{loginAttemptsCacheKey(number, ip), loginNumberAttemptsCacheKey(number)} {
+		if err := s.cacheRepo.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) createTokensAndDevice(ctx context.Context, user domain_user.User, deviceName string, deviceIP string) (map[string]string, error) {
+
+	tokens, err := jwt.CreateRefreshAndAccessFromUserWithMap(config.JWtRefreshExpire, config.JWTAccessExpire, user.ID, user.Name, user.Number, user.IsRegistered)
+	if err != nil {
+		return nil, err
+	}
+
 	// create device
-	err = s.deviceAppService.CreateOrUpdate(
+	err = s.deviceAppService.CreateOrUpdate(ctx,
 		domain_device.NewDeviceInput(
 			deviceName,
 			deviceIP,
@@ -599,18 +753,19 @@ func (s *service) Login(loginInput LoginUserInput, deviceName string, deviceIP s
 		))
 
 	if err != nil {
-		responseDTO.ServerErr = err
-		return responseDTO
+		return nil, err
 	}
 
-	responseDTO.Data = utils.ConvertMapStringStringToMapStringAny(tokens)
-	return responseDTO
+	return tokens, nil
 }
 
-func (s *service) GetAccessFromRefresh(refresh string) (responseDTO app_shared.ResponseDTO) {
+func (s *service) GetAccessFromRefresh(ctx context.Context, refresh string) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.GetAccessFromRefresh")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
-	user, userErr, serverErr := s.deviceAppService.GetDeviceUserByRefreshToken(refresh)
+	user, userErr, serverErr := s.deviceAppService.GetDeviceUserByRefreshToken(ctx, refresh)
 	if serverErr != nil {
 		responseDTO.ServerErr = serverErr
 		return responseDTO
@@ -620,6 +775,11 @@ func (s *service) GetAccessFromRefresh(refresh string) (responseDTO app_shared.R
 		return responseDTO
 	}
 
+	if user.IsBlocked {
+		responseDTO.UserErr = service_errors.ErrBlockedUser
+		return responseDTO
+	}
+
 	access, err := jwt.CreateAccessFromUser(config.JWTAccessExpire, user.ID, user.Name, user.Number, user.IsRegistered)
 	responseDTO.ServerErr = err
 	responseDTO.Data["access"] = access
@@ -628,10 +788,13 @@ func (s *service) GetAccessFromRefresh(refresh string) (responseDTO app_shared.R
 
 }
 
-func (s *service) ChooseUserAvatar(avatarName string, userID uint64) (responseDTO app_shared.ResponseDTO) {
+func (s *service) ChooseUserAvatar(ctx context.Context, avatarName string, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.ChooseUserAvatar")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
-	avatars, err := s3.GetListObjects(config.AvatarPath)
+	avatars, err := s3.GetListObjects(ctx, config.AvatarPath)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
@@ -651,27 +814,115 @@ func (s *service) ChooseUserAvatar(avatarName string, userID uint64) (responseDT
 		return
 	}
 
-	user, err := s.repo.GetByID(userID)
+	user, err := s.repo.GetByID(ctx, userID)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
 	}
 
+	previousUser := user
+
 	user.Avatar = avatarName
-	err = s.repo.Update(user)
+	user.AvatarThumbnail = avatarName
+	user.AvatarKey = ""
+	err = s.repo.UpdateAvatar(ctx, user)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
 	}
 
+	s.deleteUploadedAvatar(ctx, previousUser)
+
 	responseDTO.Data["msg"] = "avatar saved"
 	return
 }
 
-func (s *service) GetAvatars() (responseDTO app_shared.ResponseDTO) {
+// image is resized to standard sizes and saved without metadata
+func (s *service) UploadAvatar(ctx context.Context, image []byte, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.UploadAvatar")
+	defer span.End()
+
 	responseDTO.Data = make(map[string]any)
 
-	avatars, err := s3.GetListObjects(config.AvatarPath)
+	userErr := s.domainService.UploadAvatar(len(image), imageproc.DetectContentType(image))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	img, err := imageproc.Decode(image)
+	if err != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = service_errors.ErrInvalidImage
+		return
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	keyPrefix := path.Join(config.UserAvatarPath, strconv.FormatUint(userID, 10), uuid.New().String())
+	uploadedUser := domain_user.User{AvatarKey: keyPrefix}
+
+	// uploaded files are not used if saving avatar fails
+	defer func() {
+		if responseDTO.ServerErr != nil {
+			s.deleteUploadedAvatar(ctx, uploadedUser)
+		}
+	}()
+
+	for _, size := range []int{config.AvatarSize, config.AvatarThumbnailSize} {
+		encoded, err := imageproc.EncodeJPEG(imageproc.SquareThumbnail(img, size))
+		if err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		err = s3.PutObjectWithContentType(ctx, domain_user.UploadedAvatarKey(keyPrefix, size), bytes.NewReader(encoded), "image/jpeg")
+		if err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+	}
+
+	previousUser := user
+
+	user.Avatar = s3.GetObjectURL(domain_user.UploadedAvatarKey(keyPrefix, config.AvatarSize))
+	user.AvatarThumbnail = s3.GetObjectURL(domain_user.UploadedAvatarKey(keyPrefix, config.AvatarThumbnailSize))
+	user.AvatarKey = keyPrefix
+	err = s.repo.UpdateAvatar(ctx, user)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	s.deleteUploadedAvatar(ctx, previousUser)
+
+	responseDTO.Data["avatar"] = user.Avatar
+	responseDTO.Data["avatar_thumbnail"] = user.AvatarThumbnail
+	responseDTO.Data["msg"] = "avatar saved"
+	return
+}
+
+// previous uploaded avatar is not needed after changing avatar
+func (s *service) deleteUploadedAvatar(ctx context.Context, user domain_user.User) {
+	for _, key := range user.UploadedAvatarKeys() {
+		if err := s3.DeleteObject(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "cannot delete uploaded avatar", "key", key, "error", err)
+		}
+	}
+}
+
+func (s *service) GetAvatars(ctx context.Context) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.GetAvatars")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	avatars, err := s3.GetListObjects(ctx, config.AvatarPath)
 	if err != nil {
 		responseDTO.ServerErr = err
 		return
@@ -681,3 +932,642 @@ func (s *service) GetAvatars() (responseDTO app_shared.ResponseDTO) {
 	return
 
 }
+
+func twoFactorChallengeCacheKey(challengeToken string) string {
+	return "two_factor_challenge:" + challengeToken
+}
+
+// second factor attempts of user in TwoFactorAttemptsWindow. attempts are counted per user, not per challenge,
+// so new challenges from login cannot be used for guessing code
+func twoFactorAttemptsCacheKey(userID uint64) string {
+	return "two_factor_attempts:" + strconv.FormatUint(userID, 10)
+}
+
+// counts attempt before checking code, so concurrent requests cannot pass limit. returns false if user is locked out
+func (s *service) takeTwoFactorAttempt(userID uint64) (int64, bool, error) {
+	attempts, _, err := s.cacheRepo.Increment(twoFactorAttemptsCacheKey(userID), config.TwoFactorAttemptsWindow)
+	if err != nil {
+		return 0, false, err
+	}
+	return attempts, attempts <= config.TwoFactorMaxAttempts, nil
+}
+
+// purpose is "login" or "reset_password"
+func (s *service) createTwoFactorChallenge(ctx context.Context, user domain_user.User, purpose string, deviceName string, deviceIP string) (string, error) {
+
+	challengeToken := uuid.New().String()
+
+	challengeInfo := make(map[string]string)
+	challengeInfo["user_id"] = strconv.FormatUint(user.ID, 10)
+	challengeInfo["number"] = user.Number
+	challengeInfo["purpose"] = purpose
+	challengeInfo["device_name"] = deviceName
+	challengeInfo["device_ip"] = deviceIP
+
+	err := s.cacheRepo.Save(twoFactorChallengeCacheKey(challengeToken), challengeInfo, config.TwoFactorChallengeExpireTime)
+	if err != nil {
+		return "", err
+	}
+
+	return challengeToken, nil
+}
+
+func (s *service) EnrollTwoFactor(ctx context.Context, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.EnrollTwoFactor")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	secret, userErr, serverErr := s.domainService.EnrollTwoFactor(user)
+	if serverErr != nil {
+		responseDTO.ServerErr = serverErr
+		return
+	}
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.TwoFactorAlreadyEnabled
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	// secret saved but two factor is not enabled until user confirm it with a code
+	user.TOTPSecret = secret
+	user.IsTOTPEnabled = false
+	user.TOTPRecoveryCodes = ""
+	err = s.repo.UpdateTwoFactor(ctx, user)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data["secret"] = secret
+	responseDTO.Data["uri"] = totp.ProvisioningURI(secret, config.TOTPIssuer, user.Number)
+	return
+}
+
+func (s *service) ConfirmTwoFactor(ctx context.Context, input TwoFactorCodeInput, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.ConfirmTwoFactor")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	user, recoveryCodes, userErr, serverErr := s.domainService.ConfirmTwoFactor(user, input.Code)
+	if serverErr != nil {
+		responseDTO.ServerErr = serverErr
+		return
+	}
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		switch userErr {
+		case service_errors.ErrTwoFactorAlreadyEnabled:
+			responseDTO.ResponseCode = rcodes.TwoFactorAlreadyEnabled
+		case service_errors.ErrEnrollTwoFactorFirst:
+			responseDTO.ResponseCode = rcodes.EnrollTwoFactorFirst
+		}
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	err = s.repo.UpdateTwoFactor(ctx, user)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionTwoFactorEnable, domain_audit.TargetUser, user.ID, nil, nil)
+
+	// recovery codes are shown only once
+	responseDTO.Data["recovery_codes"] = recoveryCodes
+	responseDTO.Data["msg"] = "two factor authentication enabled"
+	return
+}
+
+func (s *service) VerifyTwoFactor(ctx context.Context, input TwoFactorVerifyInput) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.VerifyTwoFactor")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	cacheKey := twoFactorChallengeCacheKey(input.ChallengeToken)
+	challengeInfo, _, err := s.cacheRepo.Get(cacheKey)
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound || err == database_errors.ErrExpired {
+			responseDTO.ResponseCode = rcodes.ChallengeExpired
+			responseDTO.UserErr = service_errors.ErrTwoFactorChallengeExpired
+			return
+		}
+
+		responseDTO.ServerErr = err
+		return
+	}
+
+	if err := utils.CheckMapHaveKeys(challengeInfo, "user_id", "number", "purpose", "device_name", "device_ip"); err != nil {
+		responseDTO.ServerErr = errors.New("invalid challengeInfo in VerifyTwoFactor: " + err.Error())
+		return
+	}
+
+	userID, err := strconv.ParseUint(challengeInfo["user_id"], 10, 64)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	if user.IsBlocked {
+		responseDTO.UserErr = service_errors.ErrBlockedUser
+		return
+	}
+
+	// limit guessing code
+	attempts, allowed, err := s.takeTwoFactorAttempt(user.ID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	if !allowed {
+		if err := s.cacheRepo.Delete(cacheKey); err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		responseDTO.ResponseCode = rcodes.ChallengeExpired
+		responseDTO.UserErr = service_errors.ErrTooManyTwoFactorAttempts
+		return
+	}
+
+	recoveryCodes, lastCounter, userErr := s.domainService.VerifyTwoFactor(domain_user.NewTwoFactorInput(
+		input.Code,
+		input.RecoveryCode,
+		user,
+	))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+
+		if challengeInfo["purpose"] == "login" {
+			app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionLoginFailed, domain_audit.TargetUser, user.ID, nil, map[string]string{"reason": userErr.Error()})
+		}
+
+		// last allowed attempt is used
+		if attempts >= config.TwoFactorMaxAttempts {
+			if err := s.cacheRepo.Delete(cacheKey); err != nil {
+				responseDTO.ServerErr = err
+				return
+			}
+
+			responseDTO.ResponseCode = rcodes.ChallengeExpired
+			responseDTO.UserErr = service_errors.ErrTooManyTwoFactorAttempts
+		}
+		return
+	}
+
+	// totp code can be used only once. counter is checked again in database for concurrent requests
+	if lastCounter != user.TOTPLastCounter {
+		if err := s.repo.UpdateTOTPCounter(ctx, user.ID, lastCounter); err != nil {
+			if err == database_errors.ErrVersionConflict {
+				responseDTO.ResponseCode = rcodes.InvalidField
+				responseDTO.UserErr = service_errors.ErrWrongTOTPCode
+				return
+			}
+			responseDTO.ServerErr = err
+			return
+		}
+	}
+
+	// challenge can be used only once
+	if err := s.cacheRepo.Delete(cacheKey); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	// recovery code used. codes are compared in database for concurrent requests
+	if recoveryCodes != user.TOTPRecoveryCodes {
+		if err := s.repo.UpdateTOTPRecoveryCodes(ctx, user.ID, user.TOTPRecoveryCodes, recoveryCodes); err != nil {
+			if err == database_errors.ErrVersionConflict {
+				responseDTO.ResponseCode = rcodes.InvalidField
+				responseDTO.UserErr = service_errors.ErrWrongRecoveryCode
+				return
+			}
+			responseDTO.ServerErr = err
+			return
+		}
+	}
+
+	if err := s.cacheRepo.Delete(twoFactorAttemptsCacheKey(user.ID)); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	switch challengeInfo["purpose"] {
+	case "login":
+		if err := s.resetLoginAttempts(challengeInfo["number"], challengeInfo["device_ip"]); err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		tokens, err := s.createTokensAndDevice(ctx, user, challengeInfo["device_name"], challengeInfo["device_ip"])
+		if err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		method := "totp"
+		if input.Code == "" {
+			method = "recovery_code"
+		}
+		app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionLogin, domain_audit.TargetUser, user.ID, nil, map[string]string{"method": "password", "second_factor": method})
+
+		responseDTO.Data = utils.ConvertMapStringStringToMapStringAny(tokens)
+		return
+
+	case "reset_password":
+		verifyInfo, _, err := s.cacheRepo.Get(challengeInfo["number"])
+		if err != nil {
+			if err == database_errors.ErrRecordNotFound || err == database_errors.ErrExpired {
+				responseDTO.ResponseCode = rcodes.VerifyNumberFirst
+				responseDTO.UserErr = service_errors.ErrVerifyNumberFirst
+				return
+			}
+
+			responseDTO.ServerErr = err
+			return
+		}
+
+		if verifyInfo["mode"] != "reset_password_two_factor" {
+			responseDTO.ResponseCode = rcodes.VerifyNumberFirst
+			responseDTO.UserErr = service_errors.ErrVerifyNumberFirst
+			return
+		}
+
+		verifyInfo["mode"] = "reset_password"
+		if err := s.cacheRepo.Save(challengeInfo["number"], verifyInfo, config.VerifyNumberCacheExpireTime); err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		responseDTO.Data["msg"] = "go to reset password"
+		responseDTO.ResponseCode = rcodes.GoRestPassword
+		return
+
+	default:
+		responseDTO.ServerErr = errors.New("invalid purpose in challengeInfo in VerifyTwoFactor")
+		return
+	}
+}
+
+func (s *service) DisableTwoFactor(ctx context.Context, input TwoFactorDisableInput, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.DisableTwoFactor")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	if !user.IsTOTPEnabled {
+		responseDTO.ResponseCode = rcodes.TwoFactorNotEnabled
+		responseDTO.UserErr = service_errors.ErrTwoFactorNotEnabled
+		return
+	}
+
+	// password and code are guessed with same limit of second factor
+	_, allowed, err := s.takeTwoFactorAttempt(user.ID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+	if !allowed {
+		responseDTO.ResponseCode = rcodes.TooManyRequests
+		responseDTO.UserErr = service_errors.ErrTooManyTwoFactorAttempts
+		return
+	}
+
+	recoveryCodes, lastCounter, userErr := s.domainService.DisableTwoFactor(domain_user.NewDisableTwoFactorInput(
+		input.Password,
+		input.Code,
+		input.RecoveryCode,
+		user,
+	))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	// used code is saved before disabling, so concurrent requests cannot use it
+	if lastCounter != user.TOTPLastCounter {
+		if err := s.repo.UpdateTOTPCounter(ctx, user.ID, lastCounter); err != nil {
+			if err == database_errors.ErrVersionConflict {
+				responseDTO.ResponseCode = rcodes.InvalidField
+				responseDTO.UserErr = service_errors.ErrWrongTOTPCode
+				return
+			}
+			responseDTO.ServerErr = err
+			return
+		}
+		user.TOTPLastCounter = lastCounter
+	}
+
+	if recoveryCodes != user.TOTPRecoveryCodes {
+		if err := s.repo.UpdateTOTPRecoveryCodes(ctx, user.ID, user.TOTPRecoveryCodes, recoveryCodes); err != nil {
+			if err == database_errors.ErrVersionConflict {
+				responseDTO.ResponseCode = rcodes.InvalidField
+				responseDTO.UserErr = service_errors.ErrWrongRecoveryCode
+				return
+			}
+			responseDTO.ServerErr = err
+			return
+		}
+	}
+
+	user.TOTPSecret = ""
+	user.IsTOTPEnabled = false
+	user.TOTPRecoveryCodes = ""
+	err = s.repo.UpdateTwoFactor(ctx, user)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	if err := s.cacheRepo.Delete(twoFactorAttemptsCacheKey(user.ID)); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionTwoFactorDisable, domain_audit.TargetUser, user.ID, nil, nil)
+
+	responseDTO.Data["msg"] = "two factor authentication disabled"
+	return
+}
+
+func (s *service) UpdateProfile(ctx context.Context, input UpdateProfileInput, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.UpdateProfile")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	user, userErr := s.domainService.UpdateProfile(user, domain_user.NewUpdateProfileInput(
+		input.Name,
+		input.Language,
+		input.Currency,
+	))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	err = s.repo.Update(ctx, user)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	// name is saved in access token
+	access, err := jwt.CreateAccessFromUser(config.JWTAccessExpire, user.ID, user.Name, user.Number, user.IsRegistered)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	var userOutput UserOutput
+	userOutput.Fill(user)
+
+	responseDTO.Data["data"] = userOutput
+	responseDTO.Data["access"] = access
+	return
+}
+
+func changeNumberCacheKey(userID uint64) string {
+	return "change_number:" + strconv.FormatUint(userID, 10)
+}
+
+// wrong otps are counted for token of each sent otp, so concurrent requests cannot overwrite count
+func changeNumberAttemptsCacheKey(userID uint64, token string) string {
+	return "change_number_attempts:" + strconv.FormatUint(userID, 10) + ":" + token
+}
+
+// send otp to both current number and new number
+func (s *service) SendChangeNumberOTP(ctx context.Context, input ChangeNumberInput, userID uint64) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.SendChangeNumberOTP")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	userErr := s.domainService.ChangeNumber(domain_user.NewChangeNumberInput(user.Number, input.NewPhoneNumber))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	// only unregistered users can be merged
+	newNumberUser, err := s.repo.GetByNumber(ctx, input.NewPhoneNumber)
+	if err != nil && err != database_errors.ErrRecordNotFound {
+		responseDTO.ServerErr = err
+		return
+	}
+	if err == nil && newNumberUser.IsRegistered {
+		responseDTO.ResponseCode = rcodes.UserAlreadyRegistered
+		responseDTO.UserErr = service_errors.ErrNumberAlreadyRegistered
+		return
+	}
+
+	cacheKey := changeNumberCacheKey(userID)
+	_, expireTime, err := s.cacheRepo.Get(cacheKey)
+	delayTime := expireTime.Add(config.VerifyNumberCacheExpireTimeForNumberDelay).Sub(time.Now().Add(config.VerifyNumberCacheExpireTime))
+	if err != nil && err != database_errors.ErrExpired && err != database_errors.ErrRecordNotFound {
+		responseDTO.ServerErr = err
+		return
+	}
+	if err == nil && delayTime.Seconds() > 0 {
+		responseDTO.ResponseCode = rcodes.NumberDelay
+		responseDTO.UserErr = service_errors.ErrOTPNotExpired
+		responseDTO.Data["delayTimeSeconds"] = math.Round(delayTime.Seconds())
+		return
+	}
+
+	// generate random otp codes between 10000 and 99999
+	oldOTP := rand.Intn(90000) + 10000
+	newOTP := rand.Intn(90000) + 10000
+	token := uuid.New()
+
+	if !config.Debug {
+
+		// send codes to numbers
+		if err := sms.SendOTPSMS(ctx, user.Number[3:], oldOTP); err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		if err := sms.SendOTPSMS(ctx, input.NewPhoneNumber[3:], newOTP); err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+	}
+
+	changeInfo := make(map[string]string)
+	changeInfo["token"] = token.String()
+	changeInfo["old_otp"] = strconv.Itoa(oldOTP)
+	changeInfo["new_otp"] = strconv.Itoa(newOTP)
+	changeInfo["new_number"] = input.NewPhoneNumber
+
+	err = s.cacheRepo.Save(cacheKey, changeInfo, config.VerifyNumberCacheExpireTime)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.ResponseCode = rcodes.CodeSendToNumber
+	responseDTO.Data["token"] = token.String()
+	responseDTO.Data["delayTimeSeconds"] = math.Round(config.VerifyNumberCacheExpireTimeForNumberDelay.Seconds())
+	return
+}
+
+func (s *service) VerifyChangeNumber(ctx context.Context, input VerifyChangeNumberInput, userID uint64, deviceName string, deviceIP string) (responseDTO app_shared.ResponseDTO) {
+	ctx, span := tracing.Start(ctx, "app_user.VerifyChangeNumber")
+	defer span.End()
+
+	responseDTO.Data = make(map[string]any)
+
+	userErr := s.domainService.VerifyChangeNumber(domain_user.NewVerifyChangeNumberInput(
+		input.Token,
+		input.OldOTP,
+		input.NewOTP,
+	))
+	if userErr != nil {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = userErr
+		return
+	}
+
+	cacheKey := changeNumberCacheKey(userID)
+	changeInfo, expireTime, err := s.cacheRepo.Get(cacheKey)
+	if err != nil {
+		if err == database_errors.ErrRecordNotFound || err == database_errors.ErrExpired {
+			responseDTO.ResponseCode = rcodes.GoSendOTPFirst
+			responseDTO.UserErr = service_errors.ErrOTPNotSend
+			return
+		}
+
+		responseDTO.ServerErr = err
+		return
+	}
+
+	if err := utils.CheckMapHaveKeys(changeInfo, "token", "old_otp", "new_otp", "new_number"); err != nil {
+		responseDTO.ServerErr = errors.New("invalid changeInfo in VerifyChangeNumber: " + err.Error())
+		return
+	}
+
+	if changeInfo["token"] != input.Token {
+		responseDTO.ResponseCode = rcodes.InvalidField
+		responseDTO.UserErr = service_errors.ErrWrongToken
+		return
+	}
+
+	if changeInfo["old_otp"] != strconv.Itoa(int(input.OldOTP)) || changeInfo["new_otp"] != strconv.Itoa(int(input.NewOTP)) {
+		responseDTO.ResponseCode = rcodes.WrongOTP
+		responseDTO.UserErr = service_errors.ErrWrongNewOTP
+		if changeInfo["old_otp"] != strconv.Itoa(int(input.OldOTP)) {
+			responseDTO.UserErr = service_errors.ErrWrongOldOTP
+		}
+
+		// limit guessing otp
+		attempts, _, err := s.cacheRepo.Increment(changeNumberAttemptsCacheKey(userID, changeInfo["token"]), time.Until(expireTime))
+		if err != nil {
+			responseDTO.ServerErr = err
+			return
+		}
+
+		if attempts >= config.ChangeNumberMaxAttempts {
+			if err := s.cacheRepo.Delete(cacheKey); err != nil {
+				responseDTO.ServerErr = err
+				return
+			}
+
+			responseDTO.ResponseCode = rcodes.GoSendOTPFirst
+			responseDTO.UserErr = service_errors.ErrTooManyOTPAttempts
+		}
+		return
+	}
+
+	// check new number again. it may be registered after sending otp
+	var mergeUserID uint64
+	newNumberUser, err := s.repo.GetByNumber(ctx, changeInfo["new_number"])
+	if err != nil && err != database_errors.ErrRecordNotFound {
+		responseDTO.ServerErr = err
+		return
+	}
+	if err == nil {
+		if newNumberUser.IsRegistered {
+			responseDTO.ResponseCode = rcodes.UserAlreadyRegistered
+			responseDTO.UserErr = service_errors.ErrNumberAlreadyRegistered
+			return
+		}
+
+		mergeUserID = newNumberUser.ID
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	before := map[string]string{"number": user.Number}
+	user.Number = changeInfo["new_number"]
+	err = s.repo.ChangeNumber(ctx, user, mergeUserID)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	app_audit.RecordOrLog(ctx, s.auditRepo, user.ID, domain_audit.ActionNumberChange, domain_audit.TargetUser, user.ID, before, map[string]string{"number": user.Number})
+
+	if err := s.cacheRepo.Delete(cacheKey); err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	// number is saved in tokens
+	tokens, err := s.createTokensAndDevice(ctx, user, deviceName, deviceIP)
+	if err != nil {
+		responseDTO.ServerErr = err
+		return
+	}
+
+	responseDTO.Data = utils.ConvertMapStringStringToMapStringAny(tokens)
+	responseDTO.Data["msg"] = "number changed"
+	return
+}
@@ -0,0 +1,24 @@
+package domain_account
+
+import (
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+)
+
+type DeleteAccountInput struct {
+	shared_dto.DeleteAccountInput
+
+	StoredPassword string // password that stored in database
+	Salt           string
+}
+
+func NewDeleteAccountInput(password, code, recoveryCode, storedPassword, salt string) DeleteAccountInput {
+	return DeleteAccountInput{
+		DeleteAccountInput: shared_dto.DeleteAccountInput{
+			Password:     password,
+			Code:         code,
+			RecoveryCode: recoveryCode,
+		},
+		StoredPassword: storedPassword,
+		Salt:           salt,
+	}
+}
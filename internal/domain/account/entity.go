@@ -0,0 +1,20 @@
+package domain_account
+
+import (
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
+
+// all personal data of a user
+type UserData struct {
+	User          domain_user.User
+	Devices       []domain_device.Device
+	Expenses      []domain_expense.Expense
+	Debts         []domain_debt.Debt
+	Comments      []domain_expense_comment.ExpenseComment
+	Notifications []domain_notification.Notification
+}
@@ -0,0 +1,14 @@
+package domain_account
+
+import (
+	"context"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
+
+type AccountDomainRepository interface {
+	GetUserData(ctx context.Context, userID uint64) (UserData, error)
+	// save anonymized user and audit log, and delete devices, comments and notifications of user in one transaction. debts and expenses are kept for counterparties
+	DeleteUser(ctx context.Context, anonymizedUser domain_user.User, log *domain_audit.AuditLog) error
+}
@@ -0,0 +1,73 @@
+package domain_account
+
+import (
+	"fmt"
+	"time"
+
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
+)
+
+type AccountDomainService interface {
+	RequestExport(userID uint64) (userErr error)
+	DeleteAccount(input DeleteAccountInput) (userErr error)
+	Anonymize(user domain_user.User) (anonymizedUser domain_user.User)
+}
+
+type service struct {
+	validator domain_shared.Validator
+}
+
+func NewAccountDomainService(validator domain_shared.Validator) AccountDomainService {
+	return service{
+		validator: validator,
+	}
+}
+
+func (s service) RequestExport(userID uint64) error {
+
+	if userID == 0 {
+		return service_errors.ErrInvalidID
+	}
+
+	return nil
+}
+
+// user must enter password for deleting account
+func (s service) DeleteAccount(input DeleteAccountInput) error {
+
+	if input.Password == "" {
+		return service_errors.ErrPasswordRequired
+	}
+
+	if err := utils.CompareHashAndPassword(input.StoredPassword, input.Password, input.Salt); err != nil {
+		return service_errors.ErrWrongPassword
+	}
+
+	return nil
+}
+
+// remove personal data of user. user row is kept because debts and expenses of other users refer to it
+func (s service) Anonymize(user domain_user.User) domain_user.User {
+
+	return domain_user.User{
+		ID:   user.ID,
+		Name: "Deleted user",
+		// number must be unique and must not be a valid phone number
+		Number:       fmt.Sprintf("d%012d", user.ID),
+		Password:     "No Password",
+		Salt:         "No Salt",
+		Avatar:       "default",
+		Language:     user.Language,
+		Currency:     user.Currency,
+		RegisteredAt: user.RegisteredAt,
+		Role:         domain_admin.RoleUser,
+		IsRegistered: false,
+		IsBlocked:    true,
+		IsDeleted:    true,
+		DeletedAt:    time.Now(),
+	}
+}
@@ -0,0 +1,36 @@
+package domain_admin
+
+import shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+
+type SearchUsersInput struct {
+	shared_dto.SearchUsersInput
+}
+
+func NewSearchUsersInput(query string, page, limit int) SearchUsersInput {
+	return SearchUsersInput{
+		SearchUsersInput: shared_dto.SearchUsersInput{
+			Query: query,
+			Page:  page,
+			Limit: limit,
+		},
+	}
+}
+
+type BlockUserInput struct {
+	shared_dto.BlockUserInput
+
+	AdminID    uint64
+	TargetRole string
+}
+
+func NewBlockUserInput(userID uint64, isBlocked bool, reason string, adminID uint64, targetRole string) BlockUserInput {
+	return BlockUserInput{
+		BlockUserInput: shared_dto.BlockUserInput{
+			UserID:    userID,
+			IsBlocked: isBlocked,
+			Reason:    reason,
+		},
+		AdminID:    adminID,
+		TargetRole: targetRole,
+	}
+}
@@ -0,0 +1,63 @@
+package domain_admin
+
+type Permission string
+
+const (
+	PermissionSearchUsers Permission = "search_users"
+	PermissionViewUser    Permission = "view_user"
+	PermissionBlockUser   Permission = "block_user"
+	PermissionLogoutUser  Permission = "logout_user"
+	PermissionViewStats   Permission = "view_stats"
+	PermissionViewAudit   Permission = "view_audit"
+)
+
+// roles are stored in User.Role
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleSupport: {
+		PermissionSearchUsers,
+		PermissionViewUser,
+		PermissionLogoutUser,
+		PermissionViewAudit,
+	},
+	RoleAdmin: {
+		PermissionSearchUsers,
+		PermissionViewUser,
+		PermissionBlockUser,
+		PermissionLogoutUser,
+		PermissionViewStats,
+		PermissionViewAudit,
+	},
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+type UserExpenseCounts struct {
+	Created      int64 // expenses that user created
+	Participated int64 // expenses that user has a debt in it
+}
+
+type Stats struct {
+	TotalUsers      int64
+	RegisteredUsers int64
+	BlockedUsers    int64
+	DeletedUsers    int64
+	ActiveDevices   int64
+	TotalExpenses   int64
+	TotalDebts      int64
+	UnpaidDebts     int64
+	NewUsersToday   int64
+}
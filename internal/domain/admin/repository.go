@@ -0,0 +1,19 @@
+package domain_admin
+
+import (
+	"context"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
+
+type AdminDomainRepository interface {
+	// search users by name or number. returns users and total count of matched users
+	SearchUsers(ctx context.Context, query string, offset int, limit int) ([]domain_user.User, int64, error)
+	GetUserDevices(ctx context.Context, userID uint64) ([]domain_device.Device, error)
+	GetUserExpenseCounts(ctx context.Context, userID uint64) (UserExpenseCounts, error)
+	// set IsBlocked of user and save audit log in one transaction. refresh tokens of all user devices are removed when user is blocked
+	SetBlocked(ctx context.Context, userID uint64, isBlocked bool, log *domain_audit.AuditLog) error
+	GetStats(ctx context.Context) (Stats, error)
+}
@@ -0,0 +1,83 @@
+package domain_admin
+
+import (
+	"unicode/utf8"
+
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+type AdminDomainService interface {
+	CheckPermission(role string, permission Permission) (userErr error)
+	SearchUsers(input SearchUsersInput) (userErr error)
+	BlockUser(input BlockUserInput) (userErr error)
+	CheckUserID(userID uint64) (userErr error)
+}
+
+type service struct {
+	validator domain_shared.Validator
+}
+
+func NewAdminDomainService(validator domain_shared.Validator) AdminDomainService {
+	return service{
+		validator: validator,
+	}
+}
+
+func (s service) CheckPermission(role string, permission Permission) error {
+
+	if !HasPermission(role, permission) {
+		return service_errors.ErrPermissionDenied
+	}
+
+	return nil
+}
+
+func (s service) SearchUsers(input SearchUsersInput) error {
+
+	if input.Page < 1 {
+		return service_errors.ErrInvalidPage
+	}
+
+	if input.Limit < 1 || input.Limit > config.AdminSearchMaxLimit {
+		return service_errors.ErrInvalidLimit
+	}
+
+	if utf8.RuneCountInString(input.Query) > 30 {
+		return service_errors.ErrInvalidQuery
+	}
+
+	return nil
+}
+
+// admins cannot block themselves or other admins
+func (s service) BlockUser(input BlockUserInput) error {
+
+	if err := s.CheckUserID(input.UserID); err != nil {
+		return err
+	}
+
+	if input.UserID == input.AdminID {
+		return service_errors.ErrCannotBlockYourself
+	}
+
+	if input.TargetRole == RoleAdmin {
+		return service_errors.ErrCannotBlockAdmin
+	}
+
+	if utf8.RuneCountInString(input.Reason) > 300 {
+		return service_errors.ErrLongReason
+	}
+
+	return nil
+}
+
+func (s service) CheckUserID(userID uint64) error {
+
+	if userID == 0 {
+		return service_errors.ErrInvalidUserID
+	}
+
+	return nil
+}
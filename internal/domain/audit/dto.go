@@ -0,0 +1,26 @@
+package domain_audit
+
+import (
+	"time"
+
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+)
+
+type AuditLogsInput struct {
+	shared_dto.AuditLogsInput
+}
+
+func NewAuditLogsInput(actorID uint64, action, targetType string, targetID uint64, from, to time.Time, page, limit int) AuditLogsInput {
+	return AuditLogsInput{
+		AuditLogsInput: shared_dto.AuditLogsInput{
+			ActorID:    actorID,
+			Action:     action,
+			TargetType: targetType,
+			TargetID:   targetID,
+			From:       from,
+			To:         to,
+			Page:       page,
+			Limit:      limit,
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package domain_audit
+
+import "time"
+
+// actions of audit log
+const (
+	// security
+	ActionLogin            = "user.login"
+	ActionLoginFailed      = "user.login_failed" // actor is owner of number
+	ActionSignup           = "user.signup"
+	ActionPasswordReset    = "user.password_reset"
+	ActionTwoFactorEnable  = "user.2fa_enable"
+	ActionTwoFactorDisable = "user.2fa_disable"
+	ActionNumberChange     = "user.number_change"
+	ActionDeviceLogout     = "device.logout"
+	ActionDeviceLogoutAll  = "device.logout_all"
+	ActionAccountDelete    = "user.account_delete"
+
+	// money
+	ActionExpenseCreate     = "expense.create"
+	ActionExpenseUpdate     = "expense.update"
+	ActionExpenseDelete     = "expense.delete"
+	ActionDebtAccept        = "debt.accept"
+	ActionDebtDeleteRequest = "debt.delete_request"
+	ActionDebtDelete        = "debt.delete"
+	ActionDebtPay           = "debt.pay"
+	ActionDebtAcceptPayment = "debt.accept_payment"
+
+	// admin. actor is admin
+	ActionAdminSearchUsers = "admin.search_users"
+	ActionAdminViewUser    = "admin.view_user"
+	ActionAdminBlockUser   = "admin.block_user"
+	ActionAdminUnblockUser = "admin.unblock_user"
+	ActionAdminLogoutUser  = "admin.logout_user"
+	ActionAdminViewStats   = "admin.view_stats"
+	ActionAdminViewAudit   = "admin.view_audit"
+)
+
+// types of audit log targets
+const (
+	TargetUser    = "user"
+	TargetDevice  = "device"
+	TargetExpense = "expense"
+	TargetDebt    = "debt"
+)
+
+// append-only record of an action. logs are never updated or deleted
+type AuditLog struct {
+	ID         uint64 `gorm:"primaryKey"`
+	ActorID    uint64 `gorm:"not null;index"` // user that did action
+	Action     string `gorm:"size:50;not null"`
+	TargetType string `gorm:"size:30;not null"`
+	TargetID   uint64 `gorm:"not null"`
+	// json snapshots of target. before is empty for creation and after is empty for deletion
+	Before    string
+	After     string
+	IP        string    `gorm:"size:45"`
+	UserAgent string    `gorm:"size:300"`
+	RequestID string    `gorm:"size:64"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// zero fields are not filtered
+type Filter struct {
+	ActorID    uint64
+	Action     string
+	TargetType string
+	TargetID   uint64
+	From       time.Time
+	To         time.Time
+}
@@ -0,0 +1,11 @@
+package domain_audit
+
+import "context"
+
+// there is no update or delete. database rejects them too
+type AuditDomainRepository interface {
+	// id and created at of log are set
+	Create(ctx context.Context, log *AuditLog) error
+	// newest first. returns logs and total count of matched logs
+	Search(ctx context.Context, filter Filter, offset int, limit int) ([]AuditLog, int64, error)
+}
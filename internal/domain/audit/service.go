@@ -0,0 +1,60 @@
+package domain_audit
+
+import (
+	"unicode/utf8"
+
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+type AuditDomainService interface {
+	GetMyActivity(page int, limit int) (userErr error)
+	Search(input AuditLogsInput) (userErr error)
+}
+
+type service struct {
+	validator domain_shared.Validator
+}
+
+func NewAuditDomainService(validator domain_shared.Validator) AuditDomainService {
+	return service{
+		validator: validator,
+	}
+}
+
+func (s service) GetMyActivity(page int, limit int) error {
+
+	if page < 1 {
+		return service_errors.ErrInvalidPage
+	}
+
+	if limit < 1 || limit > config.AuditLogMaxLimit {
+		return service_errors.ErrInvalidLimit
+	}
+
+	return nil
+}
+
+func (s service) Search(input AuditLogsInput) error {
+
+	if err := s.GetMyActivity(input.Page, input.Limit); err != nil {
+		return err
+	}
+
+	if utf8.RuneCountInString(input.Action) > 50 {
+		return service_errors.ErrInvalidAction
+	}
+
+	switch input.TargetType {
+	case "", TargetUser, TargetDevice, TargetExpense, TargetDebt:
+	default:
+		return service_errors.ErrInvalidTargetType
+	}
+
+	if !input.From.IsZero() && !input.To.IsZero() && input.To.Before(input.From) {
+		return service_errors.ErrInvalidTimeRange
+	}
+
+	return nil
+}
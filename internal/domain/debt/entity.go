@@ -26,4 +26,7 @@ type Debt struct {
 	IsPaymentAccepted            bool
 	IsDebtorRequestedForDelete   bool
 	IsCreditorRequestedForDelete bool
+
+	// incremented on every update. used for detecting concurrent changes
+	Version uint64 `gorm:"not null;default:1"`
 }
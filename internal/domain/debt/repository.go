@@ -1,10 +1,17 @@
 package domain_debt
 
+import "context"
+
 type DebtDomainRepository interface {
-	GetByID(id uint64, userID uint64) (Debt, error)
-	GetLimitedByUserID(userId uint64, offset int, limit int) ([]Debt, error)
-	Create(debt *Debt) error
-	CreateMultipleWithTransaction(debts []Debt) error
-	Update(debt Debt) error
-	Delete(id uint64) error
+	GetByID(ctx context.Context, id uint64, userID uint64) (Debt, error)
+	GetLimitedByUserID(ctx context.Context, userId uint64, offset int, limit int) ([]Debt, error)
+	Create(ctx context.Context, debt *Debt) error
+	CreateMultipleWithTransaction(ctx context.Context, debts []Debt) error
+	// save changes of debt if it is not changed after it was read (same version) and increment version.
+	// ErrVersionConflict is returned if version is changed
+	Update(ctx context.Context, debt *Debt) error
+	// delete debt and its notifications if version is not changed
+	Delete(ctx context.Context, id uint64, version uint64) error
+	// delete debts of expense and their notifications
+	DeleteByExpenseID(ctx context.Context, expenseID uint64) error
 }
@@ -1,12 +1,16 @@
 package domain_device
 
-import domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+import (
+	"context"
+
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
 
 type DeviceDomainRepository interface {
-	Create(device Device) error
-	Update(device Device) error
-	CreateOrUpdate(device Device) error
-	GetUserByRefreshToken(refresh string) (domain_user.User, error)
-	Logout(userID uint64, deviceName string) error
-	LogoutAllUserDevices(userID uint64) error
+	Create(ctx context.Context, device Device) error
+	Update(ctx context.Context, device Device) error
+	CreateOrUpdate(ctx context.Context, device Device) error
+	GetUserByRefreshToken(ctx context.Context, refresh string) (domain_user.User, error)
+	Logout(ctx context.Context, userID uint64, deviceName string) error
+	LogoutAllUserDevices(ctx context.Context, userID uint64) error
 }
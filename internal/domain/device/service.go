@@ -38,7 +38,7 @@ func (s *service) Create(device *Device) error {
 	}
 
 	if device.UserID == 0 {
-		return service_errors.ErrInvalidID
+		return service_errors.ErrInvalidUserID
 	}
 
 	device.LastLogin = time.Now()
@@ -62,6 +62,10 @@ func (s *service) CreateOrUpdate(device *Device) error {
 		return service_errors.ErrInvalidRefreshToken
 	}
 
+	if device.UserID == 0 {
+		return service_errors.ErrInvalidUserID
+	}
+
 	device.LastLogin = time.Now()
 
 	// for create device
@@ -78,7 +82,7 @@ func (s *service) Logout(userID uint64, deviceName string) error {
 	}
 
 	if userID == 0 {
-		return service_errors.ErrInvalidID
+		return service_errors.ErrInvalidUserID
 	}
 
 	return nil
@@ -87,7 +91,7 @@ func (s *service) Logout(userID uint64, deviceName string) error {
 func (s *service) LogoutAllUserDevices(userID uint64) error {
 
 	if userID == 0 {
-		return service_errors.ErrInvalidID
+		return service_errors.ErrInvalidUserID
 	}
 
 	return nil
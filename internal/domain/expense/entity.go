@@ -16,6 +16,8 @@ type Expense struct {
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
 	TotalAmount uint64    `gorm:"not null"`
+	// incremented on every update. used for detecting concurrent changes
+	Version uint64 `gorm:"not null;default:1"`
 }
 
 // type ExpenseType string
@@ -1,11 +1,17 @@
 package domain_expense
 
+import "context"
+
 type ExpenseDomainRepository interface {
-	GetByID(id uint64, userID uint64) (Expense, error)
-	GetLimitedExpenseDebtByUserID(userId uint64, offset int, limit int) ([]ExpenseDebtOuput, error)
-	Create(expense *Expense) error
-	Update(expense Expense) error
-	Delete(id uint64, userID uint64) error
-	CreateUsersWithNumbers(numbers []string) error
-	GetUserIDOfPhoneNumbers(numbers []string) (map[string]uint64, error)
+	GetByID(ctx context.Context, id uint64, userID uint64) (Expense, error)
+	GetLimitedExpenseDebtByUserID(ctx context.Context, userId uint64, offset int, limit int) ([]ExpenseDebtOuput, error)
+	Create(ctx context.Context, expense *Expense) error
+	// save changes of expense if it is not changed after it was read (same version) and increment version.
+	// ErrVersionConflict is returned if version is changed
+	Update(ctx context.Context, expense *Expense) error
+	// delete expense and its comments. only creator can delete expense, otherwise ErrRecordNotFound is returned.
+	// debts of expense must be deleted before
+	Delete(ctx context.Context, id uint64, userID uint64) error
+	CreateUsersWithNumbers(ctx context.Context, numbers []string) error
+	GetUserIDOfPhoneNumbers(ctx context.Context, numbers []string) (map[string]uint64, error)
 }
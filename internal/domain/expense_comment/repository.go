@@ -1,9 +1,11 @@
 package domain_expense_comment
 
+import "context"
+
 type DebtDomainRepository interface {
-	GetByID(id uint64) (ExpenseComment, error)
-	GetLimitedByExpenseID(expenseID uint64, offset int, limit uint) ([]ExpenseComment, error)
-	Create(expenseComment *ExpenseComment) error
-	Update(expenseComment ExpenseComment) error
-	Delete(id uint64) error
+	GetByID(ctx context.Context, id uint64) (ExpenseComment, error)
+	GetLimitedByExpenseID(ctx context.Context, expenseID uint64, offset int, limit uint) ([]ExpenseComment, error)
+	Create(ctx context.Context, expenseComment *ExpenseComment) error
+	Update(ctx context.Context, expenseComment ExpenseComment) error
+	Delete(ctx context.Context, id uint64) error
 }
@@ -1,9 +1,11 @@
 package domain_notification
 
+import "context"
+
 type NotificationDomainRepository interface {
-	Create(notif Notification) error
-	Update(notif Notification) error
-	Delete(notifID uint64) error
-	GetAll(page int, limit int, sort string) ([]Notification, error)
-	Get(notifID uint64) (Notification, error)
+	Create(ctx context.Context, notif Notification) error
+	Update(ctx context.Context, notif Notification) error
+	Delete(ctx context.Context, notifID uint64) error
+	GetAll(ctx context.Context, page int, limit int, sort string) ([]Notification, error)
+	Get(ctx context.Context, notifID uint64) (Notification, error)
 }
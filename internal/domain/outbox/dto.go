@@ -0,0 +1,20 @@
+package domain_outbox
+
+// payload of expense events
+type ExpensePayload struct {
+	ExpenseID   uint64   `json:"expense_id"`
+	CreatorID   uint64   `json:"creator_id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	UserIDs     []uint64 `json:"user_ids,omitempty"` // creditors and debtors of created expense
+}
+
+// payload of debt events
+type DebtPayload struct {
+	DebtID     uint64 `json:"debt_id"`
+	ExpenseID  uint64 `json:"expense_id"`
+	CreditorID uint64 `json:"creditor_id"`
+	DebtorID   uint64 `json:"debtor_id"`
+	Amount     uint64 `json:"amount"`
+	ActorID    uint64 `json:"actor_id"` // user that changed debt
+}
@@ -0,0 +1,68 @@
+package domain_outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// types of events
+const (
+	EventExpenseCreated      = "expense.created"
+	EventExpenseUpdated      = "expense.updated"
+	EventExpenseDeleted      = "expense.deleted"
+	EventDebtAccepted        = "debt.accepted"
+	EventDebtDeleteRequested = "debt.delete_requested"
+	EventDebtDeleted         = "debt.deleted"
+	EventDebtPaid            = "debt.paid"
+	EventDebtPaymentAccepted = "debt.payment_accepted"
+)
+
+// types of aggregates that events belong to
+const (
+	AggregateExpense = "expense"
+	AggregateDebt    = "debt"
+)
+
+// domain event that is saved in same transaction as state change and is published by relay after commit
+type OutboxEvent struct {
+	ID            uint64
+	EventID       string    `gorm:"size:36;not null;unique"` // deduplication id of consumers
+	Type          string    `gorm:"size:50;not null"`
+	AggregateType string    `gorm:"size:30;not null"`
+	AggregateID   uint64    `gorm:"not null"`
+	Payload       string    `gorm:"not null"` // json
+	CreatedAt     time.Time `gorm:"not null"`
+
+	// delivery
+	Attempts      int       `gorm:"not null"`
+	NextAttemptAt time.Time `gorm:"not null;index"`
+	LastError     string    `gorm:"size:500"`
+	IsPublished   bool      `gorm:"not null"`
+	PublishedAt   time.Time
+	IsFailed      bool `gorm:"not null"` // max attempts is reached. event is not retried
+
+	// claim of relay. other relays don't get event until claim is expired
+	ClaimToken   string `gorm:"size:36;not null"`
+	ClaimedUntil time.Time
+}
+
+func NewOutboxEvent(eventType string, aggregateType string, aggregateID uint64, payload any) (OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	now := time.Now()
+
+	return OutboxEvent{
+		EventID:       uuid.New().String(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(data),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
@@ -0,0 +1,18 @@
+package domain_outbox
+
+import (
+	"context"
+	"time"
+)
+
+type OutboxDomainRepository interface {
+	// event is saved in transaction of repository. id of event is set
+	Create(ctx context.Context, event *OutboxEvent) error
+	// events that are not published or failed and their next attempt is before now. ordered by id
+	GetPending(ctx context.Context, now time.Time, limit int) ([]OutboxEvent, error)
+	// pending events that are not claimed or their claim is expired are claimed until now+lease. ordered by id.
+	// each event is claimed by one relay at a time, so relays of multiple instances don't publish same events
+	ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]OutboxEvent, error)
+	// save delivery fields of event (attempts, next attempt, last error, published and failed) and release its claim
+	UpdateDelivery(ctx context.Context, event OutboxEvent) error
+}
@@ -10,6 +10,13 @@ type Validator interface {
 
 type CacheRepository interface {
 	Save(key string, value map[string]string, expireTime time.Duration) error
+	// value is saved only if key does not exist or is expired. returns true if value is saved
+	SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error)
+	// value is replaced only if stored value of key is old and is not expired. returns true if value is replaced
+	CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error)
+	// add one to counter of key and return new count and expire time of counter.
+	// counter of absent or expired key starts from 1 and expires after expireTime. counter keys must not be used with Get
+	Increment(key string, expireTime time.Duration) (int64, time.Time, error)
 	Get(key string) (map[string]string, time.Time, error)
 	Delete(key string) error
 }
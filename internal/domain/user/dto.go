@@ -123,3 +123,82 @@ func NewResetPasswordInput(number, password, token string) ResetPasswordInput {
 		},
 	}
 }
+
+type TwoFactorInput struct {
+	Code         string
+	RecoveryCode string
+
+	Secret        string // stored totp secret
+	RecoveryCodes string // stored hashed recovery codes
+	LastCounter   int64  // time step of last accepted totp code
+}
+
+func NewTwoFactorInput(code, recoveryCode string, user User) TwoFactorInput {
+	return TwoFactorInput{
+		Code:          code,
+		RecoveryCode:  recoveryCode,
+		Secret:        user.TOTPSecret,
+		RecoveryCodes: user.TOTPRecoveryCodes,
+		LastCounter:   user.TOTPLastCounter,
+	}
+}
+
+type DisableTwoFactorInput struct {
+	TwoFactorInput
+
+	InputPassword  string
+	StoredPassword string // password that stored in database
+	Salt           string
+}
+
+func NewDisableTwoFactorInput(password, code, recoveryCode string, user User) DisableTwoFactorInput {
+	return DisableTwoFactorInput{
+		TwoFactorInput: NewTwoFactorInput(code, recoveryCode, user),
+		InputPassword:  password,
+		StoredPassword: user.Password,
+		Salt:           user.Salt,
+	}
+}
+
+type UpdateProfileInput struct {
+	shared_dto.UpdateProfileInput
+}
+
+func NewUpdateProfileInput(name, language, currency string) UpdateProfileInput {
+	return UpdateProfileInput{
+		shared_dto.UpdateProfileInput{
+			Name:     name,
+			Language: language,
+			Currency: currency,
+		},
+	}
+}
+
+type ChangeNumberInput struct {
+	shared_dto.ChangeNumberInput
+
+	CurrentPhoneNumber string
+}
+
+func NewChangeNumberInput(currentNumber, newNumber string) ChangeNumberInput {
+	return ChangeNumberInput{
+		ChangeNumberInput: shared_dto.ChangeNumberInput{
+			NewPhoneNumber: newNumber,
+		},
+		CurrentPhoneNumber: currentNumber,
+	}
+}
+
+type VerifyChangeNumberInput struct {
+	shared_dto.VerifyChangeNumberInput
+}
+
+func NewVerifyChangeNumberInput(token string, oldOTP, newOTP uint) VerifyChangeNumberInput {
+	return VerifyChangeNumberInput{
+		shared_dto.VerifyChangeNumberInput{
+			Token:  token,
+			OldOTP: oldOTP,
+			NewOTP: newOTP,
+		},
+	}
+}
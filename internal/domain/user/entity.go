@@ -1,6 +1,11 @@
 package domain_user
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+)
 
 type User struct {
 	ID       uint64 `gorm:"primaryKey"`
@@ -10,7 +15,40 @@ type User struct {
 	Salt     string `gorm:"size:32,not null"`
 	Avatar   string `gorm:"size:500,not null"`
 
+	// for uploaded avatars
+	AvatarThumbnail string `gorm:"size:500"`
+	AvatarKey       string `gorm:"size:200"` // s3 key prefix of uploaded avatar. empty for preset avatars
+
+	Language string `gorm:"size:5;not null;default:fa" validate:"oneof=fa en"`
+	Currency string `gorm:"size:3;not null;default:IRT" validate:"oneof=IRT IRR USD EUR"`
+
 	RegisteredAt time.Time `gorm:"not null"`
 	IsRegistered bool      `gorm:"not null"`
 	IsBlocked    bool
+	Role         string `gorm:"size:10;not null;default:user"` // user, support or admin. roles are set directly in database
+	IsDeleted    bool
+	DeletedAt    time.Time
+
+	// two factor authentication
+	TOTPSecret        string `gorm:"size:64"`
+	IsTOTPEnabled     bool
+	TOTPRecoveryCodes string `gorm:"size:1000"` // hashed recovery codes separated by comma
+	TOTPLastCounter   int64  // time step of last accepted totp code. codes of this or older steps are rejected
+}
+
+// s3 keys of uploaded avatar in all sizes
+func (u User) UploadedAvatarKeys() []string {
+	if u.AvatarKey == "" {
+		return nil
+	}
+
+	return []string{
+		UploadedAvatarKey(u.AvatarKey, config.AvatarSize),
+		UploadedAvatarKey(u.AvatarKey, config.AvatarThumbnailSize),
+	}
+}
+
+// key example: user-avatars/12/<uuid>_512.jpg
+func UploadedAvatarKey(keyPrefix string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", keyPrefix, size)
 }
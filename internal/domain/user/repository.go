@@ -1,10 +1,20 @@
 package domain_user
 
+import "context"
+
 type UserDomainRepository interface {
-	GetByID(id uint64) (User, error)
-	GetByNumber(number string) (User, error)
-	Create(user *User) error
-	Update(user User) error
-	UpdateColumns(user User) error
-	Delete(id uint64) error
+	GetByID(ctx context.Context, id uint64) (User, error)
+	GetByNumber(ctx context.Context, number string) (User, error)
+	Create(ctx context.Context, user *User) error
+	Update(ctx context.Context, user User) error
+	UpdateColumns(ctx context.Context, user User) error
+	UpdateTwoFactor(ctx context.Context, user User) error
+	// set TOTPLastCounter if it is less than counter, otherwise returns database_errors.ErrVersionConflict
+	UpdateTOTPCounter(ctx context.Context, userID uint64, counter int64) error
+	// set TOTPRecoveryCodes if they are oldCodes, otherwise returns database_errors.ErrVersionConflict
+	UpdateTOTPRecoveryCodes(ctx context.Context, userID uint64, oldCodes string, codes string) error
+	UpdateAvatar(ctx context.Context, user User) error
+	// change number of user. if mergeUserID is not zero, history of that unregistered user moved to user and it is deleted
+	ChangeNumber(ctx context.Context, user User, mergeUserID uint64) error
+	Delete(ctx context.Context, id uint64) error
 }
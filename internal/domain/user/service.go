@@ -1,11 +1,14 @@
 package domain_user
 
 import (
+	"strings"
 	"time"
 
 	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
 	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/imageproc"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/totp"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
 )
 
@@ -16,6 +19,14 @@ type UserDomainService interface {
 	CheckNumber(number string) error
 	Login(input LoginUserInput) (userError, serverError error)
 	ResetPassword(input ResetPasswordInput) (userErr, serverErr error, salt, outPassword string)
+	EnrollTwoFactor(user User) (secret string, userErr error, serverErr error)
+	ConfirmTwoFactor(user User, code string) (outUser User, recoveryCodes []string, userErr error, serverErr error)
+	VerifyTwoFactor(input TwoFactorInput) (outRecoveryCodes string, outLastCounter int64, userErr error)
+	DisableTwoFactor(input DisableTwoFactorInput) (outRecoveryCodes string, outLastCounter int64, userErr error)
+	UpdateProfile(user User, input UpdateProfileInput) (outUser User, userErr error)
+	ChangeNumber(input ChangeNumberInput) (userErr error)
+	VerifyChangeNumber(input VerifyChangeNumberInput) (userErr error)
+	UploadAvatar(size int, contentType string) (userErr error)
 }
 
 type service struct {
@@ -168,3 +179,187 @@ func (s *service) Login(input LoginUserInput) (error, error) {
 
 	return nil, nil
 }
+
+func (s *service) EnrollTwoFactor(user User) (string, error, error) {
+
+	if user.IsTOTPEnabled {
+		return "", service_errors.ErrTwoFactorAlreadyEnabled, nil
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return secret, nil, nil
+}
+
+// returns user with enabled two factor and plain recovery codes for showing to user. hashed recovery codes are set in user
+func (s *service) ConfirmTwoFactor(user User, code string) (User, []string, error, error) {
+
+	if user.IsTOTPEnabled {
+		return user, nil, service_errors.ErrTwoFactorAlreadyEnabled, nil
+	}
+
+	if user.TOTPSecret == "" {
+		return user, nil, service_errors.ErrEnrollTwoFactorFirst, nil
+	}
+
+	if err := s.validator.ValidateField(code, "required,numeric,len=6"); err != nil {
+		return user, nil, service_errors.ErrInvalidCode, nil
+	}
+
+	counter, ok := totp.ValidateCounter(user.TOTPSecret, code, time.Now(), user.TOTPLastCounter)
+	if !ok {
+		return user, nil, service_errors.ErrWrongTOTPCode, nil
+	}
+
+	recoveryCodes := make([]string, config.TOTPRecoveryCodesCount)
+	hashedRecoveryCodes := make([]string, config.TOTPRecoveryCodesCount)
+	for i := range recoveryCodes {
+		code, err := utils.GenerateRandomHex(5)
+		if err != nil {
+			return user, nil, nil, err
+		}
+
+		recoveryCodes[i] = code[:5] + "-" + code[5:]
+		hashedRecoveryCodes[i] = utils.HashToken(code)
+	}
+
+	user.IsTOTPEnabled = true
+	user.TOTPRecoveryCodes = strings.Join(hashedRecoveryCodes, ",")
+	user.TOTPLastCounter = counter
+
+	return user, recoveryCodes, nil, nil
+}
+
+// check totp code or recovery code. returns stored recovery codes without used recovery code and time step of accepted totp code
+func (s *service) VerifyTwoFactor(input TwoFactorInput) (string, int64, error) {
+
+	if input.Secret == "" {
+		return input.RecoveryCodes, input.LastCounter, service_errors.ErrTwoFactorNotEnabled
+	}
+
+	if input.Code != "" {
+		if err := s.validator.ValidateField(input.Code, "numeric,len=6"); err != nil {
+			return input.RecoveryCodes, input.LastCounter, service_errors.ErrInvalidCode
+		}
+
+		// accepted code cannot be used again
+		counter, ok := totp.ValidateCounter(input.Secret, input.Code, time.Now(), input.LastCounter)
+		if !ok {
+			return input.RecoveryCodes, input.LastCounter, service_errors.ErrWrongTOTPCode
+		}
+
+		return input.RecoveryCodes, counter, nil
+	}
+
+	if input.RecoveryCode == "" {
+		return input.RecoveryCodes, input.LastCounter, service_errors.ErrTwoFactorCodeRequired
+	}
+
+	recoveryCode := strings.ToLower(strings.ReplaceAll(input.RecoveryCode, "-", ""))
+	hashedRecoveryCode := utils.HashToken(recoveryCode)
+
+	storedRecoveryCodes := strings.Split(input.RecoveryCodes, ",")
+	for i, stored := range storedRecoveryCodes {
+		if stored == hashedRecoveryCode {
+			// recovery code can be used only once
+			storedRecoveryCodes = append(storedRecoveryCodes[:i], storedRecoveryCodes[i+1:]...)
+			return strings.Join(storedRecoveryCodes, ","), input.LastCounter, nil
+		}
+	}
+
+	return input.RecoveryCodes, input.LastCounter, service_errors.ErrWrongRecoveryCode
+}
+
+// user must enter password and totp code (or recovery code) for disabling two factor. returns same values as VerifyTwoFactor
+func (s *service) DisableTwoFactor(input DisableTwoFactorInput) (string, int64, error) {
+
+	if err := utils.CompareHashAndPassword(input.StoredPassword, input.InputPassword, input.Salt); err != nil {
+		return input.RecoveryCodes, input.LastCounter, service_errors.ErrWrongPassword
+	}
+
+	return s.VerifyTwoFactor(input.TwoFactorInput)
+}
+
+// empty fields are not changed
+func (s *service) UpdateProfile(user User, input UpdateProfileInput) (User, error) {
+
+	if input.Name != "" {
+		if err := s.validator.ValidateFieldByFieldName("Name", input.Name, User{}); err != nil {
+			return user, service_errors.ErrInvalidName
+		}
+
+		if len(input.Name) < 2 {
+			return user, service_errors.ErrSmallName
+		}
+
+		user.Name = input.Name
+	}
+
+	if input.Language != "" {
+		if err := s.validator.ValidateFieldByFieldName("Language", input.Language, User{}); err != nil {
+			return user, service_errors.ErrInvalidLanguage
+		}
+
+		user.Language = input.Language
+	}
+
+	if input.Currency != "" {
+		if err := s.validator.ValidateFieldByFieldName("Currency", input.Currency, User{}); err != nil {
+			return user, service_errors.ErrInvalidCurrency
+		}
+
+		user.Currency = input.Currency
+	}
+
+	return user, nil
+}
+
+func (s *service) ChangeNumber(input ChangeNumberInput) error {
+
+	if err := s.validator.ValidateFieldByFieldName("Number", input.NewPhoneNumber, User{}); err != nil {
+		return service_errors.ErrInvalidNewNumber
+	}
+
+	if input.NewPhoneNumber == input.CurrentPhoneNumber {
+		return service_errors.ErrSameNumber
+	}
+
+	return nil
+}
+
+func (s *service) VerifyChangeNumber(input VerifyChangeNumberInput) error {
+
+	if err := s.validator.ValidateField(input.Token, "uuid,required"); err != nil {
+		return service_errors.ErrInvalidToken
+	}
+
+	if input.OldOTP > 99999 || input.OldOTP < 10000 {
+		return service_errors.ErrInvalidOldOTP
+	}
+
+	if input.NewOTP > 99999 || input.NewOTP < 10000 {
+		return service_errors.ErrInvalidNewOTP
+	}
+
+	return nil
+}
+
+func (s *service) UploadAvatar(size int, contentType string) error {
+
+	if size == 0 {
+		return service_errors.ErrEmptyAvatar
+	}
+
+	if size > config.AvatarMaxSize {
+		return service_errors.ErrLargeAvatar
+	}
+
+	if !imageproc.IsAllowedContentType(contentType) {
+		return service_errors.ErrInvalidAvatarFormat
+	}
+
+	return nil
+}
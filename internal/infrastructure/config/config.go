@@ -1,15 +1,53 @@
 package config
 
 import (
-	"log/slog"
-	"os"
 	"time"
 )
 
 const (
 
 	// s3
-	AvatarPath = "avatars/"
+	AvatarPath     = "avatars/"
+	UserAvatarPath = "user-avatars/"
+	DataExportPath = "exports/"
+
+	// max body size of json requests
+	MaxBodySize = 1 << 20 // bytes
+
+	// avatar upload
+	AvatarMaxSize       = 5 << 20               // bytes
+	AvatarMaxBodySize   = AvatarMaxSize + 1<<20 // extra space for multipart headers
+	AvatarSize          = 512
+	AvatarThumbnailSize = 128
+
+	// two factor
+	TOTPIssuer             = "Pedarkharj"
+	TOTPRecoveryCodesCount = 10
+	TwoFactorMaxAttempts   = 5
+
+	ChangeNumberMaxAttempts = 5
+
+	// password attempts of a number from one ip in LoginAttemptsWindow
+	LoginMaxAttempts = 5
+	// password attempts of a number from all ips in LoginAttemptsWindow. one client cannot lock out user with it
+	LoginMaxNumberAttempts = 20
+
+	// password attempts of delete account in DeleteAccountAttemptsWindow
+	DeleteAccountMaxAttempts = 5
+
+	// admin
+	AdminSearchMaxLimit = 50
+
+	// audit
+	AuditLogMaxLimit = 50
+
+	// memory cache
+	MemoryCacheShards   = 32
+	MemoryCacheCapacity = 100000
+
+	// redis cache
+	RedisPoolSize  = 20
+	RedisKeyPrefix = "pedarkharj:"
 )
 
 var (
@@ -24,17 +62,39 @@ var (
 
 	VerifyNumberCacheExpireTimeForNumberDelay = 3 * time.Minute
 	VerifyNumberCacheExpireTime               = 10 * time.Minute
+
+	TwoFactorChallengeExpireTime = 5 * time.Minute
+	// user is locked out of second factor after TwoFactorMaxAttempts wrong attempts in this window
+	TwoFactorAttemptsWindow = 15 * time.Minute
+
+	LoginAttemptsWindow = 15 * time.Minute
+
+	DeleteAccountAttemptsWindow = 15 * time.Minute
+
+	RedisTimeout = 3 * time.Second
+
+	// data export
+	DataExportExpireTime    = 24 * time.Hour
+	DataExportURLExpireTime = 15 * time.Minute
+	// export is failed if it is not built in this time. pending export is expired after it for allowing retry
+	DataExportTimeout = 10 * time.Minute
 )
 
 func init() {
-	if os.Getenv("DEBUG") == "false" {
-		Debug = false
-		slog.Info("---Debug mode is off---")
-	}
+	setDebug(Debug)
+}
+
+// debug mode uses faster hashing and longer tokens
+func setDebug(debug bool) {
+	Debug = debug
 
-	if Debug {
+	if debug {
 		BcryptCost = 1
 		JWTAccessExpire = 24 * time.Hour
 		VerifyNumberCacheExpireTimeForNumberDelay = 15 * time.Second
+	} else {
+		BcryptCost = 16
+		JWTAccessExpire = 15 * time.Minute
+		VerifyNumberCacheExpireTimeForNumberDelay = 3 * time.Minute
 	}
 }
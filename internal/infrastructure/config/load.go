@@ -0,0 +1,646 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/ratelimit"
+	"gopkg.in/yaml.v3"
+)
+
+// application configuration. every field is loaded from (lowest to highest priority):
+// default tag, yaml config file, env variable and command line flag.
+// flag name is yaml path of field. e.g: -database.host
+type Config struct {
+	Debug     bool            `yaml:"debug" env:"DEBUG" default:"true"`
+	Log       LogConfig       `yaml:"log"`
+	Server    ServerConfig    `yaml:"server"`
+	GRPC      GRPCConfig      `yaml:"grpc"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Database  DatabaseConfig  `yaml:"database" envprefix:"DB_PREFIX"` // env names of database (except log level) are prefixed with value of DB_PREFIX env
+	Cache     CacheConfig     `yaml:"cache"`
+	Outbox    OutboxConfig    `yaml:"outbox"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	S3        S3Config        `yaml:"s3"`
+	SMS       SMSConfig       `yaml:"sms"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`                  // debug, info, warn or error. default is debug in debug mode, otherwise info
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"text"` // text or json
+}
+
+type ServerConfig struct {
+	Addr      string `yaml:"addr" env:"SERVER_ADDR" default:":8000"`
+	DebugAddr string `yaml:"debug_addr" env:"SERVER_DEBUG_ADDR" default:":2222"` // used by debug command
+
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"30s"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY" default:"0s"` // time between failing readiness and closing listener
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"65536"`
+
+	HealthCheckTimeout time.Duration `yaml:"health_check_timeout" env:"SERVER_HEALTH_CHECK_TIMEOUT" default:"2s"` // timeout of each dependency check in readiness
+
+	IdempotencyKeyExpire time.Duration `yaml:"idempotency_key_expire" env:"SERVER_IDEMPOTENCY_KEY_EXPIRE" default:"24h"` // responses of mutating requests are replayed for retries with same Idempotency-Key in this window
+
+	// tls is enabled when both files are set
+	TLSCertFile string `yaml:"tls_cert_file" env:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" env:"SERVER_TLS_KEY_FILE"`
+}
+
+// grpc api. it is served on separate address from rest api
+type GRPCConfig struct {
+	Enabled        bool   `yaml:"enabled" env:"GRPC_ENABLED" default:"true"`
+	Addr           string `yaml:"addr" env:"GRPC_ADDR" default:":9000"`
+	MaxRecvMsgSize int    `yaml:"max_recv_msg_size" env:"GRPC_MAX_RECV_MSG_SIZE" default:"4194304"` // bytes
+}
+
+// cors policy of rest api. lists are comma separated in env and flags
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" default:"*"` // exact origin (https://app.example.com), wildcard subdomain (https://*.example.com) or * for all origins
+	AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`         // cookies and authorization headers. * is not allowed in origins
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" default:"Content-Type,Accept,Accept-Language,Authorization,X-Request-ID,Traceparent,Tracestate,Idempotency-Key,If-Match"`
+	ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" default:"X-Request-ID,Traceparent,Idempotent-Replayed,ETag,Content-Language,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After"`
+	MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE" default:"24h"` // cache time of preflight responses
+}
+
+// token bucket policies of route groups in "<limit>/<period>" format. e.g: 10/1m.
+// buckets are kept in cache backend. use redis or database for sharing limits between instances
+type RateLimitConfig struct {
+	Enabled        bool     `yaml:"enabled" env:"RATE_LIMIT_ENABLED" default:"true"`
+	TrustedProxies []string `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES"` // ips or cidrs of reverse proxies. X-Forwarded-For and X-Real-Ip are used only for requests of them. also used for ip of audit logs
+	Auth           string   `yaml:"auth" env:"RATE_LIMIT_AUTH" default:"10/1m"`       // login, otp, signup and refresh by client ip
+	Public         string   `yaml:"public" env:"RATE_LIMIT_PUBLIC" default:"120/1m"`  // other public routes by client ip
+	Read           string   `yaml:"read" env:"RATE_LIMIT_READ" default:"300/1m"`      // authenticated read routes by user
+	Write          string   `yaml:"write" env:"RATE_LIMIT_WRITE" default:"60/1m"`     // authenticated mutating routes by user
+}
+
+// trusted proxies as prefixes. single ips are converted to prefixes
+func (c RateLimitConfig) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, proxy := range c.TrustedProxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("rate_limit.trusted_proxies: %w", err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		ip, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("rate_limit.trusted_proxies: %w", err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED" default:"true"`
+	Path    string `yaml:"path" env:"METRICS_PATH" default:"/metrics"` // served on server address, outside of api prefix
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" default:"none"`           // none, stdout or otlp
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" default:"localhost:4318"` // host:port of otlp http collector
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" default:"true"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" default:"pedarkharj"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+}
+
+type DatabaseConfig struct {
+	Driver      string `yaml:"driver" env:"DB_DRIVER" default:"postgres"` // postgres or sqlite
+	Host        string `yaml:"host" env:"DB_HOST"`
+	Port        int    `yaml:"port" env:"DB_PORT" default:"5432"`
+	Username    string `yaml:"username" env:"DB_USERNAME"`
+	Password    string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name        string `yaml:"name" env:"DB_NAME"`
+	Path        string `yaml:"path" env:"DB_PATH" default:"pedarkharj.db"`                      // file of sqlite database
+	AutoMigrate bool   `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE"`                              // apply pending migrations on startup
+	LogLevel    string `yaml:"log_level" env:"DB_LOG_LEVEL" noenvprefix:"true" default:"error"` // silent, error or info. DB_PREFIX is not applied
+}
+
+type CacheConfig struct {
+	Backend       string `yaml:"backend" env:"CACHE_BACKEND" default:"database"` // database, redis or memory
+	RedisAddr     string `yaml:"redis_addr" env:"REDIS_ADDR"`
+	RedisPassword string `yaml:"redis_password" env:"REDIS_PASSWORD" secret:"true"`
+	RedisDB       int    `yaml:"redis_db" env:"REDIS_DB"`
+}
+
+// relay of domain events from outbox table to event bus
+type OutboxConfig struct {
+	RelayInterval   time.Duration `yaml:"relay_interval" env:"OUTBOX_RELAY_INTERVAL" default:"1s"`
+	BatchSize       int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" default:"100"`
+	MaxAttempts     int           `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS" default:"10"`   // event is marked as failed after this many attempts
+	RetryBackoff    time.Duration `yaml:"retry_backoff" env:"OUTBOX_RETRY_BACKOFF" default:"1s"` // delay after first failed attempt. doubled after each attempt
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" env:"OUTBOX_MAX_RETRY_BACKOFF" default:"10m"`
+	ClaimLease      time.Duration `yaml:"claim_lease" env:"OUTBOX_CLAIM_LEASE" default:"5m"` // events of batch are not given to relays of other instances for this long
+	WebhookURL      string        `yaml:"webhook_url" env:"OUTBOX_WEBHOOK_URL"`              // external broker. events are posted as json. empty disables it
+	WebhookTimeout  time.Duration `yaml:"webhook_timeout" env:"OUTBOX_WEBHOOK_TIMEOUT" default:"5s"`
+}
+
+type JWTConfig struct {
+	SecretKey string `yaml:"secret_key" env:"JWT_SECRET_KEY" secret:"true"`
+}
+
+type S3Config struct {
+	AccessKey         string `yaml:"access_key" env:"S3_ACCESS_KEY" secret:"true"`
+	SecretKey         string `yaml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	BucketName        string `yaml:"bucket_name" env:"S3_BUCKET_NAME"`
+	APIURL            string `yaml:"api_url" env:"S3_API_URL_VALUE"`
+	AccessURL         string `yaml:"access_url" env:"S3_ACCESS_URL"`
+	AccessURLProtocol string `yaml:"access_url_protocol" env:"S3_ACCESS_URL_PROTOCOL" default:"https://"` // prefix of access url
+}
+
+type SMSConfig struct {
+	APIKey     string `yaml:"api_key" env:"SMS_API_KEY" secret:"true"`
+	TemplateID int    `yaml:"template_id" env:"SMS_TEMPLATE_ID"`
+}
+
+const maskedValue = "****"
+
+// a leaf field of Config
+type configField struct {
+	path   string // yaml path. e.g: database.host
+	env    string
+	secret bool
+	def    string
+	value  reflect.Value
+}
+
+// command line flag of a field. value is applied after env variables
+type fieldFlag struct {
+	raw    string
+	isSet  bool
+	isBool bool
+}
+
+func (f *fieldFlag) String() string   { return f.raw }
+func (f *fieldFlag) IsBoolFlag() bool { return f.isBool }
+
+func (f *fieldFlag) Set(s string) error {
+	f.raw = s
+	f.isSet = true
+	return nil
+}
+
+func collectFields(v reflect.Value, pathPrefix string, envPrefix string) []configField {
+	var fields []configField
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		path := pathPrefix + structField.Tag.Get("yaml")
+
+		if structField.Type.Kind() == reflect.Struct && structField.Type != reflect.TypeOf(time.Duration(0)) {
+			prefix := envPrefix
+			if name := structField.Tag.Get("envprefix"); name != "" {
+				prefix = os.Getenv(name)
+			}
+
+			fields = append(fields, collectFields(v.Field(i), path+".", prefix)...)
+			continue
+		}
+
+		env := structField.Tag.Get("env")
+		if env != "" && structField.Tag.Get("noenvprefix") != "true" {
+			env = envPrefix + env
+		}
+
+		fields = append(fields, configField{
+			path:   path,
+			env:    env,
+			secret: structField.Tag.Get("secret") == "true",
+			def:    structField.Tag.Get("default"),
+			value:  v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func setFieldValue(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported config type: %s", v.Type())
+	}
+
+	return nil
+}
+
+// load config from args (without program name). returns remaining arguments (command).
+// path of yaml file is set with -config flag or CONFIG_FILE env
+func Load(args []string) (cfg Config, command []string, err error) {
+
+	fields := collectFields(reflect.ValueOf(&cfg).Elem(), "", "")
+
+	flagSet := flag.NewFlagSet("pedarkharj", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv("CONFIG_FILE"), "path of yaml config file")
+
+	flags := make([]*fieldFlag, len(fields))
+	for i, f := range fields {
+		flags[i] = &fieldFlag{isBool: f.value.Kind() == reflect.Bool}
+
+		var usage []string
+		if f.def != "" {
+			usage = append(usage, "default: "+f.def)
+		}
+		if f.env != "" {
+			usage = append(usage, "env: "+f.env)
+		}
+		flagSet.Var(flags[i], f.path, strings.Join(usage, ", "))
+	}
+
+	if err = flagSet.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	// defaults
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+
+		if err := setFieldValue(f.value, f.def); err != nil {
+			return cfg, nil, fmt.Errorf("default of %s: %w", f.path, err)
+		}
+	}
+
+	// file
+	if *configFile != "" {
+		file, err := os.Open(*configFile)
+		if err != nil {
+			return cfg, nil, err
+		}
+		defer file.Close()
+
+		decoder := yaml.NewDecoder(file)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil {
+			return cfg, nil, fmt.Errorf("config file %s: %w", *configFile, err)
+		}
+	}
+
+	// env
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+
+		if value, ok := os.LookupEnv(f.env); ok && value != "" {
+			if err := setFieldValue(f.value, value); err != nil {
+				return cfg, nil, fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+
+	// flags
+	for i, f := range fields {
+		if !flags[i].isSet {
+			continue
+		}
+
+		if err := setFieldValue(f.value, flags[i].raw); err != nil {
+			return cfg, nil, fmt.Errorf("flag -%s: %w", f.path, err)
+		}
+	}
+
+	return cfg, flagSet.Args(), nil
+}
+
+// check all values that required for running server
+func (c Config) Validate() error {
+	var errs []error
+
+	required := func(name, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, errors.New(name+" is required"))
+		}
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, errors.New("log.level must be one of debug, info, warn or error"))
+	}
+
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, errors.New("log.format must be text or json"))
+	}
+
+	errs = append(errs, c.ValidateServer())
+
+	if c.GRPC.Enabled {
+		if c.GRPC.Addr == "" {
+			errs = append(errs, errors.New("grpc.addr is required"))
+		} else if c.GRPC.Addr == c.Server.Addr {
+			errs = append(errs, errors.New("grpc.addr must be different from server.addr"))
+		}
+		if c.GRPC.MaxRecvMsgSize < 1024 {
+			errs = append(errs, errors.New("grpc.max_recv_msg_size must be at least 1024"))
+		}
+	}
+
+	errs = append(errs, c.ValidateCORS())
+	errs = append(errs, c.ValidateRateLimit())
+
+	if c.Metrics.Enabled && (!strings.HasPrefix(c.Metrics.Path, "/") || strings.HasPrefix(c.Metrics.Path, "/api/")) {
+		errs = append(errs, errors.New("metrics.path must start with / and must not be under /api/"))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		required("tracing.endpoint", c.Tracing.Endpoint)
+	default:
+		errs = append(errs, errors.New("tracing.exporter must be one of none, stdout or otlp"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	errs = append(errs, c.ValidateDatabase())
+
+	switch c.Cache.Backend {
+	case "database", "memory":
+	case "redis":
+		required("cache.redis_addr", c.Cache.RedisAddr)
+	default:
+		errs = append(errs, errors.New("cache.backend must be one of database, redis or memory"))
+	}
+
+	errs = append(errs, c.ValidateOutbox())
+
+	required("jwt.secret_key", c.JWT.SecretKey)
+	if !c.Debug && len(c.JWT.SecretKey) < 32 {
+		errs = append(errs, errors.New("jwt.secret_key must be at least 32 characters"))
+	}
+
+	required("s3.access_key", c.S3.AccessKey)
+	required("s3.secret_key", c.S3.SecretKey)
+	required("s3.bucket_name", c.S3.BucketName)
+	required("s3.api_url", c.S3.APIURL)
+	required("s3.access_url", c.S3.AccessURL)
+	if c.S3.AccessURLProtocol != "http://" && c.S3.AccessURLProtocol != "https://" {
+		errs = append(errs, errors.New("s3.access_url_protocol must be http:// or https://"))
+	}
+
+	// sms is not sent in debug mode
+	if !c.Debug {
+		required("sms.api_key", c.SMS.APIKey)
+		if c.SMS.TemplateID <= 0 {
+			errs = append(errs, errors.New("sms.template_id is required"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) ValidateServer() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+
+	timeouts := map[string]time.Duration{
+		"server.read_timeout":           c.Server.ReadTimeout,
+		"server.read_header_timeout":    c.Server.ReadHeaderTimeout,
+		"server.write_timeout":          c.Server.WriteTimeout,
+		"server.idle_timeout":           c.Server.IdleTimeout,
+		"server.shutdown_timeout":       c.Server.ShutdownTimeout,
+		"server.health_check_timeout":   c.Server.HealthCheckTimeout,
+		"server.idempotency_key_expire": c.Server.IdempotencyKeyExpire,
+	}
+	for name, timeout := range timeouts {
+		if timeout <= 0 {
+			errs = append(errs, errors.New(name+" must be positive"))
+		}
+	}
+
+	if c.Server.ShutdownDelay < 0 {
+		errs = append(errs, errors.New("server.shutdown_delay must not be negative"))
+	}
+
+	if c.Server.MaxHeaderBytes < 1024 {
+		errs = append(errs, errors.New("server.max_header_bytes must be at least 1024"))
+	}
+
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		errs = append(errs, errors.New("server.tls_cert_file and server.tls_key_file must be set together"))
+	}
+
+	for _, file := range []string{c.Server.TLSCertFile, c.Server.TLSKeyFile} {
+		if file == "" {
+			continue
+		}
+
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, fmt.Errorf("tls file: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// check values that required for connecting to database
+func (c Config) ValidateDatabase() error {
+	var errs []error
+
+	switch c.Database.Driver {
+	case "postgres":
+		if c.Database.Host == "" {
+			errs = append(errs, errors.New("database.host is required"))
+		}
+		if c.Database.Username == "" {
+			errs = append(errs, errors.New("database.username is required"))
+		}
+		if c.Database.Name == "" {
+			errs = append(errs, errors.New("database.name is required"))
+		}
+		if c.Database.Port <= 0 || c.Database.Port > 65535 {
+			errs = append(errs, errors.New("database.port is invalid"))
+		}
+	case "sqlite":
+		if c.Database.Path == "" {
+			errs = append(errs, errors.New("database.path is required"))
+		}
+	default:
+		errs = append(errs, errors.New("database.driver must be postgres or sqlite"))
+	}
+
+	switch c.Database.LogLevel {
+	case "silent", "error", "info":
+	default:
+		errs = append(errs, errors.New("database.log_level must be one of silent, error or info"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// copy of config with masked secrets
+func (c Config) Masked() Config {
+	masked := c
+
+	for _, f := range collectFields(reflect.ValueOf(&masked).Elem(), "", "") {
+		if f.secret && !f.value.IsZero() {
+			f.value.SetString(maskedValue)
+		}
+	}
+
+	return masked
+}
+
+// yaml of config with masked secrets
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Masked())
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(out)
+}
+
+// set package level values that depend on config
+func (c Config) Apply() {
+	setDebug(c.Debug)
+}
+
+func (c Config) ValidateCORS() error {
+	var errs []error
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				errs = append(errs, errors.New("cors.allowed_origins must not contain * when cors.allow_credentials is true"))
+			}
+			continue
+		}
+
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.Contains(host, "/") {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins: invalid origin %q. e.g: https://app.example.com", origin))
+			continue
+		}
+
+		if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins: wildcard is only allowed as first label of host: %q", origin))
+		}
+	}
+
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, errors.New("cors.max_age must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) ValidateRateLimit() error {
+	var errs []error
+
+	policies := map[string]string{
+		"rate_limit.auth":   c.RateLimit.Auth,
+		"rate_limit.public": c.RateLimit.Public,
+		"rate_limit.read":   c.RateLimit.Read,
+		"rate_limit.write":  c.RateLimit.Write,
+	}
+	for name, policy := range policies {
+		if _, err := ratelimit.ParsePolicy(policy); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if _, err := c.RateLimit.TrustedProxyPrefixes(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) ValidateOutbox() error {
+	var errs []error
+
+	durations := map[string]time.Duration{
+		"outbox.relay_interval":    c.Outbox.RelayInterval,
+		"outbox.retry_backoff":     c.Outbox.RetryBackoff,
+		"outbox.max_retry_backoff": c.Outbox.MaxRetryBackoff,
+		"outbox.claim_lease":       c.Outbox.ClaimLease,
+		"outbox.webhook_timeout":   c.Outbox.WebhookTimeout,
+	}
+	for name, duration := range durations {
+		if duration <= 0 {
+			errs = append(errs, errors.New(name+" must be positive"))
+		}
+	}
+
+	if c.Outbox.BatchSize <= 0 {
+		errs = append(errs, errors.New("outbox.batch_size must be positive"))
+	}
+
+	if c.Outbox.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("outbox.max_attempts must be positive"))
+	}
+
+	if c.Outbox.WebhookURL != "" && !strings.HasPrefix(c.Outbox.WebhookURL, "http://") && !strings.HasPrefix(c.Outbox.WebhookURL, "https://") {
+		errs = append(errs, errors.New("outbox.webhook_url must be http or https url"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	domain_account "github.com/yaghoubi-mn/pedarkharj/internal/domain/account"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"gorm.io/gorm"
+)
+
+type GormAccountRepository struct {
+	DB *gorm.DB
+}
+
+func NewGormAccountRepository(db *gorm.DB) domain_account.AccountDomainRepository {
+	return &GormAccountRepository{DB: db}
+}
+
+func (repo *GormAccountRepository) GetUserData(ctx context.Context, userID uint64) (domain_account.UserData, error) {
+	var data domain_account.UserData
+
+	if err := repo.DB.WithContext(ctx).Where(domain_user.User{ID: userID}).First(&data.User).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return data, database_errors.ErrRecordNotFound
+		}
+
+		return data, err
+	}
+
+	if err := repo.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&data.Devices).Error; err != nil {
+		return data, err
+	}
+
+	if err := repo.DB.WithContext(ctx).Where("creditor_id = ? OR debtor_id = ?", userID, userID).Find(&data.Debts).Error; err != nil {
+		return data, err
+	}
+
+	// expenses that user created or has a debt in it
+	if err := repo.DB.WithContext(ctx).Where("creator_id = ? OR id IN (?)", userID,
+		repo.DB.WithContext(ctx).Model(&domain_debt.Debt{}).Select("expense_id").Where("creditor_id = ? OR debtor_id = ?", userID, userID),
+	).Find(&data.Expenses).Error; err != nil {
+		return data, err
+	}
+
+	if err := repo.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&data.Comments).Error; err != nil {
+		return data, err
+	}
+
+	if err := repo.DB.WithContext(ctx).Where("user_id = ?", userID).Find(&data.Notifications).Error; err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
+
+func (repo *GormAccountRepository) DeleteUser(ctx context.Context, anonymizedUser domain_user.User, log *domain_audit.AuditLog) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		// update all columns even zero values
+		if err := tx.Model(&anonymizedUser).Select("*").Updates(&anonymizedUser).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", anonymizedUser.ID).Delete(&domain_device.Device{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", anonymizedUser.ID).Delete(&domain_expense_comment.ExpenseComment{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", anonymizedUser.ID).Delete(&domain_notification.Notification{}).Error; err != nil {
+			return err
+		}
+
+		return NewGormAuditRepository(tx).Create(ctx, log)
+	})
+}
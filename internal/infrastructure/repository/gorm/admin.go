@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+
+	"strings"
+	"time"
+
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"gorm.io/gorm"
+)
+
+type GormAdminRepository struct {
+	DB *gorm.DB
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func NewGormAdminRepository(db *gorm.DB) domain_admin.AdminDomainRepository {
+	return &GormAdminRepository{DB: db}
+}
+
+func (repo *GormAdminRepository) SearchUsers(ctx context.Context, query string, offset int, limit int) ([]domain_user.User, int64, error) {
+	var users []domain_user.User
+	var total int64
+
+	tx := repo.DB.WithContext(ctx).Model(&domain_user.User{})
+	if query != "" {
+		// like is case sensitive in postgres but not in sqlite. wildcards of query are matched literally
+		like := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+		tx = tx.Where(`LOWER(name) LIKE ? ESCAPE '\' OR number LIKE ? ESCAPE '\'`, like, like)
+	}
+
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := tx.Order("id DESC").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (repo *GormAdminRepository) GetUserDevices(ctx context.Context, userID uint64) ([]domain_device.Device, error) {
+	var devices []domain_device.Device
+
+	if err := repo.DB.WithContext(ctx).Where("user_id = ?", userID).Order("last_login DESC").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (repo *GormAdminRepository) GetUserExpenseCounts(ctx context.Context, userID uint64) (counts domain_admin.UserExpenseCounts, err error) {
+
+	if err = repo.DB.WithContext(ctx).Model(&domain_expense.Expense{}).Where("creator_id = ?", userID).Count(&counts.Created).Error; err != nil {
+		return counts, err
+	}
+
+	if err = repo.DB.WithContext(ctx).Model(&domain_debt.Debt{}).Where("creditor_id = ? OR debtor_id = ?", userID, userID).Distinct("expense_id").Count(&counts.Participated).Error; err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
+
+func (repo *GormAdminRepository) SetBlocked(ctx context.Context, userID uint64, isBlocked bool, log *domain_audit.AuditLog) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		result := tx.Model(&domain_user.User{}).Where("id = ?", userID).Update("is_blocked", isBlocked)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return database_errors.ErrRecordNotFound
+		}
+
+		if isBlocked {
+			// blocked user cannot get new access token
+			if err := tx.Model(&domain_device.Device{}).Where("user_id = ?", userID).Update("refresh_token", "").Error; err != nil {
+				return err
+			}
+		}
+
+		return NewGormAuditRepository(tx).Create(ctx, log)
+	})
+}
+
+func (repo *GormAdminRepository) GetStats(ctx context.Context) (stats domain_admin.Stats, err error) {
+
+	counts := []struct {
+		model any
+		dest  *int64
+		query string
+		args  []any
+	}{
+		{&domain_user.User{}, &stats.TotalUsers, "", nil},
+		{&domain_user.User{}, &stats.RegisteredUsers, "is_registered = ?", []any{true}},
+		{&domain_user.User{}, &stats.BlockedUsers, "is_blocked = ? AND is_deleted = ?", []any{true, false}},
+		{&domain_user.User{}, &stats.DeletedUsers, "is_deleted = ?", []any{true}},
+		{&domain_user.User{}, &stats.NewUsersToday, "is_registered = ? AND registered_at >= ?", []any{true, time.Now().Truncate(24 * time.Hour)}},
+		{&domain_device.Device{}, &stats.ActiveDevices, "refresh_token <> ?", []any{""}},
+		{&domain_expense.Expense{}, &stats.TotalExpenses, "", nil},
+		{&domain_debt.Debt{}, &stats.TotalDebts, "", nil},
+		{&domain_debt.Debt{}, &stats.UnpaidDebts, "is_paid = ?", []any{false}},
+	}
+
+	for _, c := range counts {
+		tx := repo.DB.WithContext(ctx).Model(c.model)
+		if c.query != "" {
+			tx = tx.Where(c.query, c.args...)
+		}
+
+		if err = tx.Count(c.dest).Error; err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
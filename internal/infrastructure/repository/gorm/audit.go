@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	"gorm.io/gorm"
+)
+
+type GormAuditRepository struct {
+	DB *gorm.DB
+}
+
+func NewGormAuditRepository(db *gorm.DB) domain_audit.AuditDomainRepository {
+	return &GormAuditRepository{DB: db}
+}
+
+func (repo *GormAuditRepository) Create(ctx context.Context, log *domain_audit.AuditLog) error {
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+
+	return repo.DB.WithContext(ctx).Create(log).Error
+}
+
+// newest first
+func (repo *GormAuditRepository) Search(ctx context.Context, filter domain_audit.Filter, offset int, limit int) ([]domain_audit.AuditLog, int64, error) {
+	query := repo.DB.WithContext(ctx).Model(&domain_audit.AuditLog{})
+
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []domain_audit.AuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
+
 	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
 	"gorm.io/gorm"
 )
@@ -14,17 +17,23 @@ func NewGormDebtRepository(db *gorm.DB) domain_debt.DebtDomainRepository {
 	return &GormDebtRepository{DB: db}
 }
 
-func (repo *GormDebtRepository) CreateMultipleWithTransaction(debts []domain_debt.Debt) error {
-	return repo.DB.Transaction(func(tx *gorm.DB) error {
+func (repo *GormDebtRepository) CreateMultipleWithTransaction(ctx context.Context, debts []domain_debt.Debt) error {
+	for i := range debts {
+		if debts[i].Version == 0 {
+			debts[i].Version = 1
+		}
+	}
+
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
 		err := tx.Create(&debts).Error
 		return err
 	})
 }
 
-func (repo *GormDebtRepository) GetByID(id, userID uint64) (domain_debt.Debt, error) {
+func (repo *GormDebtRepository) GetByID(ctx context.Context, id, userID uint64) (domain_debt.Debt, error) {
 	var user domain_debt.Debt
-	if err := repo.DB.Model(domain_debt.Debt{}).Where("id = ? AND (debtor_id = ? OR creditor_id = ?)", id, userID, userID).First(&user).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Model(domain_debt.Debt{}).Where("id = ? AND (debtor_id = ? OR creditor_id = ?)", id, userID, userID).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return user, database_errors.ErrRecordNotFound
 		}
@@ -35,46 +44,87 @@ func (repo *GormDebtRepository) GetByID(id, userID uint64) (domain_debt.Debt, er
 	return user, nil
 }
 
-func (repo *GormDebtRepository) GetLimitedByUserID(userID uint64, offset int, limit int) ([]domain_debt.Debt, error) {
+// ordered by id
+func (repo *GormDebtRepository) GetLimitedByUserID(ctx context.Context, userID uint64, offset int, limit int) ([]domain_debt.Debt, error) {
 	var debts []domain_debt.Debt
-	if err := repo.DB.Where("creditor_id=? or debtor_id=?", userID, userID).Limit(limit).Find(&debts).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Where("creditor_id=? or debtor_id=?", userID, userID).Order("id").Offset(offset).Limit(limit).Find(&debts).Error; err != nil {
 		return nil, err
 	}
 	return debts, nil
 }
 
 // the pointer for debt is for returning id
-func (repo *GormDebtRepository) Create(debt *domain_debt.Debt) error {
+func (repo *GormDebtRepository) Create(ctx context.Context, debt *domain_debt.Debt) error {
 
-	if err := repo.DB.Create(&debt).Error; err != nil {
+	if debt.Version == 0 {
+		debt.Version = 1
+	}
+
+	if err := repo.DB.WithContext(ctx).Create(&debt).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (repo *GormDebtRepository) Update(debt domain_debt.Debt) error {
-
-	if err := repo.DB.Updates(&debt).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return database_errors.ErrRecordNotFound
-		}
+// all fields except expense and users are saved if version is not changed. version of debt is incremented
+func (repo *GormDebtRepository) Update(ctx context.Context, debt *domain_debt.Debt) error {
+
+	result := repo.DB.WithContext(ctx).Model(&domain_debt.Debt{}).
+		Where("id = ? AND version = ?", debt.ID, debt.Version).
+		Updates(map[string]any{
+			"amount":                           debt.Amount,
+			"is_creditor_accepted":             debt.IsCreditorAccepted,
+			"is_debtor_accepted":               debt.IsDebtorAccepted,
+			"is_creditor_rejected":             debt.IsCreditorRejected,
+			"is_debtor_rejected":               debt.IsDebtorRejected,
+			"is_paid":                          debt.IsPaid,
+			"is_payment_accepted":              debt.IsPaymentAccepted,
+			"is_debtor_requested_for_delete":   debt.IsDebtorRequestedForDelete,
+			"is_creditor_requested_for_delete": debt.IsCreditorRequestedForDelete,
+			"version":                          gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
 
-		return err
+	if result.RowsAffected == 0 {
+		return versionError(repo.DB.WithContext(ctx), &domain_debt.Debt{}, debt.ID)
 	}
 
+	debt.Version++
 	return nil
 }
 
-func (repo *GormDebtRepository) Delete(id uint64) error {
+// debt and its notifications are deleted if version is not changed
+func (repo *GormDebtRepository) Delete(ctx context.Context, id uint64, version uint64) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
-	if err := repo.DB.Delete(&domain_debt.Debt{ID: id}).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return database_errors.ErrRecordNotFound
+		if err := tx.Where("debt_id = ?", id).Delete(&domain_notification.Notification{}).Error; err != nil {
+			return err
 		}
 
-		return err
-	}
+		result := tx.Where("id = ? AND version = ?", id, version).Delete(&domain_debt.Debt{})
+		if result.Error != nil {
+			return result.Error
+		}
 
-	return nil
+		if result.RowsAffected == 0 {
+			return versionError(tx, &domain_debt.Debt{}, id)
+		}
+
+		return nil
+	})
+}
+
+func (repo *GormDebtRepository) DeleteByExpenseID(ctx context.Context, expenseID uint64) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		debtIDs := tx.Model(&domain_debt.Debt{}).Select("id").Where("expense_id = ?", expenseID)
+		if err := tx.Where("debt_id IN (?)", debtIDs).Delete(&domain_notification.Notification{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("expense_id = ?", expenseID).Delete(&domain_debt.Debt{}).Error
+	})
 }
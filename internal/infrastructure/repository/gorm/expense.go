@@ -1,7 +1,11 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
 	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
 	"gorm.io/gorm"
@@ -16,9 +20,9 @@ func NewGormExpenseRepository(db *gorm.DB) domain_expense.ExpenseDomainRepositor
 }
 
 // todo: this should be done in users service
-func (repo *GormExpenseRepository) CreateUsersWithNumbers(numbers []string) error {
+func (repo *GormExpenseRepository) CreateUsersWithNumbers(ctx context.Context, numbers []string) error {
 	var existingUsers []domain_user.User
-	if err := repo.DB.Select("number").Where("number IN ?", numbers).Find(&existingUsers).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Select("number").Where("number IN ?", numbers).Find(&existingUsers).Error; err != nil {
 		return err
 	}
 
@@ -50,16 +54,16 @@ func (repo *GormExpenseRepository) CreateUsersWithNumbers(numbers []string) erro
 		newUsers = append(newUsers, user)
 	}
 
-	return repo.DB.Transaction(func(tx *gorm.DB) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
 		return tx.Create(&newUsers).Error
 	})
 }
 
-func (repo *GormExpenseRepository) GetUserIDOfPhoneNumbers(numbers []string) (map[string]uint64, error) {
+func (repo *GormExpenseRepository) GetUserIDOfPhoneNumbers(ctx context.Context, numbers []string) (map[string]uint64, error) {
 
 	var users []domain_user.User
-	if err := repo.DB.Where("number In ?", numbers).Find(&users).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Where("number In ?", numbers).Find(&users).Error; err != nil {
 		return nil, err
 	}
 
@@ -71,11 +75,11 @@ func (repo *GormExpenseRepository) GetUserIDOfPhoneNumbers(numbers []string) (ma
 	return idNumberMap, nil
 }
 
-func (repo *GormExpenseRepository) GetByID(id uint64, userID uint64) (domain_expense.Expense, error) {
+func (repo *GormExpenseRepository) GetByID(ctx context.Context, id uint64, userID uint64) (domain_expense.Expense, error) {
 	var expense domain_expense.Expense
-	if err := repo.DB.Model(&domain_expense.Expense{}).
-		Joins("JOIN debts ON debts.expense_id = expense.id").
-		Where("expense.id = ? AND (debts.creditor_id=? OR debts.debtor_id=?)", id, userID, userID).
+	if err := repo.DB.WithContext(ctx).Model(&domain_expense.Expense{}).
+		Joins("JOIN debts ON debts.expense_id = expenses.id").
+		Where("expenses.id = ? AND (debts.creditor_id=? OR debts.debtor_id=?)", id, userID, userID).
 		First(&expense).Error; err != nil {
 
 		if err == gorm.ErrRecordNotFound {
@@ -88,9 +92,9 @@ func (repo *GormExpenseRepository) GetByID(id uint64, userID uint64) (domain_exp
 	return expense, nil
 }
 
-func (repo *GormExpenseRepository) GetLimitedExpenseDebtByUserID(userID uint64, offset int, limit int) ([]domain_expense.ExpenseDebtOuput, error) {
+func (repo *GormExpenseRepository) GetLimitedExpenseDebtByUserID(ctx context.Context, userID uint64, offset int, limit int) ([]domain_expense.ExpenseDebtOuput, error) {
 	var expenses []domain_expense.ExpenseDebtOuput
-	if err := repo.DB.Model(&domain_expense.Expense{}).
+	if err := repo.DB.WithContext(ctx).Model(&domain_expense.Expense{}).
 		Select(`expenses.id,
 			expenses.name,
 			expenses.description,
@@ -104,26 +108,26 @@ func (repo *GormExpenseRepository) GetLimitedExpenseDebtByUserID(userID uint64,
 			debts.is_creditor_rejected,
 			debts.is_debtor_rejected,
 			debts.is_paid,
-			debts.is_payment_accepted,
+			debts.is_payment_accepted as is_payment_accpeted,
 			debts.is_creditor_requested_for_delete,
 			debts.is_debtor_requested_for_delete,
 			CASE
 				WHEN debts.creditor_id = ? then debtor_user.name
 				ELSE creditor_user.name
-			END as contact_user_name
+			END as user_name,
 			CASE
 				WHEN debts.creditor_id = ? then debtor_user.avatar
 				ELSE creditor_user.avatar
-			END as contact_user_avatar
+			END as user_avatar,
 			CASE
 				WHEN debts.creditor_id = ? then 'debtor'
 				ELSE 'creditor'
-			END as user_role
+			END as type
 			`, userID, userID, userID).
 		Joins("JOIN debts ON debts.expense_id = expenses.id").
 		Joins("JOIN users as creditor_user ON creditor_user.id = debts.creditor_id"). // join users for contact name and avatar
 		Joins("JOIN users as debtor_user ON debtor_user.id = debts.debtor_id").
-		Where("debts.creditor_id=? OR debts.debtor_id=?", userID, userID).Order("created_at DESC").Offset(offset).Limit(limit).Find(&expenses).Error; err != nil {
+		Where("debts.creditor_id=? OR debts.debtor_id=?", userID, userID).Order("expenses.created_at DESC").Offset(offset).Limit(limit).Find(&expenses).Error; err != nil {
 
 		if err == gorm.ErrRecordNotFound {
 			return expenses, database_errors.ErrRecordNotFound
@@ -135,37 +139,62 @@ func (repo *GormExpenseRepository) GetLimitedExpenseDebtByUserID(userID uint64,
 	return expenses, nil
 }
 
-func (repo *GormExpenseRepository) Create(user *domain_expense.Expense) error {
+func (repo *GormExpenseRepository) Create(ctx context.Context, user *domain_expense.Expense) error {
 
-	if err := repo.DB.Create(&user).Error; err != nil {
+	if user.Version == 0 {
+		user.Version = 1
+	}
+
+	if err := repo.DB.WithContext(ctx).Create(&user).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (repo *GormExpenseRepository) Update(expense domain_expense.Expense) error {
-
-	if err := repo.DB.Updates(&expense).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return database_errors.ErrRecordNotFound
-		}
+// name, description and total amount are saved if version is not changed. version of expense is incremented
+func (repo *GormExpenseRepository) Update(ctx context.Context, expense *domain_expense.Expense) error {
+
+	updatedAt := time.Now()
+
+	result := repo.DB.WithContext(ctx).Model(&domain_expense.Expense{}).
+		Where("id = ? AND version = ?", expense.ID, expense.Version).
+		Updates(map[string]any{
+			"name":         expense.Name,
+			"description":  expense.Description,
+			"total_amount": expense.TotalAmount,
+			"updated_at":   updatedAt,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
 
-		return err
+	if result.RowsAffected == 0 {
+		return versionError(repo.DB.WithContext(ctx), &domain_expense.Expense{}, expense.ID)
 	}
 
+	expense.UpdatedAt = updatedAt
+	expense.Version++
 	return nil
 }
 
-func (repo *GormExpenseRepository) Delete(id uint64, userID uint64) error {
+// only creator of expense can delete it
+func (repo *GormExpenseRepository) Delete(ctx context.Context, id uint64, userID uint64) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 
-	if err := repo.DB.Delete(&domain_expense.Expense{ID: id, CreatorID: userID}).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+		var count int64
+		if err := tx.Model(&domain_expense.Expense{}).Where("id = ? AND creator_id = ?", id, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
 			return database_errors.ErrRecordNotFound
 		}
 
-		return err
-	}
+		if err := tx.Where("expense_id = ?", id).Delete(&domain_expense_comment.ExpenseComment{}).Error; err != nil {
+			return err
+		}
 
-	return nil
+		return tx.Delete(&domain_expense.Expense{ID: id}).Error
+	})
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"gorm.io/gorm"
+)
+
+type GormOutboxRepository struct {
+	DB *gorm.DB
+}
+
+func NewGormOutboxRepository(db *gorm.DB) domain_outbox.OutboxDomainRepository {
+	return &GormOutboxRepository{DB: db}
+}
+
+func (repo *GormOutboxRepository) Create(ctx context.Context, event *domain_outbox.OutboxEvent) error {
+	return repo.DB.WithContext(ctx).Create(event).Error
+}
+
+func (repo *GormOutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]domain_outbox.OutboxEvent, error) {
+	var events []domain_outbox.OutboxEvent
+	if err := repo.DB.WithContext(ctx).
+		Where("is_published = ? AND is_failed = ? AND next_attempt_at <= ?", false, false, now).
+		Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// claimed rows are updated with one conditional update, so concurrent relays never claim same row.
+// claim token of update is used to get claimed rows
+func (repo *GormOutboxRepository) ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain_outbox.OutboxEvent, error) {
+	db := repo.DB.WithContext(ctx)
+	claimable := "is_published = ? AND is_failed = ? AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until <= ?)"
+
+	pending := db.Model(&domain_outbox.OutboxEvent{}).Select("id").
+		Where(claimable, false, false, now, now).
+		Order("id").Limit(limit)
+
+	token := uuid.New().String()
+	if err := db.Model(&domain_outbox.OutboxEvent{}).
+		Where("id IN (?)", pending).
+		Where(claimable, false, false, now, now).
+		Updates(map[string]any{"claim_token": token, "claimed_until": now.Add(lease)}).Error; err != nil {
+		return nil, err
+	}
+
+	var events []domain_outbox.OutboxEvent
+	if err := db.Where("claim_token = ?", token).Order("id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (repo *GormOutboxRepository) UpdateDelivery(ctx context.Context, event domain_outbox.OutboxEvent) error {
+	result := repo.DB.WithContext(ctx).Model(&domain_outbox.OutboxEvent{}).Where("id = ?", event.ID).Updates(map[string]any{
+		"attempts":        event.Attempts,
+		"next_attempt_at": event.NextAttemptAt,
+		"last_error":      event.LastError,
+		"is_published":    event.IsPublished,
+		"published_at":    event.PublishedAt,
+		"is_failed":       event.IsFailed,
+		"claim_token":     "",
+		"claimed_until":   nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return database_errors.ErrRecordNotFound
+	}
+
+	return nil
+}
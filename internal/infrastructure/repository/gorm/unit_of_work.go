@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	"gorm.io/gorm"
+)
+
+type GormUnitOfWork struct {
+	DB *gorm.DB
+}
+
+func NewGormUnitOfWork(db *gorm.DB) app_shared.UnitOfWork {
+	return &GormUnitOfWork{DB: db}
+}
+
+// transactions of repositories inside fn are nested as savepoints
+func (uow *GormUnitOfWork) Do(ctx context.Context, fn func(repos app_shared.TxRepositories) error) error {
+	return uow.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(app_shared.TxRepositories{
+			User:    NewGormUserRepository(tx),
+			Expense: NewGormExpenseRepository(tx),
+			Debt:    NewGormDebtRepository(tx),
+			Outbox:  NewGormOutboxRepository(tx),
+			Audit:   NewGormAuditRepository(tx),
+		})
+	})
+}
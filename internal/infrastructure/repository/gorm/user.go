@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"context"
+
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
 	"github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
 	"gorm.io/gorm"
@@ -14,9 +18,9 @@ func NewGormUserRepository(db *gorm.DB) domain_user.UserDomainRepository {
 	return &GormUserRepository{DB: db}
 }
 
-func (repo *GormUserRepository) GetByID(id uint64) (domain_user.User, error) {
+func (repo *GormUserRepository) GetByID(ctx context.Context, id uint64) (domain_user.User, error) {
 	var user domain_user.User
-	if err := repo.DB.Where(domain_user.User{ID: id}).First(&user).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Where(domain_user.User{ID: id}).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return user, database_errors.ErrRecordNotFound
 		}
@@ -27,9 +31,9 @@ func (repo *GormUserRepository) GetByID(id uint64) (domain_user.User, error) {
 	return user, nil
 }
 
-func (repo *GormUserRepository) GetByNumber(number string) (domain_user.User, error) {
+func (repo *GormUserRepository) GetByNumber(ctx context.Context, number string) (domain_user.User, error) {
 	var u domain_user.User
-	if err := repo.DB.First(&u, domain_user.User{Number: number}).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).First(&u, domain_user.User{Number: number}).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return u, database_errors.ErrRecordNotFound
 		}
@@ -39,18 +43,31 @@ func (repo *GormUserRepository) GetByNumber(number string) (domain_user.User, er
 	return u, nil
 }
 
-func (repo *GormUserRepository) Create(user *domain_user.User) error {
+func (repo *GormUserRepository) Create(ctx context.Context, user *domain_user.User) error {
+
+	if err := repo.DB.WithContext(ctx).Create(&user).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repo *GormUserRepository) Update(ctx context.Context, user domain_user.User) error {
+
+	if err := repo.DB.WithContext(ctx).Updates(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return database_errors.ErrRecordNotFound
+		}
 
-	if err := repo.DB.Create(&user).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (repo *GormUserRepository) Update(user domain_user.User) error {
+func (repo *GormUserRepository) UpdateColumns(ctx context.Context, user domain_user.User) error {
 
-	if err := repo.DB.Updates(&user).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).UpdateColumns(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return database_errors.ErrRecordNotFound
 		}
@@ -61,9 +78,10 @@ func (repo *GormUserRepository) Update(user domain_user.User) error {
 	return nil
 }
 
-func (repo *GormUserRepository) UpdateColumns(user domain_user.User) error {
+// update two factor columns even if they are zero value (for disabling two factor)
+func (repo *GormUserRepository) UpdateTwoFactor(ctx context.Context, user domain_user.User) error {
 
-	if err := repo.DB.UpdateColumns(&user).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Model(&user).Select("TOTPSecret", "IsTOTPEnabled", "TOTPRecoveryCodes", "TOTPLastCounter").Updates(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return database_errors.ErrRecordNotFound
 		}
@@ -74,9 +92,112 @@ func (repo *GormUserRepository) UpdateColumns(user domain_user.User) error {
 	return nil
 }
 
-func (repo *GormUserRepository) Delete(id uint64) error {
+// counter is compared in database so a totp code cannot be accepted by concurrent requests
+func (repo *GormUserRepository) UpdateTOTPCounter(ctx context.Context, userID uint64, counter int64) error {
+
+	result := repo.DB.WithContext(ctx).Model(&domain_user.User{}).
+		Where("id = ? AND totp_last_counter < ?", userID, counter).
+		Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return database_errors.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// codes are compared in database so a recovery code cannot be used by concurrent requests
+func (repo *GormUserRepository) UpdateTOTPRecoveryCodes(ctx context.Context, userID uint64, oldCodes string, codes string) error {
+
+	result := repo.DB.WithContext(ctx).Model(&domain_user.User{}).
+		Where("id = ? AND totp_recovery_codes = ?", userID, oldCodes).
+		Update("totp_recovery_codes", codes)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return database_errors.ErrVersionConflict
+	}
+
+	return nil
+}
+
+// update avatar columns even if they are zero value (for preset avatars)
+func (repo *GormUserRepository) UpdateAvatar(ctx context.Context, user domain_user.User) error {
+
+	if err := repo.DB.WithContext(ctx).Model(&user).Select("Avatar", "AvatarThumbnail", "AvatarKey").Updates(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return database_errors.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (repo *GormUserRepository) ChangeNumber(ctx context.Context, user domain_user.User, mergeUserID uint64) error {
+	return repo.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		if mergeUserID != 0 {
+			// expenses that are changed by merge. their version is incremented
+			var expenseIDs []uint64
+			err := tx.Raw("SELECT DISTINCT expense_id FROM debts WHERE creditor_id = ? OR debtor_id = ? UNION SELECT id FROM expenses WHERE creator_id = ?",
+				mergeUserID, mergeUserID, mergeUserID).Scan(&expenseIDs).Error
+			if err != nil {
+				return err
+			}
+
+			// move history of unregistered user (created in expenses) to user
+			queries := []string{
+				"UPDATE debts SET creditor_id = ?, version = version + 1 WHERE creditor_id = ?",
+				"UPDATE debts SET debtor_id = ?, version = version + 1 WHERE debtor_id = ?",
+				"UPDATE expenses SET creator_id = ? WHERE creator_id = ?",
+				"UPDATE expense_comments SET user_id = ? WHERE user_id = ?",
+				"UPDATE notifications SET user_id = ? WHERE user_id = ?",
+				"UPDATE devices SET user_id = ? WHERE user_id = ?",
+			}
+			for _, query := range queries {
+				if err := tx.Exec(query, user.ID, mergeUserID).Error; err != nil {
+					return err
+				}
+			}
+
+			// debts between user and merged user are meaningless now. total amount of expenses is sum of credits so it is not changed
+			selfDebtIDs := tx.Model(&domain_debt.Debt{}).Select("id").Where("creditor_id = ? AND debtor_id = ?", user.ID, user.ID)
+			if err := tx.Where("debt_id IN (?)", selfDebtIDs).Delete(&domain_notification.Notification{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("creditor_id = ? AND debtor_id = ?", user.ID, user.ID).Delete(&domain_debt.Debt{}).Error; err != nil {
+				return err
+			}
+
+			if len(expenseIDs) > 0 {
+				if err := tx.Exec("UPDATE expenses SET version = version + 1 WHERE id IN ?", expenseIDs).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Delete(&domain_user.User{ID: mergeUserID}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&user).Update("number", user.Number).Error; err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (repo *GormUserRepository) Delete(ctx context.Context, id uint64) error {
 
-	if err := repo.DB.Delete(&domain_user.User{ID: id}).Error; err != nil {
+	if err := repo.DB.WithContext(ctx).Delete(&domain_user.User{ID: id}).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return database_errors.ErrRecordNotFound
 		}
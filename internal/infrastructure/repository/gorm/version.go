@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"gorm.io/gorm"
+)
+
+// error of conditional write that changed no rows. record is either deleted or changed by another request
+func versionError(db *gorm.DB, model any, id uint64) error {
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return database_errors.ErrRecordNotFound
+	}
+
+	return database_errors.ErrVersionConflict
+}
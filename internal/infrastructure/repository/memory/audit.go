@@ -0,0 +1,66 @@
+package repository_memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+)
+
+type MemoryAuditRepository struct {
+	store *Store
+}
+
+func NewMemoryAuditRepository(store *Store) domain_audit.AuditDomainRepository {
+	return &MemoryAuditRepository{store: store}
+}
+
+func (repo *MemoryAuditRepository) Create(ctx context.Context, log *domain_audit.AuditLog) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	log.ID = repo.store.nextID("audit_logs")
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	repo.store.auditLogs[log.ID] = *log
+
+	return nil
+}
+
+// newest first
+func (repo *MemoryAuditRepository) Search(ctx context.Context, filter domain_audit.Filter, offset int, limit int) ([]domain_audit.AuditLog, int64, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	logs := make([]domain_audit.AuditLog, 0)
+	for _, log := range repo.store.auditLogs {
+		if matchAuditFilter(log, filter) {
+			logs = append(logs, log)
+		}
+	}
+
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID > logs[j].ID })
+
+	return paginate(logs, offset, limit), int64(len(logs)), nil
+}
+
+func matchAuditFilter(log domain_audit.AuditLog, filter domain_audit.Filter) bool {
+	switch {
+	case filter.ActorID != 0 && log.ActorID != filter.ActorID:
+		return false
+	case filter.Action != "" && log.Action != filter.Action:
+		return false
+	case filter.TargetType != "" && log.TargetType != filter.TargetType:
+		return false
+	case filter.TargetID != 0 && log.TargetID != filter.TargetID:
+		return false
+	case !filter.From.IsZero() && log.CreatedAt.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && log.CreatedAt.After(filter.To):
+		return false
+	}
+
+	return true
+}
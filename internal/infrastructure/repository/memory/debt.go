@@ -0,0 +1,142 @@
+package repository_memory
+
+import (
+	"context"
+	"sort"
+
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryDebtRepository struct {
+	store *Store
+}
+
+func NewMemoryDebtRepository(store *Store) domain_debt.DebtDomainRepository {
+	return &MemoryDebtRepository{store: store}
+}
+
+// all debts are created or none of them
+func (repo *MemoryDebtRepository) CreateMultipleWithTransaction(ctx context.Context, debts []domain_debt.Debt) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for i := range debts {
+		if debts[i].Version == 0 {
+			debts[i].Version = 1
+		}
+		debts[i].ID = repo.store.nextID("debts")
+		repo.store.debts[debts[i].ID] = debts[i]
+	}
+
+	return nil
+}
+
+// debt is found only if user is creditor or debtor of it
+func (repo *MemoryDebtRepository) GetByID(ctx context.Context, id uint64, userID uint64) (domain_debt.Debt, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	debt, ok := repo.store.debts[id]
+	if !ok || (debt.CreditorID != userID && debt.DebtorID != userID) {
+		return domain_debt.Debt{}, database_errors.ErrRecordNotFound
+	}
+
+	return debt, nil
+}
+
+// ordered by id
+func (repo *MemoryDebtRepository) GetLimitedByUserID(ctx context.Context, userID uint64, offset int, limit int) ([]domain_debt.Debt, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	debts := make([]domain_debt.Debt, 0)
+	for _, debt := range repo.store.debts {
+		if debt.CreditorID == userID || debt.DebtorID == userID {
+			debts = append(debts, debt)
+		}
+	}
+
+	sort.Slice(debts, func(i, j int) bool { return debts[i].ID < debts[j].ID })
+
+	return paginate(debts, offset, limit), nil
+}
+
+// the pointer for debt is for returning id
+func (repo *MemoryDebtRepository) Create(ctx context.Context, debt *domain_debt.Debt) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	if debt.Version == 0 {
+		debt.Version = 1
+	}
+	debt.ID = repo.store.nextID("debts")
+	repo.store.debts[debt.ID] = *debt
+
+	return nil
+}
+
+// all fields except expense and users are saved if version is not changed. version of debt is incremented
+func (repo *MemoryDebtRepository) Update(ctx context.Context, debt *domain_debt.Debt) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.debts[debt.ID]
+	if !ok {
+		return database_errors.ErrRecordNotFound
+	}
+	if saved.Version != debt.Version {
+		return database_errors.ErrVersionConflict
+	}
+
+	debt.ExpenseID = saved.ExpenseID
+	debt.CreditorID = saved.CreditorID
+	debt.DebtorID = saved.DebtorID
+	debt.Version++
+	repo.store.debts[debt.ID] = *debt
+
+	return nil
+}
+
+// debt and its notifications are deleted if version is not changed
+func (repo *MemoryDebtRepository) Delete(ctx context.Context, id uint64, version uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.debts[id]
+	if !ok {
+		return database_errors.ErrRecordNotFound
+	}
+	if saved.Version != version {
+		return database_errors.ErrVersionConflict
+	}
+
+	for notifID, notif := range repo.store.notifications {
+		if notif.DebtID == id {
+			delete(repo.store.notifications, notifID)
+		}
+	}
+	delete(repo.store.debts, id)
+
+	return nil
+}
+
+func (repo *MemoryDebtRepository) DeleteByExpenseID(ctx context.Context, expenseID uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, debt := range repo.store.debts {
+		if debt.ExpenseID != expenseID {
+			continue
+		}
+
+		for notifID, notif := range repo.store.notifications {
+			if notif.DebtID == id {
+				delete(repo.store.notifications, notifID)
+			}
+		}
+		delete(repo.store.debts, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository_memory
+
+import (
+	"context"
+
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryDeviceRepository struct {
+	store *Store
+}
+
+func NewMemoryDeviceRepository(store *Store) domain_device.DeviceDomainRepository {
+	return &MemoryDeviceRepository{store: store}
+}
+
+func (repo *MemoryDeviceRepository) Create(ctx context.Context, device domain_device.Device) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	device.ID = repo.store.nextID("devices")
+	repo.store.devices[device.ID] = device
+
+	return nil
+}
+
+func (repo *MemoryDeviceRepository) Update(ctx context.Context, device domain_device.Device) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.devices[device.ID]
+	if !ok {
+		return nil
+	}
+
+	mergeNonZero(&saved, device)
+	repo.store.devices[device.ID] = saved
+
+	return nil
+}
+
+// distinguish devices with device.Name and device.UserID
+// device.ID can be zero
+func (repo *MemoryDeviceRepository) CreateOrUpdate(ctx context.Context, device domain_device.Device) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, saved := range repo.store.devices {
+		if saved.Name == device.Name && saved.UserID == device.UserID {
+			device.ID = id
+			mergeNonZero(&saved, device)
+			repo.store.devices[id] = saved
+			return nil
+		}
+	}
+
+	device.ID = repo.store.nextID("devices")
+	repo.store.devices[device.ID] = device
+
+	return nil
+}
+
+func (repo *MemoryDeviceRepository) GetUserByRefreshToken(ctx context.Context, refresh string) (domain_user.User, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	if refresh == "" {
+		return domain_user.User{}, database_errors.ErrRecordNotFound
+	}
+
+	for _, device := range repo.store.devices {
+		if device.RefreshToken != refresh {
+			continue
+		}
+
+		user, ok := repo.store.users[device.UserID]
+		if !ok {
+			break
+		}
+
+		return user, nil
+	}
+
+	return domain_user.User{}, database_errors.ErrRecordNotFound
+}
+
+func (repo *MemoryDeviceRepository) Logout(ctx context.Context, userID uint64, deviceName string) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, device := range repo.store.devices {
+		if device.UserID == userID && device.Name == deviceName {
+			device.RefreshToken = ""
+			repo.store.devices[id] = device
+		}
+	}
+
+	return nil
+}
+
+func (repo *MemoryDeviceRepository) LogoutAllUserDevices(ctx context.Context, userID uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for id, device := range repo.store.devices {
+		if device.UserID == userID {
+			device.RefreshToken = ""
+			repo.store.devices[id] = device
+		}
+	}
+
+	return nil
+}
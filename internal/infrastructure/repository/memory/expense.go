@@ -0,0 +1,199 @@
+package repository_memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryExpenseRepository struct {
+	store *Store
+}
+
+func NewMemoryExpenseRepository(store *Store) domain_expense.ExpenseDomainRepository {
+	return &MemoryExpenseRepository{store: store}
+}
+
+// create unregistered users for numbers that are not saved
+func (repo *MemoryExpenseRepository) CreateUsersWithNumbers(ctx context.Context, numbers []string) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, number := range numbers {
+		if _, exists := repo.store.userByNumber(number); exists {
+			continue
+		}
+
+		user := domain_user.User{
+			IsRegistered: false,
+			Name:         number,
+			Number:       number,
+			IsBlocked:    false,
+			Avatar:       "default",
+			Password:     "No Password",
+			Salt:         "No Salt",
+		}
+		if err := repo.store.createUser(&user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *MemoryExpenseRepository) GetUserIDOfPhoneNumbers(ctx context.Context, numbers []string) (map[string]uint64, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	idNumberMap := make(map[string]uint64)
+	for _, number := range numbers {
+		if user, ok := repo.store.userByNumber(number); ok {
+			idNumberMap[user.Number] = user.ID
+		}
+	}
+
+	return idNumberMap, nil
+}
+
+// expense is found only if user has a debt in it
+func (repo *MemoryExpenseRepository) GetByID(ctx context.Context, id uint64, userID uint64) (domain_expense.Expense, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	expense, ok := repo.store.expenses[id]
+	if !ok {
+		return domain_expense.Expense{}, database_errors.ErrRecordNotFound
+	}
+
+	for _, debt := range repo.store.debts {
+		if debt.ExpenseID == id && (debt.CreditorID == userID || debt.DebtorID == userID) {
+			return expense, nil
+		}
+	}
+
+	return domain_expense.Expense{}, database_errors.ErrRecordNotFound
+}
+
+// a row for each debt of user. ordered by newest expense
+func (repo *MemoryExpenseRepository) GetLimitedExpenseDebtByUserID(ctx context.Context, userID uint64, offset int, limit int) ([]domain_expense.ExpenseDebtOuput, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	outputs := make([]domain_expense.ExpenseDebtOuput, 0)
+	for _, debt := range repo.store.debts {
+		if debt.CreditorID != userID && debt.DebtorID != userID {
+			continue
+		}
+
+		expense, ok := repo.store.expenses[debt.ExpenseID]
+		if !ok {
+			continue
+		}
+
+		// contact is other side of debt
+		contact, contactType := repo.store.users[debt.CreditorID], "creditor"
+		if debt.CreditorID == userID {
+			contact, contactType = repo.store.users[debt.DebtorID], "debtor"
+		}
+
+		outputs = append(outputs, domain_expense.ExpenseDebtOuput{
+			ExpenseDebtOuput: shared_dto.ExpenseDebtOuput{
+				ID:                           expense.ID,
+				Name:                         expense.Name,
+				Description:                  expense.Description,
+				CreatedAt:                    expense.CreatedAt,
+				UpdatedAt:                    expense.UpdatedAt,
+				CreditorID:                   debt.CreditorID,
+				DebtorID:                     debt.DebtorID,
+				Amount:                       debt.Amount,
+				Type:                         contactType,
+				IsCreditorAccepted:           debt.IsCreditorAccepted,
+				IsDebtorAccepted:             debt.IsDebtorAccepted,
+				IsCreditorRejected:           debt.IsCreditorRejected,
+				IsDebtorRejected:             debt.IsDebtorRejected,
+				IsPaid:                       debt.IsPaid,
+				IsPaymentAccpeted:            debt.IsPaymentAccepted,
+				IsCreditorRequestedForDelete: debt.IsCreditorRequestedForDelete,
+				IsDebtorRequestedForDelete:   debt.IsDebtorRequestedForDelete,
+				UserAvatar:                   contact.Avatar,
+				UserName:                     contact.Name,
+			},
+		})
+	}
+
+	sort.SliceStable(outputs, func(i, j int) bool { return outputs[i].CreatedAt.After(outputs[j].CreatedAt) })
+
+	return paginate(outputs, offset, limit), nil
+}
+
+func (repo *MemoryExpenseRepository) Create(ctx context.Context, expense *domain_expense.Expense) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	now := time.Now()
+	if expense.CreatedAt.IsZero() {
+		expense.CreatedAt = now
+	}
+	if expense.UpdatedAt.IsZero() {
+		expense.UpdatedAt = now
+	}
+
+	if expense.Version == 0 {
+		expense.Version = 1
+	}
+	expense.ID = repo.store.nextID("expenses")
+	repo.store.expenses[expense.ID] = *expense
+
+	return nil
+}
+
+// name, description and total amount are saved if version is not changed. version of expense is incremented
+func (repo *MemoryExpenseRepository) Update(ctx context.Context, expense *domain_expense.Expense) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.expenses[expense.ID]
+	if !ok {
+		return database_errors.ErrRecordNotFound
+	}
+	if saved.Version != expense.Version {
+		return database_errors.ErrVersionConflict
+	}
+
+	saved.Name = expense.Name
+	saved.Description = expense.Description
+	saved.TotalAmount = expense.TotalAmount
+	saved.UpdatedAt = time.Now()
+	saved.Version++
+	repo.store.expenses[expense.ID] = saved
+
+	expense.UpdatedAt = saved.UpdatedAt
+	expense.Version = saved.Version
+
+	return nil
+}
+
+// only creator of expense can delete it
+func (repo *MemoryExpenseRepository) Delete(ctx context.Context, id uint64, userID uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	expense, ok := repo.store.expenses[id]
+	if !ok || expense.CreatorID != userID {
+		return database_errors.ErrRecordNotFound
+	}
+
+	for commentID, comment := range repo.store.comments {
+		if comment.ExpenseID == id {
+			delete(repo.store.comments, commentID)
+		}
+	}
+	delete(repo.store.expenses, id)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository_memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryExpenseCommentRepository struct {
+	store *Store
+}
+
+func NewMemoryExpenseCommentRepository(store *Store) domain_expense_comment.DebtDomainRepository {
+	return &MemoryExpenseCommentRepository{store: store}
+}
+
+func (repo *MemoryExpenseCommentRepository) GetByID(ctx context.Context, id uint64) (domain_expense_comment.ExpenseComment, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	comment, ok := repo.store.comments[id]
+	if !ok {
+		return comment, database_errors.ErrRecordNotFound
+	}
+
+	return comment, nil
+}
+
+// ordered by id, so older comments come first
+func (repo *MemoryExpenseCommentRepository) GetLimitedByExpenseID(ctx context.Context, expenseID uint64, offset int, limit uint) ([]domain_expense_comment.ExpenseComment, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	comments := make([]domain_expense_comment.ExpenseComment, 0)
+	for _, comment := range repo.store.comments {
+		if comment.ExpenseID == expenseID {
+			comments = append(comments, comment)
+		}
+	}
+
+	sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+
+	return paginate(comments, offset, int(limit)), nil
+}
+
+func (repo *MemoryExpenseCommentRepository) Create(ctx context.Context, comment *domain_expense_comment.ExpenseComment) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	now := time.Now()
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = now
+	}
+	if comment.UpdatedAt.IsZero() {
+		comment.UpdatedAt = now
+	}
+
+	comment.ID = repo.store.nextID("expense_comments")
+	repo.store.comments[comment.ID] = *comment
+
+	return nil
+}
+
+func (repo *MemoryExpenseCommentRepository) Update(ctx context.Context, comment domain_expense_comment.ExpenseComment) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.comments[comment.ID]
+	if !ok {
+		return nil
+	}
+
+	mergeNonZero(&saved, comment)
+	saved.UpdatedAt = time.Now()
+	repo.store.comments[comment.ID] = saved
+
+	return nil
+}
+
+func (repo *MemoryExpenseCommentRepository) Delete(ctx context.Context, id uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	delete(repo.store.comments, id)
+
+	return nil
+}
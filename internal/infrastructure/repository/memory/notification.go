@@ -0,0 +1,92 @@
+package repository_memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryNotificationRepository struct {
+	store *Store
+}
+
+func NewMemoryNotificationRepository(store *Store) domain_notification.NotificationDomainRepository {
+	return &MemoryNotificationRepository{store: store}
+}
+
+func (repo *MemoryNotificationRepository) Create(ctx context.Context, notif domain_notification.Notification) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	if notif.CreatedAt.IsZero() {
+		notif.CreatedAt = time.Now()
+	}
+
+	notif.ID = repo.store.nextID("notifications")
+	repo.store.notifications[notif.ID] = notif
+
+	return nil
+}
+
+func (repo *MemoryNotificationRepository) Update(ctx context.Context, notif domain_notification.Notification) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.notifications[notif.ID]
+	if !ok {
+		return nil
+	}
+
+	mergeNonZero(&saved, notif)
+	repo.store.notifications[notif.ID] = saved
+
+	return nil
+}
+
+func (repo *MemoryNotificationRepository) Delete(ctx context.Context, notifID uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	delete(repo.store.notifications, notifID)
+
+	return nil
+}
+
+// page starts from 1. sort is asc (oldest first) or desc (newest first). default is desc
+func (repo *MemoryNotificationRepository) GetAll(ctx context.Context, page int, limit int, sortOrder string) ([]domain_notification.Notification, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	notifs := make([]domain_notification.Notification, 0, len(repo.store.notifications))
+	for _, notif := range repo.store.notifications {
+		notifs = append(notifs, notif)
+	}
+
+	sort.Slice(notifs, func(i, j int) bool {
+		if sortOrder == "asc" {
+			return notifs[i].ID < notifs[j].ID
+		}
+		return notifs[i].ID > notifs[j].ID
+	})
+
+	if page < 1 {
+		page = 1
+	}
+
+	return paginate(notifs, (page-1)*limit, limit), nil
+}
+
+func (repo *MemoryNotificationRepository) Get(ctx context.Context, notifID uint64) (domain_notification.Notification, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	notif, ok := repo.store.notifications[notifID]
+	if !ok {
+		return notif, database_errors.ErrRecordNotFound
+	}
+
+	return notif, nil
+}
@@ -0,0 +1,99 @@
+package repository_memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryOutboxRepository struct {
+	store *Store
+}
+
+func NewMemoryOutboxRepository(store *Store) domain_outbox.OutboxDomainRepository {
+	return &MemoryOutboxRepository{store: store}
+}
+
+func (repo *MemoryOutboxRepository) Create(ctx context.Context, event *domain_outbox.OutboxEvent) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	for _, saved := range repo.store.outboxEvents {
+		if saved.EventID == event.EventID {
+			return ErrDuplicatedKey
+		}
+	}
+
+	event.ID = repo.store.nextID("outbox_events")
+	repo.store.outboxEvents[event.ID] = *event
+
+	return nil
+}
+
+// ordered by id
+func (repo *MemoryOutboxRepository) GetPending(ctx context.Context, now time.Time, limit int) ([]domain_outbox.OutboxEvent, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	events := make([]domain_outbox.OutboxEvent, 0)
+	for _, event := range repo.store.outboxEvents {
+		if !event.IsPublished && !event.IsFailed && !event.NextAttemptAt.After(now) {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	return paginate(events, 0, limit), nil
+}
+
+// ordered by id
+func (repo *MemoryOutboxRepository) ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain_outbox.OutboxEvent, error) {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	events := make([]domain_outbox.OutboxEvent, 0)
+	for _, event := range repo.store.outboxEvents {
+		if !event.IsPublished && !event.IsFailed && !event.NextAttemptAt.After(now) && !event.ClaimedUntil.After(now) {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	events = paginate(events, 0, limit)
+
+	token := uuid.New().String()
+	for i := range events {
+		events[i].ClaimToken = token
+		events[i].ClaimedUntil = now.Add(lease)
+		repo.store.outboxEvents[events[i].ID] = events[i]
+	}
+
+	return events, nil
+}
+
+func (repo *MemoryOutboxRepository) UpdateDelivery(ctx context.Context, event domain_outbox.OutboxEvent) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.outboxEvents[event.ID]
+	if !ok {
+		return database_errors.ErrRecordNotFound
+	}
+
+	saved.Attempts = event.Attempts
+	saved.NextAttemptAt = event.NextAttemptAt
+	saved.LastError = event.LastError
+	saved.IsPublished = event.IsPublished
+	saved.PublishedAt = event.PublishedAt
+	saved.IsFailed = event.IsFailed
+	saved.ClaimToken = ""
+	saved.ClaimedUntil = time.Time{}
+	repo.store.outboxEvents[event.ID] = saved
+
+	return nil
+}
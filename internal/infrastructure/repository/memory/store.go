@@ -0,0 +1,92 @@
+package repository_memory
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
+
+var ErrDuplicatedKey = errors.New("duplicated key")
+
+// tables of in-memory repositories. repositories created with same store share data, like gorm repositories with same database.
+// all repositories of a store are safe for concurrent use
+type Store struct {
+	mu sync.RWMutex
+
+	lastID map[string]uint64
+
+	users         map[uint64]domain_user.User
+	devices       map[uint64]domain_device.Device
+	expenses      map[uint64]domain_expense.Expense
+	debts         map[uint64]domain_debt.Debt
+	comments      map[uint64]domain_expense_comment.ExpenseComment
+	notifications map[uint64]domain_notification.Notification
+	outboxEvents  map[uint64]domain_outbox.OutboxEvent
+	auditLogs     map[uint64]domain_audit.AuditLog
+}
+
+func NewStore() *Store {
+	return &Store{
+		lastID:        make(map[string]uint64),
+		users:         make(map[uint64]domain_user.User),
+		devices:       make(map[uint64]domain_device.Device),
+		expenses:      make(map[uint64]domain_expense.Expense),
+		debts:         make(map[uint64]domain_debt.Debt),
+		comments:      make(map[uint64]domain_expense_comment.ExpenseComment),
+		notifications: make(map[uint64]domain_notification.Notification),
+		outboxEvents:  make(map[uint64]domain_outbox.OutboxEvent),
+		auditLogs:     make(map[uint64]domain_audit.AuditLog),
+	}
+}
+
+// auto increment id of table. caller must hold write lock
+func (s *Store) nextID(table string) uint64 {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+// copy non-zero fields of src to dst, like gorm Updates with struct.
+// associations (struct fields except time.Time) are not copied
+func mergeNonZero[T any](dst *T, src T) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src)
+
+	for i := 0; i < srcValue.NumField(); i++ {
+		field := srcValue.Field(i)
+		if !dstValue.Field(i).CanSet() || field.IsZero() {
+			continue
+		}
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}) {
+			continue
+		}
+
+		dstValue.Field(i).Set(field)
+	}
+}
+
+// returns part of items. negative limit means no limit
+func paginate[T any](items []T, offset int, limit int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
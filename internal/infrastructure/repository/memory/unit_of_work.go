@@ -0,0 +1,75 @@
+package repository_memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+)
+
+type MemoryUnitOfWork struct {
+	store *Store
+
+	// units of work run one by one
+	mu sync.Mutex
+}
+
+func NewMemoryUnitOfWork(store *Store) app_shared.UnitOfWork {
+	return &MemoryUnitOfWork{store: store}
+}
+
+// store is restored to its state before fn if fn fails.
+// changes of other repositories made while fn is running are lost on rollback
+func (uow *MemoryUnitOfWork) Do(ctx context.Context, fn func(repos app_shared.TxRepositories) error) error {
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+
+	snapshot := uow.store.snapshot()
+
+	err := fn(app_shared.TxRepositories{
+		User:    NewMemoryUserRepository(uow.store),
+		Expense: NewMemoryExpenseRepository(uow.store),
+		Debt:    NewMemoryDebtRepository(uow.store),
+		Outbox:  NewMemoryOutboxRepository(uow.store),
+		Audit:   NewMemoryAuditRepository(uow.store),
+	})
+	if err != nil {
+		uow.store.restore(snapshot)
+	}
+
+	return err
+}
+
+// copy of all tables
+func (s *Store) snapshot() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Store{
+		lastID:        maps.Clone(s.lastID),
+		users:         maps.Clone(s.users),
+		devices:       maps.Clone(s.devices),
+		expenses:      maps.Clone(s.expenses),
+		debts:         maps.Clone(s.debts),
+		comments:      maps.Clone(s.comments),
+		notifications: maps.Clone(s.notifications),
+		outboxEvents:  maps.Clone(s.outboxEvents),
+		auditLogs:     maps.Clone(s.auditLogs),
+	}
+}
+
+func (s *Store) restore(snapshot *Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID = snapshot.lastID
+	s.users = snapshot.users
+	s.devices = snapshot.devices
+	s.expenses = snapshot.expenses
+	s.debts = snapshot.debts
+	s.comments = snapshot.comments
+	s.notifications = snapshot.notifications
+	s.outboxEvents = snapshot.outboxEvents
+	s.auditLogs = snapshot.auditLogs
+}
@@ -0,0 +1,255 @@
+package repository_memory
+
+import (
+	"context"
+
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+type MemoryUserRepository struct {
+	store *Store
+}
+
+func NewMemoryUserRepository(store *Store) domain_user.UserDomainRepository {
+	return &MemoryUserRepository{store: store}
+}
+
+func (repo *MemoryUserRepository) GetByID(ctx context.Context, id uint64) (domain_user.User, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	user, ok := repo.store.users[id]
+	if !ok {
+		return user, database_errors.ErrRecordNotFound
+	}
+
+	return user, nil
+}
+
+func (repo *MemoryUserRepository) GetByNumber(ctx context.Context, number string) (domain_user.User, error) {
+	repo.store.mu.RLock()
+	defer repo.store.mu.RUnlock()
+
+	user, ok := repo.store.userByNumber(number)
+	if !ok {
+		return user, database_errors.ErrRecordNotFound
+	}
+
+	return user, nil
+}
+
+// number of user is unique
+func (repo *MemoryUserRepository) Create(ctx context.Context, user *domain_user.User) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	return repo.store.createUser(user)
+}
+
+func (repo *MemoryUserRepository) Update(ctx context.Context, user domain_user.User) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	if user.Number != "" && user.Number != saved.Number {
+		if _, exists := repo.store.userByNumber(user.Number); exists {
+			return ErrDuplicatedKey
+		}
+	}
+
+	mergeNonZero(&saved, user)
+	repo.store.users[user.ID] = saved
+
+	return nil
+}
+
+func (repo *MemoryUserRepository) UpdateColumns(ctx context.Context, user domain_user.User) error {
+	return repo.Update(ctx, user)
+}
+
+// update two factor columns even if they are zero value (for disabling two factor)
+func (repo *MemoryUserRepository) UpdateTwoFactor(ctx context.Context, user domain_user.User) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	saved.TOTPSecret = user.TOTPSecret
+	saved.IsTOTPEnabled = user.IsTOTPEnabled
+	saved.TOTPRecoveryCodes = user.TOTPRecoveryCodes
+	saved.TOTPLastCounter = user.TOTPLastCounter
+	repo.store.users[user.ID] = saved
+
+	return nil
+}
+
+func (repo *MemoryUserRepository) UpdateTOTPCounter(ctx context.Context, userID uint64, counter int64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.users[userID]
+	if !ok || saved.TOTPLastCounter >= counter {
+		return database_errors.ErrVersionConflict
+	}
+
+	saved.TOTPLastCounter = counter
+	repo.store.users[userID] = saved
+
+	return nil
+}
+
+func (repo *MemoryUserRepository) UpdateTOTPRecoveryCodes(ctx context.Context, userID uint64, oldCodes string, codes string) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.users[userID]
+	if !ok || saved.TOTPRecoveryCodes != oldCodes {
+		return database_errors.ErrVersionConflict
+	}
+
+	saved.TOTPRecoveryCodes = codes
+	repo.store.users[userID] = saved
+
+	return nil
+}
+
+// update avatar columns even if they are zero value (for preset avatars)
+func (repo *MemoryUserRepository) UpdateAvatar(ctx context.Context, user domain_user.User) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	saved, ok := repo.store.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	saved.Avatar = user.Avatar
+	saved.AvatarThumbnail = user.AvatarThumbnail
+	saved.AvatarKey = user.AvatarKey
+	repo.store.users[user.ID] = saved
+
+	return nil
+}
+
+func (repo *MemoryUserRepository) ChangeNumber(ctx context.Context, user domain_user.User, mergeUserID uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	// checked before any change, so nothing is changed on error like a rolled back transaction
+	if other, exists := repo.store.userByNumber(user.Number); exists && other.ID != user.ID && other.ID != mergeUserID {
+		return ErrDuplicatedKey
+	}
+
+	if mergeUserID != 0 {
+		// expenses that are changed by merge. their version is incremented
+		changedExpenses := make(map[uint64]bool)
+
+		// move history of unregistered user (created in expenses) to user
+		for id, debt := range repo.store.debts {
+			if debt.CreditorID != mergeUserID && debt.DebtorID != mergeUserID {
+				continue
+			}
+			changedExpenses[debt.ExpenseID] = true
+
+			if debt.CreditorID == mergeUserID {
+				debt.CreditorID = user.ID
+			}
+			if debt.DebtorID == mergeUserID {
+				debt.DebtorID = user.ID
+			}
+			debt.Version++
+
+			// debts between user and merged user are meaningless now. total amount of expenses is sum of credits so it is not changed
+			if debt.CreditorID == user.ID && debt.DebtorID == user.ID {
+				for notifID, notif := range repo.store.notifications {
+					if notif.DebtID == id {
+						delete(repo.store.notifications, notifID)
+					}
+				}
+				delete(repo.store.debts, id)
+				continue
+			}
+			repo.store.debts[id] = debt
+		}
+		for id, expense := range repo.store.expenses {
+			if expense.CreatorID == mergeUserID {
+				expense.CreatorID = user.ID
+				changedExpenses[id] = true
+			}
+			if changedExpenses[id] {
+				expense.Version++
+				repo.store.expenses[id] = expense
+			}
+		}
+		for id, comment := range repo.store.comments {
+			if comment.UserID == mergeUserID {
+				comment.UserID = user.ID
+				repo.store.comments[id] = comment
+			}
+		}
+		for id, notif := range repo.store.notifications {
+			if notif.UserID == mergeUserID {
+				notif.UserID = user.ID
+				repo.store.notifications[id] = notif
+			}
+		}
+		for id, device := range repo.store.devices {
+			if device.UserID == mergeUserID {
+				device.UserID = user.ID
+				repo.store.devices[id] = device
+			}
+		}
+
+		delete(repo.store.users, mergeUserID)
+	}
+
+	saved, ok := repo.store.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	saved.Number = user.Number
+	repo.store.users[user.ID] = saved
+
+	return nil
+}
+
+func (repo *MemoryUserRepository) Delete(ctx context.Context, id uint64) error {
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+
+	delete(repo.store.users, id)
+
+	return nil
+}
+
+// caller must hold lock
+func (s *Store) userByNumber(number string) (domain_user.User, bool) {
+	for _, user := range s.users {
+		if user.Number == number {
+			return user, true
+		}
+	}
+
+	return domain_user.User{}, false
+}
+
+// caller must hold write lock
+func (s *Store) createUser(user *domain_user.User) error {
+	if _, exists := s.userByNumber(user.Number); exists {
+		return ErrDuplicatedKey
+	}
+
+	user.ID = s.nextID("users")
+	s.users[user.ID] = *user
+
+	return nil
+}
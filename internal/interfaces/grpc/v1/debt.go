@@ -0,0 +1,91 @@
+package v1
+
+import (
+	"context"
+
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type debtServer struct {
+	pb.UnimplementedDebtServiceServer
+	appService app_debt.DebtAppService
+}
+
+func (s *debtServer) GetDebt(ctx context.Context, req *pb.GetDebtRequest) (*pb.Debt, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.Get(ctx, req.GetId(), user.ID)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return debtOutput(responseDTO.Data)
+}
+
+func (s *debtServer) AcceptDebt(ctx context.Context, req *pb.AcceptDebtRequest) (*pb.Debt, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.Accept(ctx, req.GetId(), user.ID, req.GetVersion())
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return debtOutput(responseDTO.Data)
+}
+
+func (s *debtServer) DeleteDebt(ctx context.Context, req *pb.DeleteDebtRequest) (*pb.DeleteDebtResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.Delete(ctx, req.GetId(), user.ID, req.GetVersion())
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	response := &pb.DeleteDebtResponse{}
+	response.IsDeleted, _ = responseDTO.Data["is_deleted"].(bool)
+	if !response.IsDeleted {
+		response.Debt, err = debtOutput(responseDTO.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+func debtOutput(data map[string]any) (*pb.Debt, error) {
+	debt, ok := data["debt"].(shared_dto.DebtOutput)
+	if !ok {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &pb.Debt{
+		Id:                           debt.ID,
+		ExpenseId:                    debt.ExpenseID,
+		CreditorId:                   debt.CreditorID,
+		DebtorId:                     debt.DebtorID,
+		Amount:                       debt.Amount,
+		IsCreditorAccepted:           debt.IsCreditorAccepted,
+		IsDebtorAccepted:             debt.IsDebtorAccepted,
+		IsCreditorRejected:           debt.IsCreditorRejected,
+		IsDebtorRejected:             debt.IsDebtorRejected,
+		IsPaid:                       debt.IsPaid,
+		IsPaymentAccepted:            debt.IsPaymentAccepted,
+		IsCreditorRequestedForDelete: debt.IsCreditorRequestedForDelete,
+		IsDebtorRequestedForDelete:   debt.IsDebtorRequestedForDelete,
+		Version:                      debt.Version,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"context"
+
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+)
+
+type deviceServer struct {
+	pb.UnimplementedDeviceServiceServer
+	appService app_device.DeviceAppService
+}
+
+func (s *deviceServer) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.Logout(ctx, user.ID, deviceName(ctx, req.GetDeviceName()))
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return &pb.LogoutResponse{}, nil
+}
+
+func (s *deviceServer) LogoutAll(ctx context.Context, req *pb.LogoutAllRequest) (*pb.LogoutAllResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.LogoutAllUserDevices(ctx, user.ID)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return &pb.LogoutAllResponse{}, nil
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// domain of ErrorInfo details
+const ErrorDomain = "pedarkharj"
+
+// grpc code of http status of service errors. other statuses are InvalidArgument
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.AlreadyExists,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+	http.StatusInternalServerError:   codes.Internal,
+}
+
+// errors that grpc code of them is different from http status
+var errorCodes = map[error]codes.Code{
+	service_errors.ErrVersionConflict: codes.Aborted,
+
+	// wrong credentials are bad request in http
+	service_errors.ErrWrongPassword:     codes.Unauthenticated,
+	service_errors.ErrWrongTOTPCode:     codes.Unauthenticated,
+	service_errors.ErrWrongRecoveryCode: codes.Unauthenticated,
+
+	service_errors.ErrUserNotRegistered:                        codes.FailedPrecondition,
+	service_errors.ErrUserNotRegisteredResetPasswordNotAllowed: codes.FailedPrecondition,
+	service_errors.ErrVerifyNumberFirst:                        codes.FailedPrecondition,
+	service_errors.ErrOTPNotSend:                               codes.FailedPrecondition,
+	service_errors.ErrTwoFactorNotEnabled:                      codes.FailedPrecondition,
+	service_errors.ErrEnrollTwoFactorFirst:                     codes.FailedPrecondition,
+	service_errors.ErrDebtIsNotPaid:                            codes.FailedPrecondition,
+	service_errors.ErrExportInProgress:                         codes.FailedPrecondition,
+}
+
+func ErrorCode(err error) codes.Code {
+	var serviceErr *service_errors.Error
+	if !errors.As(err, &serviceErr) {
+		return codes.InvalidArgument
+	}
+
+	if code, ok := errorCodes[serviceErr]; ok {
+		return code
+	}
+	if code, ok := statusCodes[serviceErr.Status]; ok {
+		return code
+	}
+
+	return codes.InvalidArgument
+}
+
+// status error of ServerErr or UserErr. nil if response has no error.
+// response code and code of service error are sent in ErrorInfo details and field of service error in BadRequest details.
+// current version is sent in ErrorInfo metadata on version conflict
+func DTOError(ctx context.Context, responseDTO app_shared.ResponseDTO) error {
+	if responseDTO.ServerErr != nil {
+		slog.ErrorContext(ctx, "server error", "error", responseDTO.ServerErr)
+		return status.Error(codes.Internal, "server error")
+	}
+
+	if responseDTO.UserErr == nil {
+		return nil
+	}
+
+	st := status.New(ErrorCode(responseDTO.UserErr), responseDTO.UserErr.Error())
+
+	errorInfo := &errdetails.ErrorInfo{
+		Reason:   responseDTO.ResponseCode,
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{},
+	}
+	if version, ok := currentVersion(responseDTO.Data); ok {
+		errorInfo.Metadata["version"] = strconv.FormatUint(version, 10)
+	}
+
+	details := []protoadapt.MessageV1{errorInfo}
+
+	var serviceErr *service_errors.Error
+	if errors.As(responseDTO.UserErr, &serviceErr) {
+		errorInfo.Metadata["code"] = serviceErr.Code
+		if serviceErr.Field != "" {
+			details = append(details, &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: serviceErr.Field, Description: serviceErr.Message}},
+			})
+		}
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot add details to grpc status", "error", err)
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// version of expense or debt in response
+func currentVersion(data map[string]any) (uint64, bool) {
+	if expense, ok := data["expense"].(shared_dto.ExpenseOutput); ok {
+		return expense.Version, true
+	}
+	if debt, ok := data["debt"].(shared_dto.DebtOutput); ok {
+		return debt.Version, true
+	}
+
+	return 0, false
+}
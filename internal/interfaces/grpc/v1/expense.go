@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"context"
+
+	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type expenseServer struct {
+	pb.UnimplementedExpenseServiceServer
+	appService app_expense.ExpenseAppService
+}
+
+func (s *expenseServer) CreateExpense(ctx context.Context, req *pb.CreateExpenseRequest) (*pb.CreateExpenseResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := app_expense.ExpenseInputWithPhoneNumber{ExpenseInputWithPhoneNumber: shared_dto.ExpenseInputWithPhoneNumber{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Creditors:   req.GetCreditors(),
+		Debtors:     req.GetDebtors(),
+	}}
+
+	responseDTO := s.appService.Create(ctx, input, user.ID, user.PhoneNumber)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateExpenseResponse{}, nil
+}
+
+func (s *expenseServer) GetExpense(ctx context.Context, req *pb.GetExpenseRequest) (*pb.Expense, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.Get(ctx, req.GetId(), user.ID)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return expenseOutput(responseDTO.Data)
+}
+
+func (s *expenseServer) ListExpenses(ctx context.Context, req *pb.ListExpensesRequest) (*pb.ListExpensesResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.GetLimited(ctx, user.ID, uint(req.GetPage()), uint(req.GetLimit()))
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	expenses, _ := responseDTO.Data["data"].([]domain_expense.ExpenseDebtOuput)
+
+	response := &pb.ListExpensesResponse{Expenses: make([]*pb.ExpenseDebt, 0, len(expenses))}
+	for _, expense := range expenses {
+		response.Expenses = append(response.Expenses, newExpenseDebt(expense.ExpenseDebtOuput))
+	}
+
+	return response, nil
+}
+
+func (s *expenseServer) UpdateExpense(ctx context.Context, req *pb.UpdateExpenseRequest) (*pb.Expense, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := app_expense.ExpenseUpdateInput{ExpenseUpdateInput: shared_dto.ExpenseUpdateInput{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	}}
+
+	responseDTO := s.appService.Update(ctx, req.GetId(), user.ID, req.GetVersion(), input)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return expenseOutput(responseDTO.Data)
+}
+
+func (s *expenseServer) DeleteExpense(ctx context.Context, req *pb.DeleteExpenseRequest) (*pb.DeleteExpenseResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.Delete(ctx, req.GetId(), user.ID, req.GetVersion())
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteExpenseResponse{}, nil
+}
+
+func expenseOutput(data map[string]any) (*pb.Expense, error) {
+	expense, ok := data["expense"].(shared_dto.ExpenseOutput)
+	if !ok {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &pb.Expense{
+		Id:          expense.ID,
+		CreatorId:   expense.CreatorID,
+		Name:        expense.Name,
+		Description: expense.Description,
+		TotalAmount: expense.TotalAmount,
+		CreatedAt:   timestamppb.New(expense.CreatedAt),
+		UpdatedAt:   timestamppb.New(expense.UpdatedAt),
+		Version:     expense.Version,
+	}, nil
+}
+
+func newExpenseDebt(expense shared_dto.ExpenseDebtOuput) *pb.ExpenseDebt {
+	return &pb.ExpenseDebt{
+		Id:                           expense.ID,
+		Name:                         expense.Name,
+		Description:                  expense.Description,
+		CreatedAt:                    timestamppb.New(expense.CreatedAt),
+		UpdatedAt:                    timestamppb.New(expense.UpdatedAt),
+		CreditorId:                   expense.CreditorID,
+		DebtorId:                     expense.DebtorID,
+		Amount:                       expense.Amount,
+		Type:                         expense.Type,
+		IsCreditorAccepted:           expense.IsCreditorAccepted,
+		IsDebtorAccepted:             expense.IsDebtorAccepted,
+		IsCreditorRejected:           expense.IsCreditorRejected,
+		IsDebtorRejected:             expense.IsDebtorRejected,
+		IsPaid:                       expense.IsPaid,
+		IsPaymentAccepted:            expense.IsPaymentAccpeted,
+		IsCreditorRequestedForDelete: expense.IsCreditorRequestedForDelete,
+		IsDebtorRequestedForDelete:   expense.IsDebtorRequestedForDelete,
+		UserAvatar:                   expense.UserAvatar,
+		UserName:                     expense.UserName,
+	}
+}
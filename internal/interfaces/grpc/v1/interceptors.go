@@ -0,0 +1,229 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otel_codes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RequestIDMetadata     = "x-request-id"
+	AuthorizationMetadata = "authorization"
+)
+
+// request id of client is accepted only if it is safe for logs
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// methods that do not need authentication
+var publicMethods = map[string]bool{
+	pb.UserService_Login_FullMethodName:           true,
+	pb.UserService_VerifyTwoFactor_FullMethodName: true,
+	pb.UserService_Refresh_FullMethodName:         true,
+}
+
+// adapts incoming metadata to otel propagation carrier
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) Get(key string) string {
+	values := metadata.MD(m).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (m metadataCarrier) Set(key string, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (m metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// assign request id, start server span, add request info of audit logs and log and record metrics of every call.
+// request id is taken from x-request-id metadata if it is valid and is returned in header metadata
+func requestInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := metadataCarrier(md).Get(RequestIDMetadata)
+	if !requestIDRegex.MatchString(requestID) {
+		requestID = uuid.New().String()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+	ctx = logger.WithRequestID(ctx, requestID)
+
+	userAgent := metadataCarrier(md).Get("user-agent")
+	ip := peerIP(ctx)
+	ctx = app_audit.WithRequestInfo(ctx, app_audit.RequestInfo{IP: ip, UserAgent: userAgent})
+
+	// trace of client is continued if traceparent metadata is sent
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, span := tracing.Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+			attribute.String("user_agent.original", userAgent),
+		),
+	)
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if code == codes.Internal || code == codes.Unknown {
+		span.SetStatus(otel_codes.Error, code.String())
+	}
+
+	metrics.GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	metrics.GRPCRequests.WithLabelValues(info.FullMethod, code.String()).Inc()
+
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+
+	slog.Log(ctx, level, "grpc request",
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("ip", ip),
+	)
+
+	return resp, err
+}
+
+// panic of handler is returned as internal error
+func recoveryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "grpc handler panic", "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "server error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// check access token of authorization metadata and add user to context. format of metadata is "Bearer <access>".
+// access tokens that are revoked by blocking or logging out user are rejected
+func newAuthInterceptor(cacheRepo domain_shared.CacheRepository) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return authenticate(ctx, req, info, handler, cacheRepo)
+	}
+}
+
+func authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler, cacheRepo domain_shared.CacheRepository) (any, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	access := metadataCarrier(md).Get(AuthorizationMetadata)
+	if access == "" {
+		return nil, status.Error(codes.Unauthenticated, "authentication is required")
+	}
+
+	if strings.Index(access, "Bearer ") != 0 || len(access) < 8 {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+
+	access = access[7:]
+
+	var user app_user.JWTUser
+	var err error
+	user.ID, user.Name, user.PhoneNumber, user.IsRegistered, err = jwt.GetUserFromAccess(access)
+	if err != nil {
+		slog.InfoContext(ctx, "invalid access token", "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	if user.ID == 0 {
+		slog.ErrorContext(ctx, "userID is 0 in JWT")
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	issuedAt, err := jwt.GetIssuedAtFromAccess(access)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot get issue time of access token", "error", err)
+		return nil, status.Error(codes.Internal, "server error")
+	}
+	revoked, err := app_user.IsAccessRevoked(cacheRepo, user.ID, issuedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot check revoked access token", "error", err)
+		return nil, status.Error(codes.Internal, "server error")
+	}
+	if revoked {
+		slog.InfoContext(ctx, "revoked access token", "user_id", user.ID)
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	logger.SetUserID(ctx, user.ID)
+	ctx = context.WithValue(ctx, "user", user)
+
+	return handler(ctx, req)
+}
+
+// user of context that is added by auth interceptor
+func userFromContext(ctx context.Context) (app_user.JWTUser, error) {
+	user, ok := ctx.Value("user").(app_user.JWTUser)
+	if !ok {
+		slog.ErrorContext(ctx, "cannot cast context user")
+		return user, status.Error(codes.Internal, "server error")
+	}
+
+	return user, nil
+}
+
+// ip of client without port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// name of device is user agent if it is not set. same as rest api
+func deviceName(ctx context.Context, name string) string {
+	if name != "" {
+		return name
+	}
+
+	return app_audit.RequestInfoFromContext(ctx).UserAgent
+}
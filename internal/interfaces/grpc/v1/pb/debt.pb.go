@@ -0,0 +1,588 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.1
+// source: pedarkharj/v1/debt.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Debt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                           uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpenseId                    uint64 `protobuf:"varint,2,opt,name=expense_id,json=expenseId,proto3" json:"expense_id,omitempty"`
+	CreditorId                   uint64 `protobuf:"varint,3,opt,name=creditor_id,json=creditorId,proto3" json:"creditor_id,omitempty"`
+	DebtorId                     uint64 `protobuf:"varint,4,opt,name=debtor_id,json=debtorId,proto3" json:"debtor_id,omitempty"`
+	Amount                       uint64 `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	IsCreditorAccepted           bool   `protobuf:"varint,6,opt,name=is_creditor_accepted,json=isCreditorAccepted,proto3" json:"is_creditor_accepted,omitempty"`
+	IsDebtorAccepted             bool   `protobuf:"varint,7,opt,name=is_debtor_accepted,json=isDebtorAccepted,proto3" json:"is_debtor_accepted,omitempty"`
+	IsCreditorRejected           bool   `protobuf:"varint,8,opt,name=is_creditor_rejected,json=isCreditorRejected,proto3" json:"is_creditor_rejected,omitempty"`
+	IsDebtorRejected             bool   `protobuf:"varint,9,opt,name=is_debtor_rejected,json=isDebtorRejected,proto3" json:"is_debtor_rejected,omitempty"`
+	IsPaid                       bool   `protobuf:"varint,10,opt,name=is_paid,json=isPaid,proto3" json:"is_paid,omitempty"`
+	IsPaymentAccepted            bool   `protobuf:"varint,11,opt,name=is_payment_accepted,json=isPaymentAccepted,proto3" json:"is_payment_accepted,omitempty"`
+	IsCreditorRequestedForDelete bool   `protobuf:"varint,12,opt,name=is_creditor_requested_for_delete,json=isCreditorRequestedForDelete,proto3" json:"is_creditor_requested_for_delete,omitempty"`
+	IsDebtorRequestedForDelete   bool   `protobuf:"varint,13,opt,name=is_debtor_requested_for_delete,json=isDebtorRequestedForDelete,proto3" json:"is_debtor_requested_for_delete,omitempty"`
+	Version                      uint64 `protobuf:"varint,14,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *Debt) Reset() {
+	*x = Debt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_debt_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Debt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Debt) ProtoMessage() {}
+
+func (x *Debt) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_debt_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Debt.ProtoReflect.Descriptor instead.
+func (*Debt) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_debt_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Debt) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Debt) GetExpenseId() uint64 {
+	if x != nil {
+		return x.ExpenseId
+	}
+	return 0
+}
+
+func (x *Debt) GetCreditorId() uint64 {
+	if x != nil {
+		return x.CreditorId
+	}
+	return 0
+}
+
+func (x *Debt) GetDebtorId() uint64 {
+	if x != nil {
+		return x.DebtorId
+	}
+	return 0
+}
+
+func (x *Debt) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Debt) GetIsCreditorAccepted() bool {
+	if x != nil {
+		return x.IsCreditorAccepted
+	}
+	return false
+}
+
+func (x *Debt) GetIsDebtorAccepted() bool {
+	if x != nil {
+		return x.IsDebtorAccepted
+	}
+	return false
+}
+
+func (x *Debt) GetIsCreditorRejected() bool {
+	if x != nil {
+		return x.IsCreditorRejected
+	}
+	return false
+}
+
+func (x *Debt) GetIsDebtorRejected() bool {
+	if x != nil {
+		return x.IsDebtorRejected
+	}
+	return false
+}
+
+func (x *Debt) GetIsPaid() bool {
+	if x != nil {
+		return x.IsPaid
+	}
+	return false
+}
+
+func (x *Debt) GetIsPaymentAccepted() bool {
+	if x != nil {
+		return x.IsPaymentAccepted
+	}
+	return false
+}
+
+func (x *Debt) GetIsCreditorRequestedForDelete() bool {
+	if x != nil {
+		return x.IsCreditorRequestedForDelete
+	}
+	return false
+}
+
+func (x *Debt) GetIsDebtorRequestedForDelete() bool {
+	if x != nil {
+		return x.IsDebtorRequestedForDelete
+	}
+	return false
+}
+
+func (x *Debt) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetDebtRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetDebtRequest) Reset() {
+	*x = GetDebtRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_debt_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDebtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDebtRequest) ProtoMessage() {}
+
+func (x *GetDebtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_debt_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDebtRequest.ProtoReflect.Descriptor instead.
+func (*GetDebtRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_debt_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetDebtRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type AcceptDebtRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// expected version of debt. zero skips the check
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *AcceptDebtRequest) Reset() {
+	*x = AcceptDebtRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_debt_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AcceptDebtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcceptDebtRequest) ProtoMessage() {}
+
+func (x *AcceptDebtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_debt_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcceptDebtRequest.ProtoReflect.Descriptor instead.
+func (*AcceptDebtRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_debt_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AcceptDebtRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AcceptDebtRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteDebtRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// expected version of debt. zero skips the check
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *DeleteDebtRequest) Reset() {
+	*x = DeleteDebtRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_debt_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteDebtRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDebtRequest) ProtoMessage() {}
+
+func (x *DeleteDebtRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_debt_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDebtRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDebtRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_debt_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DeleteDebtRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteDebtRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteDebtResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IsDeleted bool `protobuf:"varint,1,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	// set if debt is not deleted
+	Debt *Debt `protobuf:"bytes,2,opt,name=debt,proto3" json:"debt,omitempty"`
+}
+
+func (x *DeleteDebtResponse) Reset() {
+	*x = DeleteDebtResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_debt_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteDebtResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDebtResponse) ProtoMessage() {}
+
+func (x *DeleteDebtResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_debt_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDebtResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDebtResponse) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_debt_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteDebtResponse) GetIsDeleted() bool {
+	if x != nil {
+		return x.IsDeleted
+	}
+	return false
+}
+
+func (x *DeleteDebtResponse) GetDebt() *Debt {
+	if x != nil {
+		return x.Debt
+	}
+	return nil
+}
+
+var File_pedarkharj_v1_debt_proto protoreflect.FileDescriptor
+
+var file_pedarkharj_v1_debt_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2f, 0x76, 0x31, 0x2f,
+	0x64, 0x65, 0x62, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x70, 0x65, 0x64, 0x61,
+	0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x22, 0xba, 0x04, 0x0a, 0x04, 0x44, 0x65,
+	0x62, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x65, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x49,
+	0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72,
+	0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x30, 0x0a, 0x14, 0x69, 0x73, 0x5f, 0x63, 0x72,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x69, 0x73, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f,
+	0x72, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x2c, 0x0a, 0x12, 0x69, 0x73, 0x5f,
+	0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x73, 0x44, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x41,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x14, 0x69, 0x73, 0x5f, 0x63, 0x72,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x69, 0x73, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f,
+	0x72, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x2c, 0x0a, 0x12, 0x69, 0x73, 0x5f,
+	0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x73, 0x44, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x52,
+	0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x73, 0x5f, 0x70, 0x61,
+	0x69, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x69, 0x73, 0x50, 0x61, 0x69, 0x64,
+	0x12, 0x2e, 0x0a, 0x13, 0x69, 0x73, 0x5f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x61,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69,
+	0x73, 0x50, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64,
+	0x12, 0x46, 0x0a, 0x20, 0x69, 0x73, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x1c, 0x69, 0x73, 0x43, 0x72,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x46,
+	0x6f, 0x72, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x42, 0x0a, 0x1e, 0x69, 0x73, 0x5f, 0x64,
+	0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x5f,
+	0x66, 0x6f, 0x72, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x1a, 0x69, 0x73, 0x44, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x65, 0x64, 0x46, 0x6f, 0x72, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x20, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x62,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x22, 0x3d, 0x0a, 0x11, 0x41, 0x63, 0x63, 0x65,
+	0x70, 0x74, 0x44, 0x65, 0x62, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a,
+	0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x3d, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x44, 0x65, 0x62, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x5c, 0x0a, 0x12, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x44, 0x65, 0x62, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x69, 0x73, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x09, 0x69, 0x73, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x27, 0x0a, 0x04, 0x64,
+	0x65, 0x62, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x65, 0x64, 0x61,
+	0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x62, 0x74, 0x52, 0x04,
+	0x64, 0x65, 0x62, 0x74, 0x32, 0xe4, 0x01, 0x0a, 0x0b, 0x44, 0x65, 0x62, 0x74, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x3d, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x44, 0x65, 0x62, 0x74, 0x12,
+	0x1d, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x44, 0x65, 0x62, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13,
+	0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x44,
+	0x65, 0x62, 0x74, 0x12, 0x43, 0x0a, 0x0a, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x44, 0x65, 0x62,
+	0x74, 0x12, 0x20, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76,
+	0x31, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x44, 0x65, 0x62, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a,
+	0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x62, 0x74, 0x12, 0x51, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x44, 0x65, 0x62, 0x74, 0x12, 0x20, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68,
+	0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44, 0x65, 0x62,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72,
+	0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x44,
+	0x65, 0x62, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x42, 0x5a, 0x40, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x79, 0x61, 0x67, 0x68, 0x6f, 0x75,
+	0x62, 0x69, 0x2d, 0x6d, 0x6e, 0x2f, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a,
+	0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x73, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pedarkharj_v1_debt_proto_rawDescOnce sync.Once
+	file_pedarkharj_v1_debt_proto_rawDescData = file_pedarkharj_v1_debt_proto_rawDesc
+)
+
+func file_pedarkharj_v1_debt_proto_rawDescGZIP() []byte {
+	file_pedarkharj_v1_debt_proto_rawDescOnce.Do(func() {
+		file_pedarkharj_v1_debt_proto_rawDescData = protoimpl.X.CompressGZIP(file_pedarkharj_v1_debt_proto_rawDescData)
+	})
+	return file_pedarkharj_v1_debt_proto_rawDescData
+}
+
+var file_pedarkharj_v1_debt_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_pedarkharj_v1_debt_proto_goTypes = []any{
+	(*Debt)(nil),               // 0: pedarkharj.v1.Debt
+	(*GetDebtRequest)(nil),     // 1: pedarkharj.v1.GetDebtRequest
+	(*AcceptDebtRequest)(nil),  // 2: pedarkharj.v1.AcceptDebtRequest
+	(*DeleteDebtRequest)(nil),  // 3: pedarkharj.v1.DeleteDebtRequest
+	(*DeleteDebtResponse)(nil), // 4: pedarkharj.v1.DeleteDebtResponse
+}
+var file_pedarkharj_v1_debt_proto_depIdxs = []int32{
+	0, // 0: pedarkharj.v1.DeleteDebtResponse.debt:type_name -> pedarkharj.v1.Debt
+	1, // 1: pedarkharj.v1.DebtService.GetDebt:input_type -> pedarkharj.v1.GetDebtRequest
+	2, // 2: pedarkharj.v1.DebtService.AcceptDebt:input_type -> pedarkharj.v1.AcceptDebtRequest
+	3, // 3: pedarkharj.v1.DebtService.DeleteDebt:input_type -> pedarkharj.v1.DeleteDebtRequest
+	0, // 4: pedarkharj.v1.DebtService.GetDebt:output_type -> pedarkharj.v1.Debt
+	0, // 5: pedarkharj.v1.DebtService.AcceptDebt:output_type -> pedarkharj.v1.Debt
+	4, // 6: pedarkharj.v1.DebtService.DeleteDebt:output_type -> pedarkharj.v1.DeleteDebtResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pedarkharj_v1_debt_proto_init() }
+func file_pedarkharj_v1_debt_proto_init() {
+	if File_pedarkharj_v1_debt_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pedarkharj_v1_debt_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Debt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_debt_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetDebtRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_debt_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*AcceptDebtRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_debt_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteDebtRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_debt_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteDebtResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pedarkharj_v1_debt_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pedarkharj_v1_debt_proto_goTypes,
+		DependencyIndexes: file_pedarkharj_v1_debt_proto_depIdxs,
+		MessageInfos:      file_pedarkharj_v1_debt_proto_msgTypes,
+	}.Build()
+	File_pedarkharj_v1_debt_proto = out.File
+	file_pedarkharj_v1_debt_proto_rawDesc = nil
+	file_pedarkharj_v1_debt_proto_goTypes = nil
+	file_pedarkharj_v1_debt_proto_depIdxs = nil
+}
@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.1
+// source: pedarkharj/v1/debt.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DebtService_GetDebt_FullMethodName    = "/pedarkharj.v1.DebtService/GetDebt"
+	DebtService_AcceptDebt_FullMethodName = "/pedarkharj.v1.DebtService/AcceptDebt"
+	DebtService_DeleteDebt_FullMethodName = "/pedarkharj.v1.DebtService/DeleteDebt"
+)
+
+// DebtServiceClient is the client API for DebtService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// debts of authenticated user. user must be creditor or debtor of debt
+type DebtServiceClient interface {
+	GetDebt(ctx context.Context, in *GetDebtRequest, opts ...grpc.CallOption) (*Debt, error)
+	// debt is accepted for other side if other side is not registered
+	AcceptDebt(ctx context.Context, in *AcceptDebtRequest, opts ...grpc.CallOption) (*Debt, error)
+	// debt is deleted when both creditor and debtor request it
+	DeleteDebt(ctx context.Context, in *DeleteDebtRequest, opts ...grpc.CallOption) (*DeleteDebtResponse, error)
+}
+
+type debtServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDebtServiceClient(cc grpc.ClientConnInterface) DebtServiceClient {
+	return &debtServiceClient{cc}
+}
+
+func (c *debtServiceClient) GetDebt(ctx context.Context, in *GetDebtRequest, opts ...grpc.CallOption) (*Debt, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Debt)
+	err := c.cc.Invoke(ctx, DebtService_GetDebt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debtServiceClient) AcceptDebt(ctx context.Context, in *AcceptDebtRequest, opts ...grpc.CallOption) (*Debt, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Debt)
+	err := c.cc.Invoke(ctx, DebtService_AcceptDebt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *debtServiceClient) DeleteDebt(ctx context.Context, in *DeleteDebtRequest, opts ...grpc.CallOption) (*DeleteDebtResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteDebtResponse)
+	err := c.cc.Invoke(ctx, DebtService_DeleteDebt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DebtServiceServer is the server API for DebtService service.
+// All implementations must embed UnimplementedDebtServiceServer
+// for forward compatibility.
+//
+// debts of authenticated user. user must be creditor or debtor of debt
+type DebtServiceServer interface {
+	GetDebt(context.Context, *GetDebtRequest) (*Debt, error)
+	// debt is accepted for other side if other side is not registered
+	AcceptDebt(context.Context, *AcceptDebtRequest) (*Debt, error)
+	// debt is deleted when both creditor and debtor request it
+	DeleteDebt(context.Context, *DeleteDebtRequest) (*DeleteDebtResponse, error)
+	mustEmbedUnimplementedDebtServiceServer()
+}
+
+// UnimplementedDebtServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDebtServiceServer struct{}
+
+func (UnimplementedDebtServiceServer) GetDebt(context.Context, *GetDebtRequest) (*Debt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDebt not implemented")
+}
+func (UnimplementedDebtServiceServer) AcceptDebt(context.Context, *AcceptDebtRequest) (*Debt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptDebt not implemented")
+}
+func (UnimplementedDebtServiceServer) DeleteDebt(context.Context, *DeleteDebtRequest) (*DeleteDebtResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDebt not implemented")
+}
+func (UnimplementedDebtServiceServer) mustEmbedUnimplementedDebtServiceServer() {}
+func (UnimplementedDebtServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeDebtServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DebtServiceServer will
+// result in compilation errors.
+type UnsafeDebtServiceServer interface {
+	mustEmbedUnimplementedDebtServiceServer()
+}
+
+func RegisterDebtServiceServer(s grpc.ServiceRegistrar, srv DebtServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDebtServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DebtService_ServiceDesc, srv)
+}
+
+func _DebtService_GetDebt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDebtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebtServiceServer).GetDebt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DebtService_GetDebt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebtServiceServer).GetDebt(ctx, req.(*GetDebtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DebtService_AcceptDebt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptDebtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebtServiceServer).AcceptDebt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DebtService_AcceptDebt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebtServiceServer).AcceptDebt(ctx, req.(*AcceptDebtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DebtService_DeleteDebt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDebtRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DebtServiceServer).DeleteDebt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DebtService_DeleteDebt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DebtServiceServer).DeleteDebt(ctx, req.(*DeleteDebtRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DebtService_ServiceDesc is the grpc.ServiceDesc for DebtService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DebtService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pedarkharj.v1.DebtService",
+	HandlerType: (*DebtServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDebt",
+			Handler:    _DebtService_GetDebt_Handler,
+		},
+		{
+			MethodName: "AcceptDebt",
+			Handler:    _DebtService_AcceptDebt_Handler,
+		},
+		{
+			MethodName: "DeleteDebt",
+			Handler:    _DebtService_DeleteDebt_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pedarkharj/v1/debt.proto",
+}
@@ -0,0 +1,319 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.1
+// source: pedarkharj/v1/device.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LogoutRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// name of device that is used in login. user-agent metadata is used if it is empty
+	DeviceName string `protobuf:"bytes,1,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+}
+
+func (x *LogoutRequest) Reset() {
+	*x = LogoutRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_device_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogoutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutRequest) ProtoMessage() {}
+
+func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_device_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutRequest.ProtoReflect.Descriptor instead.
+func (*LogoutRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_device_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LogoutRequest) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+type LogoutResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *LogoutResponse) Reset() {
+	*x = LogoutResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_device_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogoutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutResponse) ProtoMessage() {}
+
+func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_device_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutResponse.ProtoReflect.Descriptor instead.
+func (*LogoutResponse) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_device_proto_rawDescGZIP(), []int{1}
+}
+
+type LogoutAllRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *LogoutAllRequest) Reset() {
+	*x = LogoutAllRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_device_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogoutAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutAllRequest) ProtoMessage() {}
+
+func (x *LogoutAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_device_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutAllRequest.ProtoReflect.Descriptor instead.
+func (*LogoutAllRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_device_proto_rawDescGZIP(), []int{2}
+}
+
+type LogoutAllResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *LogoutAllResponse) Reset() {
+	*x = LogoutAllResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_device_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogoutAllResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogoutAllResponse) ProtoMessage() {}
+
+func (x *LogoutAllResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_device_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogoutAllResponse.ProtoReflect.Descriptor instead.
+func (*LogoutAllResponse) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_device_proto_rawDescGZIP(), []int{3}
+}
+
+var File_pedarkharj_v1_device_proto protoreflect.FileDescriptor
+
+var file_pedarkharj_v1_device_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2f, 0x76, 0x31, 0x2f,
+	0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x70, 0x65,
+	0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x22, 0x30, 0x0a, 0x0d, 0x4c,
+	0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b,
+	0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x10, 0x0a,
+	0x0e, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x12, 0x0a, 0x10, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x13, 0x0a, 0x11, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x41, 0x6c, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xa6, 0x01, 0x0a, 0x0d, 0x44, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x45, 0x0a, 0x06, 0x4c, 0x6f,
+	0x67, 0x6f, 0x75, 0x74, 0x12, 0x1c, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72,
+	0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e,
+	0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x4e, 0x0a, 0x09, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x41, 0x6c, 0x6c, 0x12, 0x1f,
+	0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x4c,
+	0x6f, 0x67, 0x6f, 0x75, 0x74, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x20, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e,
+	0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x42, 0x42, 0x5a, 0x40, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x79, 0x61, 0x67, 0x68, 0x6f, 0x75, 0x62, 0x69, 0x2d, 0x6d, 0x6e, 0x2f, 0x70, 0x65, 0x64, 0x61,
+	0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f,
+	0x76, 0x31, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pedarkharj_v1_device_proto_rawDescOnce sync.Once
+	file_pedarkharj_v1_device_proto_rawDescData = file_pedarkharj_v1_device_proto_rawDesc
+)
+
+func file_pedarkharj_v1_device_proto_rawDescGZIP() []byte {
+	file_pedarkharj_v1_device_proto_rawDescOnce.Do(func() {
+		file_pedarkharj_v1_device_proto_rawDescData = protoimpl.X.CompressGZIP(file_pedarkharj_v1_device_proto_rawDescData)
+	})
+	return file_pedarkharj_v1_device_proto_rawDescData
+}
+
+var file_pedarkharj_v1_device_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pedarkharj_v1_device_proto_goTypes = []any{
+	(*LogoutRequest)(nil),     // 0: pedarkharj.v1.LogoutRequest
+	(*LogoutResponse)(nil),    // 1: pedarkharj.v1.LogoutResponse
+	(*LogoutAllRequest)(nil),  // 2: pedarkharj.v1.LogoutAllRequest
+	(*LogoutAllResponse)(nil), // 3: pedarkharj.v1.LogoutAllResponse
+}
+var file_pedarkharj_v1_device_proto_depIdxs = []int32{
+	0, // 0: pedarkharj.v1.DeviceService.Logout:input_type -> pedarkharj.v1.LogoutRequest
+	2, // 1: pedarkharj.v1.DeviceService.LogoutAll:input_type -> pedarkharj.v1.LogoutAllRequest
+	1, // 2: pedarkharj.v1.DeviceService.Logout:output_type -> pedarkharj.v1.LogoutResponse
+	3, // 3: pedarkharj.v1.DeviceService.LogoutAll:output_type -> pedarkharj.v1.LogoutAllResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pedarkharj_v1_device_proto_init() }
+func file_pedarkharj_v1_device_proto_init() {
+	if File_pedarkharj_v1_device_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pedarkharj_v1_device_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*LogoutRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_device_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*LogoutResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_device_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*LogoutAllRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_device_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*LogoutAllResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pedarkharj_v1_device_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pedarkharj_v1_device_proto_goTypes,
+		DependencyIndexes: file_pedarkharj_v1_device_proto_depIdxs,
+		MessageInfos:      file_pedarkharj_v1_device_proto_msgTypes,
+	}.Build()
+	File_pedarkharj_v1_device_proto = out.File
+	file_pedarkharj_v1_device_proto_rawDesc = nil
+	file_pedarkharj_v1_device_proto_goTypes = nil
+	file_pedarkharj_v1_device_proto_depIdxs = nil
+}
@@ -0,0 +1,1092 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.1
+// source: pedarkharj/v1/expense.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Expense struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatorId   uint64                 `protobuf:"varint,2,opt,name=creator_id,json=creatorId,proto3" json:"creator_id,omitempty"`
+	Name        string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	TotalAmount uint64                 `protobuf:"varint,5,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt   *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Version     uint64                 `protobuf:"varint,8,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *Expense) Reset() {
+	*x = Expense{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Expense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Expense) ProtoMessage() {}
+
+func (x *Expense) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Expense.ProtoReflect.Descriptor instead.
+func (*Expense) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Expense) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Expense) GetCreatorId() uint64 {
+	if x != nil {
+		return x.CreatorId
+	}
+	return 0
+}
+
+func (x *Expense) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Expense) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Expense) GetTotalAmount() uint64 {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return 0
+}
+
+func (x *Expense) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Expense) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Expense) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// expense with debt of user in it
+type ExpenseDebt struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                           uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                         string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description                  string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	CreatedAt                    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt                    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CreditorId                   uint64                 `protobuf:"varint,6,opt,name=creditor_id,json=creditorId,proto3" json:"creditor_id,omitempty"`
+	DebtorId                     uint64                 `protobuf:"varint,7,opt,name=debtor_id,json=debtorId,proto3" json:"debtor_id,omitempty"`
+	Amount                       uint64                 `protobuf:"varint,8,opt,name=amount,proto3" json:"amount,omitempty"`
+	Type                         string                 `protobuf:"bytes,9,opt,name=type,proto3" json:"type,omitempty"`
+	IsCreditorAccepted           bool                   `protobuf:"varint,10,opt,name=is_creditor_accepted,json=isCreditorAccepted,proto3" json:"is_creditor_accepted,omitempty"`
+	IsDebtorAccepted             bool                   `protobuf:"varint,11,opt,name=is_debtor_accepted,json=isDebtorAccepted,proto3" json:"is_debtor_accepted,omitempty"`
+	IsCreditorRejected           bool                   `protobuf:"varint,12,opt,name=is_creditor_rejected,json=isCreditorRejected,proto3" json:"is_creditor_rejected,omitempty"`
+	IsDebtorRejected             bool                   `protobuf:"varint,13,opt,name=is_debtor_rejected,json=isDebtorRejected,proto3" json:"is_debtor_rejected,omitempty"`
+	IsPaid                       bool                   `protobuf:"varint,14,opt,name=is_paid,json=isPaid,proto3" json:"is_paid,omitempty"`
+	IsPaymentAccepted            bool                   `protobuf:"varint,15,opt,name=is_payment_accepted,json=isPaymentAccepted,proto3" json:"is_payment_accepted,omitempty"`
+	IsCreditorRequestedForDelete bool                   `protobuf:"varint,16,opt,name=is_creditor_requested_for_delete,json=isCreditorRequestedForDelete,proto3" json:"is_creditor_requested_for_delete,omitempty"`
+	IsDebtorRequestedForDelete   bool                   `protobuf:"varint,17,opt,name=is_debtor_requested_for_delete,json=isDebtorRequestedForDelete,proto3" json:"is_debtor_requested_for_delete,omitempty"`
+	UserAvatar                   string                 `protobuf:"bytes,18,opt,name=user_avatar,json=userAvatar,proto3" json:"user_avatar,omitempty"`
+	UserName                     string                 `protobuf:"bytes,19,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`
+}
+
+func (x *ExpenseDebt) Reset() {
+	*x = ExpenseDebt{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExpenseDebt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpenseDebt) ProtoMessage() {}
+
+func (x *ExpenseDebt) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpenseDebt.ProtoReflect.Descriptor instead.
+func (*ExpenseDebt) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExpenseDebt) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ExpenseDebt) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ExpenseDebt) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ExpenseDebt) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ExpenseDebt) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *ExpenseDebt) GetCreditorId() uint64 {
+	if x != nil {
+		return x.CreditorId
+	}
+	return 0
+}
+
+func (x *ExpenseDebt) GetDebtorId() uint64 {
+	if x != nil {
+		return x.DebtorId
+	}
+	return 0
+}
+
+func (x *ExpenseDebt) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ExpenseDebt) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ExpenseDebt) GetIsCreditorAccepted() bool {
+	if x != nil {
+		return x.IsCreditorAccepted
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsDebtorAccepted() bool {
+	if x != nil {
+		return x.IsDebtorAccepted
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsCreditorRejected() bool {
+	if x != nil {
+		return x.IsCreditorRejected
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsDebtorRejected() bool {
+	if x != nil {
+		return x.IsDebtorRejected
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsPaid() bool {
+	if x != nil {
+		return x.IsPaid
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsPaymentAccepted() bool {
+	if x != nil {
+		return x.IsPaymentAccepted
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsCreditorRequestedForDelete() bool {
+	if x != nil {
+		return x.IsCreditorRequestedForDelete
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetIsDebtorRequestedForDelete() bool {
+	if x != nil {
+		return x.IsDebtorRequestedForDelete
+	}
+	return false
+}
+
+func (x *ExpenseDebt) GetUserAvatar() string {
+	if x != nil {
+		return x.UserAvatar
+	}
+	return ""
+}
+
+func (x *ExpenseDebt) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+type CreateExpenseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// phone number of creditor to amount
+	Creditors map[string]uint64 `protobuf:"bytes,3,rep,name=creditors,proto3" json:"creditors,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// phone numbers of debtors
+	Debtors []string `protobuf:"bytes,4,rep,name=debtors,proto3" json:"debtors,omitempty"`
+}
+
+func (x *CreateExpenseRequest) Reset() {
+	*x = CreateExpenseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseRequest) ProtoMessage() {}
+
+func (x *CreateExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseRequest.ProtoReflect.Descriptor instead.
+func (*CreateExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateExpenseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetCreditors() map[string]uint64 {
+	if x != nil {
+		return x.Creditors
+	}
+	return nil
+}
+
+func (x *CreateExpenseRequest) GetDebtors() []string {
+	if x != nil {
+		return x.Debtors
+	}
+	return nil
+}
+
+type CreateExpenseResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *CreateExpenseResponse) Reset() {
+	*x = CreateExpenseResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseResponse) ProtoMessage() {}
+
+func (x *CreateExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseResponse.ProtoReflect.Descriptor instead.
+func (*CreateExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{3}
+}
+
+type GetExpenseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetExpenseRequest) Reset() {
+	*x = GetExpenseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExpenseRequest) ProtoMessage() {}
+
+func (x *GetExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExpenseRequest.ProtoReflect.Descriptor instead.
+func (*GetExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetExpenseRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type ListExpensesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Page  uint32 `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Limit uint32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *ListExpensesRequest) Reset() {
+	*x = ListExpensesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListExpensesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExpensesRequest) ProtoMessage() {}
+
+func (x *ListExpensesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExpensesRequest.ProtoReflect.Descriptor instead.
+func (*ListExpensesRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListExpensesRequest) GetPage() uint32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListExpensesRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListExpensesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Expenses []*ExpenseDebt `protobuf:"bytes,1,rep,name=expenses,proto3" json:"expenses,omitempty"`
+}
+
+func (x *ListExpensesResponse) Reset() {
+	*x = ListExpensesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListExpensesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExpensesResponse) ProtoMessage() {}
+
+func (x *ListExpensesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExpensesResponse.ProtoReflect.Descriptor instead.
+func (*ListExpensesResponse) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListExpensesResponse) GetExpenses() []*ExpenseDebt {
+	if x != nil {
+		return x.Expenses
+	}
+	return nil
+}
+
+type UpdateExpenseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// expected version of expense. zero skips the check
+	Version     uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name        string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description string `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+}
+
+func (x *UpdateExpenseRequest) Reset() {
+	*x = UpdateExpenseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateExpenseRequest) ProtoMessage() {}
+
+func (x *UpdateExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateExpenseRequest.ProtoReflect.Descriptor instead.
+func (*UpdateExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateExpenseRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateExpenseRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateExpenseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateExpenseRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type DeleteExpenseRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// expected version of expense. zero skips the check
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *DeleteExpenseRequest) Reset() {
+	*x = DeleteExpenseRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExpenseRequest) ProtoMessage() {}
+
+func (x *DeleteExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExpenseRequest.ProtoReflect.Descriptor instead.
+func (*DeleteExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteExpenseRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteExpenseRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteExpenseResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteExpenseResponse) Reset() {
+	*x = DeleteExpenseResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pedarkharj_v1_expense_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteExpenseResponse) ProtoMessage() {}
+
+func (x *DeleteExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pedarkharj_v1_expense_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteExpenseResponse.ProtoReflect.Descriptor instead.
+func (*DeleteExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_pedarkharj_v1_expense_proto_rawDescGZIP(), []int{9}
+}
+
+var File_pedarkharj_v1_expense_proto protoreflect.FileDescriptor
+
+var file_pedarkharj_v1_expense_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2f, 0x76, 0x31, 0x2f,
+	0x65, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x70,
+	0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xa1, 0x02,
+	0x0a, 0x07, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x63,
+	0x72, 0x65, 0x61, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x21,
+	0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41, 0x6d, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x22, 0x86, 0x06, 0x0a, 0x0b, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x62,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x41, 0x74, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1f, 0x0a,
+	0x0b, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x0a, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x1b,
+	0x0a, 0x09, 0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x30, 0x0a, 0x14, 0x69, 0x73, 0x5f, 0x63, 0x72,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x69, 0x73, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f,
+	0x72, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x2c, 0x0a, 0x12, 0x69, 0x73, 0x5f,
+	0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18,
+	0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x73, 0x44, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x41,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x14, 0x69, 0x73, 0x5f, 0x63, 0x72,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x69, 0x73, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f,
+	0x72, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x2c, 0x0a, 0x12, 0x69, 0x73, 0x5f,
+	0x64, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x73, 0x44, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x52,
+	0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x73, 0x5f, 0x70, 0x61,
+	0x69, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x69, 0x73, 0x50, 0x61, 0x69, 0x64,
+	0x12, 0x2e, 0x0a, 0x13, 0x69, 0x73, 0x5f, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x61,
+	0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x69,
+	0x73, 0x50, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x41, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64,
+	0x12, 0x46, 0x0a, 0x20, 0x69, 0x73, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x5f,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x64, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x1c, 0x69, 0x73, 0x43, 0x72,
+	0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x46,
+	0x6f, 0x72, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x42, 0x0a, 0x1e, 0x69, 0x73, 0x5f, 0x64,
+	0x65, 0x62, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x5f,
+	0x66, 0x6f, 0x72, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x1a, 0x69, 0x73, 0x44, 0x65, 0x62, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x65, 0x64, 0x46, 0x6f, 0x72, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x1f, 0x0a, 0x0b,
+	0x75, 0x73, 0x65, 0x72, 0x5f, 0x61, 0x76, 0x61, 0x74, 0x61, 0x72, 0x18, 0x12, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x75, 0x73, 0x65, 0x72, 0x41, 0x76, 0x61, 0x74, 0x61, 0x72, 0x12, 0x1b, 0x0a,
+	0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xf6, 0x01, 0x0a, 0x14, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72,
+	0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x50, 0x0a, 0x09, 0x63, 0x72, 0x65,
+	0x64, 0x69, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x70,
+	0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x09, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x64,
+	0x65, 0x62, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64, 0x65,
+	0x62, 0x74, 0x6f, 0x72, 0x73, 0x1a, 0x3c, 0x0a, 0x0e, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x6f,
+	0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x17, 0x0a, 0x15, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70,
+	0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x23, 0x0a, 0x11,
+	0x47, 0x65, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69,
+	0x64, 0x22, 0x3f, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x67, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x70, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x22, 0x4e, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73,
+	0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x08, 0x65, 0x78,
+	0x70, 0x65, 0x6e, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70,
+	0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70,
+	0x65, 0x6e, 0x73, 0x65, 0x44, 0x65, 0x62, 0x74, 0x52, 0x08, 0x65, 0x78, 0x70, 0x65, 0x6e, 0x73,
+	0x65, 0x73, 0x22, 0x76, 0x0a, 0x14, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65,
+	0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64,
+	0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x40, 0x0a, 0x14, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
+	0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x17, 0x0a, 0x15,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xb7, 0x03, 0x0a, 0x0e, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73,
+	0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5a, 0x0a, 0x0d, 0x43, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x2e, 0x70, 0x65, 0x64, 0x61,
+	0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24,
+	0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x46, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e,
+	0x73, 0x65, 0x12, 0x20, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e,
+	0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72,
+	0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x57, 0x0a, 0x0c,
+	0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x73, 0x12, 0x22, 0x2e, 0x70,
+	0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x23, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x45,
+	0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68,
+	0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70,
+	0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x65,
+	0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x65,
+	0x6e, 0x73, 0x65, 0x12, 0x5a, 0x0a, 0x0d, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x45, 0x78, 0x70,
+	0x65, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x2e, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b, 0x68, 0x61, 0x72,
+	0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x45, 0x78, 0x70, 0x65, 0x6e,
+	0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e, 0x70, 0x65, 0x64, 0x61,
+	0x72, 0x6b, 0x68, 0x61, 0x72, 0x6a, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x45, 0x78, 0x70, 0x65, 0x6e, 0x73, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
+	0x42, 0x5a, 0x40, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x79, 0x61,
+	0x67, 0x68, 0x6f, 0x75, 0x62, 0x69, 0x2d, 0x6d, 0x6e, 0x2f, 0x70, 0x65, 0x64, 0x61, 0x72, 0x6b,
+	0x68, 0x61, 0x72, 0x6a, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x76, 0x31,
+	0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pedarkharj_v1_expense_proto_rawDescOnce sync.Once
+	file_pedarkharj_v1_expense_proto_rawDescData = file_pedarkharj_v1_expense_proto_rawDesc
+)
+
+func file_pedarkharj_v1_expense_proto_rawDescGZIP() []byte {
+	file_pedarkharj_v1_expense_proto_rawDescOnce.Do(func() {
+		file_pedarkharj_v1_expense_proto_rawDescData = protoimpl.X.CompressGZIP(file_pedarkharj_v1_expense_proto_rawDescData)
+	})
+	return file_pedarkharj_v1_expense_proto_rawDescData
+}
+
+var file_pedarkharj_v1_expense_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_pedarkharj_v1_expense_proto_goTypes = []any{
+	(*Expense)(nil),               // 0: pedarkharj.v1.Expense
+	(*ExpenseDebt)(nil),           // 1: pedarkharj.v1.ExpenseDebt
+	(*CreateExpenseRequest)(nil),  // 2: pedarkharj.v1.CreateExpenseRequest
+	(*CreateExpenseResponse)(nil), // 3: pedarkharj.v1.CreateExpenseResponse
+	(*GetExpenseRequest)(nil),     // 4: pedarkharj.v1.GetExpenseRequest
+	(*ListExpensesRequest)(nil),   // 5: pedarkharj.v1.ListExpensesRequest
+	(*ListExpensesResponse)(nil),  // 6: pedarkharj.v1.ListExpensesResponse
+	(*UpdateExpenseRequest)(nil),  // 7: pedarkharj.v1.UpdateExpenseRequest
+	(*DeleteExpenseRequest)(nil),  // 8: pedarkharj.v1.DeleteExpenseRequest
+	(*DeleteExpenseResponse)(nil), // 9: pedarkharj.v1.DeleteExpenseResponse
+	nil,                           // 10: pedarkharj.v1.CreateExpenseRequest.CreditorsEntry
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_pedarkharj_v1_expense_proto_depIdxs = []int32{
+	11, // 0: pedarkharj.v1.Expense.created_at:type_name -> google.protobuf.Timestamp
+	11, // 1: pedarkharj.v1.Expense.updated_at:type_name -> google.protobuf.Timestamp
+	11, // 2: pedarkharj.v1.ExpenseDebt.created_at:type_name -> google.protobuf.Timestamp
+	11, // 3: pedarkharj.v1.ExpenseDebt.updated_at:type_name -> google.protobuf.Timestamp
+	10, // 4: pedarkharj.v1.CreateExpenseRequest.creditors:type_name -> pedarkharj.v1.CreateExpenseRequest.CreditorsEntry
+	1,  // 5: pedarkharj.v1.ListExpensesResponse.expenses:type_name -> pedarkharj.v1.ExpenseDebt
+	2,  // 6: pedarkharj.v1.ExpenseService.CreateExpense:input_type -> pedarkharj.v1.CreateExpenseRequest
+	4,  // 7: pedarkharj.v1.ExpenseService.GetExpense:input_type -> pedarkharj.v1.GetExpenseRequest
+	5,  // 8: pedarkharj.v1.ExpenseService.ListExpenses:input_type -> pedarkharj.v1.ListExpensesRequest
+	7,  // 9: pedarkharj.v1.ExpenseService.UpdateExpense:input_type -> pedarkharj.v1.UpdateExpenseRequest
+	8,  // 10: pedarkharj.v1.ExpenseService.DeleteExpense:input_type -> pedarkharj.v1.DeleteExpenseRequest
+	3,  // 11: pedarkharj.v1.ExpenseService.CreateExpense:output_type -> pedarkharj.v1.CreateExpenseResponse
+	0,  // 12: pedarkharj.v1.ExpenseService.GetExpense:output_type -> pedarkharj.v1.Expense
+	6,  // 13: pedarkharj.v1.ExpenseService.ListExpenses:output_type -> pedarkharj.v1.ListExpensesResponse
+	0,  // 14: pedarkharj.v1.ExpenseService.UpdateExpense:output_type -> pedarkharj.v1.Expense
+	9,  // 15: pedarkharj.v1.ExpenseService.DeleteExpense:output_type -> pedarkharj.v1.DeleteExpenseResponse
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_pedarkharj_v1_expense_proto_init() }
+func file_pedarkharj_v1_expense_proto_init() {
+	if File_pedarkharj_v1_expense_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pedarkharj_v1_expense_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Expense); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ExpenseDebt); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateExpenseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateExpenseResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*GetExpenseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ListExpensesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ListExpensesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*UpdateExpenseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteExpenseRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pedarkharj_v1_expense_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteExpenseResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pedarkharj_v1_expense_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pedarkharj_v1_expense_proto_goTypes,
+		DependencyIndexes: file_pedarkharj_v1_expense_proto_depIdxs,
+		MessageInfos:      file_pedarkharj_v1_expense_proto_msgTypes,
+	}.Build()
+	File_pedarkharj_v1_expense_proto = out.File
+	file_pedarkharj_v1_expense_proto_rawDesc = nil
+	file_pedarkharj_v1_expense_proto_goTypes = nil
+	file_pedarkharj_v1_expense_proto_depIdxs = nil
+}
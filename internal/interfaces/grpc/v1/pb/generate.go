@@ -0,0 +1,4 @@
+// generated code of protobuf definitions in api/proto
+package pb
+
+//go:generate protoc -I ../../../../../api/proto --go_out=. --go_opt=module=github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb --go-grpc_out=. --go-grpc_opt=module=github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb pedarkharj/v1/user.proto pedarkharj/v1/device.proto pedarkharj/v1/expense.proto pedarkharj/v1/debt.proto
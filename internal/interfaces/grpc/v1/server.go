@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+	"google.golang.org/grpc"
+)
+
+type Options struct {
+	Addr string
+	// max size of received messages in bytes
+	MaxRecvMsgSize int
+}
+
+// grpc api of application services. it runs on separate address from rest api and shares application services with it
+type Server struct {
+	options    Options
+	grpcServer *grpc.Server
+
+	mu       sync.Mutex
+	listener net.Listener
+}
+
+func NewServer(options Options, userAppService app_user.UserAppService, deviceAppService app_device.DeviceAppService, expenseAppService app_expense.ExpenseAppService, debtAppService app_debt.DebtAppService, cacheRepo domain_shared.CacheRepository) *Server {
+
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(options.MaxRecvMsgSize),
+		// first interceptor is outermost
+		grpc.ChainUnaryInterceptor(
+			requestInterceptor,
+			recoveryInterceptor,
+			newAuthInterceptor(cacheRepo),
+		),
+	)
+
+	pb.RegisterUserServiceServer(grpcServer, &userServer{appService: userAppService})
+	pb.RegisterDeviceServiceServer(grpcServer, &deviceServer{appService: deviceAppService})
+	pb.RegisterExpenseServiceServer(grpcServer, &expenseServer{appService: expenseAppService})
+	pb.RegisterDebtServiceServer(grpcServer, &debtServer{appService: debtAppService})
+
+	return &Server{
+		options:    options,
+		grpcServer: grpcServer,
+	}
+}
+
+// listen on address and serve in background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.options.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	slog.Info("grpc server started", "addr", listener.Addr().String())
+
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.Error("grpc server", "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// address of listener. nil before start. useful when port of address is 0
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// wait for in-flight calls to finish. calls are cancelled when ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"strconv"
+
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	appService app_user.UserAppService
+}
+
+func (s *userServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	var input app_user.LoginUserInput
+	input.PhoneNumber = req.GetNumber()
+	input.InputPassword = req.GetPassword()
+
+	responseDTO := s.appService.Login(ctx, input, deviceName(ctx, req.GetDeviceName()), peerIP(ctx))
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return newLoginResponse(responseDTO.ResponseCode, responseDTO.Data), nil
+}
+
+func (s *userServer) VerifyTwoFactor(ctx context.Context, req *pb.VerifyTwoFactorRequest) (*pb.LoginResponse, error) {
+	var input app_user.TwoFactorVerifyInput
+	input.ChallengeToken = req.GetChallengeToken()
+	input.Code = req.GetCode()
+	input.RecoveryCode = req.GetRecoveryCode()
+
+	responseDTO := s.appService.VerifyTwoFactor(ctx, input)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return newLoginResponse(responseDTO.ResponseCode, responseDTO.Data), nil
+}
+
+func (s *userServer) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	responseDTO := s.appService.GetAccessFromRefresh(ctx, req.GetRefresh())
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	access, _ := responseDTO.Data["access"].(string)
+	return &pb.RefreshResponse{Access: access}, nil
+}
+
+func (s *userServer) GetUserInfo(ctx context.Context, req *pb.GetUserInfoRequest) (*pb.User, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responseDTO := s.appService.GetUserInfo(ctx, user.ID)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	return userOutput(responseDTO.Data)
+}
+
+func (s *userServer) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRequest) (*pb.UpdateProfileResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := app_user.UpdateProfileInput{UpdateProfileInput: shared_dto.UpdateProfileInput{
+		Name:     req.GetName(),
+		Language: req.GetLanguage(),
+		Currency: req.GetCurrency(),
+	}}
+
+	responseDTO := s.appService.UpdateProfile(ctx, input, user.ID)
+	if err := DTOError(ctx, responseDTO); err != nil {
+		return nil, err
+	}
+
+	output, err := userOutput(responseDTO.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	access, _ := responseDTO.Data["access"].(string)
+	return &pb.UpdateProfileResponse{User: output, Access: access}, nil
+}
+
+// tokens of login, or challenge of two factor authentication
+func newLoginResponse(code string, data map[string]any) *pb.LoginResponse {
+	response := &pb.LoginResponse{Code: code}
+
+	response.Access, _ = data["access"].(string)
+	response.Refresh, _ = data["refresh"].(string)
+	if expire, ok := data["accessExpireSeconds"].(string); ok {
+		response.AccessExpireSeconds, _ = strconv.ParseInt(expire, 10, 64)
+	}
+
+	response.ChallengeToken, _ = data["challenge_token"].(string)
+	if expire, ok := data["expireTimeSeconds"].(float64); ok {
+		response.ChallengeExpireSeconds = int64(expire)
+	}
+
+	return response
+}
+
+func userOutput(data map[string]any) (*pb.User, error) {
+	output, ok := data["data"].(app_user.UserOutput)
+	if !ok {
+		return nil, status.Error(codes.Internal, "server error")
+	}
+
+	return &pb.User{
+		Name:            output.Name,
+		Number:          output.Number,
+		Avatar:          output.Avatar,
+		AvatarThumbnail: output.AvatarThumbnail,
+		Language:        output.Language,
+		Currency:        output.Currency,
+	}, nil
+}
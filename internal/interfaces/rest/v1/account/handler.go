@@ -0,0 +1,136 @@
+package account_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	app_account "github.com/yaghoubi-mn/pedarkharj/internal/application/account"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+)
+
+type Handler struct {
+	appService app_account.AccountAppService
+	response   interfaces_rest_v1_shared.Response
+}
+
+func NewHandler(appService app_account.AccountAppService, response interfaces_rest_v1_shared.Response) Handler {
+	return Handler{
+		appService: appService,
+		response:   response,
+	}
+}
+
+// RequestExport godoc
+// @Summary Request personal data export
+// @Description Start building a zip archive of user data (profile, devices, expenses, debts, comments, notifications) in background (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "export started"
+// @Failure 400 "BadRequest:<br>code=export_in_progress: wait until export is ready"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/export [post]
+func (h *Handler) RequestExport(w http.ResponseWriter, r *http.Request) {
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.RequestExport(r.Context(), user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// GetExport godoc
+// @Summary Get personal data export
+// @Description Get status of last data export and download url if it is ready (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "status and url"
+// @Failure 400 "BadRequest:<br>code=export_not_found: request export first"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/export [get]
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.GetExport(r.Context(), user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Description Delete user account. personal data is removed but debts are kept for other users. code is required if two factor is enabled (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_account.DeleteAccountInput true "password and two factor code"
+// @Success 200 {object} map[string]interface{} "account deleted"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/delete-account [post]
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+
+	var input app_account.DeleteAccountInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.DeleteAccount(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
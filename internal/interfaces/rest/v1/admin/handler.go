@@ -0,0 +1,342 @@
+package admin_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	app_admin "github.com/yaghoubi-mn/pedarkharj/internal/application/admin"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+type Handler struct {
+	appService app_admin.AdminAppService
+	response   interfaces_rest_v1_shared.Response
+}
+
+func NewHandler(appService app_admin.AdminAppService, response interfaces_rest_v1_shared.Response) Handler {
+	return Handler{
+		appService: appService,
+		response:   response,
+	}
+}
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description Search users by part of name or number (Admin or Support role Required)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param query query string false "part of name or number"
+// @Param page query int true "page number. starts from 1"
+// @Param limit query int true "page size. max 50"
+// @Success 200 {object} map[string]interface{} "users and total"
+// @Failure 400 "BadRequest:<br>code=invalid_query_param: page or limit is not a number<br>code=invalid_field: a field is invalid"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users [get]
+func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+
+	var input app_admin.SearchUsersInput
+	var err error
+	query := r.URL.Query()
+	input.Query = query.Get("query")
+
+	input.Page, err = strconv.Atoi(query.Get("page"))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrPageNotNumber)
+		return
+	}
+
+	input.Limit, err = strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrLimitNotNumber)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.SearchUsers(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// GetUser godoc
+// @Summary Get user
+// @Description Get user info, devices and expense counts (Admin or Support role Required)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "user id"
+// @Success 200 {object} map[string]interface{} "user, devices, created_expenses and participated_expenses"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 404 "NotFound:<br>code=user_not_found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users/{id} [get]
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidID)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.GetUser(r.Context(), userID, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// BlockUser godoc
+// @Summary Block or unblock user
+// @Description Block or unblock user. all devices of blocked user are logged out (Admin role Required)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_admin.BlockUserInput true "user id, is_blocked and reason"
+// @Success 200 {object} map[string]interface{} "user blocked or unblocked"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 404 "NotFound:<br>code=user_not_found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users/block [post]
+func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
+
+	var input app_admin.BlockUserInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.BlockUser(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// LogoutUser godoc
+// @Summary Force logout user
+// @Description Logout all devices of user (Admin or Support role Required)
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_admin.UserIDInput true "user id"
+// @Success 200 {object} map[string]interface{} "user logged out"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 404 "NotFound:<br>code=user_not_found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users/logout [post]
+func (h *Handler) LogoutUser(w http.ResponseWriter, r *http.Request) {
+
+	var input app_admin.UserIDInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.LogoutUser(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// GetStats godoc
+// @Summary System stats
+// @Description Count of users, devices, expenses and debts (Admin role Required)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "stats"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/stats [get]
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.GetStats(r.Context(), user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// GetAuditLogs godoc
+// @Summary Search audit log
+// @Description Search audit log of user actions (logins, password resets, device logouts, expense and debt changes). newest first (Admin or Support role Required)
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query int false "user that did action"
+// @Param action query string false "action. e.g: user.login, expense.update, debt.accept"
+// @Param target_type query string false "user, device, expense or debt"
+// @Param target_id query int false "id of target"
+// @Param from query string false "RFC3339 time"
+// @Param to query string false "RFC3339 time"
+// @Param page query int true "page number. starts from 1"
+// @Param limit query int true "page size. max 50"
+// @Success 200 {object} map[string]interface{} "logs and total"
+// @Failure 400 "BadRequest:<br>code=invalid_query_param: a query param has invalid format<br>code=invalid_field: a field is invalid"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/audit-logs [get]
+func (h *Handler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+
+	var input app_admin.AuditLogsInput
+	var err error
+	query := r.URL.Query()
+	input.Action = query.Get("action")
+	input.TargetType = query.Get("target_type")
+
+	input.Page, err = strconv.Atoi(query.Get("page"))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrPageNotNumber)
+		return
+	}
+
+	input.Limit, err = strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrLimitNotNumber)
+		return
+	}
+
+	if value := query.Get("actor_id"); value != "" {
+		input.ActorID, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrActorIDNotNumber)
+			return
+		}
+	}
+
+	if value := query.Get("target_id"); value != "" {
+		input.TargetID, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrTargetIDNotNumber)
+			return
+		}
+	}
+
+	if value := query.Get("from"); value != "" {
+		input.From, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrFromNotTime)
+			return
+		}
+	}
+
+	if value := query.Get("to"); value != "" {
+		input.To, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrToNotTime)
+			return
+		}
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.GetAuditLogs(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
@@ -0,0 +1,68 @@
+package audit_handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+)
+
+type Handler struct {
+	appService app_audit.AuditAppService
+	response   interfaces_rest_v1_shared.Response
+}
+
+func NewHandler(appService app_audit.AuditAppService, response interfaces_rest_v1_shared.Response) Handler {
+	return Handler{
+		appService: appService,
+		response:   response,
+	}
+}
+
+// GetMyActivity godoc
+// @Summary Get my activity
+// @Description Get audit log of actions that user did (logins, password resets, device logouts, expense and debt changes). newest first
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param page query int true "page number. starts from 1"
+// @Param limit query int true "page size. max 50"
+// @Success 200 {object} map[string]interface{} "logs and total"
+// @Failure 400 "BadRequest:<br>code=invalid_query_param: page or limit is not a number<br>code=invalid_field: a field is invalid"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/activity [get]
+func (h *Handler) GetMyActivity(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrPageNotNumber)
+		return
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidQueryParam, nil, interfaces_rest_v1_shared.ErrLimitNotNumber)
+		return
+	}
+
+	user, ok := r.Context().Value("user").(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.GetMyActivity(r.Context(), user.ID, page, limit)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
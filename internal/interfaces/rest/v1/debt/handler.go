@@ -1 +1,225 @@
 package debt_handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+type Handler struct {
+	appService app_debt.DebtAppService
+	response   interfaces_rest_v1_shared.Response
+}
+
+func NewHandler(appService app_debt.DebtAppService, response interfaces_rest_v1_shared.Response) Handler {
+	return Handler{
+		appService: appService,
+		response:   response,
+	}
+}
+
+// GetDebt godoc
+// @Summery get debt
+// @Description get debt. user must be creditor or debtor of debt.
+// @Tags debts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "debt id"
+// @Success 200 "Ok. ETag header is version of debt"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 404 "NotFound:<br>code=debt_not_found"
+// @Router /debts/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+
+	debtID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidID)
+		return
+	}
+
+	user, ok := r.Context().Value("user").(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.Get(r.Context(), debtID, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	setETag(w, responseDTO)
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// AcceptDebt godoc
+// @Summery accept debt
+// @Description accept debt by creditor or debtor. debt is accepted for other side if other side is not registered.
+// @Tags debts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "debt id"
+// @Param If-Match header string false "ETag of debt. debt is accepted only if it is not changed"
+// @Success 200 "Ok. ETag header is new version of debt"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid<br>code=invalid_header: invalid If-Match"
+// @Failure 404 "NotFound:<br>code=debt_not_found"
+// @Failure 409 "Conflict:<br>code=version_conflict: debt is changed. current debt is returned"
+// @Router /debts/{id}/accept [post]
+func (h *Handler) Accept(w http.ResponseWriter, r *http.Request) {
+
+	debtID, version, user, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responseDTO := h.appService.Accept(r.Context(), debtID, user.ID, version)
+	setETag(w, responseDTO)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// PayDebt godoc
+// @Summery pay debt
+// @Description pay debt by debtor. payment is accepted too if creditor is not registered.
+// @Tags debts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "debt id"
+// @Param If-Match header string false "ETag of debt. debt is paid only if it is not changed"
+// @Success 200 "Ok. ETag header is new version of debt"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid<br>code=invalid_header: invalid If-Match"
+// @Failure 403 "Forbidden:<br>code=permission_denied: user is not debtor"
+// @Failure 404 "NotFound:<br>code=debt_not_found"
+// @Failure 409 "Conflict:<br>code=version_conflict: debt is changed. current debt is returned"
+// @Router /debts/{id}/pay [post]
+func (h *Handler) Pay(w http.ResponseWriter, r *http.Request) {
+
+	debtID, version, user, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responseDTO := h.appService.Pay(r.Context(), debtID, user.ID, version)
+	setETag(w, responseDTO)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// AcceptDebtPayment godoc
+// @Summery accept payment of debt
+// @Description accept payment of debt by creditor. debt is settled. debt is paid too if debtor is not registered.
+// @Tags debts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "debt id"
+// @Param If-Match header string false "ETag of debt. payment is accepted only if debt is not changed"
+// @Success 200 "Ok. ETag header is new version of debt"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid<br>code=invalid_header: invalid If-Match"
+// @Failure 403 "Forbidden:<br>code=permission_denied: user is not creditor"
+// @Failure 404 "NotFound:<br>code=debt_not_found"
+// @Failure 409 "Conflict:<br>code=version_conflict: debt is changed. current debt is returned<br>code=debt_not_paid: debtor has not paid debt"
+// @Router /debts/{id}/accept-payment [post]
+func (h *Handler) AcceptPayment(w http.ResponseWriter, r *http.Request) {
+
+	debtID, version, user, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responseDTO := h.appService.AcceptPayment(r.Context(), debtID, user.ID, version)
+	setETag(w, responseDTO)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// DeleteDebt godoc
+// @Summery request deletion of debt
+// @Description request deletion of debt by creditor or debtor. debt is deleted when both of them request it. is_deleted shows debt is deleted.
+// @Tags debts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "debt id"
+// @Param If-Match header string false "ETag of debt. deletion is requested only if debt is not changed"
+// @Success 200 "Ok. ETag header is new version of debt if it is not deleted"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid<br>code=invalid_header: invalid If-Match"
+// @Failure 404 "NotFound:<br>code=debt_not_found"
+// @Failure 409 "Conflict:<br>code=version_conflict: debt is changed. current debt is returned"
+// @Router /debts/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	debtID, version, user, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	responseDTO := h.appService.Delete(r.Context(), debtID, user.ID, version)
+	setETag(w, responseDTO)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// debt id of path, version of If-Match header and user of request. ok is false if error response is written
+func (h *Handler) parseRequest(w http.ResponseWriter, r *http.Request) (debtID uint64, version uint64, user app_user.JWTUser, ok bool) {
+
+	debtID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidID)
+		return
+	}
+
+	version, err = interfaces_rest_v1_shared.IfMatchVersion(r)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, err)
+		return
+	}
+
+	user, ok = r.Context().Value("user").(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	return debtID, version, user, true
+}
+
+// ETag of debt in response
+func setETag(w http.ResponseWriter, responseDTO app_shared.ResponseDTO) {
+	if debt, ok := responseDTO.Data["debt"].(shared_dto.DebtOutput); ok {
+		w.Header().Set("ETag", interfaces_rest_v1_shared.ETag(debt.Version))
+	}
+}
@@ -46,7 +46,7 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.Logout(user.ID, utils.GetUserAgent(r))
+	responseDTO := h.appService.Logout(r.Context(), user.ID, utils.GetUserAgent(r))
 
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
@@ -80,7 +80,7 @@ func (h *Handler) LogoutAllUserDevices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.LogoutAllUserDevices(user.ID)
+	responseDTO := h.appService.LogoutAllUserDevices(r.Context(), user.ID)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 	}
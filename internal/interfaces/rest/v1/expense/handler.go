@@ -4,10 +4,15 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
 	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
 	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
 )
 
 type Handler struct {
@@ -33,10 +38,13 @@ func NewHandler(appService app_expense.ExpenseAppService, response interfaces_re
 // @Param description body string true "expense description"
 // @Param creditors body map[string]uint64 true "creditors key value list: phone number is key and credit amount is value" exmaple("{"+989123456789": 2000, "+989123456788": 5000}")
 // @Param debtors body []string true "list of debtors phone number" example("["+989123456786", "+989123456787"]")
+// @Param Idempotency-Key header string false "unique key of request. retries with same key get response of first request"
 // @Success 200 "Ok"
 // @Failure 500
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 409 "Conflict:<br>code=idempotency_key_in_progress: first request with same key is not finished"
+// @Failure 422 "UnprocessableEntity:<br>code=idempotency_key_mismatch: key is used for another request"
 // @Router /expenses [post]
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
@@ -64,7 +72,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.Create(input, user.ID, user.PhoneNumber)
+	responseDTO := h.appService.Create(r.Context(), input, user.ID, user.PhoneNumber)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -73,3 +81,171 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
 
 }
+
+// GetExpense godoc
+// @Summery get expense
+// @Description get expense. user must be creditor or debtor of expense.
+// @Tags expenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "expense id"
+// @Success 200 "Ok. ETag header is version of expense"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 404 "NotFound:<br>code=expense_not_found"
+// @Router /expenses/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+
+	expenseID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidID)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.Get(r.Context(), expenseID, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	setETag(w, responseDTO)
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// UpdateExpense godoc
+// @Summery update expense
+// @Description update name and description of expense. only creator of expense can update it.
+// @Tags expenses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "expense id"
+// @Param If-Match header string false "ETag of expense. expense is updated only if it is not changed"
+// @Param name body string true "expense name"
+// @Param description body string true "expense description"
+// @Success 200 "Ok. ETag header is new version of expense"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid<br>code=invalid_header: invalid If-Match"
+// @Failure 403 "Forbidden:<br>code=permission_denied"
+// @Failure 404 "NotFound:<br>code=expense_not_found"
+// @Failure 409 "Conflict:<br>code=version_conflict: expense is changed. current expense is returned"
+// @Router /expenses/{id} [put]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+
+	expenseID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidID)
+		return
+	}
+
+	version, err := interfaces_rest_v1_shared.IfMatchVersion(r)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, err)
+		return
+	}
+
+	var input app_expense.ExpenseUpdateInput
+	// decode body
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&input)
+	defer r.Body.Close()
+
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.Update(r.Context(), expenseID, user.ID, version, input)
+	setETag(w, responseDTO)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// DeleteExpense godoc
+// @Summery delete expense
+// @Description delete expense with its debts and comments. only creator of expense can delete it.
+// @Tags expenses
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "expense id"
+// @Param If-Match header string false "ETag of expense. expense is deleted only if it is not changed"
+// @Success 200 "Ok"
+// @Failure 500
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid<br>code=invalid_header: invalid If-Match"
+// @Failure 404 "NotFound:<br>code=expense_not_found"
+// @Failure 409 "Conflict:<br>code=version_conflict: expense is changed. current expense is returned"
+// @Router /expenses/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	expenseID, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidID)
+		return
+	}
+
+	version, err := interfaces_rest_v1_shared.IfMatchVersion(r)
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.Delete(r.Context(), expenseID, user.ID, version)
+	setETag(w, responseDTO)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, http.StatusOK, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// ETag of expense in response
+func setETag(w http.ResponseWriter, responseDTO app_shared.ResponseDTO) {
+	if expense, ok := responseDTO.Data["expense"].(shared_dto.ExpenseOutput); ok {
+		w.Header().Set("ETag", interfaces_rest_v1_shared.ETag(expense.Version))
+	}
+}
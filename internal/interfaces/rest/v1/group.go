@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+)
+
+type middlewareFunc func(http.Handler) http.Handler
+
+// routes of one version of api. 404 and 405 responses are written by response.
+// OPTIONS requests of registered paths are answered with Allow header. cors preflight requests are answered by cors middleware
+type router struct {
+	mux *http.ServeMux
+	// path patterns without method. used for finding allowed methods of request path
+	paths    *http.ServeMux
+	methods  map[string][]string
+	response interfaces_rest_v1_shared.Response
+	notFound http.HandlerFunc
+	recovery middlewareFunc
+}
+
+// routes with same prefix and middlewares
+type routeGroup struct {
+	router      *router
+	prefix      string
+	middlewares []middlewareFunc
+}
+
+func newRouter(response interfaces_rest_v1_shared.Response, notFound http.HandlerFunc) *router {
+	recoveryMiddleware := middleware.NewRecoveryMiddleware(response)
+
+	rt := &router{
+		mux:      http.NewServeMux(),
+		paths:    http.NewServeMux(),
+		methods:  make(map[string][]string),
+		response: response,
+		notFound: notFound,
+		recovery: recoveryMiddleware.Recover,
+	}
+	rt.mux.HandleFunc("/", rt.fallback)
+
+	return rt
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+func (rt *router) Group(prefix string, middlewares ...middlewareFunc) *routeGroup {
+	return &routeGroup{router: rt, prefix: prefix, middlewares: middlewares}
+}
+
+// group with prefix and middlewares of g. middlewares of g are run first
+func (g *routeGroup) Group(prefix string, middlewares ...middlewareFunc) *routeGroup {
+	return &routeGroup{
+		router:      g.router,
+		prefix:      g.prefix + prefix,
+		middlewares: append(slices.Clone(g.middlewares), middlewares...),
+	}
+}
+
+// pattern is path pattern of go ServeMux. e.g: /{id}
+func (g *routeGroup) HandleFunc(method string, pattern string, handler http.HandlerFunc) {
+	route := g.prefix + pattern
+
+	var next http.Handler = handler
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		next = g.middlewares[i](next)
+	}
+
+	g.router.mux.Handle(method+" "+route, middleware.TraceRoute(method, route, middleware.InstrumentRoute(method, route, g.router.recovery(next))))
+
+	if _, ok := g.router.methods[route]; !ok {
+		g.router.paths.Handle(route, http.NotFoundHandler())
+	}
+	g.router.methods[route] = append(g.router.methods[route], method)
+}
+
+// request is not matched with any route. response is 405 if path is registered with other methods, otherwise 404
+func (rt *router) fallback(w http.ResponseWriter, r *http.Request) {
+	allow := rt.AllowedMethods(r)
+	if allow == nil {
+		rt.notFound(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rt.response.ErrorResponse(w, http.StatusMethodNotAllowed, rcodes.MethodNotAllowed, nil, interfaces_rest_v1_shared.ErrMethodNotAllowed)
+}
+
+// methods of path of request including HEAD and OPTIONS. nil if path is not registered
+func (rt *router) AllowedMethods(r *http.Request) []string {
+	_, pattern := rt.paths.Handler(r)
+	methods, ok := rt.methods[pattern]
+	if !ok {
+		return nil
+	}
+
+	allow := slices.Clone(methods)
+	if slices.Contains(allow, http.MethodGet) {
+		allow = append(allow, http.MethodHead)
+	}
+
+	return append(allow, http.MethodOptions)
+}
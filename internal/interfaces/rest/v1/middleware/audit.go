@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
+)
+
+// add ip and user agent of client to request context. they are saved in audit logs of request and devices of user.
+// forwarded headers are used only for requests of trusted proxies
+func AuditRequestInfo(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := app_audit.WithRequestInfo(r.Context(), app_audit.RequestInfo{
+				IP:        utils.GetClientIP(r, trustedProxies),
+				UserAgent: utils.GetUserAgent(r),
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
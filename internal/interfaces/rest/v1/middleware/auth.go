@@ -8,18 +8,23 @@ import (
 	"strings"
 
 	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
 	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
 )
 
+// access tokens that are revoked by blocking or logging out user are rejected
 type authMiddleware struct {
-	response interfaces_rest_v1_shared.Response
+	response  interfaces_rest_v1_shared.Response
+	cacheRepo domain_shared.CacheRepository
 }
 
-func NewAuthMiddleware(response interfaces_rest_v1_shared.Response) authMiddleware {
+func NewAuthMiddleware(response interfaces_rest_v1_shared.Response, cacheRepo domain_shared.CacheRepository) authMiddleware {
 	return authMiddleware{
-		response: response,
+		response:  response,
+		cacheRepo: cacheRepo,
 	}
 }
 
@@ -28,12 +33,12 @@ func (a *authMiddleware) EnsureAuthentication(next http.Handler) http.Handler {
 
 		access := r.Header.Get("Authorization")
 		if access == "" {
-			a.response.ErrorResponse(w, 401, rcodes.Unauthenticated, nil, errors.New("authentication is required"))
+			a.response.ErrorResponse(w, 401, rcodes.Unauthenticated, nil, interfaces_rest_v1_shared.ErrAuthenticationRequired)
 			return
 		}
 
 		if strings.Index(access, "Bearer ") != 0 || len(access) < 8 {
-			a.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, errors.New("invalid Authorization header format"))
+			a.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, interfaces_rest_v1_shared.ErrInvalidAuthorizationHeader)
 			return
 		}
 
@@ -43,8 +48,8 @@ func (a *authMiddleware) EnsureAuthentication(next http.Handler) http.Handler {
 		var err error
 		user.ID, user.Name, user.PhoneNumber, user.IsRegistered, err = jwt.GetUserFromAccess(access)
 		if err != nil {
-			slog.Info("JWT ERROR", "error", err)
-			a.response.ErrorResponse(w, 401, rcodes.InvalidToken, nil, errors.New("authorization: invalid token"))
+			slog.InfoContext(r.Context(), "invalid access token", "error", err)
+			a.response.ErrorResponse(w, 401, rcodes.InvalidToken, nil, interfaces_rest_v1_shared.ErrInvalidAccessToken)
 			return
 		}
 
@@ -53,6 +58,23 @@ func (a *authMiddleware) EnsureAuthentication(next http.Handler) http.Handler {
 			return
 		}
 
+		issuedAt, err := jwt.GetIssuedAtFromAccess(access)
+		if err != nil {
+			a.response.ServerErrorResponse(w, err)
+			return
+		}
+		revoked, err := app_user.IsAccessRevoked(a.cacheRepo, user.ID, issuedAt)
+		if err != nil {
+			a.response.ServerErrorResponse(w, err)
+			return
+		}
+		if revoked {
+			slog.InfoContext(r.Context(), "revoked access token", "user_id", user.ID)
+			a.response.ErrorResponse(w, 401, rcodes.InvalidToken, nil, interfaces_rest_v1_shared.ErrInvalidAccessToken)
+			return
+		}
+
+		logger.SetUserID(r.Context(), user.ID)
 		r = r.WithContext(context.WithValue(r.Context(), "user", user))
 
 		next.ServeHTTP(w, r)
@@ -0,0 +1,13 @@
+package middleware
+
+import "net/http"
+
+// limit size of request body. reading more than maxSize bytes fails with *http.MaxBytesError
+func LimitBody(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSPolicy struct {
+	AllowedOrigins   []string // exact origin (https://app.example.com), wildcard subdomain (https://*.example.com) or * for all origins
+	AllowCredentials bool
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration // cache time of preflight responses
+}
+
+// e.g: https://*.example.com is "https://" and ".example.com"
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+type corsMiddleware struct {
+	policy    CORSPolicy
+	allowAll  bool
+	origins   []string
+	wildcards []wildcardOrigin
+	// methods of path of request. nil if path is not registered
+	allowedMethods func(r *http.Request) []string
+}
+
+func NewCORSMiddleware(policy CORSPolicy, allowedMethods func(r *http.Request) []string) corsMiddleware {
+	c := corsMiddleware{
+		policy:         policy,
+		allowedMethods: allowedMethods,
+	}
+
+	for _, origin := range policy.AllowedOrigins {
+		origin = strings.ToLower(origin)
+
+		if origin == "*" {
+			c.allowAll = true
+		} else if scheme, host, _ := strings.Cut(origin, "://"); strings.HasPrefix(host, "*.") {
+			c.wildcards = append(c.wildcards, wildcardOrigin{scheme: scheme + "://", suffix: host[1:]})
+		} else {
+			c.origins = append(c.origins, origin)
+		}
+	}
+
+	return c
+}
+
+// add cors headers of policy to responses of allowed origins and answer preflight requests of registered paths
+func (c *corsMiddleware) HandleCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		if r.Method == http.MethodOptions && origin != "" && r.Header.Get("Access-Control-Request-Method") != "" {
+			methods := c.allowedMethods(r)
+			if methods != nil {
+				c.preflight(w, origin, methods)
+				return
+			}
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if origin != "" && c.isOriginAllowed(origin) {
+			c.setOrigin(w, origin)
+			if len(c.policy.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.policy.ExposedHeaders, ", "))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// headers are not set if origin is not allowed. browser blocks request
+func (c *corsMiddleware) preflight(w http.ResponseWriter, origin string, methods []string) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if c.isOriginAllowed(origin) {
+		c.setOrigin(w, origin)
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if len(c.policy.AllowedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.policy.AllowedHeaders, ", "))
+		}
+		if c.policy.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.policy.MaxAge.Seconds())))
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// origin is sent back instead of * when credentials are allowed
+func (c *corsMiddleware) setOrigin(w http.ResponseWriter, origin string) {
+	if c.allowAll && !c.policy.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.policy.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *corsMiddleware) isOriginAllowed(origin string) bool {
+	if c.allowAll {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if slices.Contains(c.origins, origin) {
+		return true
+	}
+
+	for _, wildcard := range c.wildcards {
+		if host, ok := strings.CutPrefix(origin, wildcard.scheme); ok && strings.HasSuffix(host, wildcard.suffix) && len(host) > len(wildcard.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
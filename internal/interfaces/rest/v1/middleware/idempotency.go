@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLength = 255
+
+	// in progress key is released after this time if instance stops before saving response
+	idempotencyLockExpire = time.Minute
+
+	// states of stored key
+	idempotencyInProgress = "in_progress"
+	idempotencyCompleted  = "completed"
+)
+
+// replay response of mutating requests that are retried with same Idempotency-Key header.
+// key is stored in cache with fingerprint of request (method, path, query and body) and response of first request.
+// keys are scoped to api version and user, so clients only need unique keys per user.
+// body must be limited before this middleware because it is read completely for fingerprint
+type idempotencyMiddleware struct {
+	response   interfaces_rest_v1_shared.Response
+	cacheRepo  domain_shared.CacheRepository
+	apiVersion string
+	expire     time.Duration
+}
+
+func NewIdempotencyMiddleware(cacheRepo domain_shared.CacheRepository, response interfaces_rest_v1_shared.Response, apiVersion string, expire time.Duration) *idempotencyMiddleware {
+	return &idempotencyMiddleware{
+		response:   response,
+		cacheRepo:  cacheRepo,
+		apiVersion: apiVersion,
+		expire:     expire,
+	}
+}
+
+// keeps status and body of response for storing. status is status of response body
+// and httpStatus is written status. they are different for some statuses of v1
+type responseRecorder struct {
+	statusRecorder
+	httpStatus int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.httpStatus == 0 {
+		r.httpStatus = status
+	}
+	r.statusRecorder.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.statusRecorder.Write(b)
+}
+
+// must be called after authentication for scoping keys to user
+func (i *idempotencyMiddleware) EnsureIdempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			i.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, interfaces_rest_v1_shared.ErrIdempotencyKeyTooLong)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				i.response.ErrorResponse(w, 413, rcodes.BodyTooLarge, nil, interfaces_rest_v1_shared.ErrBodyTooLarge)
+				return
+			}
+			i.response.ErrorResponse(w, 400, rcodes.InvalidJSON, nil, interfaces_rest_v1_shared.ErrCannotReadBody)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// path is without version prefix
+		fingerprint := requestFingerprint(r.Method, r.URL.Path, r.URL.RawQuery, body)
+
+		var userID uint64
+		if user, ok := r.Context().Value("user").(app_user.JWTUser); ok {
+			userID = user.ID
+		}
+		cacheKey := "idempotency:" + i.apiVersion + ":" + strconv.FormatUint(userID, 10) + ":" + key
+
+		stored, err := i.reserve(cacheKey, fingerprint)
+		if err != nil {
+			i.response.ServerErrorResponse(w, err)
+			return
+		}
+
+		if stored != nil {
+			switch {
+			case stored["fingerprint"] != fingerprint:
+				i.response.ErrorResponse(w, 422, rcodes.IdempotencyKeyMismatch, nil, interfaces_rest_v1_shared.ErrIdempotencyKeyMismatch)
+			case stored["state"] == idempotencyInProgress:
+				i.response.ErrorResponse(w, 409, rcodes.IdempotencyKeyInProgress, nil, interfaces_rest_v1_shared.ErrIdempotencyKeyInProgress)
+			default:
+				replay(w, stored)
+			}
+			return
+		}
+
+		// key is released if handler panics or fails with server error, so client can retry
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := i.cacheRepo.Delete(cacheKey); err != nil {
+				slog.ErrorContext(r.Context(), "cannot delete idempotency key", "error", err)
+			}
+		}()
+
+		recorder := &responseRecorder{statusRecorder: statusRecorder{ResponseWriter: w}}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		httpStatus := recorder.httpStatus
+		if httpStatus == 0 {
+			httpStatus = http.StatusOK
+		}
+
+		if status >= 500 {
+			return
+		}
+
+		// expire of in progress key is extended
+		err = i.cacheRepo.Save(cacheKey, map[string]string{
+			"state":           idempotencyCompleted,
+			"fingerprint":     fingerprint,
+			"status":          strconv.Itoa(httpStatus),
+			"response_status": strconv.Itoa(status),
+			"content_type":    recorder.Header().Get("Content-Type"),
+			"body":            recorder.body.String(),
+		}, i.expire)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "cannot save idempotency key", "error", err)
+			return
+		}
+		saved = true
+	})
+}
+
+// for mutating routes that cannot be idempotent. header is rejected instead of ignoring it,
+// so client does not retry request with assumption that it is not done twice
+func (i *idempotencyMiddleware) RejectIdempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(IdempotencyKeyHeader) == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		i.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, interfaces_rest_v1_shared.ErrIdempotencyKeyNotSupported)
+	})
+}
+
+// returns stored record of key. if key is not stored, it is saved as in progress and nil is returned.
+// key is saved only if it is absent in cache, so one of concurrent requests of all instances reserves it
+func (i *idempotencyMiddleware) reserve(cacheKey string, fingerprint string) (map[string]string, error) {
+	inProgress := map[string]string{
+		"state":       idempotencyInProgress,
+		"fingerprint": fingerprint,
+	}
+
+	lockExpire := min(i.expire, idempotencyLockExpire)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		saved, err := i.cacheRepo.SaveIfAbsent(cacheKey, inProgress, lockExpire)
+		if err != nil {
+			return nil, err
+		}
+		if saved {
+			return nil, nil
+		}
+
+		stored, _, err := i.cacheRepo.Get(cacheKey)
+		if err == nil {
+			return stored, nil
+		}
+		if err != database_errors.ErrRecordNotFound && err != database_errors.ErrExpired {
+			return nil, err
+		}
+		// key is released by other request between save and get
+	}
+
+	// key is reserved and released repeatedly by other requests
+	return inProgress, nil
+}
+
+func requestFingerprint(method string, path string, query string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "?" + query + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, stored map[string]string) {
+	status, err := strconv.Atoi(stored["status"])
+	if err != nil {
+		status = http.StatusOK
+	}
+
+	if contentType := stored["content_type"]; contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set(IdempotencyReplayedHeader, "true")
+	if responseStatus, err := strconv.Atoi(stored["response_status"]); err == nil {
+		ReportStatus(w, responseStatus)
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, stored["body"])
+}
@@ -1,8 +1,8 @@
 package middleware
 
 import (
-	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
@@ -23,9 +23,10 @@ func (j *jsonMiddleware) EnsureApplicationJson(next http.Handler) http.Handler {
 
 		if r.Method == "POST" {
 
-			// return
-			if r.Header.Get("Content-Type") != "application/json" {
-				j.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, errors.New("header application/json is required"))
+			// multipart is used for file uploads
+			contentType := r.Header.Get("Content-Type")
+			if contentType != "application/json" && !strings.HasPrefix(contentType, "multipart/form-data") {
+				j.response.ErrorResponse(w, 400, rcodes.InvalidHeader, nil, interfaces_rest_v1_shared.ErrJSONContentTypeRequired)
 				return
 			}
 		}
@@ -34,16 +35,3 @@ func (j *jsonMiddleware) EnsureApplicationJson(next http.Handler) http.Handler {
 
 	})
 }
-
-func (j *jsonMiddleware) AddCORSHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// TODO: remove comment
-		// if r.Method == "POST" || r.Method == "GET" || r.Method == "PUT" || r.Method == "DELETE" {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, DELETE, PUT")
-		w.Header().Set("Access-Control-Allow-Headers", "content-type, access-control-allow-origin, accept, user-agent, authorization")
-		w.Header().Set("Access-Control-Allow-Max-Age", "86400")
-		next.ServeHTTP(w, r)
-		// }
-	})
-}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+const ContentLanguageHeader = "Content-Language"
+
+// choose language of response from Accept-Language header and set it in Content-Language header of response.
+// response writers read language of messages from Content-Language header
+func NegotiateLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(ContentLanguageHeader, AcceptedLanguage(r.Header.Get("Accept-Language")))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// supported language with highest quality in Accept-Language header. e.g: "fa-IR,fa;q=0.9,en;q=0.8" is fa.
+// default language is returned if no language is supported
+func AcceptedLanguage(header string) string {
+	language := service_errors.DefaultLanguage
+	bestQuality := 0.0
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			quality, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		// region is ignored. e.g: fa-IR is fa
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if quality > bestQuality && isSupportedLanguage(base) {
+			language = base
+			bestQuality = quality
+		}
+	}
+
+	return language
+}
+
+func isSupportedLanguage(language string) bool {
+	for _, l := range service_errors.SupportedLanguages {
+		if l == language {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// request id of client is accepted only if it is safe for logs
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// keeps status code for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// used by http.ResponseController
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func (s *statusRecorder) ReportStatus(status int) {
+	s.status = status
+	ReportStatus(s.ResponseWriter, status)
+}
+
+// implemented by recorders of middlewares. http status of v1 response can be different from status of response body
+type StatusReporter interface {
+	ReportStatus(status int)
+}
+
+// report status of response body to logging, metrics, tracing and idempotency recorders.
+// it must be called before response is written
+func ReportStatus(w http.ResponseWriter, status int) {
+	if reporter, ok := w.(StatusReporter); ok {
+		reporter.ReportStatus(status)
+	}
+}
+
+// assign request id to request and log method, path, status, latency and user id of every request.
+// request id is taken from X-Request-ID header if it is valid and is returned in response header
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDRegex.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := logger.WithRequestID(r.Context(), requestID)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+
+		slog.Log(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("ip", r.RemoteAddr),
+		)
+	})
+}
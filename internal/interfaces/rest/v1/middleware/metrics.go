@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+)
+
+// record count and latency of requests of a route. route is registered pattern (not request path) for keeping labels limited
+func InstrumentRoute(method string, route string, next http.Handler) http.Handler {
+	duration := metrics.HTTPRequestDuration.WithLabelValues(method, route)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		duration.Observe(time.Since(start).Seconds())
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	})
+}
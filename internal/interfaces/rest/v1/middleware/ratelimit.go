@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/ratelimit"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
+)
+
+const rateLimitKeyPrefix = "rate_limit:"
+
+// policies of route groups
+type RateLimitOptions struct {
+	Limiter        *ratelimit.Limiter // nil disables rate limiting
+	TrustedProxies []netip.Prefix
+	Auth           ratelimit.Policy
+	Public         ratelimit.Policy
+	Read           ratelimit.Policy
+	Write          ratelimit.Policy
+}
+
+type rateLimitMiddleware struct {
+	limiter        *ratelimit.Limiter
+	response       interfaces_rest_v1_shared.Response
+	trustedProxies []netip.Prefix
+}
+
+// limiter is nil if rate limiting is disabled
+func NewRateLimitMiddleware(limiter *ratelimit.Limiter, response interfaces_rest_v1_shared.Response, trustedProxies []netip.Prefix) rateLimitMiddleware {
+	return rateLimitMiddleware{
+		limiter:        limiter,
+		response:       response,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// limit requests of a route group with policy. requests are counted by user id if EnsureAuthentication is run before, otherwise by client ip.
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers are added to responses and Retry-After is added to 429 responses.
+// requests are not limited if store is not available
+func (rl *rateLimitMiddleware) Limit(group string, policy ratelimit.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if rl.limiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := rateLimitKeyPrefix + group + ":ip:" + utils.GetClientIP(r, rl.trustedProxies)
+			if user, ok := r.Context().Value("user").(app_user.JWTUser); ok {
+				key = rateLimitKeyPrefix + group + ":user:" + strconv.FormatUint(user.ID, 10)
+			}
+
+			result, err := rl.limiter.Allow(key, policy)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "cannot check rate limit", "group", group, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				rl.response.ErrorResponse(w, http.StatusTooManyRequests, rcodes.TooManyRequests, nil, interfaces_rest_v1_shared.ErrTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+)
+
+type recoveryMiddleware struct {
+	response interfaces_rest_v1_shared.Response
+}
+
+func NewRecoveryMiddleware(response interfaces_rest_v1_shared.Response) recoveryMiddleware {
+	return recoveryMiddleware{
+		response: response,
+	}
+}
+
+// panic of handler is logged with stack and is returned as server error. request id of response is kept.
+// nothing is written if response is already started
+func (rm *recoveryMiddleware) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			// used by net/http to abort response
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			err := fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+
+			if recorder.status != 0 {
+				slog.ErrorContext(r.Context(), "http handler panic after response is started", "error", err)
+				return
+			}
+
+			rm.response.ServerErrorResponse(w, err)
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// start server span of a route. trace of client is continued if traceparent header is sent.
+// trace id is returned in traceparent header of response
+func TraceRoute(method string, route string, next http.Handler) http.Handler {
+	name := method + " " + route
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("user_agent.original", r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
@@ -1,19 +1,32 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
 
 	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
 	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
 )
 
 type jsonResponse struct {
 }
 
+// http status of v1 is 200 for statuses of first version of api and status is only in body.
+// statuses that are added later are also sent as http status
+var legacyStatuses = map[int]bool{
+	http.StatusOK:                  true,
+	http.StatusSeeOther:            true,
+	http.StatusBadRequest:          true,
+	http.StatusUnauthorized:        true,
+	http.StatusNotFound:            true,
+	http.StatusInternalServerError: true,
+}
+
 func NewJSONResponse() interfaces_rest_v1_shared.Response {
 	return &jsonResponse{}
 }
@@ -24,15 +37,26 @@ func (j *jsonResponse) Response(w http.ResponseWriter, status int, code string,
 
 	w.Header().Add("Content-Type", "application/json")
 
+	middleware.ReportStatus(w, status)
+	if !legacyStatuses[status] {
+		w.WriteHeader(status)
+	}
+
 	json.NewEncoder(w).Encode(mapData)
 
-	slog.Info("request info",
+	// sensitive values of data are redacted by logger
+	slog.DebugContext(requestContext(w), "response",
 		slog.Int("status", status),
-		slog.Any("code", code),
+		slog.String("code", code),
 		slog.Any("data", mapData),
 	)
 }
 
+// context with request id of response for logging
+func requestContext(w http.ResponseWriter) context.Context {
+	return logger.WithRequestID(context.Background(), w.Header().Get(middleware.RequestIDHeader))
+}
+
 func (j *jsonResponse) StructResponse(w http.ResponseWriter, status int, code string, data any) {
 	outData := make(map[string]any)
 	outData["data"] = data
@@ -69,7 +93,7 @@ func (j *jsonResponse) ErrorResponse(w http.ResponseWriter, status int, code str
 }
 
 func (j *jsonResponse) ServerErrorResponse(w http.ResponseWriter, err error) {
-	slog.Error("SERVER ERROR", "error", err.Error())
+	slog.ErrorContext(requestContext(w), "server error", "error", err)
 	j.Response(w, http.StatusInternalServerError, "", map[string]any{"msg": "Server error"})
 }
 
@@ -79,12 +103,13 @@ func (j *jsonResponse) DTOErrorResponse(w http.ResponseWriter, responseDTO app_s
 	if responseDTO.ServerErr != nil {
 		j.ServerErrorResponse(w, responseDTO.ServerErr)
 	} else if responseDTO.UserErr != nil {
-		j.ErrorResponse(w, 400, responseDTO.ResponseCode, responseDTO.Data, responseDTO.UserErr)
+		status := interfaces_rest_v1_shared.DTOStatus(responseDTO.ResponseCode)
+		j.ErrorResponse(w, status, responseDTO.ResponseCode, responseDTO.Data, responseDTO.UserErr)
 	}
 
 }
 
 func (j *jsonResponse) InvalidJSONErrorResponse(w http.ResponseWriter, err error) {
 
-	j.ErrorResponse(w, 400, "invalid_json", nil, errors.New("invalid json"))
+	j.ErrorResponse(w, 400, "invalid_json", nil, interfaces_rest_v1_shared.ErrInvalidJSON)
 }
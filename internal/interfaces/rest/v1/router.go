@@ -3,121 +3,172 @@ package v1
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	app_account "github.com/yaghoubi-mn/pedarkharj/internal/application/account"
+	app_admin "github.com/yaghoubi-mn/pedarkharj/internal/application/admin"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
 	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
 	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
 	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	account_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/account"
+	admin_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/admin"
+	audit_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/audit"
+	debt_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/debt"
 	device_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/device"
 	expense_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/expense"
 	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
 	user_handler "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v2"
 )
 
-var URLs []string
-
-func NewRouter(userAppService app_user.UserAppService, deviceAppService app_device.DeviceAppService, expenseAppService app_expense.ExpenseAppService) *http.ServeMux {
-	mux := http.NewServeMux()
-	// authMux := http.NewServeMux()
+// routes of /api/v1/ and /api/v2/. both versions have same routes.
+// v1 errors are json with errors map of fields and v2 errors are RFC 7807 problem details with localized messages
+func NewRouter(userAppService app_user.UserAppService, deviceAppService app_device.DeviceAppService, expenseAppService app_expense.ExpenseAppService, debtAppService app_debt.DebtAppService, accountAppService app_account.AccountAppService, adminAppService app_admin.AdminAppService, auditAppService app_audit.AuditAppService, cacheRepo domain_shared.CacheRepository, idempotencyKeyExpire time.Duration, corsPolicy middleware.CORSPolicy, rateLimitOptions middleware.RateLimitOptions) *http.ServeMux {
+	newMux := func(version string, response interfaces_rest_v1_shared.Response, notFound http.HandlerFunc) http.Handler {
+		return newVersionMux(userAppService, deviceAppService, expenseAppService, debtAppService, accountAppService, adminAppService, auditAppService, cacheRepo, idempotencyKeyExpire, corsPolicy, rateLimitOptions, version, response, notFound)
+	}
 
-	// setup json response
+	// v1
 	jsonResponse := NewJSONResponse()
-
-	// setup auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(jsonResponse)
-
-	// handlers
-	userHandler := user_handler.NewHandler(userAppService, jsonResponse)
-	deviceHandler := device_handler.NewHandler(deviceAppService, jsonResponse)
-	expenseHandler := expense_handler.NewHandler(expenseAppService, jsonResponse)
-
-	// handle 404
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			data := make(map[string]any)
-			data["status"] = 404
-			data["msg"] = "page not found"
-			w.Header().Add("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(data)
-		}
+	v1Mux := newMux("v1", jsonResponse, func(w http.ResponseWriter, r *http.Request) {
+		data := make(map[string]any)
+		data["status"] = 404
+		data["msg"] = "page not found"
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(data)
 	})
 
-	// #user routes
-	// authentication
-	registerRouteFunc(mux, "POST", "/users/send-otp", userHandler.SendOTP)
-	registerRouteFunc(mux, "POST", "/users/verify-otp", userHandler.VerifyOTP)
-	registerRouteFunc(mux, "POST", "/users/signup", userHandler.SignupUser)
-	registerRouteFunc(mux, "POST", "/users/check-number", userHandler.CheckNumber)
-	registerRouteFunc(mux, "POST", "/users/login", userHandler.Login)
-	registerRouteFunc(mux, "POST", "/users/refresh", userHandler.GetAccessFromRefresh)
-	registerRouteFunc(mux, "POST", "/users/reset-password", userHandler.ResetPassword)
-	// user info
-	registerRoute(mux, "GET", "/users/info", authMiddleware.EnsureAuthentication(http.HandlerFunc(userHandler.GetUserInfo)))
-	// avatar
-	registerRoute(mux, "POST", "/users/avatar", authMiddleware.EnsureAuthentication(http.HandlerFunc(userHandler.ChooseUserAvatar)))
-	registerRouteFunc(mux, "GET", "/users/avatar", userHandler.GetAvatars)
-
-	// device routes
-	registerRoute(mux, "POST", "/devices/logout", authMiddleware.EnsureAuthentication(http.HandlerFunc((deviceHandler.Logout))))
-	registerRoute(mux, "POST", "/devices/logout-all", authMiddleware.EnsureAuthentication(http.HandlerFunc(deviceHandler.LogoutAllUserDevices)))
-
-	// expense routes
-	registerRoute(mux, "POST", "/expenses", authMiddleware.EnsureAuthentication(http.HandlerFunc(expenseHandler.Create)))
-
-	// connect muxes
-	// mux.Handle("/", authMiddleware.EnsureAuthentication(authMux))
+	// v2
+	problemResponse := v2.NewProblemResponse()
+	v2Mux := newMux("v2", problemResponse, func(w http.ResponseWriter, r *http.Request) {
+		problemResponse.ErrorResponse(w, http.StatusNotFound, "not_found", nil, interfaces_rest_v1_shared.ErrPageNotFound)
+	})
 
-	// setup json middleware
-	jsonMiddleware := middleware.NewJsonMiddleware(jsonResponse)
 	// setup main mux
 	m := http.NewServeMux()
-	m.Handle("/api/v1/", http.StripPrefix("/api/v1", jsonMiddleware.AddCORSHeaders(jsonMiddleware.EnsureApplicationJson(mux))))
+	auditRequestInfo := middleware.AuditRequestInfo(rateLimitOptions.TrustedProxies)
+	m.Handle("/api/v1/", middleware.RequestLogger(auditRequestInfo(http.StripPrefix("/api/v1", v1Mux))))
+	m.Handle("/api/v2/", middleware.RequestLogger(auditRequestInfo(http.StripPrefix("/api/v2", middleware.NegotiateLanguage(v2Mux)))))
 
-	URLs = nil
 	return m
 }
 
-func registerRouteFunc(mux *http.ServeMux, method string, url string, handler func(http.ResponseWriter, *http.Request)) {
+// routes of one version of api. errors are written with response
+func newVersionMux(userAppService app_user.UserAppService, deviceAppService app_device.DeviceAppService, expenseAppService app_expense.ExpenseAppService, debtAppService app_debt.DebtAppService, accountAppService app_account.AccountAppService, adminAppService app_admin.AdminAppService, auditAppService app_audit.AuditAppService, cacheRepo domain_shared.CacheRepository, idempotencyKeyExpire time.Duration, corsPolicy middleware.CORSPolicy, rateLimitOptions middleware.RateLimitOptions, version string, jsonResponse interfaces_rest_v1_shared.Response, notFound http.HandlerFunc) http.Handler {
+	rt := newRouter(jsonResponse, notFound)
 
-	registerRoute(mux, method, url, http.HandlerFunc(handler))
-
-}
-
-// func registerRouteFuncHelper(next http.Handler) http.Handler {
-// 	return
-// }
+	// setup auth middleware
+	authMiddleware := middleware.NewAuthMiddleware(jsonResponse, cacheRepo)
 
-func registerRoute(mux *http.ServeMux, method string, url string, handle http.Handler) {
+	// setup idempotency middleware. it is applied to mutating routes after authentication.
+	// Idempotency-Key is rejected on routes whose responses contain secrets (tokens, totp secret and recovery codes),
+	// because response bodies are stored in cache. public routes are rejected too, because keys are scoped to user
+	// and keys of clients behind one ip would be shared if they were scoped to ip
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(cacheRepo, jsonResponse, version, idempotencyKeyExpire)
 
-	mux.Handle(method+" "+url, handle)
+	// setup rate limit middleware. authenticated routes are limited by user and public routes by client ip
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(rateLimitOptions.Limiter, jsonResponse, rateLimitOptions.TrustedProxies)
 
-	// check url is already handled
-	if isURLAlreadyHandled(url) {
-		return
+	// handlers
+	userHandler := user_handler.NewHandler(userAppService, jsonResponse)
+	deviceHandler := device_handler.NewHandler(deviceAppService, jsonResponse)
+	expenseHandler := expense_handler.NewHandler(expenseAppService, jsonResponse)
+	debtHandler := debt_handler.NewHandler(debtAppService, jsonResponse)
+	accountHandler := account_handler.NewHandler(accountAppService, jsonResponse)
+	adminHandler := admin_handler.NewHandler(adminAppService, jsonResponse)
+	auditHandler := audit_handler.NewHandler(auditAppService, jsonResponse)
+
+	// groups
+	public := rt.Group("", rateLimitMiddleware.Limit("public", rateLimitOptions.Public))
+	authenticated := rt.Group("", authMiddleware.EnsureAuthentication, rateLimitMiddleware.Limit("read", rateLimitOptions.Read))
+	// body of idempotent routes is limited before it is read for fingerprint
+	newIdempotent := func(maxBodySize int64) *routeGroup {
+		return rt.Group("", authMiddleware.EnsureAuthentication, rateLimitMiddleware.Limit("write", rateLimitOptions.Write), middleware.LimitBody(maxBodySize), idempotencyMiddleware.EnsureIdempotency)
 	}
-	saveURLAsHandled(url)
-
-	mux.HandleFunc("OPTIONS "+url, func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, DELETE, PUT")
-		w.Header().Set("Access-Control-Allow-Headers", "content-type, access-control-allow-origin, accept, user-agent, authorization")
-		w.Header().Set("Access-Control-Allow-Max-Age", "86400")
-	})
-}
+	idempotent := newIdempotent(config.MaxBodySize)
+	// mutating routes that return tokens
+	secretWrites := rt.Group("", authMiddleware.EnsureAuthentication, rateLimitMiddleware.Limit("write", rateLimitOptions.Write), middleware.LimitBody(config.MaxBodySize), idempotencyMiddleware.RejectIdempotency)
 
-func saveURLAsHandled(url string) {
-	if URLs == nil {
-		URLs = make([]string, 10)
-	}
+	// #user routes
+	// authentication
+	users := rt.Group("/users", rateLimitMiddleware.Limit("auth", rateLimitOptions.Auth), idempotencyMiddleware.RejectIdempotency)
+	users.HandleFunc("POST", "/send-otp", userHandler.SendOTP)
+	users.HandleFunc("POST", "/verify-otp", userHandler.VerifyOTP)
+	users.HandleFunc("POST", "/signup", userHandler.SignupUser)
+	users.HandleFunc("POST", "/check-number", userHandler.CheckNumber)
+	users.HandleFunc("POST", "/login", userHandler.Login)
+	users.HandleFunc("POST", "/refresh", userHandler.GetAccessFromRefresh)
+	users.HandleFunc("POST", "/reset-password", userHandler.ResetPassword)
+	users.HandleFunc("POST", "/2fa/verify", userHandler.VerifyTwoFactor)
+	public.HandleFunc("GET", "/users/avatar", userHandler.GetAvatars)
+
+	authenticatedUsers := authenticated.Group("/users")
+	// two factor. responses contain totp secret and recovery codes
+	twoFactor := authenticatedUsers.Group("/2fa", idempotencyMiddleware.RejectIdempotency)
+	twoFactor.HandleFunc("POST", "/enroll", userHandler.EnrollTwoFactor)
+	twoFactor.HandleFunc("POST", "/confirm", userHandler.ConfirmTwoFactor)
+	// user info
+	authenticatedUsers.HandleFunc("GET", "/info", userHandler.GetUserInfo)
+	// account
+	authenticatedUsers.HandleFunc("GET", "/export", accountHandler.GetExport)
+	// audit log
+	authenticatedUsers.HandleFunc("GET", "/activity", auditHandler.GetMyActivity)
+
+	// profile returns new access token and change number returns access and refresh tokens
+	secretWriteUsers := secretWrites.Group("/users")
+	secretWriteUsers.HandleFunc("POST", "/profile", userHandler.UpdateProfile)
+	secretWriteUsers.HandleFunc("POST", "/change-number/verify", userHandler.VerifyChangeNumber)
+
+	idempotentUsers := idempotent.Group("/users")
+	idempotentUsers.HandleFunc("POST", "/2fa/disable", userHandler.DisableTwoFactor)
+	// change number
+	idempotentUsers.HandleFunc("POST", "/change-number/send-otp", userHandler.SendChangeNumberOTP)
+	// avatar
+	idempotentUsers.HandleFunc("POST", "/avatar", userHandler.ChooseUserAvatar)
+	newIdempotent(config.AvatarMaxBodySize).HandleFunc("POST", "/users/avatar/upload", userHandler.UploadAvatar)
+	// account
+	idempotentUsers.HandleFunc("POST", "/export", accountHandler.RequestExport)
+	idempotentUsers.HandleFunc("POST", "/delete-account", accountHandler.DeleteAccount)
 
-	URLs = append(URLs, url)
-}
+	// device routes
+	devices := idempotent.Group("/devices")
+	devices.HandleFunc("POST", "/logout", deviceHandler.Logout)
+	devices.HandleFunc("POST", "/logout-all", deviceHandler.LogoutAllUserDevices)
 
-func isURLAlreadyHandled(url string) bool {
-	for _, u := range URLs {
-		if url == u {
-			return true
-		}
-	}
+	// expense routes
+	authenticated.HandleFunc("GET", "/expenses/{id}", expenseHandler.Get)
+	expenses := idempotent.Group("/expenses")
+	expenses.HandleFunc("POST", "", expenseHandler.Create)
+	expenses.HandleFunc("PUT", "/{id}", expenseHandler.Update)
+	expenses.HandleFunc("DELETE", "/{id}", expenseHandler.Delete)
+
+	// debt routes
+	authenticated.HandleFunc("GET", "/debts/{id}", debtHandler.Get)
+	debts := idempotent.Group("/debts")
+	debts.HandleFunc("POST", "/{id}/accept", debtHandler.Accept)
+	debts.HandleFunc("POST", "/{id}/pay", debtHandler.Pay)
+	debts.HandleFunc("POST", "/{id}/accept-payment", debtHandler.AcceptPayment)
+	debts.HandleFunc("DELETE", "/{id}", debtHandler.Delete)
+
+	// admin routes
+	admin := authenticated.Group("/admin")
+	admin.HandleFunc("GET", "/users", adminHandler.SearchUsers)
+	admin.HandleFunc("GET", "/users/{id}", adminHandler.GetUser)
+	admin.HandleFunc("GET", "/stats", adminHandler.GetStats)
+	admin.HandleFunc("GET", "/audit-logs", adminHandler.GetAuditLogs)
+	idempotentAdmin := idempotent.Group("/admin")
+	idempotentAdmin.HandleFunc("POST", "/users/block", adminHandler.BlockUser)
+	idempotentAdmin.HandleFunc("POST", "/users/logout", adminHandler.LogoutUser)
+
+	// setup json and cors middlewares
+	jsonMiddleware := middleware.NewJsonMiddleware(jsonResponse)
+	corsMiddleware := middleware.NewCORSMiddleware(corsPolicy, rt.AllowedMethods)
 
-	return false
+	return corsMiddleware.HandleCORS(jsonMiddleware.EnsureApplicationJson(rt))
 }
@@ -0,0 +1,50 @@
+package interfaces_rest_v1_shared
+
+import (
+	"net/http"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+// errors of request that are found before calling application services
+var (
+	ErrPageNotFound               = service_errors.New(http.StatusNotFound, "not_found", "", "page not found")
+	ErrMethodNotAllowed           = service_errors.New(http.StatusMethodNotAllowed, "method_not_allowed", "", "method not allowed")
+	ErrTooManyRequests            = service_errors.New(http.StatusTooManyRequests, "too_many_requests", "", "too many requests. retry later")
+	ErrAuthenticationRequired     = service_errors.New(http.StatusUnauthorized, "authentication_required", "", "authentication is required")
+	ErrInvalidAuthorizationHeader = service_errors.New(http.StatusBadRequest, "invalid_authorization_header", "", "invalid Authorization header format")
+	ErrInvalidAccessToken         = service_errors.New(http.StatusUnauthorized, "invalid_access_token", "authorization", "invalid token")
+	ErrJSONContentTypeRequired    = service_errors.New(http.StatusBadRequest, "json_content_type_required", "", "header application/json is required")
+	ErrInvalidJSON                = service_errors.New(http.StatusBadRequest, "invalid_json", "", "invalid json")
+	ErrCannotReadBody             = service_errors.New(http.StatusBadRequest, "cannot_read_body", "", "cannot read body")
+	ErrBodyTooLarge               = service_errors.New(http.StatusRequestEntityTooLarge, "body_too_large", "", "request body is too large")
+	ErrIdempotencyKeyTooLong      = service_errors.New(http.StatusBadRequest, "idempotency_key_too_long", "", "Idempotency-Key header is too long")
+	ErrIdempotencyKeyMismatch     = service_errors.New(http.StatusUnprocessableEntity, "idempotency_key_mismatch", "", "Idempotency-Key is already used for another request")
+	ErrIdempotencyKeyInProgress   = service_errors.New(http.StatusConflict, "idempotency_key_in_progress", "", "request with this Idempotency-Key is in progress")
+	ErrIdempotencyKeyNotSupported = service_errors.New(http.StatusBadRequest, "idempotency_key_not_supported", "", "Idempotency-Key is not supported for this route. response of this route is not stored")
+	ErrInvalidIfMatch             = service_errors.New(http.StatusBadRequest, "invalid_if_match", "", "If-Match header must be ETag of resource")
+	ErrCannotReadAvatar           = service_errors.New(http.StatusBadRequest, "cannot_read_avatar", "avatar", "cannot read avatar file")
+	ErrPageNotNumber              = service_errors.New(http.StatusBadRequest, "invalid_page", "page", "page must be a number")
+	ErrLimitNotNumber             = service_errors.New(http.StatusBadRequest, "invalid_limit", "limit", "limit must be a number")
+	ErrActorIDNotNumber           = service_errors.New(http.StatusBadRequest, "invalid_actor_id", "actor_id", "actor_id must be a number")
+	ErrTargetIDNotNumber          = service_errors.New(http.StatusBadRequest, "invalid_target_id", "target_id", "target_id must be a number")
+	ErrFromNotTime                = service_errors.New(http.StatusBadRequest, "invalid_from", "from", "from must be RFC3339 time")
+	ErrToNotTime                  = service_errors.New(http.StatusBadRequest, "invalid_to", "to", "to must be RFC3339 time")
+)
+
+// http status of user error of application services by response code
+func DTOStatus(responseCode string) int {
+	switch responseCode {
+	case rcodes.PermissionDenied:
+		return http.StatusForbidden
+	case rcodes.UserNotFound, rcodes.ExpenseNotFound, rcodes.DebtNotFound:
+		return http.StatusNotFound
+	case rcodes.VersionConflict, rcodes.DebtNotPaid:
+		return http.StatusConflict
+	case rcodes.TooManyRequests:
+		return http.StatusTooManyRequests
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,32 @@
+package interfaces_rest_v1_shared
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// strong ETag of a version of resource. e.g: "3"
+func ETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// version in If-Match header. zero is returned if header is not set or is *
+func IfMatchVersion(r *http.Request) (uint64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 3 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, ErrInvalidIfMatch
+	}
+
+	version, err := strconv.ParseUint(value[1:len(value)-1], 10, 64)
+	if err != nil || version == 0 {
+		return 0, ErrInvalidIfMatch
+	}
+
+	return version, nil
+}
@@ -3,10 +3,14 @@ package user
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
 	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
 	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
 )
 
@@ -22,6 +26,11 @@ func NewHandler(appService app_user.UserAppService, response interfaces_rest_v1_
 	}
 }
 
+// ip of client is added to context by audit middleware
+func clientIP(r *http.Request) string {
+	return app_audit.RequestInfoFromContext(r.Context()).IP
+}
+
 // SendOTP godoc
 // @Summery verify number
 // @Description verify number with sms
@@ -50,7 +59,7 @@ func (h *Handler) SendOTP(w http.ResponseWriter, r *http.Request) {
 	// userAgent := utils.GetUserAgent(r)
 	// userIP := utils.GetIPAddress(r)
 
-	responseDTO := h.appService.SendOTP(input) //, userAgent, userIP)
+	responseDTO := h.appService.SendOTP(r.Context(), input) //, userAgent, userIP)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -71,7 +80,7 @@ func (h *Handler) SendOTP(w http.ResponseWriter, r *http.Request) {
 // @Param otp body int true "OTP code" example(12345)
 // @Param token body string true "Token"
 // @Param mode body string true "verify mode" example("signup" or "reset_password")
-// @Success 303 "Success<br>Ok. code: go_reset_password <br>Ok. code: go_signup. verify number done. user must signup"
+// @Success 303 "Success<br>Ok. code: go_reset_password <br>Ok. code: go_signup. verify number done. user must signup<br>Ok. code: two_factor_required. go to verify two factor with challenge_token"
 // @Failure 500
 // @Failure 400 "BadRequest:<br>code=go_send_otp_first: Must go to send-otp first.<br>code=wrong_otp: The OTP is wrong.<br>code=invalid_field: a field is invalid"
 // @Router /users/verify-otp [post]
@@ -88,9 +97,9 @@ func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userAgent := utils.GetUserAgent(r)
-	userIP := utils.GetIPAddress(r)
+	userIP := clientIP(r)
 
-	mode, responseDTO := h.appService.VerifyOTP(input, userAgent, userIP)
+	mode, responseDTO := h.appService.VerifyOTP(r.Context(), input, userAgent, userIP)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -110,6 +119,13 @@ func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// user sent otp code and otp is currect. user must pass two factor before reset password
+	if mode == 3 {
+		responseDTO.Data["msg"] = "Two factor authentication required"
+		h.response.Response(w, 303, responseDTO.ResponseCode, responseDTO.Data)
+		return
+	}
+
 	h.response.ServerErrorResponse(w, errors.New("unhandled state"))
 
 }
@@ -141,9 +157,9 @@ func (h *Handler) SignupUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userAgent := utils.GetUserAgent(r)
-	userIP := utils.GetIPAddress(r)
+	userIP := clientIP(r)
 
-	responseDTO := h.appService.Signup(userInput, userAgent, userIP)
+	responseDTO := h.appService.Signup(r.Context(), userInput, userAgent, userIP)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -160,7 +176,7 @@ func (h *Handler) SignupUser(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param number body string true "phone number" example(+98123456789)
 // @Param password body string true "Password"
-// @Success 200
+// @Success 200 "Ok<br>Ok. code: two_factor_required. go to verify two factor with challenge_token"
 // @Failure 500
 // @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
 // @Router /users/login [post]
@@ -176,7 +192,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.Login(userInput, utils.GetUserAgent(r), utils.GetIPAddress(r))
+	responseDTO := h.appService.Login(r.Context(), userInput, utils.GetUserAgent(r), clientIP(r))
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -210,7 +226,7 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.ResetPassword(input)
+	responseDTO := h.appService.ResetPassword(r.Context(), input)
 	if responseDTO.UserErr != nil || responseDTO.ServerErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -244,7 +260,7 @@ func (h *Handler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.GetUserInfo(user.ID)
+	responseDTO := h.appService.GetUserInfo(r.Context(), user.ID)
 
 	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
 }
@@ -273,7 +289,7 @@ func (h *Handler) CheckNumber(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.CheckNumber(numberInput)
+	responseDTO := h.appService.CheckNumber(r.Context(), numberInput)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -306,7 +322,7 @@ func (h *Handler) GetAccessFromRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.GetAccessFromRefresh(refreshInput.Refresh)
+	responseDTO := h.appService.GetAccessFromRefresh(r.Context(), refreshInput.Refresh)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -351,7 +367,7 @@ func (h *Handler) ChooseUserAvatar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responseDTO := h.appService.ChooseUserAvatar(avatarInput.Avatar, user.ID)
+	responseDTO := h.appService.ChooseUserAvatar(r.Context(), avatarInput.Avatar, user.ID)
 	if responseDTO.UserErr != nil || responseDTO.ServerErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -371,7 +387,332 @@ func (h *Handler) ChooseUserAvatar(w http.ResponseWriter, r *http.Request) {
 // @Router /users/avatar [get]
 func (h *Handler) GetAvatars(w http.ResponseWriter, r *http.Request) {
 
-	responseDTO := h.appService.GetAvatars()
+	responseDTO := h.appService.GetAvatars(r.Context())
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// EnrollTwoFactor godoc
+// @Summary Enroll two factor authentication
+// @Description Generate totp secret and provisioning uri. Two factor is enabled after confirm (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "secret and uri"
+// @Failure 400 "BadRequest:<br>code=two_factor_already_enabled: two factor is already enabled"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+
+	user, ok := r.Context().Value("user").(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.EnrollTwoFactor(r.Context(), user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two factor authentication
+// @Description Enable two factor with a code from authenticator app. Recovery codes are returned only once (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_user.TwoFactorCodeInput true "TOTP code"
+// @Success 200 {object} map[string]interface{} "recovery codes"
+// @Failure 400 "BadRequest:<br>code=enroll_two_factor_first: enroll first<br>code=two_factor_already_enabled: two factor is already enabled<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/2fa/confirm [post]
+func (h *Handler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+
+	var input app_user.TwoFactorCodeInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	user, ok := r.Context().Value("user").(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.ConfirmTwoFactor(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// VerifyTwoFactor godoc
+// @Summary Verify two factor challenge
+// @Description Second step of login and reset password. code or recovery_code is required
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param input body app_user.TwoFactorVerifyInput true "challenge token and code"
+// @Success 200 "Ok. login tokens<br>Ok. code: go_reset_password"
+// @Failure 400 "BadRequest:<br>code=challenge_expired: login again<br>code=invalid_field: a field is invalid"
+// @Failure 500
+// @Router /users/2fa/verify [post]
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+
+	var input app_user.TwoFactorVerifyInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	responseDTO := h.appService.VerifyTwoFactor(r.Context(), input)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two factor authentication
+// @Description Disable two factor. password and code (or recovery_code) are required (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_user.TwoFactorDisableInput true "password and code"
+// @Success 200 {object} map[string]interface{} "two factor disabled"
+// @Failure 400 "BadRequest:<br>code=two_factor_not_enabled: two factor is not enabled<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+
+	var input app_user.TwoFactorDisableInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	user, ok := r.Context().Value("user").(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.DisableTwoFactor(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// UpdateProfile godoc
+// @Summary Update user profile
+// @Description Update name, language and currency. empty fields are not changed (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_user.UpdateProfileInput true "profile fields"
+// @Success 200 {object} map[string]interface{} "updated profile and new access token"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/profile [post]
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+
+	var input app_user.UpdateProfileInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.UpdateProfile(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// SendChangeNumberOTP godoc
+// @Summary Send otp for changing number
+// @Description Send otp to both current number and new number (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_user.ChangeNumberInput true "new phone number"
+// @Success 200 "Ok. code: code_sent_to_number"
+// @Failure 400 "BadRequest:<br>code=number_delay: Wait some minutes.<br>code=user_already_registered: new number is registered<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/change-number/send-otp [post]
+func (h *Handler) SendChangeNumberOTP(w http.ResponseWriter, r *http.Request) {
+
+	var input app_user.ChangeNumberInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.SendChangeNumberOTP(r.Context(), input, user.ID)
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	responseDTO.Data["msg"] = "Code sent to numbers"
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// VerifyChangeNumber godoc
+// @Summary Verify changing number
+// @Description Verify otp of current number and new number and change number. history of unregistered user with new number is merged (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body app_user.VerifyChangeNumberInput true "token and otp codes"
+// @Success 200 {object} map[string]interface{} "new tokens"
+// @Failure 400 "BadRequest:<br>code=go_send_otp_first: Must go to send otp first.<br>code=wrong_otp: The OTP is wrong.<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/change-number/verify [post]
+func (h *Handler) VerifyChangeNumber(w http.ResponseWriter, r *http.Request) {
+
+	var input app_user.VerifyChangeNumberInput
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&input)
+	if err != nil {
+		h.response.InvalidJSONErrorResponse(w, err)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.VerifyChangeNumber(r.Context(), input, user.ID, utils.GetUserAgent(r), clientIP(r))
+	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
+		h.response.DTOErrorResponse(w, responseDTO)
+		return
+	}
+
+	h.response.Response(w, 200, responseDTO.ResponseCode, responseDTO.Data)
+}
+
+// UploadAvatar godoc
+// @Summary Upload user avatar
+// @Description Upload jpeg or png photo as avatar. photo is cropped to square and metadata is removed (Authentication Required)
+// @Tags users
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param avatar formData file true "avatar image"
+// @Success 200 {object} map[string]interface{} "avatar urls"
+// @Failure 400 "BadRequest:<br>code=invalid_field: a field is invalid"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/avatar/upload [post]
+func (h *Handler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+
+	r.Body = http.MaxBytesReader(w, r.Body, config.AvatarMaxBodySize)
+	defer r.Body.Close()
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, interfaces_rest_v1_shared.ErrCannotReadAvatar)
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, config.AvatarMaxSize+1))
+	if err != nil {
+		h.response.ErrorResponse(w, 400, rcodes.InvalidField, nil, interfaces_rest_v1_shared.ErrCannotReadAvatar)
+		return
+	}
+
+	iUser := r.Context().Value("user")
+	if iUser == nil {
+		h.response.ServerErrorResponse(w, errors.New("user is nil in request context"))
+		return
+	}
+
+	user, ok := iUser.(app_user.JWTUser)
+	if !ok {
+		h.response.ServerErrorResponse(w, errors.New("cannot cast request context user"))
+		return
+	}
+
+	responseDTO := h.appService.UploadAvatar(r.Context(), image, user.ID)
 	if responseDTO.ServerErr != nil || responseDTO.UserErr != nil {
 		h.response.DTOErrorResponse(w, responseDTO)
 		return
@@ -0,0 +1,144 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	// type of problem is this prefix and code of problem. e.g: urn:pedarkharj:problem:invalid_name
+	ProblemTypePrefix = "urn:pedarkharj:problem:"
+	InternalErrorCode = "internal_error"
+)
+
+// error of problem details. code is stable and message is localized
+type ProblemError struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writes errors as RFC 7807 problem details. success responses are same as v1.
+// language of messages is read from Content-Language header that is set by middleware.NegotiateLanguage
+type problemResponse struct {
+}
+
+func NewProblemResponse() interfaces_rest_v1_shared.Response {
+	return &problemResponse{}
+}
+
+func (p *problemResponse) Response(w http.ResponseWriter, status int, code string, mapData map[string]any) {
+	mapData["code"] = code
+	mapData["status"] = status
+
+	p.write(w, "application/json", status, mapData)
+}
+
+func (p *problemResponse) StructResponse(w http.ResponseWriter, status int, code string, data any) {
+	outData := make(map[string]any)
+	outData["data"] = data
+	p.Response(w, status, code, outData)
+}
+
+// status and code of first typed error are used instead of status and code. other keys of data are added to problem as extension members.
+// e.g: current expense on version conflict
+func (p *problemResponse) ErrorResponse(w http.ResponseWriter, status int, code string, data map[string]any, errs ...error) {
+	if errs == nil {
+		slog.Error("err is required in ProblemErrorResponse")
+	}
+
+	language := w.Header().Get(middleware.ContentLanguageHeader)
+	if language == "" {
+		language = service_errors.DefaultLanguage
+	}
+
+	problemErrors := make([]ProblemError, 0, len(errs))
+	for i, err := range errs {
+		problemError := ProblemError{Code: code, Message: err.Error()}
+
+		var typedErr *service_errors.Error
+		if errors.As(err, &typedErr) {
+			problemError = ProblemError{Field: typedErr.Field, Code: typedErr.Code, Message: typedErr.Localize(language)}
+			if i == 0 {
+				status = typedErr.Status
+				code = typedErr.Code
+			}
+		}
+
+		problemErrors = append(problemErrors, problemError)
+	}
+
+	problem := make(map[string]any)
+	for key, value := range data {
+		problem[key] = value
+	}
+
+	problem["type"] = ProblemTypePrefix + code
+	problem["title"] = http.StatusText(status)
+	problem["status"] = status
+	problem["code"] = code
+	problem["errors"] = problemErrors
+	if len(problemErrors) > 0 {
+		problem["detail"] = problemErrors[0].Message
+	}
+	if requestID := w.Header().Get(middleware.RequestIDHeader); requestID != "" {
+		problem["request_id"] = requestID
+	}
+
+	p.write(w, ProblemContentType, status, problem)
+}
+
+func (p *problemResponse) ServerErrorResponse(w http.ResponseWriter, err error) {
+	slog.ErrorContext(requestContext(w), "server error", "error", err)
+	p.ErrorResponse(w, http.StatusInternalServerError, InternalErrorCode, nil, service_errors.ErrInternalServerError)
+}
+
+// check ServerErr and UserErr
+func (p *problemResponse) DTOErrorResponse(w http.ResponseWriter, responseDTO app_shared.ResponseDTO) {
+	if responseDTO.ServerErr != nil {
+		p.ServerErrorResponse(w, responseDTO.ServerErr)
+	} else if responseDTO.UserErr != nil {
+		data := make(map[string]any)
+		for key, value := range responseDTO.Data {
+			// msg of data is detail of problem
+			if key != "msg" {
+				data[key] = value
+			}
+		}
+
+		status := interfaces_rest_v1_shared.DTOStatus(responseDTO.ResponseCode)
+		p.ErrorResponse(w, status, responseDTO.ResponseCode, data, responseDTO.UserErr)
+	}
+}
+
+func (p *problemResponse) InvalidJSONErrorResponse(w http.ResponseWriter, err error) {
+	p.ErrorResponse(w, http.StatusBadRequest, "invalid_json", nil, interfaces_rest_v1_shared.ErrInvalidJSON)
+}
+
+func (p *problemResponse) write(w http.ResponseWriter, contentType string, status int, body map[string]any) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(body)
+
+	// sensitive values of data are redacted by logger
+	slog.DebugContext(requestContext(w), "response",
+		slog.Int("status", status),
+		slog.Any("data", body),
+	)
+}
+
+// context with request id of response for logging
+func requestContext(w http.ResponseWriter) context.Context {
+	return logger.WithRequestID(context.Background(), w.Header().Get(middleware.RequestIDHeader))
+}
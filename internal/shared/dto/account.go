@@ -0,0 +1,59 @@
+package shared_dto
+
+import "time"
+
+type DeleteAccountInput struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`          // required if two factor is enabled
+	RecoveryCode string `json:"recovery_code"` // can be used instead of code
+}
+
+type ExportProfileOutput struct {
+	ID            uint64    `json:"id"`
+	Name          string    `json:"name"`
+	Number        string    `json:"number"`
+	Avatar        string    `json:"avatar"`
+	RegisteredAt  time.Time `json:"registered_at"`
+	IsTOTPEnabled bool      `json:"is_totp_enabled"`
+}
+
+type ExportDeviceOutput struct {
+	Name       string    `json:"name"`
+	LastIP     string    `json:"last_ip"`
+	FirstLogin time.Time `json:"first_login"`
+	LastLogin  time.Time `json:"last_login"`
+}
+
+type ExportExpenseOutput struct {
+	ID          uint64    `json:"id"`
+	CreatorID   uint64    `json:"creator_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	TotalAmount uint64    `json:"total_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ExportDebtOutput struct {
+	ID                           uint64 `json:"id"`
+	ExpenseID                    uint64 `json:"expense_id"`
+	CreditorID                   uint64 `json:"creditor_id"`
+	DebtorID                     uint64 `json:"debtor_id"`
+	Amount                       uint64 `json:"amount"`
+	IsCreditorAccepted           bool   `json:"is_creditor_accepted"`
+	IsDebtorAccepted             bool   `json:"is_debtor_accepted"`
+	IsCreditorRejected           bool   `json:"is_creditor_rejected"`
+	IsDebtorRejected             bool   `json:"is_debtor_rejected"`
+	IsPaid                       bool   `json:"is_paid"`
+	IsPaymentAccepted            bool   `json:"is_payment_accepted"`
+	IsCreditorRequestedForDelete bool   `json:"is_creditor_requested_for_delete"`
+	IsDebtorRequestedForDelete   bool   `json:"is_debtor_requested_for_delete"`
+}
+
+type ExportCommentOutput struct {
+	ID        uint64    `json:"id"`
+	ExpenseID uint64    `json:"expense_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
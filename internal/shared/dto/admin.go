@@ -0,0 +1,53 @@
+package shared_dto
+
+import "time"
+
+type SearchUsersInput struct {
+	Query string `json:"query"` // part of name or number
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+type BlockUserInput struct {
+	UserID    uint64 `json:"user_id"`
+	IsBlocked bool   `json:"is_blocked"`
+	Reason    string `json:"reason"`
+}
+
+type UserIDInput struct {
+	UserID uint64 `json:"user_id"`
+}
+
+type AdminUserOutput struct {
+	ID            uint64    `json:"id"`
+	Name          string    `json:"name"`
+	Number        string    `json:"number"`
+	Avatar        string    `json:"avatar"`
+	Role          string    `json:"role"`
+	RegisteredAt  time.Time `json:"registered_at"`
+	IsRegistered  bool      `json:"is_registered"`
+	IsBlocked     bool      `json:"is_blocked"`
+	IsDeleted     bool      `json:"is_deleted"`
+	IsTOTPEnabled bool      `json:"is_totp_enabled"`
+}
+
+type AdminDeviceOutput struct {
+	ID         uint64    `json:"id"`
+	Name       string    `json:"name"`
+	LastIP     string    `json:"last_ip"`
+	FirstLogin time.Time `json:"first_login"`
+	LastLogin  time.Time `json:"last_login"`
+	IsActive   bool      `json:"is_active"` // device has valid refresh token
+}
+
+type AdminStatsOutput struct {
+	TotalUsers      int64 `json:"total_users"`
+	RegisteredUsers int64 `json:"registered_users"`
+	BlockedUsers    int64 `json:"blocked_users"`
+	DeletedUsers    int64 `json:"deleted_users"`
+	NewUsersToday   int64 `json:"new_users_today"`
+	ActiveDevices   int64 `json:"active_devices"`
+	TotalExpenses   int64 `json:"total_expenses"`
+	TotalDebts      int64 `json:"total_debts"`
+	UnpaidDebts     int64 `json:"unpaid_debts"`
+}
@@ -0,0 +1,31 @@
+package shared_dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditLogsInput struct {
+	ActorID    uint64    `json:"actor_id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   uint64    `json:"target_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+}
+
+type AuditLogOutput struct {
+	ID         uint64          `json:"id"`
+	ActorID    uint64          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   uint64          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -1 +1,18 @@
 package shared_dto
+
+type DebtOutput struct {
+	ID                           uint64 `json:"id"`
+	ExpenseID                    uint64 `json:"expense_id"`
+	CreditorID                   uint64 `json:"creditor_id"`
+	DebtorID                     uint64 `json:"debtor_id"`
+	Amount                       uint64 `json:"amount"`
+	IsCreditorAccepted           bool   `json:"is_creditor_accepted"`
+	IsDebtorAccepted             bool   `json:"is_debtor_accepted"`
+	IsCreditorRejected           bool   `json:"is_creditor_rejected"`
+	IsDebtorRejected             bool   `json:"is_debtor_rejected"`
+	IsPaid                       bool   `json:"is_paid"`
+	IsPaymentAccepted            bool   `json:"is_payment_accepted"`
+	IsCreditorRequestedForDelete bool   `json:"is_creditor_requested_for_delete"`
+	IsDebtorRequestedForDelete   bool   `json:"is_debtor_requested_for_delete"`
+	Version                      uint64 `json:"version"`
+}
@@ -22,6 +22,17 @@ type ExpenseUpdateInput struct {
 	Description string `validate:"description"`
 }
 
+type ExpenseOutput struct {
+	ID          uint64    `json:"id"`
+	CreatorID   uint64    `json:"creator_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	TotalAmount uint64    `json:"total_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     uint64    `json:"version"`
+}
+
 type ExpenseDebtOuput struct {
 	// expense
 	ID          uint64    `json:"id"`
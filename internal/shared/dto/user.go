@@ -40,3 +40,36 @@ type ResetPasswordInput struct {
 	Password    string `json:"password"`
 	Token       string `json:"token"`
 }
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorVerifyInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required,uuid"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorDisableInput struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// empty fields are not changed
+type UpdateProfileInput struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Currency string `json:"currency"`
+}
+
+type ChangeNumberInput struct {
+	NewPhoneNumber string `json:"new_number" validate:"required,phone_number"`
+}
+
+type VerifyChangeNumberInput struct {
+	Token  string `json:"token" validate:"required,uuid"`
+	OldOTP uint   `json:"old_otp" validate:"required"` // otp sent to current number
+	NewOTP uint   `json:"new_otp" validate:"required"` // otp sent to new number
+}
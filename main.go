@@ -1,45 +1,71 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 	_ "github.com/yaghoubi-mn/pedarkharj/docs"
+	app_account "github.com/yaghoubi-mn/pedarkharj/internal/application/account"
+	app_admin "github.com/yaghoubi-mn/pedarkharj/internal/application/admin"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
 	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
 	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
 	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
+	app_outbox "github.com/yaghoubi-mn/pedarkharj/internal/application/outbox"
 	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_account "github.com/yaghoubi-mn/pedarkharj/internal/domain/account"
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
 	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
 	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
 	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
 	domain_shared "github.com/yaghoubi-mn/pedarkharj/internal/domain/shared"
 	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
-	_ "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
 	gorm_repository "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/gorm"
+	interfaces_grpc_v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1"
 	interfaces_rest_v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/database"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/eventbus"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/health"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/ratelimit"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/s3"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/server"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/sms"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
 	"gorm.io/gorm"
 )
 
+// set at build time: go build -ldflags "-X main.version=1.2.0"
+var version = "dev"
+
 // @title Pedarkharj
 // @version 1.0.0
-// @description Pedarkharj project
+// @description Pedarkharj project. routes of v2 (/api/v2) are same as v1 and errors of v2 are problem details (application/problem+json)
 // @host localhost:1111
 // @BasePath /api/v1
 func main() {
-	// setup logger
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	}))
-
-	slog.SetDefault(logger)
+	// setup logger. level and format are changed after loading config
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(slog.LevelDebug)
+	slog.SetDefault(slog.New(logger.NewHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	}))))
 
 	// load .env variables
 	err := godotenv.Load()
@@ -47,87 +73,421 @@ func main() {
 		slog.Warn("Cannot load env variables", "error", err.Error())
 	}
 
+	cfg, command, err := config.Load(os.Args[1:])
+	if err != nil {
+		slog.Error("config", "error", err)
+		os.Exit(2)
+	}
+	cfg.Apply()
+
+	if err := setupLogger(cfg.Log, cfg.Debug, logLevel); err != nil {
+		slog.Error("config", "error", err)
+		os.Exit(2)
+	}
+
+	if !cfg.Debug {
+		slog.Info("---Debug mode is off---")
+	}
+
+	if len(command) > 0 {
+		switch command[0] {
+		case "config":
+			os.Exit(runConfig(cfg, command[1:]))
+
+		case "migrate":
+			// create migration files does not need database
+			if len(command) > 1 && command[1] == "create" {
+				os.Exit(runMigrateCreate(command[2:]))
+			}
+
+			if err := cfg.ValidateDatabase(); err != nil {
+				slog.Error("invalid config", "error", err)
+				os.Exit(1)
+			}
+
+			db, err := setupDatabase(cfg)
+			if err != nil {
+				slog.Error("database", "error", err)
+				os.Exit(1)
+			}
+
+			os.Exit(runMigrate(db, cfg.Database.Driver, command[1:]))
+
+		case "debug":
+			cfg.Server.Addr = cfg.Server.DebugAddr
+
+		default:
+			slog.Error("unknown command", "command", command[0])
+			os.Exit(2)
+		}
+	}
+
+	// fail fast on misconfiguration
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid config", "error", err)
+		os.Exit(1)
+	}
+
+	// setup tracing. spans are flushed on shutdown
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("tracing", "error", err)
+		os.Exit(1)
+	}
+
 	// setup s3
-	s3.Init()
+	s3.Init(s3.Options{
+		AccessKey:         cfg.S3.AccessKey,
+		SecretKey:         cfg.S3.SecretKey,
+		BucketName:        cfg.S3.BucketName,
+		APIURL:            cfg.S3.APIURL,
+		AccessURL:         cfg.S3.AccessURL,
+		AccessURLProtocol: cfg.S3.AccessURLProtocol,
+	})
+
+	// setup sms
+	sms.Init(cfg.SMS.APIKey, cfg.SMS.TemplateID)
+
+	// setup jwt
+	jwt.Init(cfg.JWT.SecretKey)
 
 	// setup database
-	db, err := database.SetupGrom()
+	db, err := setupDatabase(cfg)
 	if err != nil {
 		slog.Error("database", "error", err)
 		os.Exit(1)
 	}
 
+	// useful for single binary deployment with sqlite
+	if cfg.Database.AutoMigrate {
+		if err := autoMigrate(db, cfg.Database.Driver); err != nil {
+			slog.Error("migrate", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// setup cache
-	cacheRepo := cache.New(db)
+	cacheRepo, err := setupCache(db, cfg.Cache)
+	if err != nil {
+		slog.Error("cache", "error", err)
+		os.Exit(1)
+	}
+
+	// setup validator
+	validatorIns := validator.NewValidator()
+
+	// setup rate limit
+	rateLimitOptions, err := setupRateLimit(cacheRepo, cfg.RateLimit)
+	if err != nil {
+		slog.Error("rate limit", "error", err)
+		os.Exit(1)
+	}
+
+	mux, grpcServer, shutdownWorkers := setupRouter(db, validatorIns, cacheRepo, cfg.Server.IdempotencyKeyExpire, cfg.GRPC, cfg.CORS, rateLimitOptions)
+	mux.Handle("/swagger/", httpSwagger.WrapHandler)
+	if cfg.Metrics.Enabled {
+		mux.Handle("GET "+cfg.Metrics.Path, metrics.Handler())
+	}
+
+	// publish committed domain events
+	relay := setupOutboxRelay(db, cfg.Outbox)
+	relay.Start()
+
+	// health checks
+	checker := setupHealthChecker(db, cacheRepo, cfg.Server.HealthCheckTimeout)
+	mux.Handle("GET /healthz", checker.LivenessHandler())
+	mux.Handle("GET /readyz", checker.ReadinessHandler())
+
+	srv := server.New(mux, server.Options{
+		Addr:              cfg.Server.Addr,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+		ShutdownTimeout:   cfg.Server.ShutdownTimeout,
+		ShutdownDelay:     cfg.Server.ShutdownDelay,
+		TLSCertFile:       cfg.Server.TLSCertFile,
+		TLSKeyFile:        cfg.Server.TLSKeyFile,
+	})
+
+	// fail readiness before draining, so no new traffic is routed to this instance
+	srv.OnShutdownStart(checker.SetShuttingDown)
+
+	// hooks run in reverse order: workers first, then connections and tracing
+	srv.OnShutdown("tracing", shutdownTracing)
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+	if closer, ok := cacheRepo.(interface{ Close() }); ok {
+		srv.OnShutdown("cache", func(ctx context.Context) error {
+			closer.Close()
+			return nil
+		})
+	}
+	srv.OnShutdown("outbox relay", relay.Shutdown)
+	srv.OnShutdown("background workers", shutdownWorkers)
+	if cfg.GRPC.Enabled {
+		// stopped before workers and connections that calls depend on
+		srv.OnShutdown("grpc server", grpcServer.Shutdown)
+	}
+
+	// stop gracefully on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// grpc api is served on its own address with same application services
+	if cfg.GRPC.Enabled {
+		if err := grpcServer.Start(); err != nil {
+			slog.Error("grpc server", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	slog.Info("Swagger: http://127.0.0.1" + cfg.Server.Addr + "/swagger/index.html")
+	if err := srv.Run(ctx); err != nil {
+		slog.Error("server", "error", err)
+		os.Exit(1)
+	}
+}
+
+// all logs are redacted by logger handler
+func setupLogger(cfg config.LogConfig, debug bool, level *slog.LevelVar) error {
+	levelName := cfg.Level
+	if levelName == "" {
+		levelName = "info"
+		if debug {
+			levelName = "debug"
+		}
+	}
+
+	if err := level.UnmarshalText([]byte(levelName)); err != nil {
+		return err
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	default:
+		handler = slog.NewTextHandler(os.Stdout, options)
+	}
+
+	slog.SetDefault(slog.New(logger.NewHandler(handler)))
+	return nil
+}
+
+// config print: show effective config with masked secrets
+func runConfig(cfg config.Config, args []string) int {
+	if len(args) != 1 || args[0] != "print" {
+		fmt.Println("usage: config print")
+		return 2
+	}
+
+	fmt.Print(cfg.String())
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Println("\ninvalid config:\n" + err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+func setupDatabase(cfg config.Config) (*gorm.DB, error) {
+	db, err := database.SetupGrom(database.Options{
+		Driver:   cfg.Database.Driver,
+		Host:     cfg.Database.Host,
+		Port:     cfg.Database.Port,
+		Username: cfg.Database.Username,
+		Password: cfg.Database.Password,
+		Name:     cfg.Database.Name,
+		Path:     cfg.Database.Path,
+		LogLevel: cfg.Database.LogLevel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// record query timings and spans
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(tracing.GormPlugin{System: database.System(cfg.Database.Driver)}); err != nil {
+		return nil, err
+	}
 
-	// migrate
-	if len(os.Args) > 1 && os.Args[1] == "migrate" {
-		slog.Info("migrating tables")
+	return db, nil
+}
+
+// cache backend: database, redis or memory. hit and miss of backend is recorded in metrics
+func setupCache(db *gorm.DB, cfg config.CacheConfig) (domain_shared.CacheRepository, error) {
 
-		err := database.MigrateTables(
-			db,
-			domain_user.User{},
-			domain_device.Device{},
-			domain_expense.Expense{},
-			domain_debt.Debt{},
-		)
+	var repo cache.Repository
+	switch cfg.Backend {
+	case "database":
+		repo = cache.New(db)
 
+	case "redis":
+		slog.Info("using redis cache", "addr", cfg.RedisAddr)
+		redisRepo, err := cache.NewRedis(cache.RedisOptions{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+			PoolSize: config.RedisPoolSize,
+			Timeout:  config.RedisTimeout,
+			Prefix:   config.RedisKeyPrefix,
+		})
 		if err != nil {
-			slog.Warn("Cannot migrate tables", "error", err.Error())
+			return nil, err
 		}
-		err = cache.MigrateTables(db)
+		repo = redisRepo
+
+	case "memory":
+		slog.Info("using in-memory cache. cache is not shared between instances")
+		repo = cache.NewMemory(config.MemoryCacheShards, config.MemoryCacheCapacity)
+
+	default:
+		return nil, errors.New("unknown cache backend: " + cfg.Backend)
+	}
+
+	return cache.NewInstrumented(repo, cfg.Backend), nil
+}
+
+// readiness depends on database, cache and object storage
+func setupHealthChecker(db *gorm.DB, cacheRepo domain_shared.CacheRepository, timeout time.Duration) *health.Checker {
+	checker := health.New(version, timeout)
+
+	checker.Register("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
 		if err != nil {
-			slog.Warn("Cannot migrate tables", "error", err.Error())
+			return err
 		}
-		return
+		return sqlDB.PingContext(ctx)
+	})
+	checker.Register("cache", func(ctx context.Context) error {
+		return cache.Ping(cacheRepo)
+	})
+	checker.Register("storage", s3.Ping)
+
+	return checker
+}
+
+// events are published to in-process bus and webhook (if url is set)
+func setupOutboxRelay(db *gorm.DB, cfg config.OutboxConfig) *app_outbox.Relay {
+	bus := eventbus.New()
+	bus.Subscribe(eventbus.AllEvents, func(ctx context.Context, msg eventbus.Message) error {
+		slog.DebugContext(ctx, "event published", "id", msg.ID, "type", msg.Type)
+		return nil
+	})
+
+	var publisher eventbus.Publisher = bus
+	if cfg.WebhookURL != "" {
+		slog.Info("publishing events to webhook", "url", cfg.WebhookURL)
+		publisher = eventbus.Fanout(bus, eventbus.NewWebhookPublisher(cfg.WebhookURL, cfg.WebhookTimeout))
 	}
 
-	// setup validator
-	validatorIns := validator.NewValidator()
+	return app_outbox.NewRelay(gorm_repository.NewGormOutboxRepository(db), publisher, app_outbox.RelayOptions{
+		Interval:    cfg.RelayInterval,
+		BatchSize:   cfg.BatchSize,
+		MaxAttempts: cfg.MaxAttempts,
+		Backoff:     cfg.RetryBackoff,
+		MaxBackoff:  cfg.MaxRetryBackoff,
+		ClaimLease:  cfg.ClaimLease,
+	})
+}
 
-	// setup jwt
-	if jwtSecretKey := os.Getenv("JWT_SECRET_KEY"); jwtSecretKey == "" {
-		slog.Error("JWT_SECRET_KEY not found in ENV")
-		os.Exit(1)
-	} else {
-		jwt.Init(jwtSecretKey)
+// policies of route groups. limiter is nil if rate limiting is disabled
+func setupRateLimit(cacheRepo domain_shared.CacheRepository, cfg config.RateLimitConfig) (middleware.RateLimitOptions, error) {
+	var options middleware.RateLimitOptions
+
+	// trusted proxies are also used for client ip of audit logs
+	var err error
+	options.TrustedProxies, err = cfg.TrustedProxyPrefixes()
+	if err != nil {
+		return options, err
 	}
 
-	mux := setupRouter(db, validatorIns, cacheRepo)
-	mux.Handle("/swagger/", httpSwagger.WrapHandler)
-	if len(os.Args) > 1 && os.Args[1] == "debug" {
-		slog.Info("listening at http://127.0.0.1:2222")
-		slog.Error(http.ListenAndServe(":2222", mux).Error())
-	} else {
-		slog.Info("Swagger: http://127.0.0.1:8000/swagger/index.html")
-		slog.Info("listening at http://127.0.0.1:8000")
-		slog.Error(http.ListenAndServe(":8000", mux).Error())
+	if !cfg.Enabled {
+		slog.Warn("rate limiting is disabled")
+		return options, nil
+	}
 
+	policies := map[string]*ratelimit.Policy{
+		cfg.Auth:   &options.Auth,
+		cfg.Public: &options.Public,
+		cfg.Read:   &options.Read,
+		cfg.Write:  &options.Write,
+	}
+	for value, policy := range policies {
+		if *policy, err = ratelimit.ParsePolicy(value); err != nil {
+			return options, err
+		}
 	}
+
+	options.Limiter = ratelimit.New(cacheRepo)
+
+	return options, nil
 }
 
-func setupRouter(db *gorm.DB, validatorIns domain_shared.Validator, cacheRepo domain_shared.CacheRepository) *http.ServeMux {
+// returns router, grpc server and shutdown function of background workers
+func setupRouter(db *gorm.DB, validatorIns domain_shared.Validator, cacheRepo domain_shared.CacheRepository, idempotencyKeyExpire time.Duration, grpcCfg config.GRPCConfig, corsCfg config.CORSConfig, rateLimitOptions middleware.RateLimitOptions) (*http.ServeMux, *interfaces_grpc_v1.Server, server.ShutdownHook) {
 
 	// setup domain service
 	userDomainService := domain_user.NewUserService(validatorIns)
 	deviceDomainService := domain_device.NewDeviceService(validatorIns)
 	expenseDomainService := domain_expense.NewExpenseService(validatorIns)
 	debtDomainService := domain_debt.NewDebtDomainService(validatorIns)
+	accountDomainService := domain_account.NewAccountDomainService(validatorIns)
+	adminDomainService := domain_admin.NewAdminDomainService(validatorIns)
+	auditDomainService := domain_audit.NewAuditDomainService(validatorIns)
 
 	// setup repository
 	userRepo := gorm_repository.NewGormUserRepository(db)
 	deviceRepo := gorm_repository.NewGormDeviceRepository(db)
 	expenseRepo := gorm_repository.NewGormExpenseRepository(db)
 	debtRepo := gorm_repository.NewGormDebtRepository(db)
+	accountRepo := gorm_repository.NewGormAccountRepository(db)
+	adminRepo := gorm_repository.NewGormAdminRepository(db)
+	auditRepo := gorm_repository.NewGormAuditRepository(db)
+	unitOfWork := gorm_repository.NewGormUnitOfWork(db)
 
 	// setup application service
-	deviceAppService := app_device.NewDeviceAppService(deviceRepo, deviceDomainService)
-	userAppService := app_user.NewUserService(userRepo, cacheRepo, deviceAppService, userDomainService)
-	debtAppService := app_debt.NewDebtAppService(debtRepo, debtDomainService)
-	expenseAppService := app_expense.NewExpenseAppService(expenseRepo, expenseDomainService, debtAppService)
+	deviceAppService := app_device.NewDeviceAppService(deviceRepo, auditRepo, deviceDomainService)
+	userAppService := app_user.NewUserService(userRepo, cacheRepo, auditRepo, deviceAppService, userDomainService)
+	debtAppService := app_debt.NewDebtAppService(debtRepo, userRepo, unitOfWork, debtDomainService)
+	expenseAppService := app_expense.NewExpenseAppService(expenseRepo, unitOfWork, expenseDomainService, debtAppService)
+	accountAppService := app_account.NewAccountAppService(accountRepo, userRepo, cacheRepo, accountDomainService, userDomainService)
+	adminAppService := app_admin.NewAdminAppService(adminRepo, userRepo, deviceRepo, auditRepo, cacheRepo, adminDomainService, auditDomainService)
+	auditAppService := app_audit.NewAuditAppService(auditRepo, auditDomainService)
 
 	// setup router
-	muxV1 := interfaces_rest_v1.NewRouter(userAppService, deviceAppService, expenseAppService)
+	corsPolicy := middleware.CORSPolicy{
+		AllowedOrigins:   corsCfg.AllowedOrigins,
+		AllowCredentials: corsCfg.AllowCredentials,
+		AllowedHeaders:   corsCfg.AllowedHeaders,
+		ExposedHeaders:   corsCfg.ExposedHeaders,
+		MaxAge:           corsCfg.MaxAge,
+	}
+	muxV1 := interfaces_rest_v1.NewRouter(userAppService, deviceAppService, expenseAppService, debtAppService, accountAppService, adminAppService, auditAppService, cacheRepo, idempotencyKeyExpire, corsPolicy, rateLimitOptions)
+
+	// setup grpc server
+	grpcServer := interfaces_grpc_v1.NewServer(interfaces_grpc_v1.Options{
+		Addr:           grpcCfg.Addr,
+		MaxRecvMsgSize: grpcCfg.MaxRecvMsgSize,
+	}, userAppService, deviceAppService, expenseAppService, debtAppService, cacheRepo)
 
-	return muxV1
+	return muxV1, grpcServer, accountAppService.Shutdown
 }
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database/migrations"
+	"gorm.io/gorm"
+)
+
+// source directory of migration files. used by migrate create
+const migrationsDir = "pkg/database/migrations"
+
+// each driver has its own dialect directory
+var migrationsDialects = []string{database.DriverPostgres, database.DriverSQLite}
+
+const migrateUsage = `usage:
+  migrate up            apply all pending migrations
+  migrate down [steps]  rollback last migrations (default 1)
+  migrate status        show applied and pending migrations
+  migrate create <name> create new migration files`
+
+// returns exit code. dialect is name of database driver
+func runMigrate(db *gorm.DB, dialect string, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	migrator, err := database.NewMigrator(db, migrations.FS, dialect)
+	if err != nil {
+		slog.Error("cannot load migrations", "error", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, m := range done {
+			slog.Info("migration applied", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			slog.Error("migrate up", "error", err)
+			return 1
+		}
+		if len(done) == 0 {
+			slog.Info("no pending migration")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				slog.Error("steps must be a positive number")
+				return 2
+			}
+		}
+
+		done, err := migrator.Down(steps)
+		for _, m := range done {
+			slog.Info("migration rolled back", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			if errors.Is(err, database.ErrNoMigrationToRollback) {
+				slog.Info(err.Error())
+				return 0
+			}
+			slog.Error("migrate down", "error", err)
+			return 1
+		}
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			slog.Error("migrate status", "error", err)
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			status, appliedAt := "pending", ""
+			if s.IsApplied {
+				status, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	return 0
+}
+
+// apply pending migrations on startup
+func autoMigrate(db *gorm.DB, dialect string) error {
+	migrator, err := database.NewMigrator(db, migrations.FS, dialect)
+	if err != nil {
+		return err
+	}
+
+	done, err := migrator.Up()
+	for _, m := range done {
+		slog.Info("migration applied", "version", m.Version, "name", m.Name)
+	}
+
+	return err
+}
+
+func runMigrateCreate(args []string) int {
+	if len(args) != 1 {
+		fmt.Println(migrateUsage)
+		return 2
+	}
+
+	// same migration is created for every dialect
+	dirs := make([]string, len(migrationsDialects))
+	for i, dialect := range migrationsDialects {
+		dirs[i] = filepath.Join(migrationsDir, dialect)
+	}
+
+	paths, err := database.CreateMigration(args[0], dirs...)
+	for _, path := range paths {
+		slog.Info("migration created", "path", path)
+	}
+	if err != nil {
+		slog.Error("migrate create", "error", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+)
+
+// methods of all cache backends
+type Repository interface {
+	Save(key string, value map[string]string, expireTime time.Duration) error
+	// value is saved only if key does not exist or is expired. returns true if value is saved
+	SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error)
+	// value is replaced only if stored value of key is old and is not expired. returns true if value is replaced
+	CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error)
+	// add one to counter of key and return new count and expire time of counter.
+	// counter of absent or expired key starts from 1 and expires after expireTime. counter keys must not be used with Get
+	Increment(key string, expireTime time.Duration) (int64, time.Time, error)
+	Get(key string) (map[string]string, time.Time, error)
+	Delete(key string) error
+}
+
+// cache repository that records hit and miss of lookups in metrics
+type InstrumentedRepository struct {
+	next    Repository
+	backend string
+}
+
+func NewInstrumented(next Repository, backend string) *InstrumentedRepository {
+	return &InstrumentedRepository{
+		next:    next,
+		backend: backend,
+	}
+}
+
+func (r *InstrumentedRepository) Save(key string, value map[string]string, expireTime time.Duration) error {
+	return r.next.Save(key, value, expireTime)
+}
+
+func (r *InstrumentedRepository) SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error) {
+	return r.next.SaveIfAbsent(key, value, expireTime)
+}
+
+func (r *InstrumentedRepository) CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error) {
+	return r.next.CompareAndSwap(key, old, value, expireTime)
+}
+
+func (r *InstrumentedRepository) Increment(key string, expireTime time.Duration) (int64, time.Time, error) {
+	return r.next.Increment(key, expireTime)
+}
+
+// not found and expired records are counted as miss
+func (r *InstrumentedRepository) Get(key string) (map[string]string, time.Time, error) {
+	value, expire, err := r.next.Get(key)
+
+	result := "hit"
+	if errors.Is(err, database_errors.ErrRecordNotFound) || errors.Is(err, database_errors.ErrExpired) {
+		result = "miss"
+	} else if err != nil {
+		result = "error"
+	}
+	metrics.CacheRequests.WithLabelValues(r.backend, result).Inc()
+
+	return value, expire, err
+}
+
+func (r *InstrumentedRepository) Delete(key string) error {
+	return r.next.Delete(key)
+}
+
+// close underlying backend if it has connections
+func (r *InstrumentedRepository) Close() {
+	if closer, ok := r.next.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
+
+// check backend is reachable. missing key is not an error.
+// metrics are not recorded for instrumented repository
+func Ping(repo Repository) error {
+	if instrumented, ok := repo.(*InstrumentedRepository); ok {
+		repo = instrumented.next
+	}
+
+	_, _, err := repo.Get("health_check")
+	if errors.Is(err, database_errors.ErrRecordNotFound) || errors.Is(err, database_errors.ErrExpired) {
+		return nil
+	}
+
+	return err
+}
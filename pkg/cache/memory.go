@@ -0,0 +1,241 @@
+package cache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+// in process cache repository for tests and single node deployments.
+// keys are distributed between shards to reduce lock contention. each shard evicts least recently used item when it is full.
+// counters (keys of Increment) are not evicted, so limits of login, otp and rate limit cannot be reset by filling cache.
+// they are removed when expired, so their number is bounded by keys that are counted in their window
+type MemoryCacheRepository struct {
+	shards []*memoryShard
+	now    func() time.Time
+}
+
+type memoryShard struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List // front is most recently used
+	counters map[string]*memoryItem
+	// expired counters are removed when number of counters reaches it
+	sweepAt int
+}
+
+type memoryItem struct {
+	key    string
+	value  map[string]string
+	count  int64 // value of counter keys
+	expire time.Time
+}
+
+// capacity is maximum number of items in all shards
+func NewMemory(shardCount int, capacity int) *MemoryCacheRepository {
+	if shardCount <= 0 {
+		shardCount = 1
+	}
+
+	shardCapacity := capacity / shardCount
+	if shardCapacity <= 0 {
+		shardCapacity = 1
+	}
+
+	m := &MemoryCacheRepository{
+		shards: make([]*memoryShard, shardCount),
+		now:    time.Now,
+	}
+
+	for i := range m.shards {
+		m.shards[i] = &memoryShard{
+			capacity: shardCapacity,
+			items:    make(map[string]*list.Element),
+			order:    list.New(),
+			counters: make(map[string]*memoryItem),
+			sweepAt:  shardCapacity,
+		}
+	}
+
+	return m
+}
+
+func (m *MemoryCacheRepository) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+func (m *MemoryCacheRepository) Save(key string, value map[string]string, expireTime time.Duration) error {
+	item := &memoryItem{
+		key:    key,
+		value:  maps.Clone(value),
+		expire: m.now().Add(expireTime),
+	}
+
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(item)
+	return nil
+}
+
+func (m *MemoryCacheRepository) SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error) {
+	now := m.now()
+	item := &memoryItem{
+		key:    key,
+		value:  maps.Clone(value),
+		expire: now.Add(expireTime),
+	}
+
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok && now.Before(element.Value.(*memoryItem).expire) {
+		return false, nil
+	}
+
+	s.set(item)
+	return true, nil
+}
+
+func (m *MemoryCacheRepository) CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error) {
+	now := m.now()
+
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return false, nil
+	}
+	if item := element.Value.(*memoryItem); !now.Before(item.expire) || !maps.Equal(item.value, old) {
+		return false, nil
+	}
+
+	s.set(&memoryItem{
+		key:    key,
+		value:  maps.Clone(value),
+		expire: now.Add(expireTime),
+	})
+	return true, nil
+}
+
+func (m *MemoryCacheRepository) Increment(key string, expireTime time.Duration) (int64, time.Time, error) {
+	now := m.now()
+
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, ok := s.counters[key]; ok && now.Before(item.expire) {
+		item.count++
+		return item.count, item.expire, nil
+	}
+
+	if element, ok := s.items[key]; ok {
+		s.removeElement(element)
+	}
+
+	item := &memoryItem{
+		key:    key,
+		count:  1,
+		expire: now.Add(expireTime),
+	}
+	s.counters[key] = item
+
+	if len(s.counters) >= s.sweepAt {
+		s.sweepCounters(now)
+	}
+
+	return item.count, item.expire, nil
+}
+
+func (m *MemoryCacheRepository) Get(key string) (map[string]string, time.Time, error) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil, time.Time{}, database_errors.ErrRecordNotFound
+	}
+
+	item := element.Value.(*memoryItem)
+
+	// expired items are removed lazily
+	if !m.now().Before(item.expire) {
+		s.removeElement(element)
+		return nil, item.expire, database_errors.ErrExpired
+	}
+
+	s.order.MoveToFront(element)
+
+	return maps.Clone(item.value), item.expire, nil
+}
+
+func (m *MemoryCacheRepository) Delete(key string) error {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.removeElement(element)
+	}
+	delete(s.counters, key)
+
+	return nil
+}
+
+// number of items in cache including expired items that are not removed yet
+func (m *MemoryCacheRepository) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		s.mu.Lock()
+		n += s.order.Len() + len(s.counters)
+		s.mu.Unlock()
+	}
+
+	return n
+}
+
+// shard must be locked
+func (s *memoryShard) set(item *memoryItem) {
+	delete(s.counters, item.key)
+
+	if element, ok := s.items[item.key]; ok {
+		element.Value = item
+		s.order.MoveToFront(element)
+		return
+	}
+
+	s.items[item.key] = s.order.PushFront(item)
+
+	if s.order.Len() > s.capacity {
+		s.removeElement(s.order.Back())
+	}
+}
+
+func (s *memoryShard) removeElement(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.items, element.Value.(*memoryItem).key)
+}
+
+// remove expired counters. next sweep is done when number of counters is doubled, so sweeping is not done on every increment
+func (s *memoryShard) sweepCounters(now time.Time) {
+	for key, item := range s.counters {
+		if !now.Before(item.expire) {
+			delete(s.counters, key)
+		}
+	}
+
+	s.sweepAt = max(s.capacity, 2*len(s.counters))
+}
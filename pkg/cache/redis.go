@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+// increment counter and set ttl of new counter in one step. returns count and remaining ttl in milliseconds
+const incrementScript = `local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}`
+
+// replace value if stored value is same as old value. values are json of maps with sorted keys
+const compareAndSwapScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0`
+
+// cache repository on redis. expire is handled by redis ttl
+type RedisCacheRepository struct {
+	client *respClient
+	prefix string
+}
+
+type RedisOptions struct {
+	Addr     string // host:port
+	Password string
+	DB       int
+	PoolSize int
+	Timeout  time.Duration
+	Prefix   string // prefix of all keys
+}
+
+// connection is checked with PING
+func NewRedis(options RedisOptions) (*RedisCacheRepository, error) {
+	if options.PoolSize <= 0 {
+		options.PoolSize = 10
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 3 * time.Second
+	}
+
+	r := &RedisCacheRepository{
+		client: newRESPClient(options.Addr, options.Password, options.DB, options.PoolSize, options.Timeout),
+		prefix: options.Prefix,
+	}
+
+	replies, err := r.client.Do([]string{"PING"})
+	if err != nil {
+		return nil, err
+	}
+	if err := firstError(replies); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *RedisCacheRepository) Save(key string, value map[string]string, expireTime time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// redis does not accept zero ttl
+	milliseconds := expireTime.Milliseconds()
+	if milliseconds <= 0 {
+		milliseconds = 1
+	}
+
+	replies, err := r.client.Do([]string{"SET", r.prefix + key, string(data), "PX", strconv.FormatInt(milliseconds, 10)})
+	if err != nil {
+		return err
+	}
+
+	return firstError(replies)
+}
+
+// SET with NX option. reply is nil if key exists
+func (r *RedisCacheRepository) SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	milliseconds := expireTime.Milliseconds()
+	if milliseconds <= 0 {
+		milliseconds = 1
+	}
+
+	replies, err := r.client.Do([]string{"SET", r.prefix + key, string(data), "PX", strconv.FormatInt(milliseconds, 10), "NX"})
+	if err != nil {
+		return false, err
+	}
+	if err := firstError(replies); err != nil {
+		return false, err
+	}
+
+	_, saved := replies[0].(string)
+	return saved, nil
+}
+
+// value is compared and replaced by lua script, so it is atomic between instances
+func (r *RedisCacheRepository) CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error) {
+	oldData, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	milliseconds := expireTime.Milliseconds()
+	if milliseconds <= 0 {
+		milliseconds = 1
+	}
+
+	replies, err := r.client.Do([]string{"EVAL", compareAndSwapScript, "1", r.prefix + key, string(oldData), string(data), strconv.FormatInt(milliseconds, 10)})
+	if err != nil {
+		return false, err
+	}
+	if err := firstError(replies); err != nil {
+		return false, err
+	}
+
+	swapped, _ := replies[0].(int64)
+	return swapped == 1, nil
+}
+
+// counter is incremented by lua script, so it is atomic between instances
+func (r *RedisCacheRepository) Increment(key string, expireTime time.Duration) (int64, time.Time, error) {
+	milliseconds := expireTime.Milliseconds()
+	if milliseconds <= 0 {
+		milliseconds = 1
+	}
+
+	replies, err := r.client.Do([]string{"EVAL", incrementScript, "1", r.prefix + key, strconv.FormatInt(milliseconds, 10)})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if err := firstError(replies); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	reply, ok := replies[0].([]any)
+	if !ok || len(reply) != 2 {
+		return 0, time.Time{}, errInvalidReply
+	}
+	count, _ := reply[0].(int64)
+	ttl, _ := reply[1].(int64)
+
+	return count, time.Now().Add(time.Duration(ttl) * time.Millisecond), nil
+}
+
+func (r *RedisCacheRepository) Get(key string) (map[string]string, time.Time, error) {
+	var expire time.Time
+
+	replies, err := r.client.Do(
+		[]string{"GET", r.prefix + key},
+		[]string{"PTTL", r.prefix + key},
+	)
+	if err != nil {
+		return nil, expire, err
+	}
+	if err := firstError(replies); err != nil {
+		return nil, expire, err
+	}
+
+	data, ok := replies[0].(string)
+	if !ok {
+		// nil reply. key not exist or expired
+		return nil, expire, database_errors.ErrRecordNotFound
+	}
+
+	// ttl is -2 if key is expired between GET and PTTL
+	ttl, _ := replies[1].(int64)
+	if ttl == -2 {
+		return nil, expire, database_errors.ErrRecordNotFound
+	}
+	if ttl > 0 {
+		expire = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+	}
+
+	value := make(map[string]string)
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, expire, err
+	}
+
+	return value, expire, nil
+}
+
+func (r *RedisCacheRepository) Delete(key string) error {
+	replies, err := r.client.Do([]string{"DEL", r.prefix + key})
+	if err != nil {
+		return err
+	}
+
+	return firstError(replies)
+}
+
+func (r *RedisCacheRepository) Close() {
+	r.client.Close()
+}
@@ -2,11 +2,14 @@ package cache
 
 import (
 	"log/slog"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
 	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // database table
@@ -17,14 +20,19 @@ type Cache struct {
 	Expire time.Time
 }
 
+// expired records are deleted at most once in this interval
+const sweepInterval = time.Minute
+
 type GormCacheRepository struct {
-	DB *gorm.DB
+	DB        *gorm.DB
+	lastSweep *atomic.Int64 // unix nano. shared between copies of repository
 }
 
 func New(db *gorm.DB) GormCacheRepository {
 
 	return GormCacheRepository{
-		DB: db,
+		DB:        db,
+		lastSweep: new(atomic.Int64),
 	}
 }
 
@@ -53,11 +61,111 @@ func (g GormCacheRepository) Save(key string, value map[string]string, expireTim
 		return err
 	}
 
-	go g.DeleteExpiredRecords()
+	g.sweep()
 
 	return nil
 }
 
+// unique index of key rejects insert if key exists. expired record of key is deleted first
+func (g GormCacheRepository) SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error) {
+	var c Cache
+	c.Key = key
+	c.Expire = time.Now().Add(expireTime)
+
+	var err error
+	c.Value, err = utils.ConvertMapToString(value)
+	if err != nil {
+		return false, err
+	}
+
+	if err := g.DB.Where("key = ? AND expire < ?", key, time.Now()).Delete(&Cache{}).Error; err != nil {
+		return false, err
+	}
+
+	result := g.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&c)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	g.sweep()
+
+	return result.RowsAffected == 1, nil
+}
+
+// value is replaced by conditional update, so only one of concurrent swaps of all instances succeeds.
+// values are json of maps with sorted keys
+func (g GormCacheRepository) CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error) {
+	oldValue, err := utils.ConvertMapToString(old)
+	if err != nil {
+		return false, err
+	}
+	newValue, err := utils.ConvertMapToString(value)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	result := g.DB.Model(&Cache{}).
+		Where("key = ? AND value = ? AND expire >= ?", key, oldValue, now).
+		Updates(map[string]any{"value": newValue, "expire": now.Add(expireTime)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// value of counter is number. row of counter is locked by update until end of transaction,
+// so concurrent increments of all instances are serialized
+func (g GormCacheRepository) Increment(key string, expireTime time.Duration) (int64, time.Time, error) {
+	var c Cache
+
+	err := g.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// expired counter is started again
+		if err := tx.Where("key = ? AND expire < ?", key, now).Delete(&Cache{}).Error; err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Cache{Key: key, Value: "0", Expire: now.Add(expireTime)}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&Cache{}).Where("key = ?", key).Update("value", gorm.Expr("CAST(CAST(value AS INTEGER) + 1 AS TEXT)")).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.First(&c, Cache{Key: key}).Error
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	count, err := strconv.ParseInt(c.Value, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	g.sweep()
+
+	return count, c.Expire, nil
+}
+
+// delete expired records in background if they are not deleted in last sweep interval
+func (g GormCacheRepository) sweep() {
+	if g.lastSweep != nil {
+		now := time.Now().UnixNano()
+		last := g.lastSweep.Load()
+		if now-last < int64(sweepInterval) || !g.lastSweep.CompareAndSwap(last, now) {
+			return
+		}
+	}
+
+	go g.DeleteExpiredRecords()
+}
+
 func (g GormCacheRepository) DeleteExpiredRecords() {
 	if err := g.DB.Where("expire < ?", time.Now()).Delete(&Cache{}).Error; err != nil {
 		slog.Error("cannot delete expired records", "error", err)
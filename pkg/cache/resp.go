@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// error reply of redis server. e.g: "ERR unknown command"
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+var errInvalidReply = errors.New("redis: invalid reply")
+
+// minimal client of redis serialization protocol (RESP2) with connection pool
+type respClient struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	pool     chan *respConn
+}
+
+type respConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+func newRESPClient(addr, password string, db int, poolSize int, timeout time.Duration) *respClient {
+	return &respClient{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  timeout,
+		pool:     make(chan *respConn, poolSize),
+	}
+}
+
+func (c *respClient) dial() (*respConn, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &respConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+
+	var setup [][]string
+	if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+
+	if len(setup) > 0 {
+		replies, err := rc.pipeline(setup, c.timeout)
+		if err == nil {
+			err = firstError(replies)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+func (c *respClient) get() (*respConn, error) {
+	select {
+	case rc := <-c.pool:
+		return rc, nil
+	default:
+		return c.dial()
+	}
+}
+
+func (c *respClient) put(rc *respConn) {
+	select {
+	case c.pool <- rc:
+	default:
+		// pool is full
+		rc.conn.Close()
+	}
+}
+
+// send commands in one round trip and return replies in order.
+// error replies of redis are returned as RedisError in replies
+func (c *respClient) Do(commands ...[]string) ([]any, error) {
+	rc, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := rc.pipeline(commands, c.timeout)
+	if err != nil {
+		// connection state is unknown
+		rc.conn.Close()
+		return nil, err
+	}
+
+	c.put(rc)
+	return replies, nil
+}
+
+func (c *respClient) Close() {
+	for {
+		select {
+		case rc := <-c.pool:
+			rc.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (rc *respConn) pipeline(commands [][]string, timeout time.Duration) ([]any, error) {
+	if err := rc.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	for _, command := range commands {
+		if err := writeCommand(rc.writer, command); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rc.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, 0, len(commands))
+	for range commands {
+		reply, err := readReply(rc.reader)
+		if err != nil {
+			return nil, err
+		}
+
+		replies = append(replies, reply)
+	}
+
+	return replies, nil
+}
+
+// commands are sent as array of bulk strings
+func writeCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reply types: simple string -> string, error -> RedisError, integer -> int64,
+// bulk string -> string or nil, array -> []any or nil
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errInvalidReply
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return RedisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errInvalidReply
+		}
+		if size < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errInvalidReply
+		}
+		if count < 0 {
+			return nil, nil
+		}
+
+		items := make([]any, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+
+		return items, nil
+	}
+
+	return nil, errInvalidReply
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errInvalidReply
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func firstError(replies []any) error {
+	for _, reply := range replies {
+		if err, ok := reply.(RedisError); ok {
+			return err
+		}
+	}
+
+	return nil
+}
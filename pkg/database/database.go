@@ -3,47 +3,92 @@ package database
 import (
 	"errors"
 	"fmt"
-	"log/slog"
 	"os"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gorm_logger "gorm.io/gorm/logger"
 )
 
-func SetupGrom() (*gorm.DB, error) {
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+var ErrUnknownDriver = errors.New("unknown database driver")
+
+type Options struct {
+	Driver   string // postgres or sqlite. default is postgres
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Name     string
+	Path     string // file of sqlite database. use ":memory:" for in-memory database
+	LogLevel string // silent, error or info
+}
+
+func SetupGrom(options Options) (*gorm.DB, error) {
 
 	// select logger
 	logger := gorm_logger.Default.LogMode(gorm_logger.Error)
-	switch os.Getenv("DB_LOG_LEVEL") {
+	switch options.LogLevel {
 	case "info":
 		logger = gorm_logger.Default.LogMode(gorm_logger.Info)
 	case "silent":
 		logger = gorm_logger.Default.LogMode(gorm_logger.Silent)
 	}
 
-	dbprefix := os.Getenv("DB_PREFIX")
-	host := os.Getenv(dbprefix + "DB_HOST")
-	user := os.Getenv(dbprefix + "DB_USERNAME")
-	password := os.Getenv(dbprefix + "DB_PASSWORD")
-	dbname := os.Getenv(dbprefix + "DB_NAME")
-	port := os.Getenv(dbprefix + "DB_PORT")
-
 	// connet to database
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tehran", host, user, password, dbname, port)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	var dialector gorm.Dialector
+	switch options.Driver {
+	case DriverPostgres, "":
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=Asia/Tehran", options.Host, options.Username, options.Password, options.Name, options.Port)
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(options.Path))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, options.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: logger,
 	})
 	if err != nil {
-		if host == "" {
-			slog.Error("maybe env variable not loaded")
-		}
 		return nil, errors.New("Cannot connect to database: " + err.Error())
 	}
 
+	if options.Driver == DriverSQLite {
+		// sqlite allows only one writer. a single connection prevents "database is locked" errors
+		// and keeps in-memory database alive between queries
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
 	return db, nil
 }
 
+// foreign keys are off by default in sqlite. wal journal lets readers work while writing
+func sqliteDSN(path string) string {
+	dsn := "file:" + path + "?_foreign_keys=on&_busy_timeout=5000"
+	if path != ":memory:" {
+		dsn += "&_journal_mode=WAL"
+	}
+	return dsn
+}
+
+// name of database system used in traces
+func System(driver string) string {
+	if driver == DriverSQLite {
+		return "sqlite"
+	}
+	return "postgresql"
+}
+
 func MigrateTables(db *gorm.DB, models ...any) error {
 
 	return db.AutoMigrate(models...)
@@ -51,16 +96,22 @@ func MigrateTables(db *gorm.DB, models ...any) error {
 	// &domain_device.Device{},
 }
 
+// in-memory sqlite is used when TEST_DB_DRIVER is sqlite or TEST_DB_HOST is not set
 func SetupGromForTest() (*gorm.DB, error) {
+	if os.Getenv("TEST_DB_DRIVER") == DriverSQLite || os.Getenv("TEST_DB_HOST") == "" {
+		return SetupGrom(Options{
+			Driver:   DriverSQLite,
+			Path:     ":memory:",
+			LogLevel: "error",
+		})
+	}
+
 	// connet to database
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tehran", os.Getenv("TEST_DB_HOST"), os.Getenv("TEST_DB_USERNAME"), os.Getenv("TEST_DB_PASSWORD"), os.Getenv("TEST_DB_NAME"), os.Getenv("TEST_DB_PORT"))
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: gorm_logger.Default.LogMode(gorm_logger.Error),
 	})
 	if err != nil {
-		if os.Getenv("TEST_DB_HOST") == "" {
-			slog.Error("maybe env variable not loaded")
-		}
 		return nil, errors.New("Cannot connect to database: " + err.Error())
 	}
 
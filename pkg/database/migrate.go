@@ -0,0 +1,322 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var (
+	ErrNoMigrationToRollback = errors.New("no migration to rollback")
+	ErrInvalidMigrationName  = errors.New("migration name must contain only lowercase letters, digits and underscore")
+)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	IsApplied bool
+	AppliedAt time.Time
+}
+
+// applied migrations are saved in this table
+type SchemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// key of advisory lock of migrations in postgres
+const migrationLockID = 7320132410
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// dir is directory of migration files in fsys. e.g: postgres
+func NewMigrator(db *gorm.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// returns migrations sorted by version. every migration must have both up and down files
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d has different names: %s, %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s must have up and down files", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) isSQLite() bool {
+	return m.db.Dialector.Name() == DriverSQLite
+}
+
+// run f on one connection with lock of database, so a migration is not applied twice by concurrent migrate commands.
+// postgres uses advisory lock of session. sqlite uses exclusive transaction, so migrations of a run are committed together
+func (m *Migrator) locked(f func(db *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if !m.isSQLite() {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+			return f(conn)
+		}
+
+		if err := conn.Exec("BEGIN EXCLUSIVE").Error; err != nil {
+			return err
+		}
+
+		// statements are in exclusive transaction
+		if err := f(conn.Session(&gorm.Session{SkipDefaultTransaction: true})); err != nil {
+			conn.Exec("ROLLBACK")
+			return err
+		}
+
+		return conn.Exec("COMMIT").Error
+	})
+}
+
+// each migration runs in a transaction. migrations of sqlite are already in exclusive transaction
+func (m *Migrator) transaction(db *gorm.DB, fc func(tx *gorm.DB) error) error {
+	if m.isSQLite() {
+		return fc(db)
+	}
+
+	return db.Transaction(fc)
+}
+
+// apply all pending migrations in order
+func (m *Migrator) Up() (done []Migration, err error) {
+	err = m.locked(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			err := m.transaction(db, func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+
+				return tx.Create(&SchemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+	if err != nil && m.isSQLite() {
+		// transaction of run is rolled back
+		done = nil
+	}
+
+	return done, err
+}
+
+// rollback last applied migrations
+func (m *Migrator) Down(steps int) (done []Migration, err error) {
+	err = m.locked(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			err := m.transaction(db, func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+
+				return tx.Delete(&SchemaMigration{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+	if err != nil && m.isSQLite() {
+		done = nil
+	}
+	if err == nil && len(done) == 0 {
+		return nil, ErrNoMigrationToRollback
+	}
+
+	return done, err
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			IsApplied: ok,
+			AppliedAt: row.AppliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// create empty up and down files with next version in each dir. version is same in all dirs,
+// so migrations of dialects stay aligned. returns path of created files
+func CreateMigration(name string, dirs ...string) (paths []string, err error) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, ErrInvalidMigrationName
+	}
+
+	version := int64(1)
+	for _, dir := range dirs {
+		migrations, err := LoadMigrations(os.DirFS(dir), ".")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(migrations) > 0 && migrations[len(migrations)-1].Version >= version {
+			version = migrations[len(migrations)-1].Version + 1
+		}
+	}
+
+	base := fmt.Sprintf("%04d_%s", version, name)
+	for _, dir := range dirs {
+		upPath := filepath.Join(dir, base+".up.sql")
+		downPath := filepath.Join(dir, base+".down.sql")
+
+		if err := os.WriteFile(upPath, []byte("-- "+base+" up\n"), 0o644); err != nil {
+			return paths, err
+		}
+
+		if err := os.WriteFile(downPath, []byte("-- "+base+" down\n"), 0o644); err != nil {
+			return paths, err
+		}
+
+		paths = append(paths, upPath, downPath)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// sql migrations of each database dialect. file name format: <version>_<name>.<up|down>.sql
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
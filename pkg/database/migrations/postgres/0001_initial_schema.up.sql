@@ -0,0 +1,103 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(30) NOT NULL,
+    number VARCHAR(13) NOT NULL,
+    password VARCHAR(100) NOT NULL,
+    salt VARCHAR(32) NOT NULL,
+    avatar VARCHAR(500) NOT NULL,
+    registered_at TIMESTAMPTZ NOT NULL,
+    is_registered BOOLEAN NOT NULL DEFAULT FALSE,
+    is_blocked BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_number ON users (number);
+
+CREATE TABLE IF NOT EXISTS devices (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(300) NOT NULL,
+    last_ip VARCHAR(45) NOT NULL,
+    first_login TIMESTAMPTZ NOT NULL,
+    last_login TIMESTAMPTZ NOT NULL,
+    refresh_token VARCHAR(500) NOT NULL DEFAULT '',
+    user_id BIGINT NOT NULL,
+    CONSTRAINT fk_devices_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user_id ON devices (user_id);
+CREATE INDEX IF NOT EXISTS idx_devices_refresh_token ON devices (refresh_token);
+
+CREATE TABLE IF NOT EXISTS expenses (
+    id BIGSERIAL PRIMARY KEY,
+    creator_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(400) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    total_amount BIGINT NOT NULL,
+    CONSTRAINT fk_expenses_creator FOREIGN KEY (creator_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expenses_creator_id ON expenses (creator_id);
+
+CREATE TABLE IF NOT EXISTS debts (
+    id BIGSERIAL PRIMARY KEY,
+    expense_id BIGINT NOT NULL,
+    creditor_id BIGINT NOT NULL,
+    debtor_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    is_creditor_accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    is_debtor_accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    is_creditor_rejected BOOLEAN NOT NULL DEFAULT FALSE,
+    is_debtor_rejected BOOLEAN NOT NULL DEFAULT FALSE,
+    is_paid BOOLEAN NOT NULL DEFAULT FALSE,
+    is_payment_accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    is_debtor_requested_for_delete BOOLEAN NOT NULL DEFAULT FALSE,
+    is_creditor_requested_for_delete BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT fk_debts_expense FOREIGN KEY (expense_id) REFERENCES expenses (id),
+    CONSTRAINT fk_debts_creditor FOREIGN KEY (creditor_id) REFERENCES users (id),
+    CONSTRAINT fk_debts_debtor FOREIGN KEY (debtor_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_debts_expense_id ON debts (expense_id);
+CREATE INDEX IF NOT EXISTS idx_debts_creditor_id ON debts (creditor_id);
+CREATE INDEX IF NOT EXISTS idx_debts_debtor_id ON debts (debtor_id);
+
+CREATE TABLE IF NOT EXISTS expense_comments (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    expense_id BIGINT NOT NULL,
+    content VARCHAR(400) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_expense_comments_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_expense_comments_expense FOREIGN KEY (expense_id) REFERENCES expenses (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_comments_expense_id ON expense_comments (expense_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(50) NOT NULL,
+    image VARCHAR(1000) NOT NULL,
+    description VARCHAR(300) NOT NULL,
+    user_id BIGINT NOT NULL,
+    debt_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    amount BIGINT NOT NULL,
+    is_creditor BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_notifications_debt FOREIGN KEY (debt_id) REFERENCES debts (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id);
+
+CREATE TABLE IF NOT EXISTS caches (
+    id BIGSERIAL PRIMARY KEY,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    expire TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_caches_key ON caches (key);
+CREATE INDEX IF NOT EXISTS idx_caches_expire ON caches (expire);
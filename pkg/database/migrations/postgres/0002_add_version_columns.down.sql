@@ -0,0 +1,2 @@
+ALTER TABLE debts DROP COLUMN version;
+ALTER TABLE expenses DROP COLUMN version;
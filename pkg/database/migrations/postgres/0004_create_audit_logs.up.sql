@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(30) NOT NULL,
+    target_id BIGINT NOT NULL,
+    before TEXT NOT NULL DEFAULT '',
+    after TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(300) NOT NULL DEFAULT '',
+    request_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target ON audit_logs (target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- audit log is append only
+CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
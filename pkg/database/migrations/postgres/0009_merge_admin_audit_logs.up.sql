@@ -0,0 +1,16 @@
+-- admin actions are saved in audit_logs
+INSERT INTO audit_logs (actor_id, action, target_type, target_id, before, after, ip, user_agent, request_id, created_at)
+SELECT admin_id,
+    'admin.' || action,
+    CASE WHEN target_user_id = 0 THEN '' ELSE 'user' END,
+    target_user_id,
+    '',
+    CASE WHEN details = '' THEN '' ELSE json_build_object('details', details)::text END,
+    ip,
+    user_agent,
+    '',
+    created_at
+FROM admin_audit_logs
+ORDER BY id;
+
+DROP TABLE IF EXISTS admin_audit_logs;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS caches;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS expense_comments;
+DROP TABLE IF EXISTS debts;
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS devices;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,2 @@
+ALTER TABLE expenses ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE debts ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
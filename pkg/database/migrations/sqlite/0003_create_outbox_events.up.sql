@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id VARCHAR(36) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    aggregate_type VARCHAR(30) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error VARCHAR(500) NOT NULL DEFAULT '',
+    is_published BOOLEAN NOT NULL DEFAULT FALSE,
+    published_at DATETIME,
+    is_failed BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_events_event_id ON outbox_events (event_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (next_attempt_at) WHERE is_published = FALSE AND is_failed = FALSE;
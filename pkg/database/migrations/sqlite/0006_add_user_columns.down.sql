@@ -0,0 +1,10 @@
+ALTER TABLE users DROP COLUMN totp_recovery_codes;
+ALTER TABLE users DROP COLUMN is_totp_enabled;
+ALTER TABLE users DROP COLUMN totp_secret;
+ALTER TABLE users DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN is_deleted;
+ALTER TABLE users DROP COLUMN role;
+ALTER TABLE users DROP COLUMN currency;
+ALTER TABLE users DROP COLUMN language;
+ALTER TABLE users DROP COLUMN avatar_key;
+ALTER TABLE users DROP COLUMN avatar_thumbnail;
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN avatar_thumbnail VARCHAR(500) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_key VARCHAR(200) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN language VARCHAR(5) NOT NULL DEFAULT 'fa';
+ALTER TABLE users ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'IRT';
+ALTER TABLE users ADD COLUMN role VARCHAR(10) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN is_deleted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN deleted_at DATETIME;
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN is_totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN totp_recovery_codes VARCHAR(1000) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE outbox_events DROP COLUMN claimed_until;
+ALTER TABLE outbox_events DROP COLUMN claim_token;
@@ -0,0 +1,2 @@
+ALTER TABLE outbox_events ADD COLUMN claim_token VARCHAR(36) NOT NULL DEFAULT '';
+ALTER TABLE outbox_events ADD COLUMN claimed_until DATETIME;
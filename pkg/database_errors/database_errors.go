@@ -5,4 +5,6 @@ import "errors"
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrExpired        = errors.New("expired")
+	// record is changed after it was read
+	ErrVersionConflict = errors.New("version conflict")
 )
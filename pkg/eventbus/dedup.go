@@ -0,0 +1,30 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+)
+
+// keeps ids of handled messages. cache repositories implement it
+type IDStore interface {
+	Save(key string, value map[string]string, expireTime time.Duration) error
+	Get(key string) (map[string]string, time.Time, error)
+}
+
+// skip messages that are already handled by handler. name must be unique for each handler.
+// ids are kept for ttl, so it must be longer than retry window of publisher
+func Deduplicate(name string, store IDStore, ttl time.Duration, handler Handler) Handler {
+	return func(ctx context.Context, msg Message) error {
+		key := "event_handled:" + name + ":" + msg.ID
+
+		if _, _, err := store.Get(key); err == nil {
+			return nil
+		}
+
+		if err := handler(ctx, msg); err != nil {
+			return err
+		}
+
+		return store.Save(key, map[string]string{"type": msg.Type}, ttl)
+	}
+}
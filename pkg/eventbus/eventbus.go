@@ -0,0 +1,95 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// subscribers of AllEvents receive every message
+const AllEvents = "*"
+
+type Message struct {
+	ID         string          `json:"id"` // same for redeliveries of a message. used for deduplication
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+type Handler func(ctx context.Context, msg Message) error
+
+// in-process bus and external broker adapters implement publisher
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// in-process publish/subscribe. handlers run synchronously in publisher goroutine
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func New() *Bus {
+	return &Bus{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// eventType can be AllEvents
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// all handlers of message are called even if some of them fail. errors of handlers are joined.
+// message is published again if any handler fails, so handlers must be idempotent (see Deduplicate)
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[msg.Type])+len(b.handlers[AllEvents]))
+	handlers = append(handlers, b.handlers[msg.Type]...)
+	handlers = append(handlers, b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := callHandler(ctx, handler, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// panic of handler is returned as error
+func callHandler(ctx context.Context, handler Handler, msg Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler of %s panicked: %v", msg.Type, r)
+		}
+	}()
+
+	return handler(ctx, msg)
+}
+
+type fanout []Publisher
+
+// publish to all publishers. e.g: in-process bus and external broker
+func Fanout(publishers ...Publisher) Publisher {
+	return fanout(publishers)
+}
+
+func (f fanout) Publish(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
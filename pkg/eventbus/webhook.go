@@ -0,0 +1,57 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	EventIDHeader   = "Idempotency-Key"
+	EventTypeHeader = "X-Event-Type"
+)
+
+// external broker adapter that posts messages as json to url of a broker or webhook receiver.
+// id of message is sent in Idempotency-Key header for deduplication by receiver
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// non 2xx responses are errors, so message is retried
+func (w *WebhookPublisher) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, msg.ID)
+	req.Header.Set(EventTypeHeader, msg.Type)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusShuttingDown = "shutting_down"
+)
+
+// check of a dependency. it must respect ctx deadline
+type CheckFunc func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+type CheckResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"` // details of error are only logged
+}
+
+type Report struct {
+	Status  string                 `json:"status"`
+	Version string                 `json:"version"`
+	Uptime  string                 `json:"uptime"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// liveness and readiness of service. readiness is ok when all dependency checks pass and server is not shutting down
+type Checker struct {
+	version   string
+	timeout   time.Duration
+	startedAt time.Time
+
+	mu     sync.Mutex
+	checks []namedCheck
+
+	shuttingDown atomic.Bool
+}
+
+// timeout is applied to each check
+func New(version string, timeout time.Duration) *Checker {
+	return &Checker{
+		version:   version,
+		timeout:   timeout,
+		startedAt: time.Now(),
+	}
+}
+
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// readiness fails after this call
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// run all checks concurrently
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := c.checks
+	c.mu.Unlock()
+
+	report := c.report(StatusOK)
+	report.Checks = make(map[string]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+	for _, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := c.runCheck(ctx, nc)
+
+			resultsMu.Lock()
+			report.Checks[nc.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+			resultsMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if c.shuttingDown.Load() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+// check is abandoned when timeout is reached. it is useful for checks that do not support context
+func (c *Checker) runCheck(ctx context.Context, nc namedCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- nc.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		slog.WarnContext(ctx, "health check failed", "check", nc.name, "error", err)
+
+		result.Status = StatusFail
+		result.Error = "unavailable"
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "timeout"
+		}
+	}
+
+	return result
+}
+
+func (c *Checker) report(status string) Report {
+	return Report{
+		Status:  status,
+		Version: c.version,
+		Uptime:  time.Since(c.startedAt).Round(time.Second).String(),
+	}
+}
+
+// process is alive. dependencies are not checked, so restarting is not triggered by database outage
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, c.report(StatusOK))
+	})
+}
+
+// returns 503 when a dependency is not available or server is shutting down
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// skip checks. shutdown must be detected fast
+		if c.shuttingDown.Load() {
+			writeReport(w, http.StatusServiceUnavailable, c.report(StatusShuttingDown))
+			return
+		}
+
+		report := c.Check(r.Context())
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeReport(w, status, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
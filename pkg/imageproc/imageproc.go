@@ -0,0 +1,222 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// prevent decompression bombs. decoded image uses 4 bytes per pixel (48MB for maxPixels)
+	maxDimension = 6000
+	maxPixels    = 12_000_000
+	jpegQuality  = 85
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrLargeDimension    = errors.New("image dimension is too large")
+)
+
+var allowedContentTypes = []string{"image/jpeg", "image/png"}
+
+// returns detected content type of data. e.g: image/png
+func DetectContentType(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+func IsAllowedContentType(contentType string) bool {
+	for _, allowed := range allowedContentTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decode jpeg or png image. exif orientation of jpeg is applied because metadata is removed after encoding
+func Decode(data []byte) (image.Image, error) {
+
+	if !IsAllowedContentType(DetectContentType(data)) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width > maxDimension || config.Height > maxDimension || config.Width*config.Height > maxPixels {
+		return nil, ErrLargeDimension
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	return img, nil
+}
+
+// crop center square of image and resize it to size*size
+func SquareThumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	crop := image.Rect(x, y, x+side, y+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+
+	return dst
+}
+
+// encoded image does not contain any metadata
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// returns exif orientation tag value of jpeg data. returns 1 (normal) if not found
+func jpegOrientation(data []byte) int {
+	// skip SOI marker
+	i := 2
+
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+
+		// start of scan. no more metadata
+		if marker == 0xDA {
+			return 1
+		}
+
+		segmentStart := i + 4
+		segmentEnd := i + 2 + length
+		if segmentEnd > len(data) || length < 2 {
+			return 1
+		}
+
+		// APP1 exif segment
+		if marker == 0xE1 && segmentEnd-segmentStart > 6 && string(data[segmentStart:segmentStart+6]) == "Exif\x00\x00" {
+			return tiffOrientation(data[segmentStart+6 : segmentEnd])
+		}
+
+		i = segmentEnd
+	}
+
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifdOffset := int(order.Uint32(tiff[4:8]))
+	if ifdOffset+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	for e := 0; e < entries; e++ {
+		entry := ifdOffset + 2 + e*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		// orientation tag
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+// rotate or flip image according to exif orientation
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation == 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+
+	for sy := 0; sy < h; sy++ {
+		for sx := 0; sx < w; sx++ {
+			var dx, dy int
+
+			switch orientation {
+			case 2: // flip horizontal
+				dx, dy = w-1-sx, sy
+			case 3: // rotate 180
+				dx, dy = w-1-sx, h-1-sy
+			case 4: // flip vertical
+				dx, dy = sx, h-1-sy
+			case 5: // transpose
+				dx, dy = sy, sx
+			case 6: // rotate 90 clockwise
+				dx, dy = h-1-sy, sx
+			case 7: // transverse
+				dx, dy = h-1-sy, w-1-sx
+			case 8: // rotate 270 clockwise
+				dx, dy = sy, w-1-sx
+			default:
+				return img
+			}
+
+			dst.Set(dx, dy, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+
+	return dst
+}
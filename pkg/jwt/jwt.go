@@ -2,7 +2,7 @@ package jwt
 
 import (
 	"errors"
-	"fmt"
+	"math"
 	"strconv"
 	"time"
 
@@ -79,9 +79,11 @@ func CreateAccessFromUser(accessExpireTime time.Duration, id uint64, name string
 	if id == 0 {
 		return "", errors.New("cannot create jwt: id is zero")
 	}
-	fmt.Println(id, "----------------")
+	now := time.Now()
 	access, err = CreateJwt(map[string]any{
-		"exp":          time.Now().Add(accessExpireTime).Unix(),
+		"exp": now.Add(accessExpireTime).Unix(),
+		// in seconds with milliseconds fraction, so tokens issued after revoke in same second are accepted
+		"iat":          float64(now.UnixMilli()) / 1000,
 		"id":           id,
 		"name":         name,
 		"number":       number,
@@ -98,7 +100,6 @@ func GetUserFromAccess(access string) (id uint64, name string, number string, is
 	if err != nil {
 		return 0, "", "", false, err
 	}
-	fmt.Println(mapClaims, "====================")
 	id = uint64(mapClaims["id"].(float64))
 	name = mapClaims["name"].(string)
 	number = mapClaims["number"].(string)
@@ -107,6 +108,18 @@ func GetUserFromAccess(access string) (id uint64, name string, number string, is
 	return id, name, number, isRegistered, nil
 }
 
+// issue time of access in milliseconds. it is 0 for tokens without issue time
+func GetIssuedAtFromAccess(access string) (int64, error) {
+
+	mapClaims, err := VerifyJwt(access)
+	if err != nil {
+		return 0, err
+	}
+
+	iat, _ := mapClaims["iat"].(float64)
+	return int64(math.Round(iat * 1000)), nil
+}
+
 func CreateRefreshAndAccessFromUserWithMap(refreshExpireMinutes time.Duration, accessExpireMinutes time.Duration, id uint64, name string, number string, isRegistered bool) (tokens map[string]string, err error) {
 	tokens = make(map[string]string)
 
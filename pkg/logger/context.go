@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type contextKey struct{}
+
+// request scoped fields that are added to every log record of request
+type requestFields struct {
+	requestID string
+	userID    atomic.Uint64 // set after authentication
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	fields := &requestFields{requestID: requestID}
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		return fields.requestID
+	}
+
+	return ""
+}
+
+// user id is shared with outer middlewares because context fields are pointer
+func SetUserID(ctx context.Context, userID uint64) {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		fields.userID.Store(userID)
+	}
+}
+
+func UserIDFromContext(ctx context.Context) uint64 {
+	if fields, ok := ctx.Value(contextKey{}).(*requestFields); ok {
+		return fields.userID.Load()
+	}
+
+	return 0
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// slog handler that redacts sensitive attributes and adds request id, user id and trace id from context
+type Handler struct {
+	next slog.Handler
+}
+
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{next: next}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	out := slog.NewRecord(record.Time, record.Level, RedactString(record.Message), record.PC)
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		out.AddAttrs(slog.String("request_id", requestID))
+	}
+	if userID := UserIDFromContext(ctx); userID != 0 {
+		out.AddAttrs(slog.Uint64("user_id", userID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		out.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		out.AddAttrs(redactAttr(attr))
+		return true
+	})
+
+	return h.next.Handle(ctx, out)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = redactAttr(attr)
+	}
+
+	return &Handler{next: h.next.WithAttrs(redactedAttrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// value that is logged without redaction. use only for values that must be visible. e.g: otp in debug mode
+type Unredacted string
+
+// normalized keys (lowercase without _ and -) that their value is removed
+var secretKeys = map[string]bool{
+	"access":        true,
+	"refresh":       true,
+	"token":         true,
+	"tokens":        true,
+	"authorization": true,
+	"password":      true,
+	"salt":          true,
+	"otp":           true,
+	"secret":        true,
+	"uri":           true, // totp provisioning uri contains secret
+	"url":           true, // presigned urls contain signature
+	"recoverycode":  true,
+	"recoverycodes": true,
+	"apikey":        true,
+	"xapikey":       true,
+	"cookie":        true,
+}
+
+var secretKeySuffixes = []string{"token", "password", "secret", "otp", "secretkey"}
+
+// normalized keys that their value is masked partially
+var phoneKeys = map[string]bool{
+	"number":      true,
+	"phonenumber": true,
+	"newnumber":   true,
+	"mobile":      true,
+}
+
+var (
+	jwtRegex   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	phoneRegex = regexp.MustCompile(`\+989\d{9}\b|\b(?:0098|0)?9\d{9}\b`)
+)
+
+func normalizeKey(key string) string {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
+
+func isSecretKey(key string) bool {
+	key = normalizeKey(key)
+	if secretKeys[key] {
+		return true
+	}
+
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// +989123456789 -> +98912****789
+func MaskPhoneNumber(number string) string {
+	if len(number) < 7 {
+		return strings.Repeat("*", len(number))
+	}
+
+	return number[:len(number)-7] + "****" + number[len(number)-3:]
+}
+
+// remove jwt tokens and mask phone numbers in free text
+func RedactString(s string) string {
+	s = jwtRegex.ReplaceAllString(s, redacted)
+	return phoneRegex.ReplaceAllStringFunc(s, MaskPhoneNumber)
+}
+
+// redact value of key. maps, slices and structs are redacted recursively
+func Redact(key string, value any) any {
+	if v, ok := value.(Unredacted); ok {
+		return string(v)
+	}
+
+	if isSecretKey(key) {
+		if isEmpty(value) {
+			return value
+		}
+		return redacted
+	}
+
+	if phoneKeys[normalizeKey(key)] {
+		if s, ok := value.(string); ok {
+			return MaskPhoneNumber(s)
+		}
+	}
+
+	return redactValue(value)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case Unredacted:
+		return string(v)
+	case string:
+		return RedactString(v)
+	case error:
+		return RedactString(v.Error())
+	case fmt.Stringer:
+		return RedactString(v.String())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = Redact(k, item)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = Redact(k, item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	}
+
+	// structs and other types are converted to json values for redacting fields
+	data, err := json.Marshal(value)
+	if err != nil {
+		return redacted
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return redacted
+	}
+
+	return redactValue(generic)
+}
+
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+
+	return false
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		attrs := value.Group()
+		out := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			out[i] = redactAttr(a)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(out...)}
+	}
+
+	// numbers, durations and times are kept unless key is secret
+	if value.Kind() != slog.KindString && value.Kind() != slog.KindAny && !isSecretKey(attr.Key) {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+
+	return slog.Any(attr.Key, Redact(attr.Key, value.Any()))
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startTimeKey = "metrics:start_time"
+
+// gorm plugin that records duration and errors of every query. usage: db.Use(metrics.GormPlugin{})
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	errs := []error{
+		callback.Create().Before("gorm:create").Register("metrics:before_create", before),
+		callback.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		callback.Query().Before("gorm:query").Register("metrics:before_query", before),
+		callback.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		callback.Update().Before("gorm:update").Register("metrics:before_update", before),
+		callback.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		callback.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		callback.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		callback.Row().Before("gorm:row").Register("metrics:before_row", before),
+		callback.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		callback.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		callback.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	}
+
+	return errors.Join(errs...)
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+func after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+
+		DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			DBQueryErrors.WithLabelValues(operation, table).Inc()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pedarkharj"
+
+// registry of all application metrics. default registry is not used for avoiding metrics of other packages
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled http requests by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of http requests by route.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	GRPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Number of handled grpc requests by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of grpc requests by method.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of database queries by operation and table.",
+		Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Number of failed database queries by operation and table. record not found is not counted.",
+	}, []string{"operation", "table"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Number of cache lookups by backend and result (hit, miss or error).",
+	}, []string{"backend", "result"})
+
+	SMSSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "sms",
+		Name:      "sent_total",
+		Help:      "Number of sms send attempts by provider and result (success or failure).",
+	}, []string{"provider", "result"})
+
+	OutboxEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_total",
+		Help:      "Number of outbox event deliveries by result (published, retry or failed).",
+	}, []string{"result"})
+
+	ExpensesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "expenses_created_total",
+		Help:      "Number of created expenses.",
+	})
+
+	DebtsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "debts_created_total",
+		Help:      "Number of created debts.",
+	})
+
+	DebtsSettled = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "debts_settled_total",
+		Help:      "Number of debts that their payment is accepted.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		GRPCRequests,
+		GRPCRequestDuration,
+		DBQueryDuration,
+		DBQueryErrors,
+		CacheRequests,
+		SMSSent,
+		OutboxEvents,
+		ExpensesCreated,
+		DebtsCreated,
+		DebtsSettled,
+	)
+}
+
+// handler of metrics endpoint in prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+func SMSResult(provider string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	SMSSent.WithLabelValues(provider, result).Inc()
+}
@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+// token bucket with capacity of Limit that is refilled with Limit tokens in Period. e.g: 10/1m
+type Policy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// parse policy in "<limit>/<period>" format. e.g: 10/1m
+func ParsePolicy(s string) (Policy, error) {
+	limit, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit policy must be <limit>/<period>: %q", s)
+	}
+
+	var policy Policy
+	var err error
+
+	policy.Limit, err = strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || policy.Limit <= 0 {
+		return Policy{}, fmt.Errorf("limit of rate limit policy must be positive: %q", s)
+	}
+
+	policy.Period, err = time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || policy.Period <= 0 {
+		return Policy{}, fmt.Errorf("period of rate limit policy must be positive duration: %q", s)
+	}
+
+	return policy, nil
+}
+
+func (p Policy) String() string {
+	return strconv.Itoa(p.Limit) + "/" + p.Period.String()
+}
+
+// store of buckets. it is implemented by cache repositories
+type Store interface {
+	Get(key string) (map[string]string, time.Time, error)
+	// value is saved only if key does not exist or is expired. returns true if value is saved
+	SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error)
+	// value is replaced only if stored value of key is old and is not expired. returns true if value is replaced
+	CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error)
+}
+
+// attempts of taking a token from bucket that is changed by concurrent requests
+const maxAttempts = 5
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // time until next token. zero if request is allowed
+	Reset      time.Duration // time until bucket is full
+}
+
+// buckets are kept in store so limits are shared between instances.
+// bucket is saved only if it is not changed after it is read, so concurrent requests of all instances cannot take same token
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// take one token from bucket of key. request is rejected if bucket is changed by concurrent requests in all attempts
+func (l *Limiter) Allow(key string, policy Policy) (Result, error) {
+	capacity := float64(policy.Limit)
+	ratePerSecond := capacity / policy.Period.Seconds()
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		now := l.now()
+
+		// new bucket is full
+		tokens := capacity
+		bucket, _, err := l.store.Get(key)
+		if err == nil {
+			tokens, err = refill(bucket, now, capacity, ratePerSecond)
+			if err != nil {
+				return Result{}, err
+			}
+		} else if errors.Is(err, database_errors.ErrRecordNotFound) || errors.Is(err, database_errors.ErrExpired) {
+			bucket = nil
+		} else {
+			return Result{}, err
+		}
+
+		result := Result{Limit: policy.Limit}
+		if tokens < 1 {
+			// bucket is not changed
+			result.RetryAfter = secondsToDuration((1 - tokens) / ratePerSecond)
+			result.Reset = secondsToDuration((capacity - tokens) / ratePerSecond)
+			return result, nil
+		}
+
+		tokens--
+		result.Allowed = true
+		result.Remaining = int(math.Floor(tokens))
+		result.Reset = secondsToDuration((capacity - tokens) / ratePerSecond)
+
+		// bucket is removed when it becomes full
+		value := map[string]string{
+			"tokens":     strconv.FormatFloat(tokens, 'f', -1, 64),
+			"updated_at": strconv.FormatInt(now.UnixNano(), 10),
+		}
+		expire := max(result.Reset, time.Second)
+
+		var saved bool
+		if bucket == nil {
+			saved, err = l.store.SaveIfAbsent(key, value, expire)
+		} else {
+			saved, err = l.store.CompareAndSwap(key, bucket, value, expire)
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		if saved {
+			return result, nil
+		}
+	}
+
+	return Result{
+		Limit:      policy.Limit,
+		RetryAfter: secondsToDuration(1 / ratePerSecond),
+	}, nil
+}
+
+// tokens of bucket after refilling from last update
+func refill(bucket map[string]string, now time.Time, capacity float64, ratePerSecond float64) (float64, error) {
+	tokens, err := strconv.ParseFloat(bucket["tokens"], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tokens of rate limit bucket: %w", err)
+	}
+
+	updatedAt, err := strconv.ParseInt(bucket["updated_at"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid updated_at of rate limit bucket: %w", err)
+	}
+
+	elapsed := now.Sub(time.Unix(0, updatedAt)).Seconds()
+	if elapsed > 0 {
+		tokens += elapsed * ratePerSecond
+	}
+
+	return min(tokens, capacity), nil
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -9,7 +9,16 @@ const (
 	InvalidHeader     = "invalid_header"
 	InvalidToken      = "invalid_token"
 	Unauthenticated   = "unauthenticated"
-	InvalidJSON = "invalid_json"
+	InvalidJSON       = "invalid_json"
+	VersionConflict   = "version_conflict"
+	NotFound          = "not_found"
+	MethodNotAllowed  = "method_not_allowed"
+	TooManyRequests   = "too_many_requests"
+	BodyTooLarge      = "body_too_large"
+
+	// idempotency
+	IdempotencyKeyMismatch   = "idempotency_key_mismatch"
+	IdempotencyKeyInProgress = "idempotency_key_in_progress"
 
 	// user
 	CodeSendToNumber      = "code_sent_to_number"
@@ -24,6 +33,28 @@ const (
 	UserAlreadyRegistered = "user_already_registered"
 	UserNotRegistered     = "user_not_registered"
 	GoRestPassword        = "go_reset_password"
+
+	// two factor
+	TwoFactorRequired       = "two_factor_required"
+	TwoFactorAlreadyEnabled = "two_factor_already_enabled"
+	TwoFactorNotEnabled     = "two_factor_not_enabled"
+	EnrollTwoFactorFirst    = "enroll_two_factor_first"
+	ChallengeExpired        = "challenge_expired"
+
+	// account
+	ExportInProgress = "export_in_progress"
+	ExportNotFound   = "export_not_found"
+
+	// admin
+	PermissionDenied = "permission_denied"
+	UserNotFound     = "user_not_found"
+
+	// expense
+	ExpenseNotFound = "expense_not_found"
+
+	// debt
+	DebtNotFound = "debt_not_found"
+	DebtNotPaid  = "debt_not_paid"
 )
 
 type ResponseCode string
@@ -1,16 +1,19 @@
 package s3
 
 import (
+	"context"
 	"io"
 	"log/slog"
-	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -24,31 +27,24 @@ var (
 	s3Client *s3.S3
 )
 
-// use manual init because env variable must be loaded
-func Init() {
-
-	accessKey = os.Getenv("S3_ACCESS_KEY")
-	secretKey = os.Getenv("S3_SECRET_KEY")
-	bucketName = os.Getenv("S3_BUCKET_NAME")
-	apiUrlValue = os.Getenv("S3_API_URL_VALUE")
-	accessUrl = os.Getenv("S3_ACCESS_URL")
-	accessUrlProtocol = os.Getenv("S3_ACCESS_URL_PROTOCOL")
-
-	if accessKey == "" || secretKey == "" || bucketName == "" || apiUrlValue == "" || accessUrl == "" || accessUrlProtocol == "" {
-		printIfEmpty := func(name, value string) {
-			if value == "" {
-				slog.Warn("cannot get env variable for s3", "error", name+" is empty")
-			}
-		}
-
-		printIfEmpty("access key", accessKey)
-		printIfEmpty("secret key", secretKey)
-		printIfEmpty("bucket name", bucketName)
-		printIfEmpty("api url value", apiUrlValue)
-		printIfEmpty("access url", accessUrl)
-		printIfEmpty("access url protocol", accessUrlProtocol)
+type Options struct {
+	AccessKey         string
+	SecretKey         string
+	BucketName        string
+	APIURL            string
+	AccessURL         string
+	AccessURLProtocol string // http:// or https://
+}
 
-	}
+// use manual init because config must be loaded
+func Init(options Options) {
+
+	accessKey = options.AccessKey
+	secretKey = options.SecretKey
+	bucketName = options.BucketName
+	apiUrlValue = options.APIURL
+	accessUrl = options.AccessURL
+	accessUrlProtocol = options.AccessURLProtocol
 
 	s3Config := &aws.Config{
 		Credentials:      credentials.NewStaticCredentials(accessKey, secretKey, ""),
@@ -71,8 +67,14 @@ func Init() {
 }
 
 // key exmaple: folder/name.format
-func PutObject(key string, body io.ReadSeeker) error {
-	_, err := s3Client.PutObject(&s3.PutObjectInput{
+func PutObject(ctx context.Context, key string, body io.ReadSeeker) (err error) {
+	ctx, span := tracing.Start(ctx, "s3.PutObject", attribute.String("s3.key", key))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	_, err = s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
 		Body:   body,
 		Bucket: &bucketName,
 		Key:    &key,
@@ -81,13 +83,38 @@ func PutObject(key string, body io.ReadSeeker) error {
 	return err
 }
 
+// key exmaple: folder/name.format
+func PutObjectWithContentType(ctx context.Context, key string, body io.ReadSeeker, contentType string) (err error) {
+	ctx, span := tracing.Start(ctx, "s3.PutObject", attribute.String("s3.key", key))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	_, err = s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Body:        body,
+		Bucket:      &bucketName,
+		Key:         &key,
+		ContentType: aws.String(contentType),
+	})
+
+	return err
+}
+
 // key example: https://domain.name/folder/name.format
-func DeleteObject(key string) error {
+func DeleteObject(ctx context.Context, key string) (err error) {
 	splited := strings.Split(key, apiUrlValue)
 	if len(splited) > 1 {
 		key = splited[1]
 	}
-	_, err := s3Client.DeleteObject(&s3.DeleteObjectInput{
+
+	ctx, span := tracing.Start(ctx, "s3.DeleteObject", attribute.String("s3.key", key))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	_, err = s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
 		Bucket: &bucketName,
 		Key:    aws.String(key),
 	})
@@ -95,13 +122,23 @@ func DeleteObject(key string) error {
 	return err
 }
 
+// key exmaple: folder/name.format
+// output example: https://domain.name/folder/name.format
+func GetObjectURL(key string) string {
+	return accessUrlProtocol + path.Join(accessUrl, key)
+}
+
 // output []string example: {"https://domain.name/folder/name.format", ... }
-func GetListObjects(key string) ([]string, error) {
-	resp, err := s3Client.ListObjects(&s3.ListObjectsInput{
+func GetListObjects(ctx context.Context, key string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "s3.ListObjects", attribute.String("s3.prefix", key))
+	defer span.End()
+
+	resp, err := s3Client.ListObjectsWithContext(ctx, &s3.ListObjectsInput{
 		Bucket: &bucketName,
 		Prefix: &key,
 	})
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, err
 	}
 
@@ -114,3 +151,23 @@ func GetListObjects(key string) ([]string, error) {
 	list = list[1:]
 	return list, nil
 }
+
+// returns temporary url for private objects
+// key exmaple: folder/name.format
+func GetPresignedURL(key string, expireTime time.Duration) (string, error) {
+	req, _ := s3Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: &bucketName,
+		Key:    aws.String(key),
+	})
+
+	return req.Presign(expireTime)
+}
+
+// check bucket is reachable with current credentials
+func Ping(ctx context.Context) error {
+	_, err := s3Client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+		Bucket: &bucketName,
+	})
+
+	return err
+}
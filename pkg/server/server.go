@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// time for draining in-flight requests and running shutdown hooks
+	ShutdownTimeout time.Duration
+	// time between start of shutdown (readiness is false) and closing listener.
+	// load balancers need this time for removing instance
+	ShutdownDelay time.Duration
+
+	// tls is enabled when both files are set
+	TLSCertFile string
+	TLSKeyFile  string
+}
+
+// called after http server stopped. e.g: wait for background workers or close connections
+type ShutdownHook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook ShutdownHook
+}
+
+type Server struct {
+	options    Options
+	httpServer *http.Server
+
+	mu             sync.Mutex
+	hooks          []namedHook
+	shutdownStarts []func()
+	addr           net.Addr
+	ready          chan struct{}
+}
+
+func New(handler http.Handler, options Options) *Server {
+	return &Server{
+		options: options,
+		httpServer: &http.Server{
+			Addr:              options.Addr,
+			Handler:           handler,
+			ReadTimeout:       options.ReadTimeout,
+			ReadHeaderTimeout: options.ReadHeaderTimeout,
+			WriteTimeout:      options.WriteTimeout,
+			IdleTimeout:       options.IdleTimeout,
+			MaxHeaderBytes:    options.MaxHeaderBytes,
+			ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		},
+		ready: make(chan struct{}),
+	}
+}
+
+// hooks are run in reverse order of registration
+func (s *Server) OnShutdown(name string, hook ShutdownHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, namedHook{name: name, hook: hook})
+}
+
+// called when shutdown is started, before ShutdownDelay and draining requests. e.g: fail readiness checks
+func (s *Server) OnShutdownStart(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shutdownStarts = append(s.shutdownStarts, fn)
+}
+
+func (s *Server) IsTLS() bool {
+	return s.options.TLSCertFile != "" && s.options.TLSKeyFile != ""
+}
+
+// listening address. it is available after Ready is closed
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addr
+}
+
+// closed when server is listening
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// serve until ctx is done, then stop accepting connections, drain in-flight requests and run shutdown hooks.
+// returns nil on graceful shutdown
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.options.Addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.addr = listener.Addr()
+	s.mu.Unlock()
+	close(s.ready)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.IsTLS() {
+			serveErr <- s.httpServer.ServeTLS(listener, s.options.TLSCertFile, s.options.TLSKeyFile)
+		} else {
+			serveErr <- s.httpServer.Serve(listener)
+		}
+	}()
+
+	slog.Info("server started", "addr", listener.Addr().String(), "tls", s.IsTLS())
+
+	select {
+	case err := <-serveErr:
+		// server failed before shutdown
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.runHooks(context.Background())
+			return err
+		}
+	case <-ctx.Done():
+		slog.Info("shutting down server")
+	}
+
+	return s.Shutdown()
+}
+
+func (s *Server) Shutdown() error {
+	s.mu.Lock()
+	shutdownStarts := s.shutdownStarts
+	s.shutdownStarts = nil
+	s.mu.Unlock()
+
+	for _, fn := range shutdownStarts {
+		fn()
+	}
+
+	// server still accepts requests in this time
+	if s.options.ShutdownDelay > 0 {
+		slog.Info("waiting before closing listener", "delay", s.options.ShutdownDelay)
+		time.Sleep(s.options.ShutdownDelay)
+	}
+
+	ctx := context.Background()
+	if s.options.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.ShutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http server: %w", err))
+	}
+
+	errs = append(errs, s.runHooks(ctx))
+
+	err := errors.Join(errs...)
+	if err == nil {
+		slog.Info("server stopped gracefully")
+	}
+
+	return err
+}
+
+func (s *Server) runHooks(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].hook(ctx); err != nil {
+			slog.Error("shutdown hook failed", "hook", hooks[i].name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
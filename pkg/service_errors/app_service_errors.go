@@ -1,16 +1,31 @@
 package service_errors
 
-import "errors"
+import "net/http"
 
 var (
-	ErrOTPNotExpired                            = errors.New("otp not expired. wait some minutes")
-	ErrOTPNotSend                               = errors.New("code: OTP wasn't sent. go send-otp first")
-	ErrUserNotRegisteredResetPasswordNotAllowed = errors.New("user not exist. reset_password not allowed")
-	ErrUserAlreayRegisteredSignupNotAllowed     = errors.New("user already exist. signup mode not allowed")
-	ErrVerifyNumberFirst                        = errors.New("verify number first")
-	ErrNumberNotExist                           = errors.New("number: number not exist")
-	ErrAvatarNotFound                           = errors.New("avatar: avatar not found")
-	ErrWrongOTP                                 = errors.New("otp: wrong otp")
-	ErrWrongToken                               = errors.New("token: wrong token")
-	ErrRefreshTokenExpired                      = errors.New("refresh: refresh token expired")
+	ErrOTPNotExpired                            = New(http.StatusTooManyRequests, "otp_not_expired", "", "otp not expired. wait some minutes")
+	ErrOTPNotSend                               = badRequest("otp_not_sent", "code", "OTP wasn't sent. go send-otp first")
+	ErrUserNotRegisteredResetPasswordNotAllowed = badRequest("reset_password_not_allowed", "", "user not exist. reset_password not allowed")
+	ErrUserAlreayRegisteredSignupNotAllowed     = New(http.StatusConflict, "signup_not_allowed", "", "user already exist. signup mode not allowed")
+	ErrVerifyNumberFirst                        = badRequest("verify_number_first", "", "verify number first")
+	ErrNumberNotExist                           = New(http.StatusNotFound, "number_not_exist", "number", "number not exist")
+	ErrAvatarNotFound                           = New(http.StatusNotFound, "avatar_not_found", "avatar", "avatar not found")
+	ErrWrongOTP                                 = badRequest("wrong_otp", "otp", "wrong otp")
+	ErrWrongToken                               = badRequest("wrong_token", "token", "wrong token")
+	ErrRefreshTokenExpired                      = New(http.StatusUnauthorized, "refresh_token_expired", "refresh", "refresh token expired")
+	ErrTwoFactorChallengeExpired                = New(http.StatusUnauthorized, "challenge_expired", "challenge_token", "challenge expired or not found. login again")
+	ErrTooManyTwoFactorAttempts                 = New(http.StatusTooManyRequests, "too_many_two_factor_attempts", "", "too many attempts. wait some minutes")
+	ErrNumberAlreadyRegistered                  = New(http.StatusConflict, "number_already_registered", "new_number", "number already registered")
+	ErrWrongOldOTP                              = badRequest("wrong_old_otp", "old_otp", "wrong otp")
+	ErrWrongNewOTP                              = badRequest("wrong_new_otp", "new_otp", "wrong otp")
+	ErrTooManyOTPAttempts                       = New(http.StatusTooManyRequests, "too_many_otp_attempts", "", "too many attempts. send otp again")
+	ErrTooManyLoginAttempts                     = New(http.StatusTooManyRequests, "too_many_login_attempts", "", "too many failed login attempts. wait some minutes")
+	ErrTooManyPasswordAttempts                  = New(http.StatusTooManyRequests, "too_many_password_attempts", "password", "too many wrong passwords. wait some minutes")
+	ErrInvalidImage                             = badRequest("invalid_image", "avatar", "invalid image")
+	ErrExportInProgress                         = New(http.StatusConflict, "export_in_progress", "", "data export is in progress. wait some minutes")
+	ErrExportNotFound                           = New(http.StatusNotFound, "export_not_found", "", "data export not found. request export first")
+	ErrUserNotFound                             = New(http.StatusNotFound, "user_not_found", "user_id", "user not found")
+	ErrExpenseNotFound                          = New(http.StatusNotFound, "expense_not_found", "expense_id", "expense not found")
+	ErrDebtNotFound                             = New(http.StatusNotFound, "debt_not_found", "debt_id", "debt not found")
+	ErrVersionConflict                          = New(http.StatusConflict, "version_conflict", "version", "changed by another request. retry with current version")
 )
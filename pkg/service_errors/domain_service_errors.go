@@ -1,40 +1,70 @@
 package service_errors
 
-import "errors"
+import "net/http"
 
 var (
 	// global
-	ErrPermissionDenied   = errors.New("permission denied")
-	ErrInvalidDescription = errors.New("description: invalid description")
-	ErrInvalidPage        = errors.New("page: invalid page")
-	ErrInvalidLimit       = errors.New("limit: invalid limit")
-	ErrInvalidID          = errors.New("id: invalid id")
+	ErrPermissionDenied   = New(http.StatusForbidden, "permission_denied", "", "permission denied")
+	ErrInvalidDescription = badRequest("invalid_description", "description", "invalid description")
+	ErrInvalidPage        = badRequest("invalid_page", "page", "invalid page")
+	ErrInvalidLimit       = badRequest("invalid_limit", "limit", "invalid limit")
+	ErrInvalidID          = badRequest("invalid_id", "id", "invalid id")
 
 	// user
-	ErrInternalServerError = errors.New("internal server error")
-	ErrInvalidName         = errors.New("name: invalid name")
-	ErrBlockedUser         = errors.New("you are blocked")
-	ErrInvalidNumber       = errors.New("number: invalid number")
-	ErrInvalidCode         = errors.New("code: invalid code")
-	ErrInvalidToken        = errors.New("token: invalid token")
-	ErrSmallPassword       = errors.New("password: small password")
-	ErrLongPassword        = errors.New("password: long password")
-	ErrLongName            = errors.New("name: long name")
-	ErrSmallName           = errors.New("name: small name")
-	ErrWrongPassword       = errors.New("password: wrong password")
-	ErrUserNotRegistered   = errors.New("user not registered")
-	ErrInvalidMode         = errors.New("mode: invalid mode")
+	ErrInternalServerError = New(http.StatusInternalServerError, "internal_error", "", "internal server error")
+	ErrInvalidName         = badRequest("invalid_name", "name", "invalid name")
+	ErrBlockedUser         = New(http.StatusForbidden, "blocked_user", "", "you are blocked")
+	ErrInvalidNumber       = badRequest("invalid_number", "number", "invalid number")
+	ErrInvalidCode         = badRequest("invalid_code", "code", "invalid code")
+	ErrInvalidToken        = badRequest("invalid_token", "token", "invalid token")
+	ErrSmallPassword       = badRequest("short_password", "password", "small password")
+	ErrLongPassword        = badRequest("long_password", "password", "long password")
+	ErrLongName            = badRequest("long_name", "name", "long name")
+	ErrSmallName           = badRequest("short_name", "name", "small name")
+	ErrWrongPassword       = badRequest("wrong_password", "password", "wrong password")
+	ErrUserNotRegistered   = badRequest("user_not_registered", "", "user not registered")
+	ErrInvalidMode         = badRequest("invalid_mode", "mode", "invalid mode")
+	ErrPasswordRequired    = badRequest("password_required", "password", "password is required")
+	ErrInvalidLanguage     = badRequest("invalid_language", "language", "invalid language")
+	ErrInvalidCurrency     = badRequest("invalid_currency", "currency", "invalid currency")
+	ErrInvalidNewNumber    = badRequest("invalid_new_number", "new_number", "invalid number")
+	ErrSameNumber          = badRequest("same_number", "new_number", "new number is same as current number")
+	ErrInvalidOldOTP       = badRequest("invalid_old_otp", "old_otp", "invalid otp")
+	ErrInvalidNewOTP       = badRequest("invalid_new_otp", "new_otp", "invalid otp")
+	ErrEmptyAvatar         = badRequest("avatar_required", "avatar", "avatar file is required")
+	ErrLargeAvatar         = New(http.StatusRequestEntityTooLarge, "avatar_too_large", "avatar", "avatar file is too large")
+	ErrInvalidAvatarFormat = New(http.StatusUnsupportedMediaType, "invalid_avatar_format", "avatar", "only jpeg and png are allowed")
+
+	// two factor
+	ErrTwoFactorAlreadyEnabled = New(http.StatusConflict, "two_factor_already_enabled", "", "two factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = New(http.StatusConflict, "two_factor_not_enabled", "", "two factor authentication is not enabled")
+	ErrEnrollTwoFactorFirst    = New(http.StatusConflict, "enroll_two_factor_first", "", "enroll two factor authentication first")
+	ErrWrongTOTPCode           = badRequest("wrong_code", "code", "wrong code")
+	ErrWrongRecoveryCode       = badRequest("wrong_recovery_code", "recovery_code", "wrong recovery code")
+	ErrTwoFactorCodeRequired   = badRequest("code_required", "code", "code or recovery_code is required")
 
 	// device
-	ErrInvalidIP           = errors.New("lastIP: invalid last ip")
-	ErrInvalidRefreshToken = errors.New("refresh: invalid refresh token")
-	ErrInvalidUserAgent    = errors.New("useragent: invalid user agent")
+	ErrInvalidIP           = badRequest("invalid_ip", "lastIP", "invalid last ip")
+	ErrInvalidRefreshToken = New(http.StatusUnauthorized, "invalid_refresh_token", "refresh", "invalid refresh token")
+	ErrInvalidUserAgent    = badRequest("invalid_user_agent", "useragent", "invalid user agent")
 
 	// expense
-	ErrInvalidCredit                     = errors.New("credit: invalid credit")
-	ErrDebtIsNotPaid                     = errors.New("debt is not paid yet")
-	ErrEmptyCreditors                    = errors.New("creditors: creditors cannot be empty")
-	ErrCommonCreditorAndDebtor           = errors.New("debtors: list of creditors and debtors cannot overlap")
-	ErrLowCredit                         = errors.New("creditors: credit is too low")
-	ErrCreatorNustBeInCreditorsOrDebtors = errors.New("creator must be in creditors or debtors")
+	ErrInvalidCredit                     = badRequest("invalid_credit", "credit", "invalid credit")
+	ErrDebtIsNotPaid                     = New(http.StatusConflict, "debt_not_paid", "", "debt is not paid yet")
+	ErrEmptyCreditors                    = badRequest("creditors_required", "creditors", "creditors cannot be empty")
+	ErrCommonCreditorAndDebtor           = badRequest("common_creditor_and_debtor", "debtors", "list of creditors and debtors cannot overlap")
+	ErrLowCredit                         = badRequest("low_credit", "creditors", "credit is too low")
+	ErrCreatorNustBeInCreditorsOrDebtors = badRequest("creator_not_participant", "", "creator must be in creditors or debtors")
+
+	// admin
+	ErrInvalidQuery        = badRequest("invalid_query", "query", "invalid query")
+	ErrInvalidUserID       = badRequest("invalid_user_id", "user_id", "invalid user id")
+	ErrCannotBlockYourself = badRequest("cannot_block_yourself", "user_id", "you cannot block yourself")
+	ErrCannotBlockAdmin    = New(http.StatusForbidden, "cannot_block_admin", "user_id", "admins cannot be blocked")
+	ErrLongReason          = badRequest("long_reason", "reason", "long reason")
+
+	// audit
+	ErrInvalidAction     = badRequest("invalid_action", "action", "invalid action")
+	ErrInvalidTargetType = badRequest("invalid_target_type", "target_type", "invalid target type")
+	ErrInvalidTimeRange  = badRequest("invalid_time_range", "from", "from must be before to")
 )
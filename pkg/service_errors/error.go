@@ -0,0 +1,47 @@
+package service_errors
+
+import "net/http"
+
+const DefaultLanguage = "en"
+
+// languages that messages are translated to. first one is default
+var SupportedLanguages = []string{DefaultLanguage, "fa"}
+
+// typed error with http status, stable machine code and field of error.
+// clients must use Code instead of message. Error() is "<field>: <message>" like older untyped errors
+type Error struct {
+	Status  int    // http status
+	Code    string // stable machine code. e.g: invalid_name
+	Field   string // empty if error is not about a field
+	Message string // english message
+}
+
+func New(status int, code string, field string, message string) error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Field:   field,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+
+	return e.Field + ": " + e.Message
+}
+
+// message in language. english message is returned if there is no translation
+func (e *Error) Localize(language string) string {
+	if message, ok := translations[language][e.Code]; ok {
+		return message
+	}
+
+	return e.Message
+}
+
+func badRequest(code string, field string, message string) error {
+	return New(http.StatusBadRequest, code, field, message)
+}
@@ -0,0 +1,118 @@
+package service_errors
+
+// translated messages by language and code of error
+var translations = map[string]map[string]string{
+	"fa": {
+		// global
+		"permission_denied":   "دسترسی ندارید",
+		"invalid_description": "توضیحات نامعتبر است",
+		"invalid_page":        "شماره صفحه نامعتبر است",
+		"invalid_limit":       "تعداد نامعتبر است",
+		"invalid_id":          "شناسه نامعتبر است",
+		"internal_error":      "خطای سرور",
+		"not_found":           "صفحه پیدا نشد",
+		"method_not_allowed":  "این متد مجاز نیست",
+		"too_many_requests":   "تعداد درخواست‌ها زیاد است. بعدا تلاش کنید",
+
+		// request
+		"authentication_required":       "ابتدا وارد شوید",
+		"invalid_authorization_header":  "فرمت هدر Authorization نامعتبر است",
+		"invalid_access_token":          "توکن نامعتبر است",
+		"json_content_type_required":    "هدر application/json الزامی است",
+		"invalid_json":                  "json نامعتبر است",
+		"cannot_read_body":              "خواندن بدنه درخواست ممکن نیست",
+		"body_too_large":                "بدنه درخواست بیش از حد بزرگ است",
+		"idempotency_key_too_long":      "هدر Idempotency-Key بیش از حد طولانی است",
+		"idempotency_key_mismatch":      "این Idempotency-Key برای درخواست دیگری استفاده شده است",
+		"idempotency_key_in_progress":   "درخواستی با این Idempotency-Key در حال انجام است",
+		"idempotency_key_not_supported": "Idempotency-Key برای این مسیر پشتیبانی نمی‌شود",
+		"invalid_if_match":              "هدر If-Match باید ETag منبع باشد",
+		"cannot_read_avatar":            "خواندن فایل آواتار ممکن نیست",
+		"invalid_actor_id":              "شناسه انجام‌دهنده باید عدد باشد",
+		"invalid_target_id":             "شناسه هدف باید عدد باشد",
+		"invalid_from":                  "زمان شروع باید در قالب RFC3339 باشد",
+		"invalid_to":                    "زمان پایان باید در قالب RFC3339 باشد",
+
+		// user
+		"otp_not_expired":              "کد قبلی هنوز منقضی نشده است. چند دقیقه صبر کنید",
+		"otp_not_sent":                 "کد ارسال نشده است. ابتدا درخواست ارسال کد دهید",
+		"reset_password_not_allowed":   "کاربر وجود ندارد. بازیابی رمز عبور ممکن نیست",
+		"signup_not_allowed":           "کاربر قبلا ثبت نام کرده است",
+		"verify_number_first":          "ابتدا شماره را تایید کنید",
+		"number_not_exist":             "شماره وجود ندارد",
+		"avatar_not_found":             "آواتار پیدا نشد",
+		"wrong_otp":                    "کد اشتباه است",
+		"wrong_token":                  "توکن اشتباه است",
+		"refresh_token_expired":        "توکن منقضی شده است",
+		"challenge_expired":            "زمان تایید منقضی شده است. دوباره وارد شوید",
+		"too_many_two_factor_attempts": "تلاش‌های زیاد. دوباره وارد شوید",
+		"number_already_registered":    "این شماره قبلا ثبت شده است",
+		"wrong_old_otp":                "کد اشتباه است",
+		"wrong_new_otp":                "کد اشتباه است",
+		"too_many_otp_attempts":        "تلاش‌های زیاد. دوباره کد دریافت کنید",
+		"too_many_login_attempts":      "تلاش‌های ناموفق زیاد. چند دقیقه صبر کنید",
+		"too_many_password_attempts":   "رمز عبور اشتباه زیاد. چند دقیقه صبر کنید",
+		"invalid_image":                "تصویر نامعتبر است",
+		"export_in_progress":           "خروجی اطلاعات در حال آماده‌سازی است. چند دقیقه صبر کنید",
+		"export_not_found":             "خروجی اطلاعات پیدا نشد. ابتدا درخواست دهید",
+		"user_not_found":               "کاربر پیدا نشد",
+		"invalid_name":                 "نام نامعتبر است",
+		"blocked_user":                 "حساب شما مسدود شده است",
+		"invalid_number":               "شماره نامعتبر است",
+		"invalid_code":                 "کد نامعتبر است",
+		"invalid_token":                "توکن نامعتبر است",
+		"short_password":               "رمز عبور کوتاه است",
+		"long_password":                "رمز عبور طولانی است",
+		"long_name":                    "نام طولانی است",
+		"short_name":                   "نام کوتاه است",
+		"wrong_password":               "رمز عبور اشتباه است",
+		"user_not_registered":          "کاربر ثبت نام نکرده است",
+		"invalid_mode":                 "حالت نامعتبر است",
+		"password_required":            "رمز عبور الزامی است",
+		"invalid_language":             "زبان نامعتبر است",
+		"invalid_currency":             "واحد پول نامعتبر است",
+		"invalid_new_number":           "شماره نامعتبر است",
+		"same_number":                  "شماره جدید با شماره فعلی یکسان است",
+		"invalid_old_otp":              "کد نامعتبر است",
+		"invalid_new_otp":              "کد نامعتبر است",
+		"avatar_required":              "فایل آواتار الزامی است",
+		"avatar_too_large":             "حجم فایل آواتار زیاد است",
+		"invalid_avatar_format":        "فقط فرمت‌های jpeg و png مجاز هستند",
+
+		// two factor
+		"two_factor_already_enabled": "ورود دو مرحله‌ای قبلا فعال شده است",
+		"two_factor_not_enabled":     "ورود دو مرحله‌ای فعال نیست",
+		"enroll_two_factor_first":    "ابتدا ورود دو مرحله‌ای را راه‌اندازی کنید",
+		"wrong_code":                 "کد اشتباه است",
+		"wrong_recovery_code":        "کد بازیابی اشتباه است",
+		"code_required":              "کد یا کد بازیابی الزامی است",
+
+		// device
+		"invalid_ip":            "آی‌پی نامعتبر است",
+		"invalid_refresh_token": "توکن نامعتبر است",
+		"invalid_user_agent":    "user agent نامعتبر است",
+
+		// expense and debt
+		"expense_not_found":          "هزینه پیدا نشد",
+		"debt_not_found":             "بدهی پیدا نشد",
+		"version_conflict":           "توسط درخواست دیگری تغییر کرده است. با نسخه فعلی دوباره تلاش کنید",
+		"invalid_credit":             "مبلغ نامعتبر است",
+		"debt_not_paid":              "بدهی هنوز پرداخت نشده است",
+		"creditors_required":         "لیست طلبکاران نمی‌تواند خالی باشد",
+		"common_creditor_and_debtor": "طلبکاران و بدهکاران نمی‌توانند مشترک باشند",
+		"low_credit":                 "مبلغ کم است",
+		"creator_not_participant":    "سازنده باید جزو طلبکاران یا بدهکاران باشد",
+
+		// admin
+		"invalid_query":         "عبارت جستجو نامعتبر است",
+		"invalid_user_id":       "شناسه کاربر نامعتبر است",
+		"cannot_block_yourself": "نمی‌توانید خودتان را مسدود کنید",
+		"cannot_block_admin":    "مدیران را نمی‌توان مسدود کرد",
+		"long_reason":           "دلیل طولانی است",
+
+		// audit
+		"invalid_action":      "عملیات نامعتبر است",
+		"invalid_target_type": "نوع هدف نامعتبر است",
+		"invalid_time_range":  "زمان شروع باید قبل از زمان پایان باشد",
+	},
+}
@@ -2,14 +2,19 @@ package sms
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const provider = "sms.ir"
+
 type SMSInput struct {
 	Mobile     string         `json:"mobile"`
 	TemplateId int            `json:"templateId"`
@@ -32,15 +37,28 @@ type SMSOutputData struct {
 	Cost      float32 `json:"cost"`
 }
 
-func SendOTPSMS(mobile string, otp int) error {
-	fmt.Println(mobile, "------------------")
-	templateId, err := strconv.Atoi(os.Getenv("SMS_TEMPLATE_ID"))
-	if err != nil {
-		return err
-	}
+var (
+	apiKey     string
+	templateID int
+)
+
+// use manual init because config must be loaded
+func Init(apiKeyIn string, templateIDIn int) {
+	apiKey = apiKeyIn
+	templateID = templateIDIn
+}
+
+func SendOTPSMS(ctx context.Context, mobile string, otp int) (err error) {
+	ctx, span := tracing.Start(ctx, "sms.SendOTP", attribute.String("sms.provider", provider))
+	defer func() {
+		metrics.SMSResult(provider, err)
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	smsInput := SMSInput{
 		Mobile:     mobile,
-		TemplateId: templateId,
+		TemplateId: templateID,
 		Parameters: []SMSParameter{
 			{
 				Name:  "Code",
@@ -52,11 +70,11 @@ func SendOTPSMS(mobile string, otp int) error {
 	if err != nil {
 		return err
 	}
-	request, err := http.NewRequest(http.MethodPost, "https://api.sms.ir/v1/send/verify", bytes.NewBuffer(jsonSMS))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.sms.ir/v1/send/verify", bytes.NewBuffer(jsonSMS))
 	if err != nil {
 		return err
 	}
-	request.Header.Set("x-api-key", os.Getenv("SMS_API_KEY"))
+	request.Header.Set("x-api-key", apiKey)
 	request.Header.Set("Content-Type", "application/json")
 	request.Header.Set("Accept", "application/json")
 
@@ -65,6 +83,7 @@ func SendOTPSMS(mobile string, otp int) error {
 	if err != nil {
 		return err
 	}
+	defer response.Body.Close()
 
 	var smsOutput SMSOutput
 
@@ -0,0 +1,104 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	secretSize = 20
+	digits     = 6
+	period     = 30 * time.Second
+
+	// number of periods accepted before and after current period
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// returns random base32 secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// uri example: otpauth://totp/Pedarkharj:+989123456789?secret=...&issuer=Pedarkharj
+func ProvisioningURI(secret, issuer, accountName string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(int(period.Seconds())))
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+accountName) + "?" + query.Encode()
+}
+
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return generateCode(key, uint64(t.Unix()/int64(period.Seconds()))), nil
+}
+
+// check code with allowed clock skew
+func Validate(secret, code string, t time.Time) bool {
+	_, ok := ValidateCounter(secret, code, t, 0)
+	return ok
+}
+
+// check code with allowed clock skew and return time step of code.
+// codes of time steps less than or equal to lastCounter are rejected so an accepted code cannot be used again
+func ValidateCounter(secret, code string, t time.Time, lastCounter int64) (int64, bool) {
+	if len(code) != digits {
+		return 0, false
+	}
+
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	counter := t.Unix() / int64(period.Seconds())
+	for i := -skew; i <= skew; i++ {
+		if counter+int64(i) <= lastCounter {
+			continue
+		}
+
+		if hmac.Equal([]byte(generateCode(key, uint64(counter+int64(i)))), []byte(code)) {
+			return counter + int64(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// RFC 4226 HOTP
+func generateCode(key []byte, counter uint64) string {
+	var message [8]byte
+	binary.BigEndian.PutUint64(message[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// gorm plugin that creates a span for every query as child of context of query (db.WithContext).
+// usage: db.Use(tracing.GormPlugin{System: "postgresql"})
+type GormPlugin struct {
+	System string // database system. e.g: postgresql
+}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	errs := []error{
+		callback.Create().Before("gorm:create").Register("tracing:before_create", p.before("create")),
+		callback.Create().After("gorm:create").Register("tracing:after_create", after),
+		callback.Query().Before("gorm:query").Register("tracing:before_query", p.before("query")),
+		callback.Query().After("gorm:query").Register("tracing:after_query", after),
+		callback.Update().Before("gorm:update").Register("tracing:before_update", p.before("update")),
+		callback.Update().After("gorm:update").Register("tracing:after_update", after),
+		callback.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("delete")),
+		callback.Delete().After("gorm:delete").Register("tracing:after_delete", after),
+		callback.Row().Before("gorm:row").Register("tracing:before_row", p.before("row")),
+		callback.Row().After("gorm:row").Register("tracing:after_row", after),
+		callback.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("raw")),
+		callback.Raw().After("gorm:raw").Register("tracing:after_raw", after),
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := Tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", p.System),
+				attribute.String("db.operation", operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+	}
+}
+
+// sql is recorded with placeholders. values are not recorded
+func after(db *gorm.DB) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+
+	if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		RecordError(span, db.Error)
+	}
+}
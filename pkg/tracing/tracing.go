@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/yaghoubi-mn/pedarkharj"
+
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+type Options struct {
+	Exporter    string  // none, stdout or otlp
+	Endpoint    string  // host:port of otlp http collector
+	Insecure    bool    // use http instead of https for otlp
+	ServiceName string  // name of service in traces
+	SampleRatio float64 // ratio of traced requests. parent decision is respected
+}
+
+// setup global tracer provider and w3c trace context propagator.
+// returned function flushes remaining spans and must be called on shutdown
+func Setup(ctx context.Context, options Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch options.Exporter {
+	case "", "none":
+		// spans are not recorded. global provider is noop
+		return func(context.Context) error { return nil }, nil
+
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	case "otlp":
+		otlpOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+		if options.Insecure {
+			otlpOptions = append(otlpOptions, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, otlpOptions...)
+
+	default:
+		return nil, ErrUnknownExporter
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(options.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// start a span as child of span of ctx. usage:
+//
+//	ctx, span := tracing.Start(ctx, "app_expense.Create")
+//	defer span.End()
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// record error on span and mark it as failed. nil error is ignored
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 
 	"golang.org/x/crypto/bcrypt"
@@ -30,3 +31,18 @@ func CompareHashAndPassword(hashedPassword, password, salt string) error {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password+salt))
 	return err
 }
+
+// for high entropy tokens like recovery codes. bcrypt is not needed
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// returns random hex string with n bytes
+func GenerateRandomHex(n int) (string, error) {
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+
+	return hex.EncodeToString(b), err
+}
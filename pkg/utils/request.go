@@ -1,31 +1,60 @@
 package utils
 
 import (
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 )
 
-// returns "" if ip not found
-func GetIPAddress(r *http.Request) string {
-	ip := r.Header.Get("X-Real-Ip")
-	if ip == "" {
-		ip = r.Header.Get("X-Forwarded-For")
+func GetUserAgent(r *http.Request) string {
+	return r.Header.Get("User-Agent")
+}
+
+// ip of client. X-Forwarded-For and X-Real-Ip headers are used only if request is sent by trusted proxies.
+// in X-Forwarded-For, first address from right that is not trusted proxy is client.
+// returned value is always a parsed ip or "" if remote address is not an ip
+func GetClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
 	}
-	if ip == "" {
-		ip = r.RemoteAddr
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
 	}
+	remote = remote.Unmap()
 
-	// check localhost
-	if ip[:5] == "[::1]" {
-		ip = "127.0.0.1"
+	if !isTrustedProxy(remote, trustedProxies) {
+		return remote.String()
 	}
 
-	// remove port from ip
-	ip = strings.Split(ip, ":")[0]
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+
+		if ip = ip.Unmap(); !isTrustedProxy(ip, trustedProxies) {
+			return ip.String()
+		}
+	}
 
-	return ip
+	if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-Ip"))); err == nil {
+		return ip.Unmap().String()
+	}
+
+	return remote.String()
 }
 
-func GetUserAgent(r *http.Request) string {
-	return r.Header.Get("User-Agent")
+func isTrustedProxy(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,376 @@
+package account_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_account "github.com/yaghoubi-mn/pedarkharj/internal/application/account"
+	domain_account "github.com/yaghoubi-mn/pedarkharj/internal/domain/account"
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/s3"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/totp"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+const password = "password123"
+
+// account repository that returns data of users from user repository
+type accountRepository struct {
+	userRepo domain_user.UserDomainRepository
+	// GetUserData waits for it if it is not nil
+	block       chan struct{}
+	dataErr     error
+	deleteErr   error
+	deletedUser domain_user.User
+	deletedLog  *domain_audit.AuditLog
+}
+
+func (repo *accountRepository) GetUserData(ctx context.Context, userID uint64) (domain_account.UserData, error) {
+	if repo.block != nil {
+		select {
+		case <-repo.block:
+		case <-ctx.Done():
+			return domain_account.UserData{}, ctx.Err()
+		}
+	}
+
+	if repo.dataErr != nil {
+		return domain_account.UserData{}, repo.dataErr
+	}
+
+	user, err := repo.userRepo.GetByID(ctx, userID)
+	return domain_account.UserData{User: user}, err
+}
+
+func (repo *accountRepository) DeleteUser(ctx context.Context, anonymizedUser domain_user.User, log *domain_audit.AuditLog) error {
+	if repo.deleteErr != nil {
+		return repo.deleteErr
+	}
+
+	repo.deletedUser = anonymizedUser
+	repo.deletedLog = log
+	return repo.userRepo.Update(ctx, anonymizedUser)
+}
+
+// s3 server that keeps keys of stored objects
+type s3Server struct {
+	mu      sync.Mutex
+	objects map[string]bool
+}
+
+func (s *s3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[key] = true
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *s3Server) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type fixture struct {
+	service  app_account.AccountAppService
+	repo     *accountRepository
+	userRepo domain_user.UserDomainRepository
+	s3       *s3Server
+	user     domain_user.User
+}
+
+func setup(t *testing.T) fixture {
+	ctx := context.Background()
+	vld := validator.NewValidator()
+
+	f := fixture{
+		userRepo: repository_memory.NewMemoryUserRepository(repository_memory.NewStore()),
+		s3:       &s3Server{objects: make(map[string]bool)},
+	}
+	f.repo = &accountRepository{userRepo: f.userRepo}
+	f.service = app_account.NewAccountAppService(f.repo, f.userRepo, cache.NewMemory(1, 100), domain_account.NewAccountDomainService(vld), domain_user.NewUserService(vld))
+
+	server := httptest.NewServer(f.s3)
+	t.Cleanup(server.Close)
+	s3.Init(s3.Options{AccessKey: "access", SecretKey: "secret", BucketName: "bucket", APIURL: server.URL})
+
+	salt, err := utils.GenerateRandomSalt()
+	require.NoError(t, err)
+	hashedPassword, err := utils.HashPasswordWithSalt(password, salt, 4)
+	require.NoError(t, err)
+
+	f.user = domain_user.User{Name: "Ali", Number: "+989120000001", Password: hashedPassword, Salt: salt, IsRegistered: true}
+	require.NoError(t, f.userRepo.Create(ctx, &f.user))
+
+	return f
+}
+
+func (f fixture) waitExports(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, f.service.Shutdown(ctx))
+}
+
+func TestRequestExport(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	f.repo.block = make(chan struct{})
+
+	res := f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+	assert.Equal(t, "pending", res.Data["status"])
+
+	// one export at a time
+	res = f.service.RequestExport(ctx, f.user.ID)
+	assert.Equal(t, service_errors.ErrExportInProgress, res.UserErr)
+
+	close(f.repo.block)
+	f.waitExports(t)
+
+	res = f.service.GetExport(ctx, f.user.ID)
+	require.NoError(t, res.ServerErr)
+	assert.Equal(t, "ready", res.Data["status"])
+	assert.NotEmpty(t, res.Data["url"])
+	keys := f.s3.keys()
+	assert.Len(t, keys, 1)
+
+	// previous export is deleted
+	res = f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+	if assert.Len(t, f.s3.keys(), 1) {
+		assert.NotEqual(t, keys[0], f.s3.keys()[0])
+	}
+}
+
+func TestRequestExportFailed(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	res := f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, rcodes.ExportNotFound, res.ResponseCode)
+
+	f.repo.dataErr = errors.New("database is down")
+	res = f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+
+	res = f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, "failed", res.Data["status"])
+
+	// failed export can be retried
+	f.repo.dataErr = nil
+	res = f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+
+	res = f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, "ready", res.Data["status"])
+}
+
+func TestRequestExportTimeout(t *testing.T) {
+	timeout := config.DataExportTimeout
+	config.DataExportTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { config.DataExportTimeout = timeout })
+
+	f := setup(t)
+	ctx := context.Background()
+	f.repo.block = make(chan struct{})
+
+	res := f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+
+	// stuck export is failed and it does not block retry
+	res = f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, "failed", res.Data["status"])
+
+	close(f.repo.block)
+	res = f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+}
+
+func TestRequestExportConcurrent(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	f.repo.block = make(chan struct{})
+
+	// one of concurrent requests starts export
+	var started sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for i := 0; i < 10; i++ {
+		started.Add(1)
+		go func() {
+			defer started.Done()
+			res := f.service.RequestExport(ctx, f.user.ID)
+			assert.NoError(t, res.ServerErr)
+			if res.UserErr == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+				return
+			}
+			assert.Equal(t, service_errors.ErrExportInProgress, res.UserErr)
+		}()
+	}
+	started.Wait()
+	assert.Equal(t, 1, accepted)
+
+	close(f.repo.block)
+	f.waitExports(t)
+	assert.Len(t, f.s3.keys(), 1)
+}
+
+func deleteInput(password, code, recoveryCode string) app_account.DeleteAccountInput {
+	return app_account.DeleteAccountInput{DeleteAccountInput: shared_dto.DeleteAccountInput{Password: password, Code: code, RecoveryCode: recoveryCode}}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	res := f.service.DeleteAccount(ctx, deleteInput("", "", ""), f.user.ID)
+	assert.Equal(t, service_errors.ErrPasswordRequired, res.UserErr)
+	res = f.service.DeleteAccount(ctx, deleteInput("wrong-password", "", ""), f.user.ID)
+	assert.Equal(t, service_errors.ErrWrongPassword, res.UserErr)
+
+	// exported data is deleted with account
+	res = f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+	require.Len(t, f.s3.keys(), 1)
+
+	res = f.service.DeleteAccount(ctx, deleteInput(password, "", ""), f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+
+	assert.True(t, f.repo.deletedUser.IsDeleted)
+	assert.Equal(t, "Deleted user", f.repo.deletedUser.Name)
+	assert.Equal(t, domain_admin.RoleUser, f.repo.deletedUser.Role)
+	if assert.NotNil(t, f.repo.deletedLog) {
+		assert.Equal(t, domain_audit.ActionAccountDelete, f.repo.deletedLog.Action)
+		assert.Equal(t, f.user.ID, f.repo.deletedLog.TargetID)
+	}
+	assert.Empty(t, f.s3.keys())
+
+	res = f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, rcodes.ExportNotFound, res.ResponseCode)
+}
+
+func TestDeleteAccountAttempts(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	for i := 0; i < config.DeleteAccountMaxAttempts; i++ {
+		res := f.service.DeleteAccount(ctx, deleteInput("wrong-password", "", ""), f.user.ID)
+		assert.Equal(t, service_errors.ErrWrongPassword, res.UserErr, i)
+	}
+
+	// correct password is rejected too
+	res := f.service.DeleteAccount(ctx, deleteInput(password, "", ""), f.user.ID)
+	assert.Equal(t, rcodes.TooManyRequests, res.ResponseCode)
+	assert.Equal(t, service_errors.ErrTooManyPasswordAttempts, res.UserErr)
+	assert.False(t, f.repo.deletedUser.IsDeleted)
+}
+
+func TestDeleteAccountCancelsExport(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	f.repo.block = make(chan struct{})
+
+	res := f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+
+	// running export is canceled and finished before deleting
+	res = f.service.DeleteAccount(ctx, deleteInput(password, "", ""), f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+
+	assert.Empty(t, f.s3.keys())
+	res = f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, rcodes.ExportNotFound, res.ResponseCode)
+
+	// data of deleted user is not uploaded by export that is requested after deleting
+	close(f.repo.block)
+	res = f.service.RequestExport(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	f.waitExports(t)
+
+	assert.Empty(t, f.s3.keys())
+	res = f.service.GetExport(ctx, f.user.ID)
+	assert.Equal(t, rcodes.ExportNotFound, res.ResponseCode)
+}
+
+func TestDeleteAccountTwoFactor(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	f.user.TOTPSecret = secret
+	f.user.IsTOTPEnabled = true
+	f.user.TOTPRecoveryCodes = utils.HashToken("aaaaabbbbb") + "," + utils.HashToken("cccccddddd")
+	require.NoError(t, f.userRepo.UpdateTwoFactor(ctx, f.user))
+
+	res := f.service.DeleteAccount(ctx, deleteInput(password, "", ""), f.user.ID)
+	assert.Equal(t, service_errors.ErrTwoFactorCodeRequired, res.UserErr)
+
+	// used recovery code is consumed even if deleting fails
+	f.repo.deleteErr = errors.New("database is down")
+	res = f.service.DeleteAccount(ctx, deleteInput(password, "", "aaaaa-bbbbb"), f.user.ID)
+	assert.Error(t, res.ServerErr)
+
+	res = f.service.DeleteAccount(ctx, deleteInput(password, "", "aaaaa-bbbbb"), f.user.ID)
+	assert.Equal(t, service_errors.ErrWrongRecoveryCode, res.UserErr)
+
+	// used totp code is rejected
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	res = f.service.DeleteAccount(ctx, deleteInput(password, code, ""), f.user.ID)
+	assert.Error(t, res.ServerErr)
+
+	f.repo.deleteErr = nil
+	res = f.service.DeleteAccount(ctx, deleteInput(password, code, ""), f.user.ID)
+	assert.Equal(t, service_errors.ErrWrongTOTPCode, res.UserErr)
+
+	res = f.service.DeleteAccount(ctx, deleteInput(password, "", "ccccc-ddddd"), f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+	assert.True(t, f.repo.deletedUser.IsDeleted)
+}
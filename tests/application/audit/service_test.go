@@ -0,0 +1,79 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+func TestRecord(t *testing.T) {
+	repo := repository_memory.NewMemoryAuditRepository(repository_memory.NewStore())
+
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	ctx = app_audit.WithRequestInfo(ctx, app_audit.RequestInfo{IP: "1.1.1.1", UserAgent: "Mozilla/5.0"})
+
+	before := map[string]string{"number": "+989120000001"}
+	after := map[string]string{"number": "+989120000002"}
+	require.NoError(t, app_audit.Record(ctx, repo, 1, domain_audit.ActionNumberChange, domain_audit.TargetUser, 1, before, after))
+
+	// nil snapshots are not saved. request info is empty outside of requests
+	require.NoError(t, app_audit.Record(context.Background(), repo, 2, domain_audit.ActionDeviceLogoutAll, domain_audit.TargetUser, 2, nil, nil))
+
+	logs, total, err := repo.Search(context.Background(), domain_audit.Filter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, logs, 2)
+
+	assert.Equal(t, uint64(2), logs[0].ActorID)
+	assert.Empty(t, logs[0].Before)
+	assert.Empty(t, logs[0].After)
+	assert.Empty(t, logs[0].IP)
+
+	assert.Equal(t, domain_audit.ActionNumberChange, logs[1].Action)
+	assert.JSONEq(t, `{"number":"+989120000001"}`, logs[1].Before)
+	assert.JSONEq(t, `{"number":"+989120000002"}`, logs[1].After)
+	assert.Equal(t, "1.1.1.1", logs[1].IP)
+	assert.Equal(t, "Mozilla/5.0", logs[1].UserAgent)
+	assert.Equal(t, "req-1", logs[1].RequestID)
+}
+
+func TestGetMyActivity(t *testing.T) {
+	repo := repository_memory.NewMemoryAuditRepository(repository_memory.NewStore())
+	service := app_audit.NewAuditAppService(repo, domain_audit.NewAuditDomainService(validator.NewValidator()))
+	ctx := context.Background()
+
+	require.NoError(t, app_audit.Record(ctx, repo, 1, domain_audit.ActionLogin, domain_audit.TargetUser, 1, nil, nil))
+	require.NoError(t, app_audit.Record(ctx, repo, 1, domain_audit.ActionExpenseCreate, domain_audit.TargetExpense, 3, nil, map[string]string{"name": "dinner"}))
+	require.NoError(t, app_audit.Record(ctx, repo, 2, domain_audit.ActionLogin, domain_audit.TargetUser, 2, nil, nil))
+
+	// only actions of user
+	responseDTO := service.GetMyActivity(ctx, 1, 1, 10)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+	assert.Equal(t, int64(2), responseDTO.Data["total"])
+	if logs, ok := responseDTO.Data["logs"].([]shared_dto.AuditLogOutput); assert.True(t, ok) && assert.Len(t, logs, 2) {
+		assert.Equal(t, domain_audit.ActionExpenseCreate, logs[0].Action)
+		assert.JSONEq(t, `{"name":"dinner"}`, string(logs[0].After))
+		assert.Nil(t, logs[0].Before)
+	}
+
+	responseDTO = service.GetMyActivity(ctx, 1, 2, 1)
+	if logs, ok := responseDTO.Data["logs"].([]shared_dto.AuditLogOutput); assert.True(t, ok) && assert.Len(t, logs, 1) {
+		assert.Equal(t, domain_audit.ActionLogin, logs[0].Action)
+	}
+
+	responseDTO = service.GetMyActivity(ctx, 1, 0, 10)
+	assert.Equal(t, service_errors.ErrInvalidPage, responseDTO.UserErr)
+
+	responseDTO = service.GetMyActivity(ctx, 1, 1, 51)
+	assert.Equal(t, service_errors.ErrInvalidLimit, responseDTO.UserErr)
+}
@@ -0,0 +1,104 @@
+package changenumber_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+const newNumber = "+989120000002"
+
+type fixture struct {
+	service   app_user.UserAppService
+	cacheRepo *cache.MemoryCacheRepository
+	user      domain_user.User
+}
+
+func setup(t *testing.T) fixture {
+	vld := validator.NewValidator()
+	store := repository_memory.NewStore()
+	auditRepo := repository_memory.NewMemoryAuditRepository(store)
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+	deviceAppService := app_device.NewDeviceAppService(repository_memory.NewMemoryDeviceRepository(store), auditRepo, domain_device.NewDeviceService(vld))
+
+	f := fixture{cacheRepo: cache.NewMemory(1, 100)}
+	f.user = domain_user.User{Name: "Ali", Number: "+989120000001", IsRegistered: true}
+	require.NoError(t, userRepo.Create(context.Background(), &f.user))
+
+	jwt.Init("test-secret")
+
+	f.service = app_user.NewUserService(userRepo, f.cacheRepo, auditRepo, deviceAppService, domain_user.NewUserService(vld))
+	return f
+}
+
+// send otps and returns token, old otp and new otp. otps are not sent in debug mode
+func (f fixture) send(t *testing.T) (string, uint, uint) {
+	res := f.service.SendChangeNumberOTP(context.Background(), app_user.ChangeNumberInput{ChangeNumberInput: shared_dto.ChangeNumberInput{NewPhoneNumber: newNumber}}, f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+
+	changeInfo, _, err := f.cacheRepo.Get("change_number:" + strconv.FormatUint(f.user.ID, 10))
+	require.NoError(t, err)
+	oldOTP, err := strconv.Atoi(changeInfo["old_otp"])
+	require.NoError(t, err)
+	newOTP, err := strconv.Atoi(changeInfo["new_otp"])
+	require.NoError(t, err)
+
+	return res.Data["token"].(string), uint(oldOTP), uint(newOTP)
+}
+
+func (f fixture) verify(token string, oldOTP uint, newOTP uint) error {
+	res := f.service.VerifyChangeNumber(context.Background(), app_user.VerifyChangeNumberInput{VerifyChangeNumberInput: shared_dto.VerifyChangeNumberInput{
+		Token:  token,
+		OldOTP: oldOTP,
+		NewOTP: newOTP,
+	}}, f.user.ID, "test", "127.0.0.1")
+	if res.ServerErr != nil {
+		return res.ServerErr
+	}
+	return res.UserErr
+}
+
+func TestVerifyConcurrentAttempts(t *testing.T) {
+	f := setup(t)
+	token, oldOTP, newOTP := f.send(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.ChangeNumberMaxAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.verify(token, oldOTP, newOTP%90000+10001)
+		}()
+	}
+	wg.Wait()
+
+	// all wrong otps are counted
+	assert.Equal(t, service_errors.ErrOTPNotSend, f.verify(token, oldOTP, newOTP))
+}
+
+func TestVerifyAttemptsOfToken(t *testing.T) {
+	f := setup(t)
+	token, oldOTP, newOTP := f.send(t)
+
+	for i := 0; i < config.ChangeNumberMaxAttempts-1; i++ {
+		assert.Equal(t, service_errors.ErrWrongOldOTP, f.verify(token, oldOTP%90000+10001, newOTP))
+	}
+
+	assert.NoError(t, f.verify(token, oldOTP, newOTP))
+}
@@ -0,0 +1,214 @@
+package debt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+type fixture struct {
+	service    app_debt.DebtAppService
+	debtRepo   domain_debt.DebtDomainRepository
+	outboxRepo domain_outbox.OutboxDomainRepository
+	auditRepo  domain_audit.AuditDomainRepository
+	creditor   domain_user.User
+	debtor     domain_user.User
+	debt       domain_debt.Debt
+}
+
+func setup(t *testing.T, isDebtorRegistered bool) fixture {
+	ctx := context.Background()
+	store := repository_memory.NewStore()
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+
+	f := fixture{
+		debtRepo:   repository_memory.NewMemoryDebtRepository(store),
+		outboxRepo: repository_memory.NewMemoryOutboxRepository(store),
+		auditRepo:  repository_memory.NewMemoryAuditRepository(store),
+	}
+	f.service = app_debt.NewDebtAppService(f.debtRepo, userRepo, repository_memory.NewMemoryUnitOfWork(store), domain_debt.NewDebtDomainService(validator.NewValidator()))
+
+	f.creditor = domain_user.User{Name: "Ali", Number: "+989120000001", IsRegistered: true}
+	require.NoError(t, userRepo.Create(ctx, &f.creditor))
+	f.debtor = domain_user.User{Name: "Reza", Number: "+989120000002", IsRegistered: isDebtorRegistered}
+	require.NoError(t, userRepo.Create(ctx, &f.debtor))
+
+	f.debt = domain_debt.Debt{ExpenseID: 1, CreditorID: f.creditor.ID, DebtorID: f.debtor.ID, Amount: 100}
+	require.NoError(t, f.debtRepo.Create(ctx, &f.debt))
+
+	return f
+}
+
+func output(t *testing.T, data map[string]any) shared_dto.DebtOutput {
+	debt, ok := data["debt"].(shared_dto.DebtOutput)
+	require.True(t, ok)
+	return debt
+}
+
+// types of pending outbox events
+func eventTypes(t *testing.T, repo domain_outbox.OutboxDomainRepository) []string {
+	events, err := repo.GetPending(context.Background(), time.Now(), 100)
+	require.NoError(t, err)
+
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// actions of audit logs, oldest first
+func auditActions(t *testing.T, repo domain_audit.AuditDomainRepository) []string {
+	logs, _, err := repo.Search(context.Background(), domain_audit.Filter{}, 0, 100)
+	require.NoError(t, err)
+
+	actions := make([]string, 0, len(logs))
+	for i := len(logs) - 1; i >= 0; i-- {
+		actions = append(actions, logs[i].Action)
+	}
+	return actions
+}
+
+func TestAccept(t *testing.T) {
+	f := setup(t, false)
+	ctx := context.Background()
+
+	responseDTO := f.service.Accept(ctx, f.debt.ID, f.creditor.ID, 1)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+
+	// debtor is not registered, so debt is accepted for debtor too
+	debt := output(t, responseDTO.Data)
+	assert.True(t, debt.IsCreditorAccepted)
+	assert.True(t, debt.IsDebtorAccepted)
+	assert.Equal(t, uint64(2), debt.Version)
+
+	responseDTO = f.service.Accept(ctx, f.debt.ID, 1000, 0)
+	assert.Equal(t, rcodes.DebtNotFound, responseDTO.ResponseCode)
+
+	assert.Equal(t, []string{domain_outbox.EventDebtAccepted}, eventTypes(t, f.outboxRepo))
+
+	logs, _, err := f.auditRepo.Search(ctx, domain_audit.Filter{}, 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, domain_audit.ActionDebtAccept, logs[0].Action)
+		assert.Equal(t, f.creditor.ID, logs[0].ActorID)
+		assert.Contains(t, logs[0].Before, `"is_creditor_accepted":false`)
+		assert.Contains(t, logs[0].After, `"is_creditor_accepted":true`)
+	}
+}
+
+func TestPayAndAcceptPayment(t *testing.T) {
+	f := setup(t, true)
+	ctx := context.Background()
+	settled := testutil.ToFloat64(metrics.DebtsSettled)
+
+	// payment is accepted after debtor pays
+	responseDTO := f.service.AcceptPayment(ctx, f.debt.ID, f.creditor.ID, 0)
+	assert.Equal(t, rcodes.DebtNotPaid, responseDTO.ResponseCode)
+
+	responseDTO = f.service.Pay(ctx, f.debt.ID, f.creditor.ID, 0)
+	assert.Equal(t, rcodes.PermissionDenied, responseDTO.ResponseCode)
+
+	responseDTO = f.service.Pay(ctx, f.debt.ID, f.debtor.ID, 1)
+	require.NoError(t, responseDTO.UserErr)
+	require.NoError(t, responseDTO.ServerErr)
+	debt := output(t, responseDTO.Data)
+	assert.True(t, debt.IsPaid)
+	assert.False(t, debt.IsPaymentAccepted)
+	assert.Equal(t, settled, testutil.ToFloat64(metrics.DebtsSettled))
+
+	responseDTO = f.service.AcceptPayment(ctx, f.debt.ID, f.debtor.ID, 0)
+	assert.Equal(t, rcodes.PermissionDenied, responseDTO.ResponseCode)
+
+	responseDTO = f.service.AcceptPayment(ctx, f.debt.ID, f.creditor.ID, debt.Version)
+	require.NoError(t, responseDTO.UserErr)
+	require.NoError(t, responseDTO.ServerErr)
+	assert.True(t, output(t, responseDTO.Data).IsPaymentAccepted)
+	assert.Equal(t, settled+1, testutil.ToFloat64(metrics.DebtsSettled))
+
+	// settled debt is not counted again
+	responseDTO = f.service.AcceptPayment(ctx, f.debt.ID, f.creditor.ID, 0)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.Equal(t, settled+1, testutil.ToFloat64(metrics.DebtsSettled))
+
+	assert.Equal(t, []string{domain_outbox.EventDebtPaid, domain_outbox.EventDebtPaymentAccepted, domain_outbox.EventDebtPaymentAccepted}, eventTypes(t, f.outboxRepo))
+	assert.Equal(t, []string{domain_audit.ActionDebtPay, domain_audit.ActionDebtAcceptPayment, domain_audit.ActionDebtAcceptPayment}, auditActions(t, f.auditRepo))
+}
+
+// payment of unregistered debtor is accepted without payment
+func TestAcceptPaymentOfUnregisteredDebtor(t *testing.T) {
+	f := setup(t, false)
+	settled := testutil.ToFloat64(metrics.DebtsSettled)
+
+	responseDTO := f.service.AcceptPayment(context.Background(), f.debt.ID, f.creditor.ID, 0)
+	require.NoError(t, responseDTO.UserErr)
+	require.NoError(t, responseDTO.ServerErr)
+	debt := output(t, responseDTO.Data)
+	assert.True(t, debt.IsPaid)
+	assert.True(t, debt.IsPaymentAccepted)
+	assert.Equal(t, settled+1, testutil.ToFloat64(metrics.DebtsSettled))
+}
+
+func TestConcurrentAcceptAndDelete(t *testing.T) {
+	f := setup(t, true)
+	ctx := context.Background()
+
+	// creditor and debtor both act on version 1
+	responseDTO := f.service.Accept(ctx, f.debt.ID, f.creditor.ID, 1)
+	assert.NoError(t, responseDTO.UserErr)
+
+	responseDTO = f.service.Delete(ctx, f.debt.ID, f.debtor.ID, 1)
+	assert.Equal(t, service_errors.ErrVersionConflict, responseDTO.UserErr)
+	assert.Equal(t, rcodes.VersionConflict, responseDTO.ResponseCode)
+
+	// current debt is returned, so debtor can retry on new version
+	current := output(t, responseDTO.Data)
+	assert.True(t, current.IsCreditorAccepted)
+	assert.False(t, current.IsDebtorRequestedForDelete)
+
+	responseDTO = f.service.Delete(ctx, f.debt.ID, f.debtor.ID, current.Version)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.Equal(t, false, responseDTO.Data["is_deleted"])
+	assert.True(t, output(t, responseDTO.Data).IsDebtorRequestedForDelete)
+	assert.True(t, output(t, responseDTO.Data).IsCreditorAccepted)
+
+	// conflicted request does not save event
+	assert.Equal(t, []string{domain_outbox.EventDebtAccepted, domain_outbox.EventDebtDeleteRequested}, eventTypes(t, f.outboxRepo))
+	assert.Equal(t, []string{domain_audit.ActionDebtAccept, domain_audit.ActionDebtDeleteRequest}, auditActions(t, f.auditRepo))
+}
+
+func TestDelete(t *testing.T) {
+	f := setup(t, true)
+	ctx := context.Background()
+
+	responseDTO := f.service.Delete(ctx, f.debt.ID, f.debtor.ID, 0)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.Equal(t, false, responseDTO.Data["is_deleted"])
+
+	// debt is deleted when both sides request it
+	responseDTO = f.service.Delete(ctx, f.debt.ID, f.creditor.ID, 0)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.Equal(t, true, responseDTO.Data["is_deleted"])
+
+	responseDTO = f.service.Get(ctx, f.debt.ID, f.creditor.ID)
+	assert.Equal(t, rcodes.DebtNotFound, responseDTO.ResponseCode)
+
+	assert.Equal(t, []string{domain_outbox.EventDebtDeleteRequested, domain_outbox.EventDebtDeleted}, eventTypes(t, f.outboxRepo))
+	assert.Equal(t, []string{domain_audit.ActionDebtDeleteRequest, domain_audit.ActionDebtDelete}, auditActions(t, f.auditRepo))
+}
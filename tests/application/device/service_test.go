@@ -1 +1,90 @@
 package device_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+func TestRefreshTokenAfterLogout(t *testing.T) {
+	jwt.Init("test-secret-key")
+
+	store := repository_memory.NewStore()
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+	service := app_device.NewDeviceAppService(
+		repository_memory.NewMemoryDeviceRepository(store),
+		repository_memory.NewMemoryAuditRepository(store),
+		domain_device.NewDeviceService(validator.NewValidator()),
+	)
+	ctx := context.Background()
+
+	user := domain_user.User{Name: "Ali", Number: "+989120000001", IsRegistered: true}
+	require.NoError(t, userRepo.Create(ctx, &user))
+
+	refresh, _, err := jwt.CreateRefreshAndAccessFromUser(time.Hour, time.Minute, user.ID, user.Name, user.Number, true)
+	require.NoError(t, err)
+
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	require.NoError(t, service.CreateOrUpdate(ctx, domain_device.NewDeviceInput(userAgent, "1.1.1.1", refresh, user.ID)))
+
+	got, userErr, err := service.GetDeviceUserByRefreshToken(ctx, refresh)
+	assert.NoError(t, userErr)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	response := service.LogoutAllUserDevices(ctx, user.ID)
+	assert.NoError(t, response.ServerErr)
+
+	// token is valid but device is logged out
+	_, userErr, err = service.GetDeviceUserByRefreshToken(ctx, refresh)
+	assert.NoError(t, userErr)
+	assert.Error(t, err)
+}
+
+type failingAuditRepository struct {
+	domain_audit.AuditDomainRepository
+}
+
+func (failingAuditRepository) Create(ctx context.Context, log *domain_audit.AuditLog) error {
+	return errors.New("database is down")
+}
+
+// device is logged out even if audit log cannot be saved
+func TestLogoutAuditError(t *testing.T) {
+	jwt.Init("test-secret-key")
+
+	store := repository_memory.NewStore()
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+	service := app_device.NewDeviceAppService(
+		repository_memory.NewMemoryDeviceRepository(store),
+		failingAuditRepository{},
+		domain_device.NewDeviceService(validator.NewValidator()),
+	)
+	ctx := context.Background()
+
+	user := domain_user.User{Name: "Ali", Number: "+989120000001", IsRegistered: true}
+	require.NoError(t, userRepo.Create(ctx, &user))
+
+	refresh, _, err := jwt.CreateRefreshAndAccessFromUser(time.Hour, time.Minute, user.ID, user.Name, user.Number, true)
+	require.NoError(t, err)
+
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	require.NoError(t, service.CreateOrUpdate(ctx, domain_device.NewDeviceInput(userAgent, "1.1.1.1", refresh, user.ID)))
+
+	response := service.LogoutAllUserDevices(ctx, user.ID)
+	assert.NoError(t, response.ServerErr)
+
+	_, _, err = service.GetDeviceUserByRefreshToken(ctx, refresh)
+	assert.Error(t, err)
+}
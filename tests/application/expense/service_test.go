@@ -0,0 +1,260 @@
+package expense_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+type fixture struct {
+	service     app_expense.ExpenseAppService
+	userRepo    domain_user.UserDomainRepository
+	expenseRepo domain_expense.ExpenseDomainRepository
+	debtRepo    domain_debt.DebtDomainRepository
+	outboxRepo  domain_outbox.OutboxDomainRepository
+	auditRepo   domain_audit.AuditDomainRepository
+	creator     domain_user.User
+}
+
+func setup(t *testing.T) fixture {
+	vld := validator.NewValidator()
+	store := repository_memory.NewStore()
+
+	f := fixture{
+		userRepo:    repository_memory.NewMemoryUserRepository(store),
+		expenseRepo: repository_memory.NewMemoryExpenseRepository(store),
+		debtRepo:    repository_memory.NewMemoryDebtRepository(store),
+		outboxRepo:  repository_memory.NewMemoryOutboxRepository(store),
+		auditRepo:   repository_memory.NewMemoryAuditRepository(store),
+	}
+	unitOfWork := repository_memory.NewMemoryUnitOfWork(store)
+	f.service = app_expense.NewExpenseAppService(
+		f.expenseRepo,
+		unitOfWork,
+		domain_expense.NewExpenseService(vld),
+		app_debt.NewDebtAppService(f.debtRepo, f.userRepo, unitOfWork, domain_debt.NewDebtDomainService(vld)),
+	)
+
+	f.creator = domain_user.User{Name: "Ali", Number: "+989120000001", IsRegistered: true}
+	require.NoError(t, f.userRepo.Create(context.Background(), &f.creator))
+
+	return f
+}
+
+func input(creditors map[string]uint64, debtors ...string) app_expense.ExpenseInputWithPhoneNumber {
+	return app_expense.ExpenseInputWithPhoneNumber{
+		ExpenseInputWithPhoneNumber: shared_dto.ExpenseInputWithPhoneNumber{
+			Name:      "dinner",
+			Creditors: creditors,
+			Debtors:   debtors,
+		},
+	}
+}
+
+// pending outbox events
+func events(t *testing.T, repo domain_outbox.OutboxDomainRepository) []domain_outbox.OutboxEvent {
+	events, err := repo.GetPending(context.Background(), time.Now(), 100)
+	require.NoError(t, err)
+	return events
+}
+
+// actions of audit logs, oldest first
+func auditActions(t *testing.T, repo domain_audit.AuditDomainRepository) []string {
+	logs, _, err := repo.Search(context.Background(), domain_audit.Filter{}, 0, 100)
+	require.NoError(t, err)
+
+	actions := make([]string, 0, len(logs))
+	for i := len(logs) - 1; i >= 0; i-- {
+		actions = append(actions, logs[i].Action)
+	}
+	return actions
+}
+
+func TestCreate(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	debtsCreated := testutil.ToFloat64(metrics.DebtsCreated)
+
+	responseDTO := f.service.Create(ctx, input(map[string]uint64{f.creator.Number: 3000}, "+989120000002", "+989120000003"), f.creator.ID, f.creator.Number)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+	assert.Equal(t, debtsCreated+2, testutil.ToFloat64(metrics.DebtsCreated))
+
+	debts, err := f.debtRepo.GetLimitedByUserID(ctx, f.creator.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, debts, 2)
+
+	expenses, err := f.expenseRepo.GetLimitedExpenseDebtByUserID(ctx, f.creator.ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, expenses, 2)
+
+	// event is saved with expense
+	if events := events(t, f.outboxRepo); assert.Len(t, events, 1) {
+		assert.Equal(t, domain_outbox.EventExpenseCreated, events[0].Type)
+		assert.Equal(t, domain_outbox.AggregateExpense, events[0].AggregateType)
+		assert.Equal(t, uint64(1), events[0].AggregateID)
+		assert.JSONEq(t, `{"expense_id":1,"creator_id":1,"name":"dinner","description":"","user_ids":[1,2,3]}`, events[0].Payload)
+	}
+
+	logs, _, err := f.auditRepo.Search(ctx, domain_audit.Filter{}, 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, domain_audit.ActionExpenseCreate, logs[0].Action)
+		assert.Equal(t, f.creator.ID, logs[0].ActorID)
+		assert.Equal(t, domain_audit.TargetExpense, logs[0].TargetType)
+		assert.Equal(t, uint64(1), logs[0].TargetID)
+		assert.Empty(t, logs[0].Before)
+		assert.Contains(t, logs[0].After, `"name":"dinner"`)
+	}
+}
+
+func TestCreateRollback(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	debtsCreated := testutil.ToFloat64(metrics.DebtsCreated)
+
+	// average credit is zero, so debts cannot be created
+	responseDTO := f.service.Create(ctx, input(map[string]uint64{f.creator.Number: 1}, "+989120000002", "+989120000003"), f.creator.ID, f.creator.Number)
+	assert.Equal(t, service_errors.ErrLowCredit, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+	assert.Equal(t, debtsCreated, testutil.ToFloat64(metrics.DebtsCreated))
+
+	// expense and placeholder users are not saved
+	ids, err := f.expenseRepo.GetUserIDOfPhoneNumbers(ctx, []string{"+989120000002", "+989120000003"})
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// id of rolled back expense is reused
+	expense := domain_expense.Expense{CreatorID: f.creator.ID, Name: "lunch"}
+	assert.NoError(t, f.expenseRepo.Create(ctx, &expense))
+	assert.Equal(t, uint64(1), expense.ID)
+
+	// event and audit log of rolled back expense are not saved
+	assert.Empty(t, events(t, f.outboxRepo))
+	assert.Empty(t, auditActions(t, f.auditRepo))
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	responseDTO := f.service.Create(ctx, input(map[string]uint64{f.creator.Number: 2000}, "+989120000002"), f.creator.ID, f.creator.Number)
+	require.NoError(t, responseDTO.UserErr)
+
+	ids, _ := f.expenseRepo.GetUserIDOfPhoneNumbers(ctx, []string{"+989120000002"})
+	debtorID := ids["+989120000002"]
+
+	update := app_expense.ExpenseUpdateInput{ExpenseUpdateInput: shared_dto.ExpenseUpdateInput{Name: "lunch", Description: "friday"}}
+
+	// only creator can update
+	responseDTO = f.service.Update(ctx, 1, debtorID, 0, update)
+	assert.Equal(t, rcodes.PermissionDenied, responseDTO.ResponseCode)
+
+	responseDTO = f.service.Update(ctx, 1, f.creator.ID, 0, update)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+
+	expense, err := f.expenseRepo.GetByID(ctx, 1, debtorID)
+	assert.NoError(t, err)
+	assert.Equal(t, "lunch", expense.Name)
+
+	// only creator can delete. debts are kept if expense is not deleted
+	responseDTO = f.service.Delete(ctx, 1, debtorID, 0)
+	assert.Equal(t, rcodes.PermissionDenied, responseDTO.ResponseCode)
+	responseDTO = f.service.Delete(ctx, 1, debtorID, expense.Version)
+	assert.Equal(t, rcodes.PermissionDenied, responseDTO.ResponseCode)
+
+	debts, _ := f.debtRepo.GetLimitedByUserID(ctx, debtorID, 0, 10)
+	assert.Len(t, debts, 1)
+	notChanged, err := f.expenseRepo.GetByID(ctx, 1, debtorID)
+	assert.NoError(t, err)
+	assert.Equal(t, expense.Version, notChanged.Version)
+
+	responseDTO = f.service.Delete(ctx, 1, f.creator.ID, 0)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+
+	debts, _ = f.debtRepo.GetLimitedByUserID(ctx, debtorID, 0, 10)
+	assert.Empty(t, debts)
+
+	// failed update and delete do not save events
+	types := make([]string, 0)
+	for _, event := range events(t, f.outboxRepo) {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{domain_outbox.EventExpenseCreated, domain_outbox.EventExpenseUpdated, domain_outbox.EventExpenseDeleted}, types)
+	assert.Equal(t, []string{domain_audit.ActionExpenseCreate, domain_audit.ActionExpenseUpdate, domain_audit.ActionExpenseDelete}, auditActions(t, f.auditRepo))
+}
+
+func TestVersionConflict(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	responseDTO := f.service.Create(ctx, input(map[string]uint64{f.creator.Number: 2000}, "+989120000002"), f.creator.ID, f.creator.Number)
+	require.NoError(t, responseDTO.UserErr)
+
+	update := app_expense.ExpenseUpdateInput{ExpenseUpdateInput: shared_dto.ExpenseUpdateInput{Name: "lunch"}}
+
+	responseDTO = f.service.Update(ctx, 1, f.creator.ID, 1, update)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.Equal(t, uint64(2), responseDTO.Data["expense"].(shared_dto.ExpenseOutput).Version)
+
+	// second update with old version gets current expense
+	update.Name = "breakfast"
+	responseDTO = f.service.Update(ctx, 1, f.creator.ID, 1, update)
+	assert.Equal(t, service_errors.ErrVersionConflict, responseDTO.UserErr)
+	assert.Equal(t, rcodes.VersionConflict, responseDTO.ResponseCode)
+	if current, ok := responseDTO.Data["expense"].(shared_dto.ExpenseOutput); assert.True(t, ok) {
+		assert.Equal(t, "lunch", current.Name)
+		assert.Equal(t, uint64(2), current.Version)
+	}
+
+	responseDTO = f.service.Delete(ctx, 1, f.creator.ID, 1)
+	assert.Equal(t, rcodes.VersionConflict, responseDTO.ResponseCode)
+
+	debts, _ := f.debtRepo.GetLimitedByUserID(ctx, f.creator.ID, 0, 10)
+	assert.Len(t, debts, 1)
+
+	responseDTO = f.service.Delete(ctx, 1, f.creator.ID, 2)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.NoError(t, responseDTO.ServerErr)
+}
+
+func TestGetLimited(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	responseDTO := f.service.Create(ctx, input(map[string]uint64{f.creator.Number: 3000}, "+989120000002", "+989120000003"), f.creator.ID, f.creator.Number)
+	require.NoError(t, responseDTO.UserErr)
+
+	// first page starts from first expense
+	responseDTO = f.service.GetLimited(ctx, f.creator.ID, 1, 1)
+	assert.NoError(t, responseDTO.UserErr)
+	assert.Len(t, responseDTO.Data["data"], 1)
+
+	responseDTO = f.service.GetLimited(ctx, f.creator.ID, 1, 10)
+	assert.Len(t, responseDTO.Data["data"], 2)
+
+	responseDTO = f.service.GetLimited(ctx, f.creator.ID, 3, 1)
+	assert.Len(t, responseDTO.Data["data"], 0)
+
+	responseDTO = f.service.GetLimited(ctx, f.creator.ID, 0, 10)
+	assert.Equal(t, service_errors.ErrInvalidPage, responseDTO.UserErr)
+}
@@ -0,0 +1,140 @@
+package login_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+const (
+	number   = "+989120000001"
+	password = "password123"
+)
+
+func setup(t *testing.T) app_user.UserAppService {
+	store := repository_memory.NewStore()
+	return setupWithStore(t, store, repository_memory.NewMemoryAuditRepository(store))
+}
+
+func setupWithStore(t *testing.T, store *repository_memory.Store, auditRepo domain_audit.AuditDomainRepository) app_user.UserAppService {
+	vld := validator.NewValidator()
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+	deviceAppService := app_device.NewDeviceAppService(repository_memory.NewMemoryDeviceRepository(store), auditRepo, domain_device.NewDeviceService(vld))
+
+	salt, err := utils.GenerateRandomSalt()
+	require.NoError(t, err)
+	hashedPassword, err := utils.HashPasswordWithSalt(password, salt, 4)
+	require.NoError(t, err)
+
+	user := domain_user.User{Name: "Ali", Number: number, Password: hashedPassword, Salt: salt, IsRegistered: true}
+	require.NoError(t, userRepo.Create(context.Background(), &user))
+
+	jwt.Init("test-secret")
+
+	return app_user.NewUserService(userRepo, cache.NewMemory(1, 100), auditRepo, deviceAppService, domain_user.NewUserService(vld))
+}
+
+func login(service app_user.UserAppService, password string, ip string) error {
+	res := service.Login(context.Background(), app_user.LoginUserInput{LoginUserInput: shared_dto.LoginUserInput{PhoneNumber: number, InputPassword: password}}, "test", ip)
+	if res.ServerErr != nil {
+		return res.ServerErr
+	}
+	return res.UserErr
+}
+
+func TestLoginAttemptsOfIP(t *testing.T) {
+	service := setup(t)
+
+	for i := 0; i < config.LoginMaxAttempts; i++ {
+		assert.NotEqual(t, service_errors.ErrTooManyLoginAttempts, login(service, "wrong-password", "192.0.2.1"), i)
+	}
+
+	// correct password is rejected too
+	assert.Equal(t, service_errors.ErrTooManyLoginAttempts, login(service, password, "192.0.2.1"))
+
+	// user can login from other ip. attempts of ip are reset after login
+	assert.NoError(t, login(service, password, "192.0.2.2"))
+	for i := 0; i < config.LoginMaxAttempts; i++ {
+		assert.NotEqual(t, service_errors.ErrTooManyLoginAttempts, login(service, "wrong-password", "192.0.2.2"), i)
+	}
+}
+
+func TestLoginAttemptsOfNumber(t *testing.T) {
+	service := setup(t)
+
+	for i := 0; i < config.LoginMaxNumberAttempts; i++ {
+		assert.NotEqual(t, service_errors.ErrTooManyLoginAttempts, login(service, "wrong-password", fmt.Sprintf("192.0.2.%d", i+1)), i)
+	}
+
+	assert.Equal(t, service_errors.ErrTooManyLoginAttempts, login(service, password, "198.51.100.1"))
+}
+
+func TestLoginAttemptsOfIPDoNotLockOutNumber(t *testing.T) {
+	service := setup(t)
+
+	// attempts after limit of ip are not counted for number
+	for i := 0; i < config.LoginMaxNumberAttempts+1; i++ {
+		login(service, "wrong-password", "192.0.2.1")
+	}
+
+	assert.NoError(t, login(service, password, "198.51.100.1"))
+}
+
+func TestLoginConcurrentAttempts(t *testing.T) {
+	service := setup(t)
+
+	var mu sync.Mutex
+	checked := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := login(service, "wrong-password", "192.0.2.1"); err != service_errors.ErrTooManyLoginAttempts {
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// password is checked only for allowed attempts
+	assert.Equal(t, config.LoginMaxAttempts, checked)
+}
+
+type failingAuditRepository struct {
+	domain_audit.AuditDomainRepository
+}
+
+func (failingAuditRepository) Create(ctx context.Context, log *domain_audit.AuditLog) error {
+	return errors.New("database is down")
+}
+
+// tokens of created device are returned even if audit log cannot be saved
+func TestLoginAuditError(t *testing.T) {
+	service := setupWithStore(t, repository_memory.NewStore(), failingAuditRepository{})
+
+	res := service.Login(context.Background(), app_user.LoginUserInput{LoginUserInput: shared_dto.LoginUserInput{PhoneNumber: number, InputPassword: password}}, "test", "192.0.2.1")
+	assert.NoError(t, res.ServerErr)
+	assert.NoError(t, res.UserErr)
+	assert.NotEmpty(t, res.Data["access"])
+}
@@ -0,0 +1,159 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_outbox "github.com/yaghoubi-mn/pedarkharj/internal/application/outbox"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/eventbus"
+)
+
+// publisher that fails while err is set
+type publisher struct {
+	mu       sync.Mutex
+	err      error
+	messages []eventbus.Message
+}
+
+func (p *publisher) Publish(ctx context.Context, msg eventbus.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func (p *publisher) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.messages)
+}
+
+func options() app_outbox.RelayOptions {
+	return app_outbox.RelayOptions{
+		Interval:    10 * time.Millisecond,
+		BatchSize:   2,
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+		MaxBackoff:  90 * time.Second,
+		ClaimLease:  time.Minute,
+	}
+}
+
+func saveEvents(t *testing.T, repo domain_outbox.OutboxDomainRepository, count int) {
+	for i := range count {
+		require.NoError(t, app_outbox.SaveEvent(context.Background(), repo, domain_outbox.EventExpenseCreated, domain_outbox.AggregateExpense, uint64(i+1), domain_outbox.ExpensePayload{ExpenseID: uint64(i + 1)}))
+	}
+}
+
+func TestPublishPending(t *testing.T) {
+	repo := repository_memory.NewMemoryOutboxRepository(repository_memory.NewStore())
+	pub := &publisher{}
+	relay := app_outbox.NewRelay(repo, pub, options())
+	ctx := context.Background()
+
+	saveEvents(t, repo, 3)
+
+	// batch size is 2
+	count, err := relay.PublishPending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = relay.PublishPending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// published events are not published again
+	count, err = relay.PublishPending(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	if assert.Len(t, pub.messages, 3) {
+		assert.Equal(t, domain_outbox.EventExpenseCreated, pub.messages[0].Type)
+		assert.JSONEq(t, `{"expense_id":1,"creator_id":0,"name":"","description":""}`, string(pub.messages[0].Payload))
+		assert.NotEmpty(t, pub.messages[0].ID)
+		assert.NotEqual(t, pub.messages[0].ID, pub.messages[1].ID)
+	}
+}
+
+func TestPublishPendingRetry(t *testing.T) {
+	repo := repository_memory.NewMemoryOutboxRepository(repository_memory.NewStore())
+	pub := &publisher{err: errors.New("broker is down")}
+	relay := app_outbox.NewRelay(repo, pub, options())
+	ctx := context.Background()
+
+	saveEvents(t, repo, 1)
+
+	count, err := relay.PublishPending(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// event is retried after backoff
+	pending, err := repo.GetPending(ctx, time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	pending, err = repo.GetPending(ctx, time.Now().Add(time.Minute+time.Second), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, 1, pending[0].Attempts)
+		assert.Equal(t, "broker is down", pending[0].LastError)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), pending[0].NextAttemptAt, time.Second)
+	}
+
+	// backoff is doubled and capped at max backoff
+	event := pending[0]
+	event.NextAttemptAt = time.Now()
+	require.NoError(t, repo.UpdateDelivery(ctx, event))
+	_, err = relay.PublishPending(ctx)
+	assert.NoError(t, err)
+
+	pending, _ = repo.GetPending(ctx, time.Now().Add(time.Hour), 10)
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, 2, pending[0].Attempts)
+		assert.WithinDuration(t, time.Now().Add(90*time.Second), pending[0].NextAttemptAt, time.Second)
+	}
+
+	// event is failed after max attempts
+	event = pending[0]
+	event.NextAttemptAt = time.Now()
+	require.NoError(t, repo.UpdateDelivery(ctx, event))
+	_, err = relay.PublishPending(ctx)
+	assert.NoError(t, err)
+
+	pending, _ = repo.GetPending(ctx, time.Now().Add(time.Hour), 10)
+	assert.Empty(t, pending)
+
+	pub.err = nil
+	count, err = relay.PublishPending(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Empty(t, pub.messages)
+}
+
+func TestRelayStartAndShutdown(t *testing.T) {
+	repo := repository_memory.NewMemoryOutboxRepository(repository_memory.NewStore())
+	pub := &publisher{}
+	relay := app_outbox.NewRelay(repo, pub, options())
+
+	saveEvents(t, repo, 5)
+	relay.Start()
+
+	// all batches are drained
+	assert.Eventually(t, func() bool { return pub.count() == 5 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, relay.Shutdown(ctx))
+	assert.NoError(t, relay.Shutdown(ctx))
+}
@@ -0,0 +1,309 @@
+package twofactor_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	shared_dto "github.com/yaghoubi-mn/pedarkharj/internal/shared/dto"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/totp"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/utils"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+const password = "password123"
+
+type fixture struct {
+	service  app_user.UserAppService
+	userRepo domain_user.UserDomainRepository
+	user     domain_user.User
+}
+
+func setup(t *testing.T) fixture {
+	ctx := context.Background()
+	vld := validator.NewValidator()
+	store := repository_memory.NewStore()
+	auditRepo := repository_memory.NewMemoryAuditRepository(store)
+
+	f := fixture{userRepo: repository_memory.NewMemoryUserRepository(store)}
+	deviceAppService := app_device.NewDeviceAppService(repository_memory.NewMemoryDeviceRepository(store), auditRepo, domain_device.NewDeviceService(vld))
+	f.service = app_user.NewUserService(f.userRepo, cache.NewMemory(1, 100), auditRepo, deviceAppService, domain_user.NewUserService(vld))
+
+	salt, err := utils.GenerateRandomSalt()
+	require.NoError(t, err)
+	hashedPassword, err := utils.HashPasswordWithSalt(password, salt, 4)
+	require.NoError(t, err)
+
+	f.user = domain_user.User{Name: "Ali", Number: "+989120000001", Password: hashedPassword, Salt: salt, IsRegistered: true}
+	require.NoError(t, f.userRepo.Create(ctx, &f.user))
+
+	jwt.Init("test-secret")
+
+	return f
+}
+
+// enroll and confirm two factor. returns secret and recovery codes
+func (f fixture) enable(t *testing.T) (string, []string) {
+	ctx := context.Background()
+
+	res := f.service.EnrollTwoFactor(ctx, f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+	secret := res.Data["secret"].(string)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	res = f.service.ConfirmTwoFactor(ctx, app_user.TwoFactorCodeInput{TwoFactorCodeInput: shared_dto.TwoFactorCodeInput{Code: code}}, f.user.ID)
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+
+	return secret, res.Data["recovery_codes"].([]string)
+}
+
+// login with password and returns challenge token of second step
+func (f fixture) login(t *testing.T) string {
+	res := f.service.Login(context.Background(), app_user.LoginUserInput{LoginUserInput: shared_dto.LoginUserInput{PhoneNumber: f.user.Number, InputPassword: password}}, "test", "127.0.0.1")
+	require.NoError(t, res.ServerErr)
+	require.NoError(t, res.UserErr)
+	require.Equal(t, rcodes.TwoFactorRequired, res.ResponseCode)
+
+	return res.Data["challenge_token"].(string)
+}
+
+func (f fixture) verify(challengeToken, code, recoveryCode string) error {
+	res := f.service.VerifyTwoFactor(context.Background(), app_user.TwoFactorVerifyInput{TwoFactorVerifyInput: shared_dto.TwoFactorVerifyInput{
+		ChallengeToken: challengeToken,
+		Code:           code,
+		RecoveryCode:   recoveryCode,
+	}})
+	if res.ServerErr != nil {
+		return res.ServerErr
+	}
+	return res.UserErr
+}
+
+func TestEnrollAndConfirm(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+
+	// confirm before enroll
+	res := f.service.ConfirmTwoFactor(ctx, app_user.TwoFactorCodeInput{TwoFactorCodeInput: shared_dto.TwoFactorCodeInput{Code: "123456"}}, f.user.ID)
+	assert.Equal(t, service_errors.ErrEnrollTwoFactorFirst, res.UserErr)
+
+	res = f.service.EnrollTwoFactor(ctx, f.user.ID)
+	require.NoError(t, res.UserErr)
+	secret := res.Data["secret"].(string)
+
+	// wrong code
+	res = f.service.ConfirmTwoFactor(ctx, app_user.TwoFactorCodeInput{TwoFactorCodeInput: shared_dto.TwoFactorCodeInput{Code: "000000"}}, f.user.ID)
+	if code, _ := totp.GenerateCode(secret, time.Now()); code != "000000" {
+		assert.Equal(t, service_errors.ErrWrongTOTPCode, res.UserErr)
+	}
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	res = f.service.ConfirmTwoFactor(ctx, app_user.TwoFactorCodeInput{TwoFactorCodeInput: shared_dto.TwoFactorCodeInput{Code: code}}, f.user.ID)
+	require.NoError(t, res.UserErr)
+	assert.Len(t, res.Data["recovery_codes"], 10)
+
+	user, err := f.userRepo.GetByID(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.True(t, user.IsTOTPEnabled)
+	assert.NotZero(t, user.TOTPLastCounter)
+
+	res = f.service.EnrollTwoFactor(ctx, f.user.ID)
+	assert.Equal(t, service_errors.ErrTwoFactorAlreadyEnabled, res.UserErr)
+}
+
+func TestVerifyRejectsReplayedCode(t *testing.T) {
+	f := setup(t)
+	secret, _ := f.enable(t)
+
+	// code used for confirm cannot be used for login
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, service_errors.ErrWrongTOTPCode, f.verify(f.login(t), code, ""))
+
+	// next code is accepted once
+	nextCode, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	assert.NoError(t, f.verify(f.login(t), nextCode, ""))
+	assert.Equal(t, service_errors.ErrWrongTOTPCode, f.verify(f.login(t), nextCode, ""))
+}
+
+func TestVerifyRecoveryCode(t *testing.T) {
+	f := setup(t)
+	_, recoveryCodes := f.enable(t)
+
+	assert.NoError(t, f.verify(f.login(t), "", recoveryCodes[0]))
+	// recovery code can be used only once
+	assert.Equal(t, service_errors.ErrWrongRecoveryCode, f.verify(f.login(t), "", recoveryCodes[0]))
+	assert.NoError(t, f.verify(f.login(t), "", recoveryCodes[1]))
+}
+
+func TestVerifyConcurrentRecoveryCode(t *testing.T) {
+	f := setup(t)
+	_, recoveryCodes := f.enable(t)
+
+	// challenges are fewer than limit of attempts, so all requests check code
+	challengeTokens := make([]string, config.TwoFactorMaxAttempts-1)
+	for i := range challengeTokens {
+		challengeTokens[i] = f.login(t)
+	}
+
+	// recovery code is accepted by one of concurrent requests
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for _, challengeToken := range challengeTokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := f.verify(challengeToken, "", recoveryCodes[0])
+			if err == nil {
+				accepted.Add(1)
+				return
+			}
+			assert.Equal(t, service_errors.ErrWrongRecoveryCode, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), accepted.Load())
+}
+
+func TestVerifyConcurrentAttempts(t *testing.T) {
+	f := setup(t)
+	_, recoveryCodes := f.enable(t)
+	challengeToken := f.login(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.TwoFactorMaxAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.verify(challengeToken, "", "aaaaa-bbbbb")
+		}()
+	}
+	wg.Wait()
+
+	// all wrong codes are counted
+	assert.Equal(t, service_errors.ErrTwoFactorChallengeExpired, f.verify(challengeToken, "", recoveryCodes[0]))
+}
+
+func TestVerifyLocksOutUser(t *testing.T) {
+	f := setup(t)
+	_, recoveryCodes := f.enable(t)
+
+	for i := 0; i < config.TwoFactorMaxAttempts-1; i++ {
+		assert.Equal(t, service_errors.ErrWrongRecoveryCode, f.verify(f.login(t), "", "aaaaa-bbbbb"))
+	}
+	assert.Equal(t, service_errors.ErrTooManyTwoFactorAttempts, f.verify(f.login(t), "", "aaaaa-bbbbb"))
+
+	// new challenge from login does not reset attempts
+	res := f.service.Login(context.Background(), app_user.LoginUserInput{LoginUserInput: shared_dto.LoginUserInput{PhoneNumber: f.user.Number, InputPassword: password}}, "test", "127.0.0.2")
+	require.NoError(t, res.ServerErr)
+	require.Equal(t, rcodes.TwoFactorRequired, res.ResponseCode)
+	assert.Equal(t, service_errors.ErrTooManyTwoFactorAttempts, f.verify(res.Data["challenge_token"].(string), "", recoveryCodes[0]))
+}
+
+func TestLoginCountersResetAfterSecondFactor(t *testing.T) {
+	f := setup(t)
+	_, recoveryCodes := f.enable(t)
+
+	// password alone does not reset counters
+	for i := 0; i < config.LoginMaxAttempts-1; i++ {
+		f.login(t)
+	}
+	require.NoError(t, f.verify(f.login(t), "", recoveryCodes[0]))
+
+	for i := 0; i < config.LoginMaxAttempts; i++ {
+		f.login(t)
+	}
+	res := f.service.Login(context.Background(), app_user.LoginUserInput{LoginUserInput: shared_dto.LoginUserInput{PhoneNumber: f.user.Number, InputPassword: password}}, "test", "127.0.0.1")
+	assert.Equal(t, service_errors.ErrTooManyLoginAttempts, res.UserErr)
+}
+
+func TestDisable(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	_, recoveryCodes := f.enable(t)
+
+	disable := func(password, recoveryCode string) error {
+		res := f.service.DisableTwoFactor(ctx, app_user.TwoFactorDisableInput{TwoFactorDisableInput: shared_dto.TwoFactorDisableInput{Password: password, RecoveryCode: recoveryCode}}, f.user.ID)
+		require.NoError(t, res.ServerErr)
+		return res.UserErr
+	}
+
+	assert.Equal(t, service_errors.ErrWrongPassword, disable("wrong-password", recoveryCodes[0]))
+	assert.Equal(t, service_errors.ErrWrongRecoveryCode, disable(password, "aaaaa-bbbbb"))
+	assert.NoError(t, disable(password, recoveryCodes[0]))
+
+	user, err := f.userRepo.GetByID(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.False(t, user.IsTOTPEnabled)
+	assert.Empty(t, user.TOTPSecret)
+
+	assert.Equal(t, service_errors.ErrTwoFactorNotEnabled, disable(password, recoveryCodes[1]))
+}
+
+func TestDisableConcurrentCode(t *testing.T) {
+	f := setup(t)
+	secret, _ := f.enable(t)
+
+	// code of confirm is used, so next code is sent
+	code, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+
+	var disabled atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < config.TwoFactorMaxAttempts-1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := f.service.DisableTwoFactor(context.Background(), app_user.TwoFactorDisableInput{TwoFactorDisableInput: shared_dto.TwoFactorDisableInput{Password: password, Code: code}}, f.user.ID)
+			assert.NoError(t, res.ServerErr)
+			if res.UserErr == nil {
+				disabled.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// code is accepted once
+	assert.Equal(t, int32(1), disabled.Load())
+}
+
+func TestDisableLocksOutUser(t *testing.T) {
+	f := setup(t)
+	ctx := context.Background()
+	_, recoveryCodes := f.enable(t)
+
+	disable := func(password, recoveryCode string) error {
+		res := f.service.DisableTwoFactor(ctx, app_user.TwoFactorDisableInput{TwoFactorDisableInput: shared_dto.TwoFactorDisableInput{Password: password, RecoveryCode: recoveryCode}}, f.user.ID)
+		require.NoError(t, res.ServerErr)
+		return res.UserErr
+	}
+
+	for i := 0; i < config.TwoFactorMaxAttempts; i++ {
+		assert.Equal(t, service_errors.ErrWrongPassword, disable("wrong-password", recoveryCodes[0]))
+	}
+	assert.Equal(t, service_errors.ErrTooManyTwoFactorAttempts, disable(password, recoveryCodes[0]))
+
+	// attempts of disable are shared with login second step
+	assert.Equal(t, service_errors.ErrTooManyTwoFactorAttempts, f.verify(f.login(t), "", recoveryCodes[0]))
+}
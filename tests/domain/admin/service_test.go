@@ -0,0 +1,85 @@
+package admin_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain_admin "github.com/yaghoubi-mn/pedarkharj/internal/domain/admin"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+)
+
+var adminService domain_admin.AdminDomainService
+
+func setup() {
+	adminService = domain_admin.NewAdminDomainService(validator.NewValidator())
+}
+
+func TestMain(m *testing.M) {
+	setup()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestCheckPermission(t *testing.T) {
+
+	tests := []struct {
+		ID         int
+		Role       string
+		Permission domain_admin.Permission
+		WantErr    error
+	}{
+		{ID: 1, Role: domain_admin.RoleAdmin, Permission: domain_admin.PermissionBlockUser, WantErr: nil},
+		{ID: 2, Role: domain_admin.RoleSupport, Permission: domain_admin.PermissionLogoutUser, WantErr: nil},
+		{ID: 3, Role: domain_admin.RoleSupport, Permission: domain_admin.PermissionBlockUser, WantErr: service_errors.ErrPermissionDenied},
+		{ID: 4, Role: domain_admin.RoleUser, Permission: domain_admin.PermissionSearchUsers, WantErr: service_errors.ErrPermissionDenied},
+		{ID: 5, Role: "", Permission: domain_admin.PermissionViewStats, WantErr: service_errors.ErrPermissionDenied},
+	}
+
+	for _, test := range tests {
+		err := adminService.CheckPermission(test.Role, test.Permission)
+		assert.Equal(t, test.WantErr, err, "test id: %d", test.ID)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+
+	tests := []struct {
+		ID      int
+		Input   domain_admin.SearchUsersInput
+		WantErr error
+	}{
+		{ID: 1, Input: domain_admin.NewSearchUsersInput("ali", 1, 10), WantErr: nil},
+		{ID: 2, Input: domain_admin.NewSearchUsersInput("", 0, 10), WantErr: service_errors.ErrInvalidPage},
+		{ID: 3, Input: domain_admin.NewSearchUsersInput("", 1, 0), WantErr: service_errors.ErrInvalidLimit},
+		{ID: 4, Input: domain_admin.NewSearchUsersInput("", 1, 1000), WantErr: service_errors.ErrInvalidLimit},
+		{ID: 5, Input: domain_admin.NewSearchUsersInput(strings.Repeat("a", 31), 1, 10), WantErr: service_errors.ErrInvalidQuery},
+	}
+
+	for _, test := range tests {
+		err := adminService.SearchUsers(test.Input)
+		assert.Equal(t, test.WantErr, err, "test id: %d", test.ID)
+	}
+}
+
+func TestBlockUser(t *testing.T) {
+
+	tests := []struct {
+		ID      int
+		Input   domain_admin.BlockUserInput
+		WantErr error
+	}{
+		{ID: 1, Input: domain_admin.NewBlockUserInput(2, true, "spam", 1, domain_admin.RoleUser), WantErr: nil},
+		{ID: 2, Input: domain_admin.NewBlockUserInput(0, true, "", 1, domain_admin.RoleUser), WantErr: service_errors.ErrInvalidUserID},
+		{ID: 3, Input: domain_admin.NewBlockUserInput(1, true, "", 1, domain_admin.RoleAdmin), WantErr: service_errors.ErrCannotBlockYourself},
+		{ID: 4, Input: domain_admin.NewBlockUserInput(2, true, "", 1, domain_admin.RoleAdmin), WantErr: service_errors.ErrCannotBlockAdmin},
+		{ID: 5, Input: domain_admin.NewBlockUserInput(2, false, strings.Repeat("a", 301), 1, domain_admin.RoleSupport), WantErr: service_errors.ErrLongReason},
+	}
+
+	for _, test := range tests {
+		err := adminService.BlockUser(test.Input)
+		assert.Equal(t, test.WantErr, err, "test id: %d", test.ID)
+	}
+}
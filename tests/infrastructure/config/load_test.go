@@ -0,0 +1,173 @@
+package config_test
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/config"
+)
+
+func validConfig() config.Config {
+	cfg, _, _ := config.Load(nil)
+	cfg.Database.Host = "localhost"
+	cfg.Database.Username = "user"
+	cfg.Database.Name = "db"
+	cfg.JWT.SecretKey = strings.Repeat("k", 32)
+	cfg.S3.AccessKey = "access"
+	cfg.S3.SecretKey = "secret"
+	cfg.S3.BucketName = "bucket"
+	cfg.S3.APIURL = "s3.example.com"
+	cfg.S3.AccessURL = "cdn.example.com"
+	cfg.SMS.APIKey = "key"
+	cfg.SMS.TemplateID = 1
+	return cfg
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, command, err := config.Load([]string{"migrate", "up"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, command)
+
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, ":8000", cfg.Server.Addr)
+	assert.Equal(t, ":9000", cfg.GRPC.Addr)
+	assert.Equal(t, []string{"*"}, cfg.CORS.AllowedOrigins)
+	assert.Subset(t, cfg.CORS.ExposedHeaders, []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"})
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, "database", cfg.Cache.Backend)
+}
+
+func TestLoadPriority(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	err := os.WriteFile(file, []byte("debug: false\ndatabase:\n  host: file-host\n  port: 1000\n  name: file-db\n"), 0o644)
+	assert.NoError(t, err)
+
+	// env overrides file
+	t.Setenv("DB_PORT", "2000")
+	t.Setenv("DB_NAME", "env-db")
+
+	// flag overrides env
+	cfg, command, err := config.Load([]string{"-config", file, "-database.name=flag-db", "debug"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"debug"}, command)
+
+	assert.False(t, cfg.Debug)
+	assert.Equal(t, "file-host", cfg.Database.Host)
+	assert.Equal(t, 2000, cfg.Database.Port)
+	assert.Equal(t, "flag-db", cfg.Database.Name)
+}
+
+func TestLoadDatabaseEnvPrefix(t *testing.T) {
+	t.Setenv("DB_PREFIX", "TEST_")
+	t.Setenv("TEST_DB_HOST", "test-host")
+	t.Setenv("DB_HOST", "host")
+	// log level is not prefixed
+	t.Setenv("DB_LOG_LEVEL", "info")
+	t.Setenv("TEST_DB_LOG_LEVEL", "silent")
+
+	cfg, _, err := config.Load(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-host", cfg.Database.Host)
+	assert.Equal(t, "info", cfg.Database.LogLevel)
+}
+
+func TestLoadList(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://*.example.com")
+
+	cfg, _, err := config.Load(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com", "https://*.example.com"}, cfg.CORS.AllowedOrigins)
+}
+
+func TestTrustedProxyPrefixes(t *testing.T) {
+	t.Setenv("RATE_LIMIT_TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1, ::1")
+	cfg, _, err := config.Load(nil)
+	assert.NoError(t, err)
+
+	prefixes, err := cfg.RateLimit.TrustedProxyPrefixes()
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+		netip.MustParsePrefix("::1/128"),
+	}, prefixes)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	os.WriteFile(file, []byte("database:\n  unknown_field: 1\n"), 0o644)
+
+	_, _, err := config.Load([]string{"-config", file})
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-database.port=abc"})
+	assert.Error(t, err)
+
+	_, _, err = config.Load([]string{"-config", filepath.Join(dir, "not-exist.yaml")})
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+
+	// sqlite does not need host and credentials
+	sqliteCfg := validConfig()
+	sqliteCfg.Database = config.DatabaseConfig{Driver: "sqlite", Path: "test.db", LogLevel: "error"}
+	assert.NoError(t, sqliteCfg.Validate())
+
+	tests := []struct {
+		ID      int
+		Change  func(cfg *config.Config)
+		WantErr string
+	}{
+		{ID: 1, Change: func(cfg *config.Config) { cfg.Database.Host = "" }, WantErr: "database.host is required"},
+		{ID: 2, Change: func(cfg *config.Config) { cfg.JWT.SecretKey = "" }, WantErr: "jwt.secret_key is required"},
+		{ID: 3, Change: func(cfg *config.Config) { cfg.Cache.Backend = "redis" }, WantErr: "cache.redis_addr is required"},
+		{ID: 4, Change: func(cfg *config.Config) { cfg.Cache.Backend = "file" }, WantErr: "cache.backend"},
+		{ID: 5, Change: func(cfg *config.Config) { cfg.Debug = false; cfg.SMS.APIKey = "" }, WantErr: "sms.api_key is required"},
+		{ID: 6, Change: func(cfg *config.Config) { cfg.Debug = false; cfg.JWT.SecretKey = "short" }, WantErr: "at least 32 characters"},
+		{ID: 7, Change: func(cfg *config.Config) { cfg.S3.AccessURLProtocol = "ftp://" }, WantErr: "s3.access_url_protocol"},
+		{ID: 8, Change: func(cfg *config.Config) { cfg.Database.Driver = "mysql" }, WantErr: "database.driver"},
+		{ID: 9, Change: func(cfg *config.Config) { cfg.Database.Driver = "sqlite"; cfg.Database.Path = "" }, WantErr: "database.path is required"},
+		{ID: 10, Change: func(cfg *config.Config) { cfg.GRPC.Addr = cfg.Server.Addr }, WantErr: "grpc.addr must be different"},
+		{ID: 11, Change: func(cfg *config.Config) { cfg.CORS.AllowCredentials = true }, WantErr: "cors.allowed_origins must not contain *"},
+		{ID: 12, Change: func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"app.example.com"} }, WantErr: "cors.allowed_origins: invalid origin"},
+		{ID: 13, Change: func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"https://app.*.example.com"} }, WantErr: "wildcard is only allowed"},
+		{ID: 14, Change: func(cfg *config.Config) { cfg.RateLimit.Auth = "10" }, WantErr: "rate_limit.auth"},
+		{ID: 15, Change: func(cfg *config.Config) { cfg.RateLimit.Write = "0/1m" }, WantErr: "rate_limit.write"},
+		{ID: 16, Change: func(cfg *config.Config) { cfg.RateLimit.TrustedProxies = []string{"10.0.0.0/33"} }, WantErr: "rate_limit.trusted_proxies"},
+	}
+
+	for _, test := range tests {
+		cfg := validConfig()
+		test.Change(&cfg)
+
+		err := cfg.Validate()
+		if assert.Error(t, err, "test id: %d", test.ID) {
+			assert.Contains(t, err.Error(), test.WantErr, "test id: %d", test.ID)
+		}
+	}
+}
+
+func TestMasked(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.Password = "db-password"
+
+	out := cfg.String()
+	assert.NotContains(t, out, "db-password")
+	assert.NotContains(t, out, cfg.JWT.SecretKey)
+	assert.Contains(t, out, "localhost")
+
+	// original config is not changed
+	assert.Equal(t, "db-password", cfg.Database.Password)
+
+	// empty secrets are not masked
+	cfg.Cache.RedisPassword = ""
+	assert.Equal(t, "", cfg.Masked().Cache.RedisPassword)
+}
@@ -0,0 +1,80 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+)
+
+func TestAuditRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	if newRepos(t).Audit == nil {
+		t.Skip("audit repository is not implemented")
+	}
+
+	t.Run("CreateAndSearch", func(t *testing.T) {
+		repos := newRepos(t)
+		now := time.Now()
+
+		logs := []domain_audit.AuditLog{
+			{ActorID: 1, Action: domain_audit.ActionLogin, TargetType: domain_audit.TargetUser, TargetID: 1, IP: "1.1.1.1", RequestID: "req-1", CreatedAt: now.Add(-2 * time.Hour)},
+			{ActorID: 1, Action: domain_audit.ActionExpenseCreate, TargetType: domain_audit.TargetExpense, TargetID: 5, After: `{"name":"dinner"}`, CreatedAt: now.Add(-time.Hour)},
+			{ActorID: 2, Action: domain_audit.ActionExpenseUpdate, TargetType: domain_audit.TargetExpense, TargetID: 5, Before: `{"name":"dinner"}`, After: `{"name":"lunch"}`, CreatedAt: now},
+		}
+		for i := range logs {
+			require.NoError(t, repos.Audit.Create(ctx, &logs[i]))
+			assert.NotZero(t, logs[i].ID)
+		}
+
+		// newest first
+		found, total, err := repos.Audit.Search(ctx, domain_audit.Filter{}, 0, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		if assert.Len(t, found, 2) {
+			assert.Equal(t, logs[2].ID, found[0].ID)
+			assert.Equal(t, `{"name":"dinner"}`, found[0].Before)
+			assert.Equal(t, `{"name":"lunch"}`, found[0].After)
+		}
+
+		found, total, err = repos.Audit.Search(ctx, domain_audit.Filter{ActorID: 1}, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, domain_audit.ActionLogin, found[0].Action)
+			assert.Equal(t, "1.1.1.1", found[0].IP)
+			assert.Equal(t, "req-1", found[0].RequestID)
+		}
+
+		// history of a target
+		found, total, err = repos.Audit.Search(ctx, domain_audit.Filter{TargetType: domain_audit.TargetExpense, TargetID: 5}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, found, 2)
+
+		found, _, err = repos.Audit.Search(ctx, domain_audit.Filter{Action: domain_audit.ActionExpenseUpdate}, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, uint64(2), found[0].ActorID)
+		}
+
+		found, total, err = repos.Audit.Search(ctx, domain_audit.Filter{From: now.Add(-90 * time.Minute), To: now.Add(-30 * time.Minute)}, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, domain_audit.ActionExpenseCreate, found[0].Action)
+		}
+	})
+
+	t.Run("CreatedAtIsSet", func(t *testing.T) {
+		repos := newRepos(t)
+
+		log := domain_audit.AuditLog{ActorID: 1, Action: domain_audit.ActionDeviceLogoutAll, TargetType: domain_audit.TargetUser, TargetID: 1}
+		require.NoError(t, repos.Audit.Create(ctx, &log))
+		assert.WithinDuration(t, time.Now(), log.CreatedAt, time.Minute)
+	})
+}
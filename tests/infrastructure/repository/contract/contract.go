@@ -0,0 +1,67 @@
+// contract tests that every implementation of domain repositories must pass.
+// they are run against gorm and in-memory repositories, so in-memory repositories behave like database
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+)
+
+// repositories of an implementation. all of them must share same storage.
+// nil repositories are not implemented and their tests are skipped
+type Repositories struct {
+	User           domain_user.UserDomainRepository
+	Device         domain_device.DeviceDomainRepository
+	Expense        domain_expense.ExpenseDomainRepository
+	Debt           domain_debt.DebtDomainRepository
+	ExpenseComment domain_expense_comment.DebtDomainRepository
+	Notification   domain_notification.NotificationDomainRepository
+	Outbox         domain_outbox.OutboxDomainRepository
+	Audit          domain_audit.AuditDomainRepository
+}
+
+// returns repositories with empty storage
+type Factory func(t *testing.T) Repositories
+
+// run all contract tests
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("User", func(t *testing.T) { TestUserRepository(t, newRepos) })
+	t.Run("Device", func(t *testing.T) { TestDeviceRepository(t, newRepos) })
+	t.Run("Debt", func(t *testing.T) { TestDebtRepository(t, newRepos) })
+	t.Run("Expense", func(t *testing.T) { TestExpenseRepository(t, newRepos) })
+	t.Run("ExpenseComment", func(t *testing.T) { TestExpenseCommentRepository(t, newRepos) })
+	t.Run("Notification", func(t *testing.T) { TestNotificationRepository(t, newRepos) })
+	t.Run("Outbox", func(t *testing.T) { TestOutboxRepository(t, newRepos) })
+	t.Run("Audit", func(t *testing.T) { TestAuditRepository(t, newRepos) })
+}
+
+func createUser(t *testing.T, repos Repositories, name string, number string) domain_user.User {
+	user := domain_user.User{Name: name, Number: number, Avatar: "avatar.png", Salt: "salt", IsRegistered: true}
+	require.NoError(t, repos.User.Create(context.Background(), &user))
+	require.NotZero(t, user.ID)
+	return user
+}
+
+func createExpense(t *testing.T, repos Repositories, creatorID uint64, name string) domain_expense.Expense {
+	expense := domain_expense.Expense{CreatorID: creatorID, Name: name, TotalAmount: 1000}
+	require.NoError(t, repos.Expense.Create(context.Background(), &expense))
+	require.NotZero(t, expense.ID)
+	return expense
+}
+
+func createDebt(t *testing.T, repos Repositories, expenseID uint64, creditorID uint64, debtorID uint64, amount uint64) domain_debt.Debt {
+	debt := domain_debt.Debt{ExpenseID: expenseID, CreditorID: creditorID, DebtorID: debtorID, Amount: amount}
+	require.NoError(t, repos.Debt.Create(context.Background(), &debt))
+	require.NotZero(t, debt.ID)
+	return debt
+}
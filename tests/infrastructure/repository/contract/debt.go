@@ -0,0 +1,140 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestDebtRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("GetByID", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		other := createUser(t, repos, "Sara", "+989120000003")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		debt := createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+
+		for _, userID := range []uint64{creditor.ID, debtor.ID} {
+			got, err := repos.Debt.GetByID(ctx, debt.ID, userID)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(100), got.Amount)
+		}
+
+		// user is not side of debt
+		_, err := repos.Debt.GetByID(ctx, debt.ID, other.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+
+	t.Run("CreateMultipleAndGetLimited", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+
+		debts := []domain_debt.Debt{
+			{ExpenseID: expense.ID, CreditorID: creditor.ID, DebtorID: debtor.ID, Amount: 1},
+			{ExpenseID: expense.ID, CreditorID: creditor.ID, DebtorID: debtor.ID, Amount: 2},
+			{ExpenseID: expense.ID, CreditorID: debtor.ID, DebtorID: creditor.ID, Amount: 3},
+		}
+		assert.NoError(t, repos.Debt.CreateMultipleWithTransaction(ctx, debts))
+		for _, debt := range debts {
+			assert.NotZero(t, debt.ID)
+		}
+
+		got, err := repos.Debt.GetLimitedByUserID(ctx, creditor.ID, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, got, 3)
+
+		// ordered by id
+		got, err = repos.Debt.GetLimitedByUserID(ctx, debtor.ID, 1, 1)
+		assert.NoError(t, err)
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, uint64(2), got[0].Amount)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		debt := createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+		assert.Equal(t, uint64(1), debt.Version)
+
+		debt.IsPaid = true
+		assert.NoError(t, repos.Debt.Update(ctx, &debt))
+		assert.Equal(t, uint64(2), debt.Version)
+
+		got, err := repos.Debt.GetByID(ctx, debt.ID, debtor.ID)
+		assert.NoError(t, err)
+		assert.True(t, got.IsPaid)
+		assert.Equal(t, uint64(100), got.Amount)
+		assert.Equal(t, uint64(2), got.Version)
+
+		// false values are saved
+		got.IsPaid = false
+		assert.NoError(t, repos.Debt.Update(ctx, &got))
+		got, err = repos.Debt.GetByID(ctx, debt.ID, debtor.ID)
+		assert.NoError(t, err)
+		assert.False(t, got.IsPaid)
+
+		assert.NoError(t, repos.Debt.Delete(ctx, debt.ID, got.Version))
+
+		_, err = repos.Debt.GetByID(ctx, debt.ID, debtor.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		assert.ErrorIs(t, repos.Debt.Update(ctx, &got), database_errors.ErrRecordNotFound)
+		assert.ErrorIs(t, repos.Debt.Delete(ctx, debt.ID, got.Version), database_errors.ErrRecordNotFound)
+	})
+
+	t.Run("VersionConflict", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		debt := createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+
+		// creditor and debtor read same version of debt
+		byCreditor, err := repos.Debt.GetByID(ctx, debt.ID, creditor.ID)
+		assert.NoError(t, err)
+		byDebtor, err := repos.Debt.GetByID(ctx, debt.ID, debtor.ID)
+		assert.NoError(t, err)
+
+		byCreditor.IsCreditorAccepted = true
+		assert.NoError(t, repos.Debt.Update(ctx, &byCreditor))
+
+		byDebtor.IsDebtorRequestedForDelete = true
+		assert.ErrorIs(t, repos.Debt.Update(ctx, &byDebtor), database_errors.ErrVersionConflict)
+		assert.ErrorIs(t, repos.Debt.Delete(ctx, debt.ID, byDebtor.Version), database_errors.ErrVersionConflict)
+
+		got, err := repos.Debt.GetByID(ctx, debt.ID, debtor.ID)
+		assert.NoError(t, err)
+		assert.True(t, got.IsCreditorAccepted)
+		assert.False(t, got.IsDebtorRequestedForDelete)
+		assert.Equal(t, uint64(2), got.Version)
+	})
+
+	t.Run("DeleteByExpenseID", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		otherExpense := createExpense(t, repos, creditor.ID, "lunch")
+		debt := createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+		otherDebt := createDebt(t, repos, otherExpense.ID, creditor.ID, debtor.ID, 100)
+
+		assert.NoError(t, repos.Debt.DeleteByExpenseID(ctx, expense.ID))
+
+		_, err := repos.Debt.GetByID(ctx, debt.ID, debtor.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		_, err = repos.Debt.GetByID(ctx, otherDebt.ID, debtor.ID)
+		assert.NoError(t, err)
+	})
+}
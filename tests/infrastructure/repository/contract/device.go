@@ -0,0 +1,70 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestDeviceRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateOrUpdate", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		device := domain_device.Device{Name: "phone", LastIP: "1.1.1.1", FirstLogin: time.Now(), LastLogin: time.Now(), UserID: user.ID, RefreshToken: "refresh-1"}
+		assert.NoError(t, repos.Device.CreateOrUpdate(ctx, device))
+
+		got, err := repos.Device.GetUserByRefreshToken(ctx, "refresh-1")
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		// same name and user updates device
+		device.RefreshToken = "refresh-2"
+		assert.NoError(t, repos.Device.CreateOrUpdate(ctx, device))
+
+		_, err = repos.Device.GetUserByRefreshToken(ctx, "refresh-1")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		got, err = repos.Device.GetUserByRefreshToken(ctx, "refresh-2")
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+	})
+
+	t.Run("UnknownRefreshToken", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		assert.NoError(t, repos.Device.Create(ctx, domain_device.Device{Name: "phone", LastIP: "1.1.1.1", UserID: user.ID, RefreshToken: "refresh"}))
+
+		_, err := repos.Device.GetUserByRefreshToken(ctx, "unknown")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		_, err = repos.Device.GetUserByRefreshToken(ctx, "")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+
+	t.Run("Logout", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		assert.NoError(t, repos.Device.Create(ctx, domain_device.Device{Name: "phone", LastIP: "1.1.1.1", UserID: user.ID, RefreshToken: "refresh-1"}))
+		assert.NoError(t, repos.Device.Create(ctx, domain_device.Device{Name: "laptop", LastIP: "1.1.1.1", UserID: user.ID, RefreshToken: "refresh-2"}))
+
+		assert.NoError(t, repos.Device.Logout(ctx, user.ID, "phone"))
+
+		_, err := repos.Device.GetUserByRefreshToken(ctx, "refresh-1")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		_, err = repos.Device.GetUserByRefreshToken(ctx, "refresh-2")
+		assert.NoError(t, err)
+
+		assert.NoError(t, repos.Device.LogoutAllUserDevices(ctx, user.ID))
+
+		_, err = repos.Device.GetUserByRefreshToken(ctx, "refresh-2")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+}
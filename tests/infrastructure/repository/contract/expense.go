@@ -0,0 +1,150 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestExpenseRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateUsersWithNumbers", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		numbers := []string{"+989120000001", "+989120000002"}
+		assert.NoError(t, repos.Expense.CreateUsersWithNumbers(ctx, numbers))
+
+		ids, err := repos.Expense.GetUserIDOfPhoneNumbers(ctx, append(numbers, "+989120000009"))
+		assert.NoError(t, err)
+		assert.Len(t, ids, 2)
+		assert.Equal(t, user.ID, ids["+989120000001"])
+
+		// existing user is not changed
+		got, _ := repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, "Ali", got.Name)
+		assert.True(t, got.IsRegistered)
+
+		created, err := repos.User.GetByID(ctx, ids["+989120000002"])
+		assert.NoError(t, err)
+		assert.False(t, created.IsRegistered)
+		assert.Equal(t, "+989120000002", created.Name)
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		other := createUser(t, repos, "Sara", "+989120000003")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+
+		assert.False(t, expense.CreatedAt.IsZero())
+
+		got, err := repos.Expense.GetByID(ctx, expense.ID, debtor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "dinner", got.Name)
+		assert.Equal(t, creditor.ID, got.CreatorID)
+
+		// user has no debt in expense
+		_, err = repos.Expense.GetByID(ctx, expense.ID, other.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		_, err = repos.Expense.GetByID(ctx, expense.ID+100, debtor.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+
+	t.Run("GetLimitedExpenseDebtByUserID", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		friend := createUser(t, repos, "Reza", "+989120000002")
+
+		older := domain_expense.Expense{CreatorID: user.ID, Name: "older", TotalAmount: 100, CreatedAt: time.Now().Add(-time.Hour)}
+		assert.NoError(t, repos.Expense.Create(ctx, &older))
+		newer := domain_expense.Expense{CreatorID: friend.ID, Name: "newer", TotalAmount: 200, CreatedAt: time.Now()}
+		assert.NoError(t, repos.Expense.Create(ctx, &newer))
+
+		createDebt(t, repos, older.ID, user.ID, friend.ID, 100)
+		createDebt(t, repos, newer.ID, friend.ID, user.ID, 200)
+
+		got, err := repos.Expense.GetLimitedExpenseDebtByUserID(ctx, user.ID, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, got, 2) {
+			// newest first
+			assert.Equal(t, "newer", got[0].Name)
+			assert.Equal(t, uint64(200), got[0].Amount)
+
+			// contact is other side of debt
+			assert.Equal(t, "Reza", got[0].UserName)
+			assert.Equal(t, "avatar.png", got[0].UserAvatar)
+			assert.Equal(t, "creditor", got[0].Type)
+			assert.Equal(t, "debtor", got[1].Type)
+		}
+
+		got, err = repos.Expense.GetLimitedExpenseDebtByUserID(ctx, user.ID, 1, 1)
+		assert.NoError(t, err)
+		if assert.Len(t, got, 1) {
+			assert.Equal(t, "older", got[0].Name)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := domain_expense.Expense{CreatorID: creditor.ID, Name: "dinner", Description: "friday", TotalAmount: 100}
+		assert.NoError(t, repos.Expense.Create(ctx, &expense))
+		createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+
+		assert.Equal(t, uint64(1), expense.Version)
+
+		stale := expense
+		expense.Name = "lunch"
+		assert.NoError(t, repos.Expense.Update(ctx, &expense))
+		assert.Equal(t, uint64(2), expense.Version)
+
+		got, err := repos.Expense.GetByID(ctx, expense.ID, creditor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "lunch", got.Name)
+		assert.Equal(t, "friday", got.Description)
+		assert.Equal(t, uint64(2), got.Version)
+
+		// expense is changed after stale copy was read
+		stale.Description = "saturday"
+		assert.ErrorIs(t, repos.Expense.Update(ctx, &stale), database_errors.ErrVersionConflict)
+
+		got, err = repos.Expense.GetByID(ctx, expense.ID, creditor.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "friday", got.Description)
+
+		missing := domain_expense.Expense{ID: expense.ID + 100, Version: 1}
+		assert.ErrorIs(t, repos.Expense.Update(ctx, &missing), database_errors.ErrRecordNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repos := newRepos(t)
+		creator := createUser(t, repos, "Ali", "+989120000001")
+		other := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creator.ID, "dinner")
+		createDebt(t, repos, expense.ID, creator.ID, other.ID, 100)
+
+		// only creator can delete expense
+		err := repos.Expense.Delete(ctx, expense.ID, other.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		_, err = repos.Expense.GetByID(ctx, expense.ID, creator.ID)
+		assert.NoError(t, err)
+
+		// debts are deleted before expense
+		assert.NoError(t, repos.Debt.DeleteByExpenseID(ctx, expense.ID))
+		assert.NoError(t, repos.Expense.Delete(ctx, expense.ID, creator.ID))
+
+		err = repos.Expense.Delete(ctx, expense.ID, creator.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+}
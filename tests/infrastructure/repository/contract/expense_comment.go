@@ -0,0 +1,67 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain_expense_comment "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense_comment"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestExpenseCommentRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	if newRepos(t).ExpenseComment == nil {
+		t.Skip("expense comment repository is not implemented")
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		expense := createExpense(t, repos, user.ID, "dinner")
+
+		for _, content := range []string{"first", "second", "third"} {
+			comment := domain_expense_comment.ExpenseComment{UserID: user.ID, ExpenseID: expense.ID, Content: content}
+			assert.NoError(t, repos.ExpenseComment.Create(ctx, &comment))
+			assert.NotZero(t, comment.ID)
+			assert.False(t, comment.CreatedAt.IsZero())
+		}
+
+		// older first
+		comments, err := repos.ExpenseComment.GetLimitedByExpenseID(ctx, expense.ID, 1, 5)
+		assert.NoError(t, err)
+		if assert.Len(t, comments, 2) {
+			assert.Equal(t, "second", comments[0].Content)
+		}
+
+		got, err := repos.ExpenseComment.GetByID(ctx, comments[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", got.Content)
+
+		comments, err = repos.ExpenseComment.GetLimitedByExpenseID(ctx, expense.ID+100, 0, 5)
+		assert.NoError(t, err)
+		assert.Empty(t, comments)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		expense := createExpense(t, repos, user.ID, "dinner")
+
+		comment := domain_expense_comment.ExpenseComment{UserID: user.ID, ExpenseID: expense.ID, Content: "first"}
+		assert.NoError(t, repos.ExpenseComment.Create(ctx, &comment))
+
+		assert.NoError(t, repos.ExpenseComment.Update(ctx, domain_expense_comment.ExpenseComment{ID: comment.ID, Content: "edited"}))
+
+		got, err := repos.ExpenseComment.GetByID(ctx, comment.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "edited", got.Content)
+		assert.Equal(t, user.ID, got.UserID)
+
+		assert.NoError(t, repos.ExpenseComment.Delete(ctx, comment.ID))
+
+		_, err = repos.ExpenseComment.GetByID(ctx, comment.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,67 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestNotificationRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	if newRepos(t).Notification == nil {
+		t.Skip("notification repository is not implemented")
+	}
+
+	t.Run("CreateAndGetAll", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		debt := createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+
+		for _, title := range []string{"first", "second", "third"} {
+			assert.NoError(t, repos.Notification.Create(ctx, domain_notification.Notification{Title: title, UserID: debtor.ID, DebtID: debt.ID, Type: "new_debt", Amount: 100}))
+		}
+
+		// newest first by default
+		notifs, err := repos.Notification.GetAll(ctx, 1, 2, "")
+		assert.NoError(t, err)
+		if assert.Len(t, notifs, 2) {
+			assert.Equal(t, "third", notifs[0].Title)
+		}
+
+		notifs, err = repos.Notification.GetAll(ctx, 2, 2, "asc")
+		assert.NoError(t, err)
+		if assert.Len(t, notifs, 1) {
+			assert.Equal(t, "third", notifs[0].Title)
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repos := newRepos(t)
+		creditor := createUser(t, repos, "Ali", "+989120000001")
+		debtor := createUser(t, repos, "Reza", "+989120000002")
+		expense := createExpense(t, repos, creditor.ID, "dinner")
+		debt := createDebt(t, repos, expense.ID, creditor.ID, debtor.ID, 100)
+
+		assert.NoError(t, repos.Notification.Create(ctx, domain_notification.Notification{Title: "new debt", UserID: debtor.ID, DebtID: debt.ID, Type: "new_debt", Amount: 100}))
+		notifs, _ := repos.Notification.GetAll(ctx, 1, 1, "")
+		notif := notifs[0]
+
+		assert.NoError(t, repos.Notification.Update(ctx, domain_notification.Notification{ID: notif.ID, Title: "debt paid"}))
+
+		got, err := repos.Notification.Get(ctx, notif.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "debt paid", got.Title)
+		assert.Equal(t, uint64(100), got.Amount)
+
+		assert.NoError(t, repos.Notification.Delete(ctx, notif.ID))
+
+		_, err = repos.Notification.Get(ctx, notif.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,152 @@
+package contract
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	domain_outbox "github.com/yaghoubi-mn/pedarkharj/internal/domain/outbox"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestOutboxRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	if newRepos(t).Outbox == nil {
+		t.Skip("outbox repository is not implemented")
+	}
+
+	newEvent := func(t *testing.T, aggregateID uint64) domain_outbox.OutboxEvent {
+		event, err := domain_outbox.NewOutboxEvent(domain_outbox.EventExpenseCreated, domain_outbox.AggregateExpense, aggregateID, domain_outbox.ExpensePayload{ExpenseID: aggregateID})
+		require.NoError(t, err)
+		return event
+	}
+
+	t.Run("CreateAndGetPending", func(t *testing.T) {
+		repos := newRepos(t)
+
+		events := make([]domain_outbox.OutboxEvent, 3)
+		for i := range events {
+			events[i] = newEvent(t, uint64(i+1))
+			assert.NoError(t, repos.Outbox.Create(ctx, &events[i]))
+			assert.NotZero(t, events[i].ID)
+		}
+
+		// ordered by id
+		pending, err := repos.Outbox.GetPending(ctx, time.Now(), 2)
+		assert.NoError(t, err)
+		if assert.Len(t, pending, 2) {
+			assert.Equal(t, events[0].EventID, pending[0].EventID)
+			assert.Equal(t, events[1].EventID, pending[1].EventID)
+			assert.JSONEq(t, `{"expense_id":1,"creator_id":0,"name":"","description":""}`, pending[0].Payload)
+		}
+
+		// next attempt is in future
+		pending, err = repos.Outbox.GetPending(ctx, time.Now().Add(-time.Hour), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("ClaimPending", func(t *testing.T) {
+		repos := newRepos(t)
+
+		events := make([]domain_outbox.OutboxEvent, 3)
+		for i := range events {
+			events[i] = newEvent(t, uint64(i+1))
+			require.NoError(t, repos.Outbox.Create(ctx, &events[i]))
+		}
+
+		now := time.Now()
+		claimed, err := repos.Outbox.ClaimPending(ctx, now, time.Minute, 2)
+		assert.NoError(t, err)
+		if assert.Len(t, claimed, 2) {
+			assert.Equal(t, events[0].EventID, claimed[0].EventID)
+			assert.Equal(t, events[1].EventID, claimed[1].EventID)
+			assert.WithinDuration(t, now.Add(time.Minute), claimed[0].ClaimedUntil, time.Second)
+		}
+
+		// claimed events are not given to other relays
+		other, err := repos.Outbox.ClaimPending(ctx, now, time.Minute, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, other, 1) {
+			assert.Equal(t, events[2].EventID, other[0].EventID)
+			assert.NotEqual(t, claimed[0].ClaimToken, other[0].ClaimToken)
+		}
+
+		other, err = repos.Outbox.ClaimPending(ctx, now, time.Minute, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, other)
+
+		// claim is released by update of delivery
+		retried := claimed[0]
+		retried.Attempts = 1
+		assert.NoError(t, repos.Outbox.UpdateDelivery(ctx, retried))
+
+		other, err = repos.Outbox.ClaimPending(ctx, now, time.Minute, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, other, 1) {
+			assert.Equal(t, events[0].EventID, other[0].EventID)
+			assert.Equal(t, 1, other[0].Attempts)
+		}
+
+		// expired claims are claimed again
+		other, err = repos.Outbox.ClaimPending(ctx, now.Add(2*time.Minute), time.Minute, 10)
+		assert.NoError(t, err)
+		assert.Len(t, other, 3)
+	})
+
+	t.Run("DuplicatedEventID", func(t *testing.T) {
+		repos := newRepos(t)
+
+		event := newEvent(t, 1)
+		assert.NoError(t, repos.Outbox.Create(ctx, &event))
+
+		duplicated := event
+		duplicated.ID = 0
+		assert.Error(t, repos.Outbox.Create(ctx, &duplicated))
+	})
+
+	t.Run("UpdateDelivery", func(t *testing.T) {
+		repos := newRepos(t)
+
+		published := newEvent(t, 1)
+		retried := newEvent(t, 2)
+		failed := newEvent(t, 3)
+		for _, event := range []*domain_outbox.OutboxEvent{&published, &retried, &failed} {
+			require.NoError(t, repos.Outbox.Create(ctx, event))
+		}
+
+		published.Attempts = 1
+		published.IsPublished = true
+		published.PublishedAt = time.Now()
+		assert.NoError(t, repos.Outbox.UpdateDelivery(ctx, published))
+
+		retried.Attempts = 1
+		retried.LastError = "timeout"
+		retried.NextAttemptAt = time.Now().Add(time.Minute)
+		assert.NoError(t, repos.Outbox.UpdateDelivery(ctx, retried))
+
+		failed.Attempts = 10
+		failed.IsFailed = true
+		assert.NoError(t, repos.Outbox.UpdateDelivery(ctx, failed))
+
+		// published and failed events are never pending
+		pending, err := repos.Outbox.GetPending(ctx, time.Now(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+
+		pending, err = repos.Outbox.GetPending(ctx, time.Now().Add(2*time.Minute), 10)
+		assert.NoError(t, err)
+		if assert.Len(t, pending, 1) {
+			assert.Equal(t, retried.EventID, pending[0].EventID)
+			assert.Equal(t, 1, pending[0].Attempts)
+			assert.Equal(t, "timeout", pending[0].LastError)
+		}
+
+		notFound := newEvent(t, 4)
+		notFound.ID = 1000
+		assert.ErrorIs(t, repos.Outbox.UpdateDelivery(ctx, notFound), database_errors.ErrRecordNotFound)
+	})
+}
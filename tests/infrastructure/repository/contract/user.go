@@ -0,0 +1,232 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestUserRepository(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		got, err := repos.User.GetByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Ali", got.Name)
+		assert.Equal(t, "+989120000001", got.Number)
+
+		got, err = repos.User.GetByNumber(ctx, "+989120000001")
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		_, err = repos.User.GetByID(ctx, user.ID+100)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		_, err = repos.User.GetByNumber(ctx, "+989129999999")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+
+	t.Run("DuplicatedNumber", func(t *testing.T) {
+		repos := newRepos(t)
+		createUser(t, repos, "Ali", "+989120000001")
+
+		user := domain_user.User{Name: "Reza", Number: "+989120000001", Avatar: "avatar.png"}
+		assert.Error(t, repos.User.Create(ctx, &user))
+	})
+
+	t.Run("UpdateNonZeroFields", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		assert.NoError(t, repos.User.Update(ctx, domain_user.User{ID: user.ID, Name: "Reza"}))
+
+		got, err := repos.User.GetByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Reza", got.Name)
+		assert.Equal(t, "avatar.png", got.Avatar)
+
+		assert.NoError(t, repos.User.UpdateColumns(ctx, domain_user.User{ID: user.ID, Language: "en"}))
+
+		got, _ = repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, "en", got.Language)
+		assert.Equal(t, "Reza", got.Name)
+	})
+
+	t.Run("UpdateTwoFactor", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		user.TOTPSecret = "secret"
+		user.IsTOTPEnabled = true
+		user.TOTPRecoveryCodes = "codes"
+		assert.NoError(t, repos.User.UpdateTwoFactor(ctx, user))
+
+		got, _ := repos.User.GetByID(ctx, user.ID)
+		assert.True(t, got.IsTOTPEnabled)
+		assert.Equal(t, "secret", got.TOTPSecret)
+
+		// zero values are saved for disabling
+		user.TOTPSecret = ""
+		user.IsTOTPEnabled = false
+		user.TOTPRecoveryCodes = ""
+		assert.NoError(t, repos.User.UpdateTwoFactor(ctx, user))
+
+		got, _ = repos.User.GetByID(ctx, user.ID)
+		assert.False(t, got.IsTOTPEnabled)
+		assert.Empty(t, got.TOTPSecret)
+		assert.Empty(t, got.TOTPRecoveryCodes)
+	})
+
+	t.Run("UpdateTOTPCounter", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		assert.NoError(t, repos.User.UpdateTOTPCounter(ctx, user.ID, 10))
+		got, _ := repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, int64(10), got.TOTPLastCounter)
+
+		// same or older time step is rejected
+		assert.ErrorIs(t, repos.User.UpdateTOTPCounter(ctx, user.ID, 10), database_errors.ErrVersionConflict)
+		assert.ErrorIs(t, repos.User.UpdateTOTPCounter(ctx, user.ID, 9), database_errors.ErrVersionConflict)
+		assert.NoError(t, repos.User.UpdateTOTPCounter(ctx, user.ID, 11))
+	})
+
+	t.Run("UpdateTOTPRecoveryCodes", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		user.TOTPRecoveryCodes = "a,b"
+		assert.NoError(t, repos.User.UpdateTwoFactor(ctx, user))
+
+		assert.NoError(t, repos.User.UpdateTOTPRecoveryCodes(ctx, user.ID, "a,b", "b"))
+		got, _ := repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, "b", got.TOTPRecoveryCodes)
+
+		// codes are changed by other request
+		assert.ErrorIs(t, repos.User.UpdateTOTPRecoveryCodes(ctx, user.ID, "a,b", "a"), database_errors.ErrVersionConflict)
+		got, _ = repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, "b", got.TOTPRecoveryCodes)
+	})
+
+	t.Run("UpdateAvatar", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		user.Avatar = "uploaded.webp"
+		user.AvatarThumbnail = "thumbnail.webp"
+		user.AvatarKey = "avatars/1"
+		assert.NoError(t, repos.User.UpdateAvatar(ctx, user))
+
+		// back to preset avatar
+		user.Avatar = "preset.png"
+		user.AvatarThumbnail = ""
+		user.AvatarKey = ""
+		assert.NoError(t, repos.User.UpdateAvatar(ctx, user))
+
+		got, _ := repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, "preset.png", got.Avatar)
+		assert.Empty(t, got.AvatarThumbnail)
+		assert.Empty(t, got.AvatarKey)
+	})
+
+	t.Run("ChangeNumber", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		user.Number = "+989120000002"
+		assert.NoError(t, repos.User.ChangeNumber(ctx, user, 0))
+
+		got, err := repos.User.GetByNumber(ctx, "+989120000002")
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		_, err = repos.User.GetByNumber(ctx, "+989120000001")
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		// number of another user
+		other := createUser(t, repos, "Reza", "+989120000003")
+		user.Number = other.Number
+		assert.Error(t, repos.User.ChangeNumber(ctx, user, 0))
+
+		got, _ = repos.User.GetByID(ctx, user.ID)
+		assert.Equal(t, "+989120000002", got.Number)
+	})
+
+	t.Run("ChangeNumberWithMerge", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+		friend := createUser(t, repos, "Reza", "+989120000002")
+
+		// unregistered user created in expenses of friend
+		assert.NoError(t, repos.Expense.CreateUsersWithNumbers(ctx, []string{"+989120000003"}))
+		unregistered, err := repos.User.GetByNumber(ctx, "+989120000003")
+		assert.NoError(t, err)
+
+		expense := createExpense(t, repos, friend.ID, "dinner")
+		debt := createDebt(t, repos, expense.ID, friend.ID, unregistered.ID, 100)
+		selfDebt := createDebt(t, repos, expense.ID, user.ID, unregistered.ID, 50)
+		if repos.Notification != nil {
+			assert.NoError(t, repos.Notification.Create(ctx, domain_notification.Notification{Title: "new debt", UserID: user.ID, DebtID: selfDebt.ID, Type: "new_debt", Amount: 50}))
+		}
+		assert.NoError(t, repos.Device.Create(ctx, domain_device.Device{Name: "phone", LastIP: "1.1.1.1", UserID: unregistered.ID, RefreshToken: "refresh"}))
+		unregisteredExpense := createExpense(t, repos, unregistered.ID, "taxi")
+		createDebt(t, repos, unregisteredExpense.ID, unregistered.ID, friend.ID, 30)
+
+		user.Number = unregistered.Number
+		assert.NoError(t, repos.User.ChangeNumber(ctx, user, unregistered.ID))
+
+		got, err := repos.User.GetByNumber(ctx, "+989120000003")
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+
+		_, err = repos.User.GetByID(ctx, unregistered.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		// history is moved to user and versions of changed rows are incremented
+		movedDebt, err := repos.Debt.GetByID(ctx, debt.ID, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, movedDebt.DebtorID)
+		assert.Equal(t, debt.Version+1, movedDebt.Version)
+
+		gotExpense, err := repos.Expense.GetByID(ctx, expense.ID, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, expense.Version+1, gotExpense.Version)
+		assert.Equal(t, expense.TotalAmount, gotExpense.TotalAmount)
+
+		gotExpense, err = repos.Expense.GetByID(ctx, unregisteredExpense.ID, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, gotExpense.CreatorID)
+		assert.Equal(t, unregisteredExpense.Version+1, gotExpense.Version)
+
+		owner, err := repos.Device.GetUserByRefreshToken(ctx, "refresh")
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, owner.ID)
+
+		// debt with himself is deleted with its notifications
+		_, err = repos.Debt.GetByID(ctx, selfDebt.ID, user.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+
+		if repos.Notification != nil {
+			notifs, err := repos.Notification.GetAll(ctx, 1, 10, "")
+			assert.NoError(t, err)
+			assert.Empty(t, notifs)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repos := newRepos(t)
+		user := createUser(t, repos, "Ali", "+989120000001")
+
+		assert.NoError(t, repos.User.Delete(ctx, user.ID))
+
+		_, err := repos.User.GetByID(ctx, user.ID)
+		assert.ErrorIs(t, err, database_errors.ErrRecordNotFound)
+	})
+}
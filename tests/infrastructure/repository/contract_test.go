@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	repository "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/gorm"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	"github.com/yaghoubi-mn/pedarkharj/tests/infrastructure/repository/contract"
+)
+
+func TestMemoryRepositories(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Repositories {
+		store := repository_memory.NewStore()
+
+		return contract.Repositories{
+			User:           repository_memory.NewMemoryUserRepository(store),
+			Device:         repository_memory.NewMemoryDeviceRepository(store),
+			Expense:        repository_memory.NewMemoryExpenseRepository(store),
+			Debt:           repository_memory.NewMemoryDebtRepository(store),
+			ExpenseComment: repository_memory.NewMemoryExpenseCommentRepository(store),
+			Notification:   repository_memory.NewMemoryNotificationRepository(store),
+			Outbox:         repository_memory.NewMemoryOutboxRepository(store),
+			Audit:          repository_memory.NewMemoryAuditRepository(store),
+		}
+	})
+}
+
+// gorm repositories are tested with in-memory sqlite.
+// comment and notification repositories have no gorm implementation yet
+func TestGormRepositories(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Repositories {
+		db := setupSQLite(t)
+
+		return contract.Repositories{
+			User:    repository.NewGormUserRepository(db),
+			Device:  repository.NewGormDeviceRepository(db),
+			Expense: repository.NewGormExpenseRepository(db),
+			Debt:    repository.NewGormDebtRepository(db),
+			Outbox:  repository.NewGormOutboxRepository(db),
+			Audit:   repository.NewGormAuditRepository(db),
+		}
+	})
+}
+
+func TestMemoryRepositoriesConcurrency(t *testing.T) {
+	store := repository_memory.NewStore()
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			user := domain_user.User{Name: "user", Number: fmt.Sprintf("+98912%07d", i)}
+			assert.NoError(t, userRepo.Create(ctx, &user))
+			_, err := userRepo.GetByID(ctx, user.ID)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// ids are unique
+	for i := range 50 {
+		_, err := userRepo.GetByID(ctx, uint64(i+1))
+		assert.NoError(t, err)
+	}
+}
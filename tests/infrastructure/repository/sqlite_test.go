@@ -0,0 +1,172 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	domain_audit "github.com/yaghoubi-mn/pedarkharj/internal/domain/audit"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_notification "github.com/yaghoubi-mn/pedarkharj/internal/domain/notification"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	repository "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/gorm"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database/migrations"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+	"gorm.io/gorm"
+)
+
+// in-memory sqlite database with migrated schema
+func setupSQLite(t *testing.T) *gorm.DB {
+	db, err := database.SetupGrom(database.Options{Driver: database.DriverSQLite, Path: ":memory:", LogLevel: "silent"})
+	require.NoError(t, err)
+
+	migrator, err := database.NewMigrator(db, migrations.FS, database.DriverSQLite)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	return db
+}
+
+func createUser(t *testing.T, db *gorm.DB, name string, number string) domain_user.User {
+	user := domain_user.User{Name: name, Number: number, Avatar: "a.png", RegisteredAt: time.Now(), IsRegistered: true}
+	require.NoError(t, db.Create(&user).Error)
+	return user
+}
+
+func TestExpenseRepositorySQLite(t *testing.T) {
+	db := setupSQLite(t)
+	repo := repository.NewGormExpenseRepository(db)
+	ctx := context.Background()
+
+	creditor := createUser(t, db, "Ali", "+989120000001")
+	debtor := createUser(t, db, "Reza", "+989120000002")
+	other := createUser(t, db, "Sara", "+989120000003")
+
+	expense := domain_expense.Expense{CreatorID: creditor.ID, Name: "dinner", TotalAmount: 100}
+	require.NoError(t, repo.Create(ctx, &expense))
+	require.NoError(t, db.Create(&domain_debt.Debt{ExpenseID: expense.ID, CreditorID: creditor.ID, DebtorID: debtor.ID, Amount: 100}).Error)
+
+	got, err := repo.GetByID(ctx, expense.ID, debtor.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "dinner", got.Name)
+
+	// not participated in expense
+	_, err = repo.GetByID(ctx, expense.ID, other.ID)
+	assert.Error(t, err)
+
+	outputs, err := repo.GetLimitedExpenseDebtByUserID(ctx, debtor.ID, 0, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, outputs, 1) {
+		assert.Equal(t, "Ali", outputs[0].UserName)
+		assert.Equal(t, "creditor", outputs[0].Type)
+		assert.Equal(t, uint64(100), outputs[0].Amount)
+	}
+}
+
+func TestAdminRepositorySQLite(t *testing.T) {
+	db := setupSQLite(t)
+	repo := repository.NewGormAdminRepository(db)
+	ctx := context.Background()
+
+	createUser(t, db, "Ali", "+989120000001")
+	createUser(t, db, "ali reza", "+989120000002")
+	createUser(t, db, "Sara", "+989120000003")
+
+	// search is case insensitive
+	users, total, err := repo.SearchUsers(ctx, "ALI", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 2)
+
+	users, total, err = repo.SearchUsers(ctx, "0003", 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Sara", users[0].Name)
+
+	// wildcards are matched literally
+	createUser(t, db, "100%_off", "+989120000004")
+	for _, query := range []string{"%", "_", "0%_"} {
+		users, total, err = repo.SearchUsers(ctx, query, 0, 10)
+		assert.NoError(t, err, query)
+		assert.Equal(t, int64(1), total, query)
+		if assert.Len(t, users, 1, query) {
+			assert.Equal(t, "100%_off", users[0].Name, query)
+		}
+	}
+
+	stats, err := repo.GetStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), stats.TotalUsers)
+	assert.Equal(t, int64(4), stats.RegisteredUsers)
+
+	// audit log is saved with block
+	assert.NoError(t, repo.SetBlocked(ctx, 3, true, &domain_audit.AuditLog{ActorID: 1, Action: domain_audit.ActionAdminBlockUser, TargetType: domain_audit.TargetUser, TargetID: 3}))
+	var logs []domain_audit.AuditLog
+	require.NoError(t, db.Find(&logs).Error)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, domain_audit.ActionAdminBlockUser, logs[0].Action)
+		assert.Equal(t, uint64(3), logs[0].TargetID)
+	}
+
+	stats, err = repo.GetStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats.BlockedUsers)
+
+	// audit log is not saved if user is not found
+	assert.ErrorIs(t, repo.SetBlocked(ctx, 100, true, &domain_audit.AuditLog{ActorID: 1, Action: domain_audit.ActionAdminBlockUser, TargetType: domain_audit.TargetUser, TargetID: 100}), database_errors.ErrRecordNotFound)
+	require.NoError(t, db.Find(&logs).Error)
+	assert.Len(t, logs, 1)
+}
+
+// notifications refer to debts with foreign key
+func TestChangeNumberWithMergeSQLite(t *testing.T) {
+	db := setupSQLite(t)
+	repo := repository.NewGormUserRepository(db)
+	ctx := context.Background()
+
+	user := createUser(t, db, "Ali", "+989120000001")
+	unregistered := createUser(t, db, "", "+989120000002")
+
+	expense := domain_expense.Expense{CreatorID: user.ID, Name: "dinner", TotalAmount: 100}
+	require.NoError(t, db.Create(&expense).Error)
+	selfDebt := domain_debt.Debt{ExpenseID: expense.ID, CreditorID: user.ID, DebtorID: unregistered.ID, Amount: 50}
+	require.NoError(t, db.Create(&selfDebt).Error)
+	require.NoError(t, db.Create(&domain_notification.Notification{Title: "new debt", UserID: user.ID, DebtID: selfDebt.ID, Type: "new_debt", Amount: 50}).Error)
+
+	user.Number = unregistered.Number
+	assert.NoError(t, repo.ChangeNumber(ctx, user, unregistered.ID))
+
+	var count int64
+	require.NoError(t, db.Model(&domain_notification.Notification{}).Where("debt_id = ?", selfDebt.ID).Count(&count).Error)
+	assert.Zero(t, count)
+	require.NoError(t, db.Model(&domain_debt.Debt{}).Where("id = ?", selfDebt.ID).Count(&count).Error)
+	assert.Zero(t, count)
+
+	var got domain_expense.Expense
+	require.NoError(t, db.First(&got, expense.ID).Error)
+	assert.Equal(t, uint64(2), got.Version)
+}
+
+func TestAuditLogAppendOnlySQLite(t *testing.T) {
+	db := setupSQLite(t)
+	repo := repository.NewGormAuditRepository(db)
+
+	log := domain_audit.AuditLog{ActorID: 1, Action: domain_audit.ActionLogin, TargetType: domain_audit.TargetUser, TargetID: 1}
+	require.NoError(t, repo.Create(context.Background(), &log))
+
+	// database rejects changes of audit log
+	assert.ErrorContains(t, db.Model(&domain_audit.AuditLog{}).Where("id = ?", log.ID).Update("action", "changed").Error, "append only")
+	assert.ErrorContains(t, db.Delete(&domain_audit.AuditLog{}, log.ID).Error, "append only")
+
+	found, total, err := repo.Search(context.Background(), domain_audit.Filter{}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, domain_audit.ActionLogin, found[0].Action)
+	}
+}
@@ -0,0 +1,235 @@
+package v1_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_debt "github.com/yaghoubi-mn/pedarkharj/internal/application/debt"
+	app_device "github.com/yaghoubi-mn/pedarkharj/internal/application/device"
+	app_expense "github.com/yaghoubi-mn/pedarkharj/internal/application/expense"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	domain_debt "github.com/yaghoubi-mn/pedarkharj/internal/domain/debt"
+	domain_device "github.com/yaghoubi-mn/pedarkharj/internal/domain/device"
+	domain_expense "github.com/yaghoubi-mn/pedarkharj/internal/domain/expense"
+	domain_user "github.com/yaghoubi-mn/pedarkharj/internal/domain/user"
+	repository_memory "github.com/yaghoubi-mn/pedarkharj/internal/infrastructure/repository/memory"
+	interfaces_grpc_v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/grpc/v1/pb"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/validator"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fixture struct {
+	conn      *grpc.ClientConn
+	cacheRepo *cache.MemoryCacheRepository
+	user      domain_user.User
+	access    string
+}
+
+// server with in memory repositories on random port
+func setup(t *testing.T) fixture {
+	ctx := context.Background()
+	vld := validator.NewValidator()
+	store := repository_memory.NewStore()
+
+	userRepo := repository_memory.NewMemoryUserRepository(store)
+	auditRepo := repository_memory.NewMemoryAuditRepository(store)
+	unitOfWork := repository_memory.NewMemoryUnitOfWork(store)
+
+	deviceAppService := app_device.NewDeviceAppService(repository_memory.NewMemoryDeviceRepository(store), auditRepo, domain_device.NewDeviceService(vld))
+	cacheRepo := cache.NewMemory(1, 100)
+	userAppService := app_user.NewUserService(userRepo, cacheRepo, auditRepo, deviceAppService, domain_user.NewUserService(vld))
+	debtAppService := app_debt.NewDebtAppService(repository_memory.NewMemoryDebtRepository(store), userRepo, unitOfWork, domain_debt.NewDebtDomainService(vld))
+	expenseAppService := app_expense.NewExpenseAppService(repository_memory.NewMemoryExpenseRepository(store), unitOfWork, domain_expense.NewExpenseService(vld), debtAppService)
+
+	server := interfaces_grpc_v1.NewServer(interfaces_grpc_v1.Options{Addr: "127.0.0.1:0", MaxRecvMsgSize: 1 << 20},
+		userAppService, deviceAppService, expenseAppService, debtAppService, cacheRepo)
+	require.NoError(t, server.Start())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+
+	conn, err := grpc.NewClient(server.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	f := fixture{conn: conn, cacheRepo: cacheRepo}
+	f.user = domain_user.User{Name: "Ali", Number: "+989120000001", IsRegistered: true}
+	require.NoError(t, userRepo.Create(ctx, &f.user))
+
+	jwt.Init("test-secret")
+	f.access, err = jwt.CreateAccessFromUser(time.Hour, f.user.ID, f.user.Name, f.user.Number, f.user.IsRegistered)
+	require.NoError(t, err)
+
+	return f
+}
+
+func (f fixture) authContext() context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), interfaces_grpc_v1.AuthorizationMetadata, "Bearer "+f.access)
+}
+
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+
+	t.Fatal("ErrorInfo is not in details")
+	return nil
+}
+
+func TestAuthentication(t *testing.T) {
+	f := setup(t)
+	client := pb.NewUserServiceClient(f.conn)
+
+	_, err := client.GetUserInfo(context.Background(), &pb.GetUserInfoRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), interfaces_grpc_v1.AuthorizationMetadata, "Bearer invalid")
+	_, err = client.GetUserInfo(ctx, &pb.GetUserInfoRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// request id of client is returned
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(f.authContext(), interfaces_grpc_v1.RequestIDMetadata, "req-1")
+	user, err := client.GetUserInfo(ctx, &pb.GetUserInfoRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, "Ali", user.GetName())
+	assert.Equal(t, "+989120000001", user.GetNumber())
+	assert.Equal(t, []string{"req-1"}, header.Get(interfaces_grpc_v1.RequestIDMetadata))
+
+	// access tokens of blocked user are revoked
+	require.NoError(t, app_user.RevokeAccess(f.cacheRepo, f.user.ID))
+	_, err = client.GetUserInfo(f.authContext(), &pb.GetUserInfoRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// login is public
+	_, err = client.Login(context.Background(), &pb.LoginRequest{Number: "+989120000009", Password: "password"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, rcodes.NumberNotExist, errorInfo(t, err).GetReason())
+}
+
+func TestLoginAttempts(t *testing.T) {
+	f := setup(t)
+	client := pb.NewUserServiceClient(f.conn)
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Login(context.Background(), &pb.LoginRequest{Number: f.user.Number, Password: "wrong-password"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), i)
+	}
+
+	// password is not checked after max attempts
+	_, err := client.Login(context.Background(), &pb.LoginRequest{Number: f.user.Number, Password: "wrong-password"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, rcodes.TooManyRequests, errorInfo(t, err).GetReason())
+}
+
+func TestExpenseAndDebt(t *testing.T) {
+	f := setup(t)
+	ctx := f.authContext()
+	expenseClient := pb.NewExpenseServiceClient(f.conn)
+	debtClient := pb.NewDebtServiceClient(f.conn)
+
+	_, err := expenseClient.CreateExpense(ctx, &pb.CreateExpenseRequest{
+		Name:      "dinner",
+		Creditors: map[string]uint64{f.user.Number: 2000},
+		Debtors:   []string{"+989120000002"},
+	})
+	require.NoError(t, err)
+
+	list, err := expenseClient.ListExpenses(ctx, &pb.ListExpensesRequest{Page: 1, Limit: 10})
+	require.NoError(t, err)
+	if assert.Len(t, list.GetExpenses(), 1) {
+		assert.Equal(t, "dinner", list.GetExpenses()[0].GetName())
+		assert.Equal(t, uint64(1000), list.GetExpenses()[0].GetAmount())
+	}
+
+	expense, err := expenseClient.UpdateExpense(ctx, &pb.UpdateExpenseRequest{Id: 1, Version: 1, Name: "lunch"})
+	require.NoError(t, err)
+	assert.Equal(t, "lunch", expense.GetName())
+	assert.Equal(t, uint64(2), expense.GetVersion())
+
+	// current version is returned on conflict
+	_, err = expenseClient.UpdateExpense(ctx, &pb.UpdateExpenseRequest{Id: 1, Version: 1, Name: "breakfast"})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.Equal(t, rcodes.VersionConflict, errorInfo(t, err).GetReason())
+	assert.Equal(t, "2", errorInfo(t, err).GetMetadata()["version"])
+
+	_, err = expenseClient.GetExpense(ctx, &pb.GetExpenseRequest{Id: 100})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, rcodes.ExpenseNotFound, errorInfo(t, err).GetReason())
+	assert.Equal(t, "expense_not_found", errorInfo(t, err).GetMetadata()["code"])
+
+	// invalid fields are sent in BadRequest details
+	_, err = expenseClient.ListExpenses(ctx, &pb.ListExpensesRequest{Page: 0, Limit: 10})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = badRequest.GetFieldViolations()
+		}
+	}
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "page", violations[0].GetField())
+		assert.Equal(t, "invalid page", violations[0].GetDescription())
+	}
+
+	debt, err := debtClient.AcceptDebt(ctx, &pb.AcceptDebtRequest{Id: 1})
+	require.NoError(t, err)
+	assert.True(t, debt.GetIsCreditorAccepted())
+
+	deleted, err := debtClient.DeleteDebt(ctx, &pb.DeleteDebtRequest{Id: 1})
+	require.NoError(t, err)
+	assert.False(t, deleted.GetIsDeleted())
+	assert.True(t, deleted.GetDebt().GetIsCreditorRequestedForDelete())
+
+	_, err = expenseClient.DeleteExpense(ctx, &pb.DeleteExpenseRequest{Id: 1})
+	require.NoError(t, err)
+
+	_, err = debtClient.GetDebt(ctx, &pb.GetDebtRequest{Id: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		Err  error
+		Want codes.Code
+	}{
+		{Err: service_errors.ErrPermissionDenied, Want: codes.PermissionDenied},
+		{Err: service_errors.ErrDebtNotFound, Want: codes.NotFound},
+		{Err: service_errors.ErrVersionConflict, Want: codes.Aborted},
+		{Err: service_errors.ErrWrongPassword, Want: codes.Unauthenticated},
+		{Err: service_errors.ErrTooManyOTPAttempts, Want: codes.ResourceExhausted},
+		{Err: service_errors.ErrTwoFactorAlreadyEnabled, Want: codes.AlreadyExists},
+		{Err: service_errors.ErrTwoFactorNotEnabled, Want: codes.FailedPrecondition},
+		{Err: service_errors.ErrInvalidName, Want: codes.InvalidArgument},
+		{Err: service_errors.ErrCannotBlockAdmin, Want: codes.PermissionDenied},
+		{Err: service_errors.ErrLargeAvatar, Want: codes.InvalidArgument},
+		{Err: service_errors.ErrInvalidAvatarFormat, Want: codes.InvalidArgument},
+		{Err: service_errors.ErrInternalServerError, Want: codes.Internal},
+		{Err: errors.Join(service_errors.ErrExpenseNotFound), Want: codes.NotFound},
+		{Err: fmt.Errorf("wrapped: %w", service_errors.ErrWrongPassword), Want: codes.Unauthenticated},
+		{Err: errors.New("wrapped: " + service_errors.ErrExpenseNotFound.Error()), Want: codes.InvalidArgument},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.Want, interfaces_grpc_v1.ErrorCode(test.Err), test.Err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	app_audit "github.com/yaghoubi-mn/pedarkharj/internal/application/audit"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+)
+
+func TestAuditRequestInfo(t *testing.T) {
+	var info app_audit.RequestInfo
+	handler := middleware.AuditRequestInfo([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = app_audit.RequestInfoFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		TestID       int
+		RemoteAddr   string
+		RealIP       string
+		ForwardedFor string
+		IP           string
+	}{
+		{TestID: 1, RemoteAddr: "10.0.0.1:1234", RealIP: "1.1.1.1", IP: "1.1.1.1"},
+		{TestID: 2, RemoteAddr: "10.0.0.1:1234", ForwardedFor: "2.2.2.2, 1.1.1.1, 10.0.0.2", IP: "1.1.1.1"},
+		// headers of untrusted client are ignored
+		{TestID: 3, RemoteAddr: "192.0.2.1:1234", RealIP: "1.1.1.1", ForwardedFor: "1.1.1.1", IP: "192.0.2.1"},
+		// short or invalid values
+		{TestID: 4, RemoteAddr: "10.0.0.1:1234", RealIP: "1", ForwardedFor: "x", IP: "10.0.0.1"},
+		{TestID: 5, RemoteAddr: "[::1]:1234", IP: "::1"},
+		// value is never longer than an ip
+		{TestID: 6, RemoteAddr: "10.0.0.1:1234", ForwardedFor: strings.Repeat("1.1.1.1, ", 100) + "10.0.0.2", IP: "1.1.1.1"},
+		{TestID: 7, RemoteAddr: "10.0.0.1:1234", ForwardedFor: strings.Repeat("a", 100), IP: "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/expenses", nil)
+		r.RemoteAddr = test.RemoteAddr
+		if test.RealIP != "" {
+			r.Header.Set("X-Real-Ip", test.RealIP)
+		}
+		if test.ForwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", test.ForwardedFor)
+		}
+		r.Header.Set("User-Agent", "Mozilla/5.0")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		assert.Equal(t, app_audit.RequestInfo{IP: test.IP, UserAgent: "Mozilla/5.0"}, info, test)
+	}
+}
@@ -0,0 +1,52 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+)
+
+func TestRevokedAccess(t *testing.T) {
+	jwt.Init("test-secret")
+	cacheRepo := cache.NewMemory(1, 100)
+	authMiddleware := middleware.NewAuthMiddleware(interfaces_rest_v1.NewJSONResponse(), cacheRepo)
+	handler := authMiddleware.EnsureAuthentication(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	authenticate := func(access string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/users/info", nil)
+		r.Header.Set("Authorization", "Bearer "+access)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	access, err := jwt.CreateAccessFromUser(time.Minute, 1, "Ali", "+989120000001", true)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, authenticate(access).Code)
+
+	// tokens issued before revoke are rejected
+	require.NoError(t, app_user.RevokeAccess(cacheRepo, 1))
+	assert.Equal(t, rcodes.InvalidToken, code(t, authenticate(access)))
+
+	// tokens of other users and new tokens are accepted
+	other, err := jwt.CreateAccessFromUser(time.Minute, 2, "Reza", "+989120000002", true)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, authenticate(other).Code)
+
+	time.Sleep(2 * time.Millisecond)
+	access, err = jwt.CreateAccessFromUser(time.Minute, 1, "Ali", "+989120000001", true)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, authenticate(access).Code)
+}
@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+)
+
+func newCORSHandler(policy middleware.CORSPolicy) http.Handler {
+	allowedMethods := func(r *http.Request) []string {
+		if r.URL.Path == "/expenses" {
+			return []string{"POST", "OPTIONS"}
+		}
+		return nil
+	}
+
+	corsMiddleware := middleware.NewCORSMiddleware(policy, allowedMethods)
+	return corsMiddleware.HandleCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestCORSOrigins(t *testing.T) {
+	handler := newCORSHandler(middleware.CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"X-Request-ID"},
+	})
+
+	tests := []struct {
+		TestID  int
+		Origin  string
+		Allowed bool
+	}{
+		{TestID: 1, Origin: "https://app.example.com", Allowed: true},
+		{TestID: 2, Origin: "https://APP.example.com", Allowed: true},
+		{TestID: 3, Origin: "http://app.example.com", Allowed: false},
+		{TestID: 4, Origin: "https://other.example.com", Allowed: false},
+		{TestID: 5, Origin: "https://a.example.org", Allowed: true},
+		{TestID: 6, Origin: "https://a.b.example.org", Allowed: true},
+		{TestID: 7, Origin: "https://example.org", Allowed: false},
+		{TestID: 8, Origin: "https://evilexample.org", Allowed: false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/expenses", nil)
+		r.Header.Set("Origin", test.Origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code, test)
+		assert.Equal(t, "Origin", w.Header().Get("Vary"), test)
+		if test.Allowed {
+			assert.Equal(t, test.Origin, w.Header().Get("Access-Control-Allow-Origin"), test)
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"), test)
+			assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"), test)
+		} else {
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), test)
+		}
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	handler := newCORSHandler(middleware.CORSPolicy{AllowedOrigins: []string{"*"}})
+
+	r := httptest.NewRequest("GET", "/expenses", nil)
+	r.Header.Set("Origin", "https://any.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := newCORSHandler(middleware.CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:         time.Hour,
+	})
+
+	preflight := func(origin string, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("OPTIONS", path, nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := preflight("https://app.example.com", "/expenses")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, w.Header().Values("Vary"))
+
+	// not allowed origin
+	w = preflight("https://evil.example.com", "/expenses")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	// not registered path is passed to next handler
+	w = preflight("https://app.example.com", "/unknown")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,240 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+)
+
+// handler creates a new resource on every call
+func setup(expire time.Duration, status int) (http.Handler, *int) {
+	return setupWithCache(cache.NewMemory(1, 100), "v1", expire, status)
+}
+
+// instances with same cache are like servers behind a load balancer
+func setupWithCache(cacheRepo *cache.MemoryCacheRepository, version string, expire time.Duration, status int) (http.Handler, *int) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{"id": calls})
+	})
+
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(cacheRepo, interfaces_rest_v1.NewJSONResponse(), version, expire)
+
+	return middleware.LimitBody(1024)(idempotencyMiddleware.EnsureIdempotency(handler)), &calls
+}
+
+func request(handler http.Handler, userID uint64, key string, body string) *httptest.ResponseRecorder {
+	return requestPath(handler, "/expenses", userID, key, body)
+}
+
+func requestPath(handler http.Handler, path string, userID uint64, key string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	r = r.WithContext(context.WithValue(r.Context(), "user", app_user.JWTUser{ID: userID}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func code(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	code, _ := body["code"].(string)
+	return code
+}
+
+// http status of v1 responses is 200 for statuses of first version of api. status is in body
+func status(t *testing.T, w *httptest.ResponseRecorder) int {
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	status, _ := body["status"].(float64)
+	return int(status)
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	handler, calls := setup(time.Hour, 201)
+
+	first := request(handler, 1, "key-1", `{"name": "dinner"}`)
+	second := request(handler, 1, "key-1", `{"name": "dinner"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, 201, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get(middleware.IdempotencyReplayedHeader))
+	assert.Empty(t, first.Header().Get(middleware.IdempotencyReplayedHeader))
+}
+
+func TestIdempotencyMismatch(t *testing.T) {
+	handler, calls := setup(time.Hour, 201)
+
+	request(handler, 1, "key-1", `{"name": "dinner"}`)
+	w := request(handler, 1, "key-1", `{"name": "lunch"}`)
+
+	assert.Equal(t, 1, *calls)
+	assert.Equal(t, 422, w.Code)
+	assert.Equal(t, rcodes.IdempotencyKeyMismatch, code(t, w))
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	handler, calls := setup(time.Hour, 201)
+
+	// keys of users are separated
+	request(handler, 1, "key-1", `{"name": "dinner"}`)
+	request(handler, 2, "key-1", `{"name": "dinner"}`)
+	assert.Equal(t, 2, *calls)
+
+	// requests without key are not deduplicated
+	request(handler, 1, "", `{"name": "dinner"}`)
+	request(handler, 1, "", `{"name": "dinner"}`)
+	assert.Equal(t, 4, *calls)
+
+	w := request(handler, 1, strings.Repeat("k", 256), `{}`)
+	assert.Equal(t, 400, status(t, w))
+	assert.Equal(t, 4, *calls)
+
+	// query is part of request
+	w = requestPath(handler, "/expenses?notify=true", 1, "key-1", `{"name": "dinner"}`)
+	assert.Equal(t, 422, w.Code)
+	assert.Equal(t, 4, *calls)
+}
+
+func TestIdempotencyVersionScope(t *testing.T) {
+	cacheRepo := cache.NewMemory(1, 100)
+	v1Handler, v1Calls := setupWithCache(cacheRepo, "v1", time.Hour, 201)
+	v2Handler, v2Calls := setupWithCache(cacheRepo, "v2", time.Hour, 201)
+
+	// paths of versions are same after prefix is stripped
+	request(v1Handler, 1, "key-1", `{"name": "dinner"}`)
+	w := request(v2Handler, 1, "key-1", `{"name": "dinner"}`)
+
+	assert.Equal(t, 1, *v1Calls)
+	assert.Equal(t, 1, *v2Calls)
+	assert.Empty(t, w.Header().Get(middleware.IdempotencyReplayedHeader))
+
+	// other instance of same version replays response
+	otherHandler, otherCalls := setupWithCache(cacheRepo, "v1", time.Hour, 201)
+	w = request(otherHandler, 1, "key-1", `{"name": "dinner"}`)
+	assert.Equal(t, 0, *otherCalls)
+	assert.Equal(t, "true", w.Header().Get(middleware.IdempotencyReplayedHeader))
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	handler, calls := setup(time.Hour, 201)
+
+	w := request(handler, 1, "key-1", `{"name": "`+strings.Repeat("a", 2048)+`"}`)
+	assert.Equal(t, 0, *calls)
+	assert.Equal(t, 413, w.Code)
+	assert.Equal(t, rcodes.BodyTooLarge, code(t, w))
+}
+
+func TestIdempotencyExpire(t *testing.T) {
+	handler, calls := setup(50*time.Millisecond, 201)
+
+	request(handler, 1, "key-1", `{"name": "dinner"}`)
+	time.Sleep(100 * time.Millisecond)
+	w := request(handler, 1, "key-1", `{"name": "lunch"}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, 201, w.Code)
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	handler, calls := setup(time.Hour, 500)
+
+	// server errors can be retried
+	request(handler, 1, "key-1", `{"name": "dinner"}`)
+	w := request(handler, 1, "key-1", `{"name": "dinner"}`)
+
+	assert.Equal(t, 2, *calls)
+	assert.Empty(t, w.Header().Get(middleware.IdempotencyReplayedHeader))
+}
+
+func TestIdempotencyServerErrorV1(t *testing.T) {
+	jsonResponse := interfaces_rest_v1.NewJSONResponse()
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(cache.NewMemory(1, 100), jsonResponse, "v1", time.Hour)
+
+	calls := 0
+	handler := idempotencyMiddleware.EnsureIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		jsonResponse.ServerErrorResponse(w, errors.New("database is down"))
+	}))
+
+	// http status of v1 server errors is 200
+	w := request(handler, 1, "key-1", `{"name": "dinner"}`)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, 500, status(t, w))
+
+	w = request(handler, 1, "key-1", `{"name": "dinner"}`)
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, w.Header().Get(middleware.IdempotencyReplayedHeader))
+}
+
+func TestIdempotencyUserErrorV1(t *testing.T) {
+	jsonResponse := interfaces_rest_v1.NewJSONResponse()
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(cache.NewMemory(1, 100), jsonResponse, "v1", time.Hour)
+
+	calls := 0
+	handler := idempotencyMiddleware.EnsureIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		jsonResponse.ErrorResponse(w, 400, rcodes.InvalidField, nil, errors.New("name: invalid name"))
+	}))
+
+	request(handler, 1, "key-1", `{"name": ""}`)
+	w := request(handler, 1, "key-1", `{"name": ""}`)
+
+	// http status of replay is same as first response
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, 400, status(t, w))
+	assert.Equal(t, "true", w.Header().Get(middleware.IdempotencyReplayedHeader))
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	cacheRepo := cache.NewMemory(1, 100)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(cacheRepo, interfaces_rest_v1.NewJSONResponse(), "v1", time.Hour)
+	other, otherCalls := setupWithCache(cacheRepo, "v1", time.Hour, 201)
+
+	var inner *httptest.ResponseRecorder
+	handler := idempotencyMiddleware.EnsureIdempotency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// in progress key is released soon if instance stops
+		_, expire, err := cacheRepo.Get("idempotency:v1:1:key-1")
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), expire, 5*time.Second)
+
+		// retry arrives at other instance while first request is running
+		if inner == nil {
+			inner = request(other, 1, "key-1", `{"name": "dinner"}`)
+		}
+		w.WriteHeader(201)
+	}))
+
+	request(handler, 1, "key-1", `{"name": "dinner"}`)
+
+	// expire is extended after response is saved
+	_, expire, err := cacheRepo.Get("idempotency:v1:1:key-1")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expire, 5*time.Second)
+
+	assert.Equal(t, 0, *otherCalls)
+	assert.Equal(t, 409, inner.Code)
+	assert.Equal(t, rcodes.IdempotencyKeyInProgress, code(t, inner))
+}
@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+)
+
+func TestAcceptedLanguage(t *testing.T) {
+	tests := []struct {
+		TestID int
+		Header string
+		Want   string
+	}{
+		{TestID: 1, Header: "", Want: "en"},
+		{TestID: 2, Header: "fa", Want: "fa"},
+		{TestID: 3, Header: "fa-IR,fa;q=0.9,en;q=0.8", Want: "fa"},
+		{TestID: 4, Header: "en;q=0.5, fa;q=0.7", Want: "fa"},
+		{TestID: 5, Header: "de,fr;q=0.9", Want: "en"},
+		{TestID: 6, Header: "fa;q=abc,en;q=0.1", Want: "en"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.Want, middleware.AcceptedLanguage(test.Header), test)
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	handler := middleware.NegotiateLanguage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("GET", "/expenses", nil)
+	r.Header.Set("Accept-Language", "fa-IR")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, "fa", w.Header().Get(middleware.ContentLanguageHeader))
+	assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+}
@@ -0,0 +1,104 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	app_user "github.com/yaghoubi-mn/pedarkharj/internal/application/user"
+	interfaces_rest_v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/ratelimit"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+)
+
+func newRateLimitHandler(limiter *ratelimit.Limiter, trustedProxies []netip.Prefix) http.Handler {
+	rateLimitMiddleware := middleware.NewRateLimitMiddleware(limiter, interfaces_rest_v1.NewJSONResponse(), trustedProxies)
+	return rateLimitMiddleware.Limit("read", ratelimit.Policy{Limit: 2, Period: time.Minute})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func rateLimitRequest(handler http.Handler, remoteAddr string, forwardedFor string, userID uint64) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/expenses", nil)
+	r.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	if userID != 0 {
+		r = r.WithContext(context.WithValue(r.Context(), "user", app_user.JWTUser{ID: userID}))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	handler := newRateLimitHandler(ratelimit.New(cache.NewMemory(1, 100)), nil)
+
+	w := rateLimitRequest(handler, "192.0.2.1:1234", "", 0)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+
+	w = rateLimitRequest(handler, "192.0.2.1:1234", "", 0)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = rateLimitRequest(handler, "192.0.2.1:1234", "", 0)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, rcodes.TooManyRequests, code(t, w))
+
+	// other ip
+	w = rateLimitRequest(handler, "192.0.2.2:1234", "", 0)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// authenticated users are limited by user id
+	for i := 0; i < 2; i++ {
+		w = rateLimitRequest(handler, "192.0.2.1:1234", "", 1)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w = rateLimitRequest(handler, "192.0.2.3:1234", "", 1)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	handler := newRateLimitHandler(ratelimit.New(cache.NewMemory(1, 100)), []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	// clients behind trusted proxy are limited by forwarded ip
+	for i := 0; i < 2; i++ {
+		w := rateLimitRequest(handler, "10.0.0.1:1234", "198.51.100.1", 0)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w := rateLimitRequest(handler, "10.0.0.2:1234", "198.51.100.1, 10.0.0.1", 0)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = rateLimitRequest(handler, "10.0.0.1:1234", "198.51.100.2", 0)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// forwarded header of untrusted client is ignored
+	for i := 0; i < 2; i++ {
+		w = rateLimitRequest(handler, "192.0.2.1:1234", "198.51.100.3", 0)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w = rateLimitRequest(handler, "192.0.2.1:1234", "198.51.100.4", 0)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	handler := newRateLimitHandler(nil, nil)
+
+	for i := 0; i < 5; i++ {
+		w := rateLimitRequest(handler, "192.0.2.1:1234", "", 0)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
@@ -0,0 +1,204 @@
+package v1_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v2"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/jwt"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+)
+
+// app services are not needed for routing. handlers that use them panic
+func newRouter() http.Handler {
+	return v1.NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, time.Minute, middleware.CORSPolicy{AllowedOrigins: []string{"https://app.example.com"}}, middleware.RateLimitOptions{})
+}
+
+func serve(router http.Handler, method string, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func status(t *testing.T, w *httptest.ResponseRecorder) int {
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	status, _ := body["status"].(float64)
+	return int(status)
+}
+
+func TestNotFound(t *testing.T) {
+	router := newRouter()
+
+	for _, path := range []string{"/api/v1/", "/api/v1/unknown", "/api/v1/expenses/1/unknown"} {
+		w := serve(router, "GET", path)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+
+	w := serve(router, "GET", "/api/v2/unknown")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, v2.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	router := newRouter()
+
+	tests := []struct {
+		TestID int
+		Method string
+		Path   string
+		Allow  string
+	}{
+		{TestID: 1, Method: "PATCH", Path: "/api/v1/expenses/1", Allow: "GET, PUT, DELETE, HEAD, OPTIONS"},
+		{TestID: 2, Method: "GET", Path: "/api/v1/users/login", Allow: "POST, OPTIONS"},
+		{TestID: 3, Method: "DELETE", Path: "/api/v2/users/avatar", Allow: "GET, POST, HEAD, OPTIONS"},
+		// static segment is more specific than {id}
+		{TestID: 4, Method: "PUT", Path: "/api/v1/admin/users/block", Allow: "POST, OPTIONS"},
+		{TestID: 5, Method: "PUT", Path: "/api/v1/debts/1", Allow: "GET, DELETE, HEAD, OPTIONS"},
+	}
+
+	for _, test := range tests {
+		w := serve(router, test.Method, test.Path)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code, test)
+		assert.Equal(t, test.Allow, w.Header().Get("Allow"), test)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	router := newRouter()
+
+	w := serve(router, "OPTIONS", "/api/v1/debts/1/accept")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "POST, OPTIONS", w.Header().Get("Allow"))
+
+	// cors preflight
+	r := httptest.NewRequest("OPTIONS", "/api/v2/expenses/1", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "PUT")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, PUT, DELETE, HEAD, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+
+	w = serve(router, "OPTIONS", "/api/v1/unknown")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRecovery(t *testing.T) {
+	router := newRouter()
+
+	// user app service is nil
+	w := serve(router, "GET", "/api/v2/users/avatar")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	requestID := w.Header().Get(middleware.RequestIDHeader)
+	assert.NotEmpty(t, requestID)
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "internal_error", body["code"])
+	assert.Equal(t, requestID, body["request_id"])
+}
+
+func TestRecoveryV1(t *testing.T) {
+	router := newRouter()
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	counter := metrics.HTTPRequests.WithLabelValues("GET", "/users/avatar", "500")
+	before := testutil.ToFloat64(counter)
+
+	// http status of v1 server errors is 200. status is in body
+	w := serve(router, "GET", "/api/v1/users/avatar")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, status(t, w))
+
+	// status of body is logged and counted
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+
+	var requestLog map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record["msg"] == "request" {
+			requestLog = record
+		}
+	}
+	require.NotNil(t, requestLog)
+	assert.Equal(t, "ERROR", requestLog["level"])
+	assert.Equal(t, float64(http.StatusInternalServerError), requestLog["status"])
+}
+
+// records keys that are reserved by idempotency middleware
+type reserveRecorder struct {
+	*cache.MemoryCacheRepository
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *reserveRecorder) SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error) {
+	r.mu.Lock()
+	r.keys = append(r.keys, key)
+	r.mu.Unlock()
+	return r.MemoryCacheRepository.SaveIfAbsent(key, value, expireTime)
+}
+
+func TestSecretResponsesAreNotIdempotent(t *testing.T) {
+	jwt.Init("test-secret")
+	access, err := jwt.CreateAccessFromUser(time.Minute, 1, "Ali", "+989120000001", true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		TestID     int
+		Path       string
+		Idempotent bool
+	}{
+		// new access token
+		{TestID: 1, Path: "/api/v1/users/profile", Idempotent: false},
+		// access and refresh tokens
+		{TestID: 2, Path: "/api/v1/users/change-number/verify", Idempotent: false},
+		{TestID: 3, Path: "/api/v2/users/change-number/verify", Idempotent: false},
+		{TestID: 4, Path: "/api/v1/users/2fa/enroll", Idempotent: false},
+		{TestID: 5, Path: "/api/v1/users/2fa/confirm", Idempotent: false},
+		{TestID: 6, Path: "/api/v1/users/2fa/disable", Idempotent: true},
+		// public routes
+		{TestID: 7, Path: "/api/v1/users/login", Idempotent: false},
+		{TestID: 8, Path: "/api/v1/users/send-otp", Idempotent: false},
+		{TestID: 9, Path: "/api/v2/users/signup", Idempotent: false},
+	}
+
+	for _, test := range tests {
+		recorder := &reserveRecorder{MemoryCacheRepository: cache.NewMemory(1, 100)}
+		router := v1.NewRouter(nil, nil, nil, nil, nil, nil, nil, recorder, time.Minute, middleware.CORSPolicy{}, middleware.RateLimitOptions{})
+
+		r := httptest.NewRequest("POST", test.Path, strings.NewReader("{}"))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Authorization", "Bearer "+access)
+		r.Header.Set(middleware.IdempotencyKeyHeader, "key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, test.Idempotent, len(recorder.keys) > 0, test)
+		// header is rejected instead of ignoring it
+		if !test.Idempotent {
+			assert.Contains(t, w.Body.String(), "Idempotency-Key is not supported", test)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package shared_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	interfaces_rest_v1_shared "github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/shared"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version uint64
+		isErr   bool
+	}{
+		{"", 0, false},
+		{"*", 0, false},
+		{`"3"`, 3, false},
+		{`W/"3"`, 3, false},
+		{interfaces_rest_v1_shared.ETag(12), 12, false},
+		{"3", 0, true},
+		{`"0"`, 0, true},
+		{`"abc"`, 0, true},
+		{`"1", "2"`, 0, true},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("PUT", "/expenses/1", nil)
+		if test.header != "" {
+			r.Header.Set("If-Match", test.header)
+		}
+
+		version, err := interfaces_rest_v1_shared.IfMatchVersion(r)
+		if test.isErr {
+			assert.Error(t, err, test.header)
+			continue
+		}
+		assert.NoError(t, err, test.header)
+		assert.Equal(t, test.version, version, test.header)
+	}
+}
@@ -0,0 +1,96 @@
+package v2_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	app_shared "github.com/yaghoubi-mn/pedarkharj/internal/application/shared"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v2"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/rcodes"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/service_errors"
+)
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	return body
+}
+
+func TestErrorResponse(t *testing.T) {
+	response := v2.NewProblemResponse()
+
+	w := httptest.NewRecorder()
+	w.Header().Set(middleware.RequestIDHeader, "req-1")
+	response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrInvalidName)
+
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, v2.ProblemContentType, w.Header().Get("Content-Type"))
+
+	body := decode(t, w)
+	assert.Equal(t, "urn:pedarkharj:problem:invalid_name", body["type"])
+	assert.Equal(t, "Bad Request", body["title"])
+	assert.Equal(t, float64(400), body["status"])
+	assert.Equal(t, "invalid_name", body["code"])
+	assert.Equal(t, "invalid name", body["detail"])
+	assert.Equal(t, "req-1", body["request_id"])
+	assert.Equal(t, []any{map[string]any{"field": "name", "code": "invalid_name", "message": "invalid name"}}, body["errors"])
+}
+
+func TestErrorResponseLocalized(t *testing.T) {
+	response := v2.NewProblemResponse()
+
+	w := httptest.NewRecorder()
+	w.Header().Set(middleware.ContentLanguageHeader, "fa")
+	response.ErrorResponse(w, 400, rcodes.InvalidField, nil, service_errors.ErrExpenseNotFound)
+
+	// status of typed error is used
+	assert.Equal(t, 404, w.Code)
+
+	body := decode(t, w)
+	assert.Equal(t, "expense_not_found", body["code"])
+	assert.Equal(t, "هزینه پیدا نشد", body["detail"])
+}
+
+func TestErrorResponseUntyped(t *testing.T) {
+	response := v2.NewProblemResponse()
+
+	w := httptest.NewRecorder()
+	response.ErrorResponse(w, 400, rcodes.InvalidField, nil, errors.New("name: invalid name"))
+
+	assert.Equal(t, 400, w.Code)
+
+	body := decode(t, w)
+	assert.Equal(t, rcodes.InvalidField, body["code"])
+	assert.Equal(t, "name: invalid name", body["detail"])
+}
+
+func TestDTOErrorResponse(t *testing.T) {
+	response := v2.NewProblemResponse()
+
+	// server error
+	w := httptest.NewRecorder()
+	response.DTOErrorResponse(w, app_shared.ResponseDTO{ServerErr: errors.New("database is down")})
+
+	assert.Equal(t, 500, w.Code)
+	body := decode(t, w)
+	assert.Equal(t, v2.InternalErrorCode, body["code"])
+	assert.NotContains(t, body["detail"], "database")
+
+	// data is added as extension members
+	w = httptest.NewRecorder()
+	response.DTOErrorResponse(w, app_shared.ResponseDTO{
+		UserErr:      service_errors.ErrVersionConflict,
+		ResponseCode: rcodes.VersionConflict,
+		Data:         map[string]any{"expense": map[string]any{"version": 2}},
+	})
+
+	assert.Equal(t, 409, w.Code)
+	body = decode(t, w)
+	assert.Equal(t, "version_conflict", body["code"])
+	assert.Equal(t, map[string]any{"version": float64(2)}, body["expense"])
+}
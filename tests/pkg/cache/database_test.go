@@ -0,0 +1,142 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database"
+)
+
+// same behavior is expected from all backends
+func testSaveIfAbsent(t *testing.T, c cache.Repository) {
+	saved, err := c.SaveIfAbsent("key", map[string]string{"state": "first"}, 50*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	// existing key is not overwritten
+	saved, err = c.SaveIfAbsent("key", map[string]string{"state": "second"}, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	value, _, err := c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", value["state"])
+
+	// expired key is replaced
+	time.Sleep(100 * time.Millisecond)
+	saved, err = c.SaveIfAbsent("key", map[string]string{"state": "third"}, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	value, _, err = c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "third", value["state"])
+
+	// deleted key can be saved again
+	assert.NoError(t, c.Delete("key"))
+	saved, err = c.SaveIfAbsent("key", map[string]string{"state": "fourth"}, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+}
+
+func testIncrement(t *testing.T, c cache.Repository) {
+	for i := int64(1); i <= 3; i++ {
+		count, expire, err := c.Increment("counter", 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.Equal(t, i, count)
+		// window is not extended by increments
+		assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), expire, 30*time.Millisecond)
+	}
+
+	// other keys have their own counter
+	count, _, err := c.Increment("other", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// counter is started again after expire
+	time.Sleep(100 * time.Millisecond)
+	count, expire, err := c.Increment("counter", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expire, time.Second)
+
+	// deleted counter is started again
+	assert.NoError(t, c.Delete("counter"))
+	count, _, err = c.Increment("counter", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func testCompareAndSwap(t *testing.T, c cache.Repository) {
+	old := map[string]string{"tokens": "2", "updated_at": "1"}
+	value := map[string]string{"tokens": "1", "updated_at": "2"}
+
+	// absent key is not saved
+	swapped, err := c.CompareAndSwap("key", old, value, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+
+	assert.NoError(t, c.Save("key", old, 50*time.Millisecond))
+
+	swapped, err = c.CompareAndSwap("key", old, value, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+
+	stored, expire, err := c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, value, stored)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expire, time.Second)
+
+	// value is changed by other request
+	swapped, err = c.CompareAndSwap("key", old, map[string]string{"tokens": "0"}, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+
+	stored, _, err = c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, value, stored)
+
+	// expired value is not replaced
+	assert.NoError(t, c.Save("expired", old, 50*time.Millisecond))
+	time.Sleep(100 * time.Millisecond)
+	swapped, err = c.CompareAndSwap("expired", old, value, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+}
+
+func newDatabaseCache(t *testing.T) cache.GormCacheRepository {
+	db, err := database.SetupGrom(database.Options{Driver: database.DriverSQLite, Path: ":memory:", LogLevel: "silent"})
+	require.NoError(t, err)
+	require.NoError(t, cache.MigrateTables(db))
+
+	return cache.New(db)
+}
+
+func TestDatabaseSaveGetDelete(t *testing.T) {
+	c := newDatabaseCache(t)
+
+	assert.NoError(t, c.Save("key", map[string]string{"token": "abc"}, time.Minute))
+	value, expire, err := c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", value["token"])
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expire, time.Second)
+
+	assert.NoError(t, c.Delete("key"))
+	_, _, err = c.Get("key")
+	assert.Error(t, err)
+}
+
+func TestDatabaseSaveIfAbsent(t *testing.T) {
+	testSaveIfAbsent(t, newDatabaseCache(t))
+}
+
+func TestDatabaseIncrement(t *testing.T) {
+	testIncrement(t, newDatabaseCache(t))
+}
+
+func TestDatabaseCompareAndSwap(t *testing.T) {
+	testCompareAndSwap(t, newDatabaseCache(t))
+}
@@ -0,0 +1,136 @@
+package cache_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+func TestMemorySaveGetDelete(t *testing.T) {
+	c := cache.NewMemory(4, 100)
+
+	assert.NoError(t, c.Save("key", map[string]string{"token": "abc"}, time.Minute))
+
+	value, expire, err := c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", value["token"])
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expire, time.Second)
+
+	// overwrite
+	assert.NoError(t, c.Save("key", map[string]string{"token": "def"}, time.Minute))
+	value, _, err = c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "def", value["token"])
+
+	// returned map is a copy
+	value["token"] = "changed"
+	value, _, _ = c.Get("key")
+	assert.Equal(t, "def", value["token"])
+
+	assert.NoError(t, c.Delete("key"))
+	_, _, err = c.Get("key")
+	assert.Equal(t, database_errors.ErrRecordNotFound, err)
+}
+
+func TestMemoryExpire(t *testing.T) {
+	c := cache.NewMemory(1, 10)
+
+	assert.NoError(t, c.Save("key", map[string]string{"a": "b"}, 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	_, _, err := c.Get("key")
+	assert.Equal(t, database_errors.ErrExpired, err)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestMemorySaveIfAbsent(t *testing.T) {
+	testSaveIfAbsent(t, cache.NewMemory(4, 100))
+}
+
+func TestMemoryIncrement(t *testing.T) {
+	testIncrement(t, cache.NewMemory(4, 100))
+}
+
+func TestMemoryCompareAndSwap(t *testing.T) {
+	testCompareAndSwap(t, cache.NewMemory(4, 100))
+}
+
+func TestMemoryEvictLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewMemory(1, 2)
+
+	c.Save("a", map[string]string{}, time.Minute)
+	c.Save("b", map[string]string{}, time.Minute)
+
+	// a is used so b is least recently used
+	_, _, err := c.Get("a")
+	assert.NoError(t, err)
+
+	c.Save("c", map[string]string{}, time.Minute)
+
+	_, _, err = c.Get("b")
+	assert.Equal(t, database_errors.ErrRecordNotFound, err)
+	_, _, err = c.Get("a")
+	assert.NoError(t, err)
+	_, _, err = c.Get("c")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.Len())
+}
+
+// filling cache does not reset attempt counters
+func TestMemoryCountersAreNotEvicted(t *testing.T) {
+	c := cache.NewMemory(1, 2)
+
+	_, _, err := c.Increment("attempts", time.Minute)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		c.Save(fmt.Sprint(i), map[string]string{}, time.Minute)
+	}
+
+	count, _, err := c.Increment("attempts", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+// expired counters are removed when counters reach capacity
+func TestMemoryExpiredCountersAreRemoved(t *testing.T) {
+	c := cache.NewMemory(1, 2)
+
+	c.Increment("a", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	c.Increment("b", time.Minute)
+	c.Increment("c", time.Minute)
+	assert.Equal(t, 2, c.Len())
+
+	count, _, err := c.Increment("a", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMemoryConcurrent(t *testing.T) {
+	c := cache.NewMemory(8, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d-%d", i, j)
+				c.Save(key, map[string]string{"v": key}, time.Minute)
+				value, _, err := c.Get(key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, value["v"])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Len(), 1000)
+}
@@ -0,0 +1,262 @@
+package cache_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database_errors"
+)
+
+// fake redis server that supports commands used by cache repository
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	password string
+	listener net.Listener
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeRedis{
+		values:   make(map[string]string),
+		expires:  make(map[string]time.Time),
+		password: password,
+		listener: listener,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authenticated := f.password == ""
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(args[0])
+		if command == "AUTH" {
+			if args[1] == f.password {
+				authenticated = true
+				io.WriteString(conn, "+OK\r\n")
+			} else {
+				io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+			}
+			continue
+		}
+
+		if !authenticated {
+			io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+
+		io.WriteString(conn, f.execute(command, args[1:]))
+	}
+}
+
+func (f *fakeRedis) execute(command string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// remove expired key
+	if len(args) > 0 {
+		if expire, ok := f.expires[args[0]]; ok && time.Now().After(expire) {
+			delete(f.values, args[0])
+			delete(f.expires, args[0])
+		}
+	}
+
+	switch command {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		// NX option
+		if _, ok := f.values[args[0]]; ok && len(args) > 4 {
+			return "$-1\r\n"
+		}
+		f.values[args[0]] = args[1]
+		ms, _ := strconv.Atoi(args[3])
+		f.expires[args[0]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.values[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "PTTL":
+		if _, ok := f.values[args[0]]; !ok {
+			return ":-2\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", time.Until(f.expires[args[0]]).Milliseconds())
+	case "EVAL":
+		// scripts of cache repository are known by number of args
+		key := args[2]
+		if expire, ok := f.expires[key]; ok && time.Now().After(expire) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+		if len(args) == 6 {
+			// compare and swap script. args are script, key count, key, old value, value and ttl
+			value, ok := f.values[key]
+			if !ok || value != args[3] {
+				return ":0\r\n"
+			}
+			ms, _ := strconv.Atoi(args[5])
+			f.values[key] = args[4]
+			f.expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			return ":1\r\n"
+		}
+		// increment script. args are script, key count, key and ttl
+		count, _ := strconv.Atoi(f.values[key])
+		count++
+		f.values[key] = strconv.Itoa(count)
+		if _, ok := f.expires[key]; !ok {
+			ms, _ := strconv.Atoi(args[3])
+			f.expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return fmt.Sprintf("*2\r\n:%d\r\n:%d\r\n", count, time.Until(f.expires[key]).Milliseconds())
+	case "DEL":
+		_, ok := f.values[args[0]]
+		delete(f.values, args[0])
+		delete(f.expires, args[0])
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	}
+
+	return "-ERR unknown command\r\n"
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+func TestRedisSaveGetDelete(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	c, err := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String(), Password: "secret", Prefix: "test:"})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.NoError(t, c.Save("key", map[string]string{"token": "abc", "mode": "login"}, time.Minute))
+
+	// key is prefixed
+	server.mu.Lock()
+	_, ok := server.values["test:key"]
+	server.mu.Unlock()
+	assert.True(t, ok)
+
+	value, expire, err := c.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"token": "abc", "mode": "login"}, value)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expire, time.Second)
+
+	assert.NoError(t, c.Delete("key"))
+	_, _, err = c.Get("key")
+	assert.Equal(t, database_errors.ErrRecordNotFound, err)
+}
+
+func TestRedisExpire(t *testing.T) {
+	server := newFakeRedis(t, "")
+
+	c, err := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String()})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.NoError(t, c.Save("key", map[string]string{"a": "b"}, 10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+
+	_, _, err = c.Get("key")
+	assert.Equal(t, database_errors.ErrRecordNotFound, err)
+}
+
+func TestRedisWrongPassword(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	_, err := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String(), Password: "wrong"})
+	assert.Error(t, err)
+	_, ok := err.(cache.RedisError)
+	assert.True(t, ok)
+}
+
+func TestRedisSaveIfAbsent(t *testing.T) {
+	server := newFakeRedis(t, "")
+
+	c, err := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String()})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	testSaveIfAbsent(t, c)
+}
+
+func TestRedisIncrement(t *testing.T) {
+	server := newFakeRedis(t, "")
+
+	c, err := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String()})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	testIncrement(t, c)
+}
+
+func TestRedisCompareAndSwap(t *testing.T) {
+	server := newFakeRedis(t, "")
+
+	c, err := cache.NewRedis(cache.RedisOptions{Addr: server.listener.Addr().String()})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	testCompareAndSwap(t, c)
+}
@@ -0,0 +1,115 @@
+package database_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_add_role.up.sql":    {Data: []byte("ALTER TABLE users ADD role TEXT;")},
+		"sql/0002_add_role.down.sql":  {Data: []byte("ALTER TABLE users DROP role;")},
+		"sql/0001_init.up.sql":        {Data: []byte("CREATE TABLE users (id INT);")},
+		"sql/0001_init.down.sql":      {Data: []byte("DROP TABLE users;")},
+		"sql/0010_add_index.up.sql":   {Data: []byte("CREATE INDEX idx ON users (id);")},
+		"sql/0010_add_index.down.sql": {Data: []byte("DROP INDEX idx;")},
+		"other/0001_ignored.up.sql":   {Data: []byte("SELECT 1;")},
+		"other/0001_ignored.down.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	loaded, err := database.LoadMigrations(fsys, "sql")
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 3)
+
+	// sorted by version
+	assert.Equal(t, int64(1), loaded[0].Version)
+	assert.Equal(t, "init", loaded[0].Name)
+	assert.Equal(t, "DROP TABLE users;", loaded[0].Down)
+	assert.Equal(t, int64(2), loaded[1].Version)
+	assert.Equal(t, int64(10), loaded[2].Version)
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+
+	tests := []struct {
+		ID   int
+		FSys fstest.MapFS
+	}{
+		{ // missing down file
+			ID:   1,
+			FSys: fstest.MapFS{"sql/0001_init.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{ // invalid file name
+			ID:   2,
+			FSys: fstest.MapFS{"sql/init.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{ // same version with different names
+			ID: 3,
+			FSys: fstest.MapFS{
+				"sql/0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+				"sql/0001_b.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := database.LoadMigrations(test.FSys, "sql")
+		assert.Error(t, err, "test id: %d", test.ID)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	postgres, err := database.LoadMigrations(migrations.FS, "postgres")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, postgres)
+	assert.Equal(t, int64(1), postgres[0].Version)
+
+	// every postgres migration has a sqlite version
+	sqlite, err := database.LoadMigrations(migrations.FS, "sqlite")
+	assert.NoError(t, err)
+	assert.Len(t, sqlite, len(postgres))
+	for i := range sqlite {
+		assert.Equal(t, postgres[i].Version, sqlite[i].Version)
+		assert.Equal(t, postgres[i].Name, sqlite[i].Name)
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+
+	paths, err := database.CreateMigration("create_users", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "0001_create_users.up.sql"), filepath.Join(dir, "0001_create_users.down.sql")}, paths)
+	assert.FileExists(t, paths[0])
+	assert.FileExists(t, paths[1])
+
+	// next version
+	paths, err = database.CreateMigration("add_role", dir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0002_add_role.up.sql"), paths[0])
+
+	_, err = database.CreateMigration("Invalid Name", dir)
+	assert.Equal(t, database.ErrInvalidMigrationName, err)
+
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 4)
+}
+
+func TestCreateMigrationDialects(t *testing.T) {
+	postgresDir, sqliteDir := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(postgresDir, "0003_init.up.sql"), []byte("SELECT 1;"), 0o644)
+	os.WriteFile(filepath.Join(postgresDir, "0003_init.down.sql"), []byte("SELECT 1;"), 0o644)
+
+	// version is same in all dialects
+	paths, err := database.CreateMigration("add_role", postgresDir, sqliteDir)
+	assert.NoError(t, err)
+	assert.Len(t, paths, 4)
+	assert.FileExists(t, filepath.Join(postgresDir, "0004_add_role.up.sql"))
+	assert.FileExists(t, filepath.Join(sqliteDir, "0004_add_role.down.sql"))
+}
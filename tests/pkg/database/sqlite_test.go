@@ -0,0 +1,120 @@
+package database_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/database/migrations"
+)
+
+func TestSetupSQLite(t *testing.T) {
+	db, err := database.SetupGrom(database.Options{Driver: database.DriverSQLite, Path: ":memory:", LogLevel: "silent"})
+	assert.NoError(t, err)
+
+	migrator, err := database.NewMigrator(db, migrations.FS, database.DriverSQLite)
+	assert.NoError(t, err)
+
+	done, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, done)
+
+	// foreign keys are enforced
+	err = db.Exec("INSERT INTO devices (name, last_ip, first_login, last_login, user_id) VALUES ('a', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 100)").Error
+	assert.Error(t, err)
+
+	done, err = migrator.Down(len(done))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, done)
+	assert.False(t, db.Migrator().HasTable("users"))
+}
+
+// database that is created by auto migrate of first version has tables without migrations
+func TestUpBaselineDatabase(t *testing.T) {
+	db, err := database.SetupGrom(database.Options{Driver: database.DriverSQLite, Path: ":memory:", LogLevel: "silent"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, name text, number text, password text, salt text, avatar text, registered_at datetime, is_registered numeric, is_blocked numeric)").Error)
+
+	migrator, err := database.NewMigrator(db, migrations.FS, database.DriverSQLite)
+	assert.NoError(t, err)
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	for _, column := range []string{"role", "is_deleted", "totp_secret", "totp_last_counter", "avatar_key", "language"} {
+		assert.True(t, db.Migrator().HasColumn("users", column), column)
+	}
+}
+
+// admin audit logs are moved to audit log
+func TestUpMergeAdminAuditLogs(t *testing.T) {
+	db, err := database.SetupGrom(database.Options{Driver: database.DriverSQLite, Path: ":memory:", LogLevel: "silent"})
+	assert.NoError(t, err)
+
+	migrator, err := database.NewMigrator(db, migrations.FS, database.DriverSQLite)
+	assert.NoError(t, err)
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.False(t, db.Migrator().HasTable("admin_audit_logs"))
+
+	_, err = migrator.Down(1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("INSERT INTO admin_audit_logs (admin_id, action, target_user_id, details, created_at) VALUES (1, 'block_user', 3, 'reason=spam', CURRENT_TIMESTAMP)").Error)
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	var log struct {
+		ActorID    uint64
+		Action     string
+		TargetType string
+		TargetID   uint64
+		After      string
+	}
+	assert.NoError(t, db.Table("audit_logs").Take(&log).Error)
+	assert.Equal(t, uint64(1), log.ActorID)
+	assert.Equal(t, "admin.block_user", log.Action)
+	assert.Equal(t, "user", log.TargetType)
+	assert.Equal(t, uint64(3), log.TargetID)
+	assert.JSONEq(t, `{"details":"reason=spam"}`, log.After)
+}
+
+// migrate command of instances run at same time
+func TestUpConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	applied := 0
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db, err := database.SetupGrom(database.Options{Driver: database.DriverSQLite, Path: path, LogLevel: "silent"})
+			assert.NoError(t, err)
+
+			migrator, err := database.NewMigrator(db, migrations.FS, database.DriverSQLite)
+			assert.NoError(t, err)
+
+			done, err := migrator.Up()
+			assert.NoError(t, err)
+
+			mu.Lock()
+			applied += len(done)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	all, err := database.LoadMigrations(migrations.FS, database.DriverSQLite)
+	assert.NoError(t, err)
+	assert.Equal(t, len(all), applied)
+}
+
+func TestSetupUnknownDriver(t *testing.T) {
+	_, err := database.SetupGrom(database.Options{Driver: "mysql"})
+	assert.ErrorIs(t, err, database.ErrUnknownDriver)
+}
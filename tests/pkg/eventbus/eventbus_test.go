@@ -0,0 +1,153 @@
+package eventbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/eventbus"
+)
+
+func message(id string) eventbus.Message {
+	return eventbus.Message{ID: id, Type: "expense.created", Payload: json.RawMessage(`{"expense_id":1}`), OccurredAt: time.Now()}
+}
+
+func TestBus(t *testing.T) {
+	bus := eventbus.New()
+	ctx := context.Background()
+
+	var received []string
+	bus.Subscribe("expense.created", func(ctx context.Context, msg eventbus.Message) error {
+		received = append(received, "created:"+msg.ID)
+		return nil
+	})
+	bus.Subscribe("debt.accepted", func(ctx context.Context, msg eventbus.Message) error {
+		received = append(received, "accepted:"+msg.ID)
+		return nil
+	})
+	bus.Subscribe(eventbus.AllEvents, func(ctx context.Context, msg eventbus.Message) error {
+		received = append(received, "all:"+msg.ID)
+		return nil
+	})
+
+	assert.NoError(t, bus.Publish(ctx, message("1")))
+	assert.Equal(t, []string{"created:1", "all:1"}, received)
+
+	// message without subscriber
+	assert.NoError(t, eventbus.New().Publish(ctx, message("2")))
+}
+
+func TestBusHandlerErrors(t *testing.T) {
+	bus := eventbus.New()
+	errHandler := errors.New("handler failed")
+
+	called := false
+	bus.Subscribe("expense.created", func(ctx context.Context, msg eventbus.Message) error {
+		panic("boom")
+	})
+	bus.Subscribe("expense.created", func(ctx context.Context, msg eventbus.Message) error {
+		return errHandler
+	})
+	bus.Subscribe("expense.created", func(ctx context.Context, msg eventbus.Message) error {
+		called = true
+		return nil
+	})
+
+	// all handlers are called
+	err := bus.Publish(context.Background(), message("1"))
+	assert.ErrorIs(t, err, errHandler)
+	assert.ErrorContains(t, err, "panicked: boom")
+	assert.True(t, called)
+}
+
+type publisherFunc func(ctx context.Context, msg eventbus.Message) error
+
+func (f publisherFunc) Publish(ctx context.Context, msg eventbus.Message) error {
+	return f(ctx, msg)
+}
+
+func TestFanout(t *testing.T) {
+	errPublish := errors.New("broker is down")
+	count := 0
+
+	publisher := eventbus.Fanout(
+		publisherFunc(func(ctx context.Context, msg eventbus.Message) error { return errPublish }),
+		publisherFunc(func(ctx context.Context, msg eventbus.Message) error { count++; return nil }),
+	)
+
+	assert.ErrorIs(t, publisher.Publish(context.Background(), message("1")), errPublish)
+	assert.Equal(t, 1, count)
+}
+
+func TestDeduplicate(t *testing.T) {
+	store := cache.NewMemory(4, 100)
+	ctx := context.Background()
+
+	count := 0
+	fail := true
+	handler := eventbus.Deduplicate("counter", store, time.Minute, func(ctx context.Context, msg eventbus.Message) error {
+		if fail {
+			fail = false
+			return errors.New("failed")
+		}
+		count++
+		return nil
+	})
+
+	// failed message is handled again
+	assert.Error(t, handler(ctx, message("1")))
+	assert.NoError(t, handler(ctx, message("1")))
+	assert.NoError(t, handler(ctx, message("1")))
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, handler(ctx, message("2")))
+	assert.Equal(t, 2, count)
+
+	// ids are scoped to name of handler
+	other := 0
+	otherHandler := eventbus.Deduplicate("other", store, time.Minute, func(ctx context.Context, msg eventbus.Message) error {
+		other++
+		return nil
+	})
+	assert.NoError(t, otherHandler(ctx, message("1")))
+	assert.Equal(t, 1, other)
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	status := http.StatusAccepted
+	var request *http.Request
+	var body []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	publisher := eventbus.NewWebhookPublisher(srv.URL, time.Second)
+	msg := message("5f1c")
+
+	require.NoError(t, publisher.Publish(context.Background(), msg))
+	assert.Equal(t, http.MethodPost, request.Method)
+	assert.Equal(t, "5f1c", request.Header.Get(eventbus.EventIDHeader))
+	assert.Equal(t, "expense.created", request.Header.Get(eventbus.EventTypeHeader))
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+	var received eventbus.Message
+	require.NoError(t, json.Unmarshal(body, &received))
+	assert.Equal(t, msg.ID, received.ID)
+	assert.JSONEq(t, `{"expense_id":1}`, string(received.Payload))
+
+	// non 2xx is error
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, publisher.Publish(context.Background(), msg), "503")
+}
@@ -0,0 +1,88 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/health"
+)
+
+func serve(handler http.Handler) (int, health.Report) {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	var report health.Report
+	json.NewDecoder(w.Body).Decode(&report)
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+
+	checker := health.New("1.2.0", time.Second)
+	checker.Register("database", func(ctx context.Context) error { return nil })
+	checker.Register("cache", func(ctx context.Context) error { return nil })
+
+	status, report := serve(checker.ReadinessHandler())
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, "1.2.0", report.Version)
+	assert.Len(t, report.Checks, 2)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+}
+
+func TestReadinessFailedDependency(t *testing.T) {
+
+	checker := health.New("dev", time.Second)
+	checker.Register("database", func(ctx context.Context) error { return nil })
+	checker.Register("storage", func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.1:443: connection refused") })
+
+	status, report := serve(checker.ReadinessHandler())
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, health.StatusFail, report.Checks["storage"].Status)
+	// details of error are not exposed
+	assert.Equal(t, "unavailable", report.Checks["storage"].Error)
+}
+
+func TestReadinessTimeout(t *testing.T) {
+
+	checker := health.New("dev", 50*time.Millisecond)
+	// check that ignores context
+	checker.Register("cache", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	status, report := serve(checker.ReadinessHandler())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "timeout", report.Checks["cache"].Error)
+}
+
+func TestShuttingDown(t *testing.T) {
+
+	checker := health.New("dev", time.Second)
+	checker.Register("database", func(ctx context.Context) error { return nil })
+
+	checker.SetShuttingDown()
+
+	status, report := serve(checker.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+
+	// process is still alive
+	status, report = serve(checker.LivenessHandler())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health.StatusOK, report.Status)
+}
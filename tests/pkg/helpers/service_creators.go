@@ -69,6 +69,7 @@ func GetDeviceAppService() app_device.DeviceAppService {
 
 	return app_device.NewDeviceAppService(
 		repository.NewGormDeviceRepository(db),
+		repository.NewGormAuditRepository(db),
 		domain_device.NewDeviceService(vld),
 	)
 }
@@ -79,6 +80,7 @@ func GetUserAppService() app_user.UserAppService {
 	return app_user.NewUserService(
 		GetUserDomainRepository(),
 		GetCacheRepository(),
+		repository.NewGormAuditRepository(db),
 		GetDeviceAppService(),
 		domain_user.NewUserService(vld),
 	)
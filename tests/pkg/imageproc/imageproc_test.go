@@ -0,0 +1,129 @@
+package imageproc_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/imageproc"
+)
+
+func newImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+// insert exif segment with orientation tag after SOI marker
+func withOrientation(data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, uint16(0x0112))
+	binary.Write(&tiff, binary.BigEndian, uint16(3))
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(data[2:])
+	return out.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+
+	// jpeg
+	img, err := imageproc.Decode(encodeJPEG(t, newImage(40, 20)))
+	assert.NoError(t, err)
+	assert.Equal(t, 40, img.Bounds().Dx())
+	assert.Equal(t, 20, img.Bounds().Dy())
+
+	// png
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, newImage(10, 30)))
+	img, err = imageproc.Decode(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, 30, img.Bounds().Dy())
+
+	// unsupported format
+	_, err = imageproc.Decode([]byte("GIF89a this is not allowed"))
+	assert.ErrorIs(t, err, imageproc.ErrUnsupportedFormat)
+}
+
+// png with changed dimension in header. only header is read for checking dimension
+func pngWithSize(t *testing.T, w, h uint32) []byte {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, newImage(1, 1)))
+	data := buf.Bytes()
+
+	// IHDR chunk data is after signature (8 bytes), length (4 bytes) and type (4 bytes)
+	binary.BigEndian.PutUint32(data[16:], w)
+	binary.BigEndian.PutUint32(data[20:], h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestDecodeLargeImage(t *testing.T) {
+	_, err := imageproc.Decode(pngWithSize(t, 6001, 10))
+	assert.ErrorIs(t, err, imageproc.ErrLargeDimension)
+
+	// dimensions are allowed but decoded image is too large
+	_, err = imageproc.Decode(pngWithSize(t, 5000, 5000))
+	assert.ErrorIs(t, err, imageproc.ErrLargeDimension)
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, newImage(40, 20)), 6)
+
+	img, err := imageproc.Decode(data)
+	assert.NoError(t, err)
+
+	// rotated 90 degree
+	assert.Equal(t, 20, img.Bounds().Dx())
+	assert.Equal(t, 40, img.Bounds().Dy())
+}
+
+func TestSquareThumbnailAndEncode(t *testing.T) {
+	thumbnail := imageproc.SquareThumbnail(newImage(300, 200), 128)
+	assert.Equal(t, image.Rect(0, 0, 128, 128), thumbnail.Bounds())
+
+	encoded, err := imageproc.EncodeJPEG(thumbnail)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", imageproc.DetectContentType(encoded))
+
+	// metadata is removed
+	reencoded, err := imageproc.EncodeJPEG(imageproc.SquareThumbnail(mustDecode(t, withOrientation(encoded, 3)), 128))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(reencoded), "Exif")
+}
+
+func mustDecode(t *testing.T, data []byte) image.Image {
+	img, err := imageproc.Decode(data)
+	assert.NoError(t, err)
+	return img
+}
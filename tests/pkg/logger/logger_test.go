@@ -0,0 +1,87 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+)
+
+const jwtToken = "eyJhbGciOiJIUzI1NiJ9.eyJpZCI6MX0.c2lnbmF0dXJl"
+
+func TestRedact(t *testing.T) {
+
+	assert.Equal(t, "[REDACTED]", logger.Redact("password", "secret123"))
+	assert.Equal(t, "[REDACTED]", logger.Redact("refresh_token", "abc"))
+	assert.Equal(t, "[REDACTED]", logger.Redact("X-Api-Key", "abc"))
+	assert.Equal(t, "", logger.Redact("password", ""))
+	assert.Equal(t, "+98912****789", logger.Redact("phone_number", "+989123456789"))
+	assert.Equal(t, "ali", logger.Redact("name", "ali"))
+	assert.Equal(t, 12, logger.Redact("count", 12))
+	assert.Equal(t, "1234", logger.Redact("otp", logger.Unredacted("1234")))
+
+	nested := logger.Redact("body", map[string]any{
+		"number": "+989123456789",
+		"tokens": map[string]any{"access": "a", "refresh": "b"},
+		"items":  []any{"call +989123456789"},
+	})
+	assert.Equal(t, map[string]any{
+		"number": "+98912****789",
+		"tokens": "[REDACTED]",
+		"items":  []any{"call +98912****789"},
+	}, nested)
+
+	type input struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	assert.Equal(t, map[string]any{"name": "ali", "password": "[REDACTED]"}, logger.Redact("input", input{Name: "ali", Password: "pass"}))
+}
+
+func TestRedactString(t *testing.T) {
+
+	assert.Equal(t, "Bearer [REDACTED]", logger.RedactString("Bearer "+jwtToken))
+	assert.Equal(t, "otp sent to +98912****789", logger.RedactString("otp sent to +989123456789"))
+	assert.Equal(t, "otp sent to 0912****789", logger.RedactString("otp sent to 09123456789"))
+	assert.Equal(t, "order 123456", logger.RedactString("order 123456"))
+}
+
+func TestHandler(t *testing.T) {
+
+	var buf bytes.Buffer
+	log := slog.New(logger.NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	logger.SetUserID(ctx, 42)
+
+	log.With("access", jwtToken).InfoContext(ctx, "login +989123456789", "status", 200, "password", "pass")
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "login +98912****789", record["msg"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, float64(42), record["user_id"])
+	assert.Equal(t, float64(200), record["status"])
+	assert.Equal(t, "[REDACTED]", record["password"])
+	assert.Equal(t, "[REDACTED]", record["access"])
+	assert.NotContains(t, buf.String(), jwtToken)
+}
+
+func TestHandlerWithoutRequestContext(t *testing.T) {
+
+	var buf bytes.Buffer
+	log := slog.New(logger.NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+	log.Info("started")
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.NotContains(t, record, "request_id")
+	assert.NotContains(t, record, "user_id")
+}
@@ -0,0 +1,113 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/metrics"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gorm_logger "gorm.io/gorm/logger"
+)
+
+func TestInstrumentedCache(t *testing.T) {
+
+	repo := cache.NewInstrumented(cache.NewMemory(1, 10), "test")
+	hit := metrics.CacheRequests.WithLabelValues("test", "hit")
+	miss := metrics.CacheRequests.WithLabelValues("test", "miss")
+
+	_, _, err := repo.Get("key")
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(miss))
+
+	assert.NoError(t, repo.Save("key", map[string]string{"a": "b"}, time.Minute))
+	value, _, err := repo.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", value["a"])
+	assert.Equal(t, float64(1), testutil.ToFloat64(hit))
+
+	assert.NoError(t, repo.Save("expired", map[string]string{"a": "b"}, -time.Second))
+	_, _, err = repo.Get("expired")
+	assert.Error(t, err)
+	assert.Equal(t, float64(2), testutil.ToFloat64(miss))
+}
+
+func TestInstrumentRoute(t *testing.T) {
+
+	handler := middleware.InstrumentRoute("GET", "/test/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("fail") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/1", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/2", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test/3?fail", nil))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/test/{id}", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("GET", "/test/{id}", "400")))
+}
+
+func TestSMSResult(t *testing.T) {
+
+	metrics.SMSResult("test", nil)
+	metrics.SMSResult("test", errors.New("failed"))
+	metrics.SMSResult("test", errors.New("failed"))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.SMSSent.WithLabelValues("test", "success")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.SMSSent.WithLabelValues("test", "failure")))
+}
+
+func TestGormPlugin(t *testing.T) {
+
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: gorm_logger.Default.LogMode(gorm_logger.Silent),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Use(metrics.GormPlugin{}))
+
+	type Item struct {
+		ID   uint64
+		Name string
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mock.ExpectQuery(`SELECT \* FROM "items"`).WillReturnError(errors.New("connection lost"))
+	mock.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	var item Item
+	assert.NoError(t, db.First(&item).Error)
+	assert.Error(t, db.First(&item).Error)
+	assert.ErrorIs(t, db.First(&item).Error, gorm.ErrRecordNotFound)
+
+	// one series for query on items table
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.DBQueryDuration, "pedarkharj_db_query_duration_seconds"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("query", "items")))
+}
+
+func TestHandler(t *testing.T) {
+
+	metrics.ExpensesCreated.Inc()
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.Contains(w.Body.String(), "pedarkharj_expenses_created_total"))
+	assert.True(t, strings.Contains(w.Body.String(), "go_goroutines"))
+}
@@ -0,0 +1,153 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/cache"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/ratelimit"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		TestID  int
+		Value   string
+		Policy  ratelimit.Policy
+		WantErr bool
+	}{
+		{TestID: 1, Value: "10/1m", Policy: ratelimit.Policy{Limit: 10, Period: time.Minute}},
+		{TestID: 2, Value: " 5 / 30s ", Policy: ratelimit.Policy{Limit: 5, Period: 30 * time.Second}},
+		{TestID: 3, Value: "10", WantErr: true},
+		{TestID: 4, Value: "0/1m", WantErr: true},
+		{TestID: 5, Value: "-1/1m", WantErr: true},
+		{TestID: 6, Value: "10/0s", WantErr: true},
+		{TestID: 7, Value: "10/minute", WantErr: true},
+	}
+
+	for _, test := range tests {
+		policy, err := ratelimit.ParsePolicy(test.Value)
+		if test.WantErr {
+			assert.Error(t, err, test)
+			continue
+		}
+		assert.NoError(t, err, test)
+		assert.Equal(t, test.Policy, policy, test)
+	}
+
+	assert.Equal(t, "10/1m0s", ratelimit.Policy{Limit: 10, Period: time.Minute}.String())
+}
+
+func TestAllow(t *testing.T) {
+	limiter := ratelimit.New(cache.NewMemory(4, 100))
+	policy := ratelimit.Policy{Limit: 3, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow("key", policy)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed, i)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, 2-i, result.Remaining)
+		assert.Zero(t, result.RetryAfter)
+	}
+
+	result, err := limiter.Allow("key", policy)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	// one token is added every 20 seconds
+	assert.InDelta(t, 20*time.Second, result.RetryAfter, float64(time.Second))
+	assert.InDelta(t, time.Minute, result.Reset, float64(time.Second))
+
+	// other keys have their own bucket
+	result, err = limiter.Allow("other", policy)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestAllowRefill(t *testing.T) {
+	limiter := ratelimit.New(cache.NewMemory(4, 100))
+	policy := ratelimit.Policy{Limit: 2, Period: 100 * time.Millisecond}
+
+	for i := 0; i < 2; i++ {
+		result, err := limiter.Allow("key", policy)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	result, err := limiter.Allow("key", policy)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// one token is added after 50ms
+	time.Sleep(60 * time.Millisecond)
+
+	result, err = limiter.Allow("key", policy)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestAllowConcurrent(t *testing.T) {
+	limiter := ratelimit.New(cache.NewMemory(4, 100))
+	policy := ratelimit.Policy{Limit: 10, Period: time.Minute}
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := limiter.Allow("key", policy)
+			assert.NoError(t, err)
+			if result.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// concurrent requests cannot take same token. requests may be rejected if bucket is changed in all attempts
+	assert.LessOrEqual(t, allowed.Load(), int64(10))
+	assert.Positive(t, allowed.Load())
+}
+
+// instances with same store share buckets
+func TestAllowInstances(t *testing.T) {
+	store := cache.NewMemory(4, 100)
+	policy := ratelimit.Policy{Limit: 4, Period: time.Minute}
+
+	for i := 0; i < 4; i++ {
+		result, err := ratelimit.New(store).Allow("key", policy)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed, i)
+	}
+
+	result, err := ratelimit.New(store).Allow("key", policy)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(key string) (map[string]string, time.Time, error) {
+	return nil, time.Time{}, errors.New("store is down")
+}
+
+func (failingStore) SaveIfAbsent(key string, value map[string]string, expireTime time.Duration) (bool, error) {
+	return false, errors.New("store is down")
+}
+
+func (failingStore) CompareAndSwap(key string, old map[string]string, value map[string]string, expireTime time.Duration) (bool, error) {
+	return false, errors.New("store is down")
+}
+
+func TestAllowStoreError(t *testing.T) {
+	limiter := ratelimit.New(failingStore{})
+
+	_, err := limiter.Allow("key", ratelimit.Policy{Limit: 1, Period: time.Minute})
+	assert.Error(t, err)
+}
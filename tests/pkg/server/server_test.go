@@ -0,0 +1,206 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/server"
+)
+
+func testOptions() server.Options {
+	return server.Options{
+		Addr:              "127.0.0.1:0",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: time.Second,
+		WriteTimeout:      2 * time.Second,
+		IdleTimeout:       time.Second,
+		ShutdownTimeout:   2 * time.Second,
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	srv := server.New(handler, testOptions())
+
+	var mu sync.Mutex
+	var order []string
+	hook := func(name string) server.ShutdownHook {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	srv.OnShutdown("first", hook("first"))
+	srv.OnShutdown("second", hook("second"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+	<-srv.Ready()
+
+	// in-flight request must be completed
+	body := make(chan string, 1)
+	go func() {
+		response, err := http.Get("http://" + srv.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		b, _ := io.ReadAll(response.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+
+	assert.Equal(t, "done", <-body)
+	assert.NoError(t, <-runErr)
+
+	// hooks run in reverse order
+	assert.Equal(t, []string{"second", "first"}, order)
+
+	// server does not accept new connections
+	_, err := http.Get("http://" + srv.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestShutdownHookError(t *testing.T) {
+	srv := server.New(http.NotFoundHandler(), testOptions())
+	srv.OnShutdown("failing", func(ctx context.Context) error {
+		return errors.New("cannot close")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+	<-srv.Ready()
+	cancel()
+
+	err := <-runErr
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failing")
+}
+
+func TestListenError(t *testing.T) {
+	options := testOptions()
+	options.Addr = "invalid-address"
+
+	err := server.New(http.NotFoundHandler(), options).Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+
+	options := testOptions()
+	options.TLSCertFile = certFile
+	options.TLSKeyFile = keyFile
+
+	srv := server.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure")
+	}), options)
+	assert.True(t, srv.IsTLS())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Run(ctx)
+	<-srv.Ready()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	response, err := client.Get("https://" + srv.Addr().String())
+	if assert.NoError(t, err) {
+		defer response.Body.Close()
+		b, _ := io.ReadAll(response.Body)
+		assert.Equal(t, "secure", string(b))
+		assert.NotNil(t, response.TLS)
+	}
+}
+
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	return certFile, keyFile
+}
+
+func TestShutdownDelay(t *testing.T) {
+	options := testOptions()
+	options.ShutdownDelay = 300 * time.Millisecond
+
+	srv := server.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}), options)
+
+	shutdownStarted := make(chan struct{})
+	srv.OnShutdownStart(func() { close(shutdownStarted) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+	<-srv.Ready()
+
+	cancel()
+	<-shutdownStarted
+
+	// requests are accepted during delay
+	response, err := http.Get("http://" + srv.Addr().String())
+	assert.NoError(t, err)
+	if err == nil {
+		response.Body.Close()
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+
+	assert.NoError(t, <-runErr)
+}
@@ -0,0 +1,82 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/totp"
+)
+
+// RFC 6238 test secret
+var secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestGenerateCode(t *testing.T) {
+
+	tests := []struct {
+		Unix int64
+		Code string
+	}{
+		{Unix: 59, Code: "287082"},
+		{Unix: 1111111109, Code: "081804"},
+		{Unix: 1234567890, Code: "005924"},
+		{Unix: 2000000000, Code: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.GenerateCode(secret, time.Unix(tt.Unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.Code, code, "unix: %d", tt.Unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	assert.True(t, totp.Validate(secret, "005924", now))
+	// previous and next periods are accepted
+	assert.True(t, totp.Validate(secret, "005924", now.Add(30*time.Second)))
+	assert.True(t, totp.Validate(secret, "005924", now.Add(-30*time.Second)))
+
+	assert.False(t, totp.Validate(secret, "005924", now.Add(90*time.Second)))
+	assert.False(t, totp.Validate(secret, "123456", now))
+	assert.False(t, totp.Validate(secret, "5924", now))
+	assert.False(t, totp.Validate("invalid secret!", "005924", now))
+}
+
+func TestValidateCounter(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Unix(59, 0)
+	code, err := totp.GenerateCode(secret, now)
+	assert.NoError(t, err)
+
+	counter, ok := totp.ValidateCounter(secret, code, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), counter)
+
+	// accepted code cannot be used again in skew window
+	_, ok = totp.ValidateCounter(secret, code, now, counter)
+	assert.False(t, ok)
+	_, ok = totp.ValidateCounter(secret, code, now.Add(30*time.Second), counter)
+	assert.False(t, ok)
+
+	// next code is accepted
+	nextCode, err := totp.GenerateCode(secret, now.Add(30*time.Second))
+	assert.NoError(t, err)
+	counter, ok = totp.ValidateCounter(secret, nextCode, now.Add(30*time.Second), counter)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), counter)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	newSecret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	uri := totp.ProvisioningURI(newSecret, "Pedarkharj", "+989123456789")
+	assert.Contains(t, uri, "otpauth://totp/Pedarkharj:+989123456789?")
+	assert.Contains(t, uri, "secret="+newSecret)
+	assert.Contains(t, uri, "issuer=Pedarkharj")
+}
@@ -0,0 +1,124 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaghoubi-mn/pedarkharj/internal/interfaces/rest/v1/middleware"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/logger"
+	"github.com/yaghoubi-mn/pedarkharj/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gorm_logger "gorm.io/gorm/logger"
+)
+
+func setupRecorder() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestSetupExporter(t *testing.T) {
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Options{Exporter: "none"})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = tracing.Setup(context.Background(), tracing.Options{Exporter: "jaeger"})
+	assert.ErrorIs(t, err, tracing.ErrUnknownExporter)
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := setupRecorder()
+
+	sqlDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: gorm_logger.Default.LogMode(gorm_logger.Silent),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Use(tracing.GormPlugin{System: "postgresql"}))
+
+	type Item struct {
+		ID   uint64
+		Name string
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "items"`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a"))
+	mock.ExpectQuery(`SELECT \* FROM "items"`).WillReturnError(errors.New("connection lost"))
+
+	ctx, parent := tracing.Start(context.Background(), "app_test.Get")
+	var item Item
+	assert.NoError(t, db.WithContext(ctx).First(&item).Error)
+	assert.Error(t, db.WithContext(ctx).First(&item).Error)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	query := spans[0]
+	assert.Equal(t, "gorm.query", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, codes.Unset, query.Status().Code)
+
+	failed := spans[1]
+	assert.Equal(t, codes.Error, failed.Status().Code)
+}
+
+func TestTraceRoute(t *testing.T) {
+	recorder := setupRecorder()
+
+	var handlerTraceID string
+	handler := middleware.TraceRoute("GET", "/items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.Start(r.Context(), "app_test.Get")
+		handlerTraceID = span.SpanContext().TraceID().String()
+		span.End()
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	request := httptest.NewRequest("GET", "/items/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request)
+
+	// trace of client is continued
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", handlerTraceID)
+	assert.Contains(t, w.Header().Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+
+	server := spans[1]
+	assert.Equal(t, "GET /items/{id}", server.Name())
+	assert.Equal(t, codes.Error, server.Status().Code)
+}
+
+func TestLoggerTraceID(t *testing.T) {
+	setupRecorder()
+
+	var buf bytes.Buffer
+	log := slog.New(logger.NewHandler(slog.NewTextHandler(&buf, nil)))
+
+	ctx, span := tracing.Start(context.Background(), "test")
+	defer span.End()
+
+	log.InfoContext(ctx, "message")
+
+	assert.Contains(t, buf.String(), "trace_id="+span.SpanContext().TraceID().String())
+}